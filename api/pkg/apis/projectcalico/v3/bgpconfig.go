@@ -98,6 +98,12 @@ type BGPConfigurationSpec struct {
 	// +optional
 	NodeMeshMaxRestartTime *metav1.Duration `json:"nodeMeshMaxRestartTime,omitempty" confignamev1:"node_mesh_restart_time"`
 
+	// NodeMeshGracefulRestart configures graceful restart and long-lived graceful restart for
+	// node-to-mesh peerings.  RestartTime cannot be set together with NodeMeshMaxRestartTime.
+	// This field can only be set on the default BGPConfiguration instance and requires that NodeMesh is enabled
+	// +optional
+	NodeMeshGracefulRestart *BGPGracefulRestart `json:"nodeMeshGracefulRestart,omitempty" validate:"omitempty" confignamev1:"node_mesh_graceful_restart"`
+
 	// BindMode indicates whether to listen for BGP connections on all addresses (None)
 	// or only on the node's canonical IP address Node.Spec.BGP.IPvXAddress (NodeIP).
 	// Default behaviour is to listen for BGP connections on all addresses.
@@ -80,6 +80,21 @@ type BGPPeerSpec struct {
 	// +optional
 	PeerSelector string `json:"peerSelector,omitempty" validate:"omitempty,selector"`
 
+	// Name of a local interface to peer over using BGP unnumbered, i.e. with the peer's
+	// IPv6 link-local address discovered on that interface.  When this is set, the PeerIP,
+	// PeerSelector and PeerCIDR fields must be empty and ASNumber must be specified.  Only
+	// supported by the FRR BGP configuration; the BIRD configuration skips these peers.
+	// +optional
+	PeerInterface string `json:"peerInterface,omitempty" validate:"omitempty,interface"`
+
+	// CIDR from which to accept dynamic BGP sessions.  The local node does not initiate
+	// these sessions; any peer within the CIDR that connects with the given ASNumber is
+	// accepted.  When this is set, the PeerIP, PeerSelector and PeerInterface fields must be
+	// empty and ASNumber must be specified.  Only supported by the FRR BGP configuration;
+	// the BIRD configuration skips these peers.
+	// +optional
+	PeerCIDR string `json:"peerCIDR,omitempty" validate:"omitempty,net"`
+
 	// Option to keep the original nexthop field when routes are sent to a BGP Peer.
 	// Setting "true" configures the selected BGP Peers node to use the "next hop keep;"
 	// instead of "next hop self;"(default) in the specific branch of the Node on "bird.cfg".
@@ -93,7 +108,12 @@ type BGPPeerSpec struct {
 	SourceAddress SourceAddress `json:"sourceAddress,omitempty" validate:"omitempty,sourceAddress"`
 	// Time to allow for software restart.  When specified, this is configured as the graceful
 	// restart timeout.  When not specified, the BIRD default of 120s is used.
+	// This field cannot be set together with GracefulRestart.RestartTime.
 	MaxRestartTime *metav1.Duration `json:"maxRestartTime,omitempty"`
+	// GracefulRestart configures graceful restart and long-lived graceful restart for the
+	// peerings generated by this BGPPeer resource.
+	// +optional
+	GracefulRestart *BGPGracefulRestart `json:"gracefulRestart,omitempty" validate:"omitempty"`
 	// Maximum number of local AS numbers that are allowed in the AS path for received routes.
 	// This removes BGP loop prevention and should only be used if absolutely necessary.
 	// +optional
@@ -121,6 +141,43 @@ const (
 	SourceAddressNone      SourceAddress = "None"
 )
 
+type GracefulRestartMode string
+
+const (
+	// GracefulRestartEnabled enables graceful restart in both directions: the local node
+	// advertises that it can restart gracefully, and retains routes while a peer restarts.
+	GracefulRestartEnabled GracefulRestartMode = "Enabled"
+	// GracefulRestartAware only retains routes while a peer restarts; the local node does not
+	// advertise that it can restart gracefully itself.
+	GracefulRestartAware GracefulRestartMode = "Aware"
+	// GracefulRestartDisabled turns graceful restart off.
+	GracefulRestartDisabled GracefulRestartMode = "Disabled"
+)
+
+// BGPGracefulRestart contains graceful restart settings for BGP sessions.
+type BGPGracefulRestart struct {
+	// Mode controls graceful restart (RFC 4724) for the sessions. [Default: Enabled]
+	// +optional
+	Mode GracefulRestartMode `json:"mode,omitempty" validate:"omitempty,oneof=Enabled Aware Disabled"`
+
+	// RestartTime is the time the peer should retain our routes for while we restart.
+	// When not specified, the BIRD default of 120s is used.
+	// +optional
+	RestartTime *metav1.Duration `json:"restartTime,omitempty"`
+
+	// LongLivedMode controls long-lived graceful restart (LLGR), which retains routes from a
+	// restarting peer as stale, least-preferred routes for an extended period after the
+	// graceful restart time has expired. LLGR requires graceful restart not to be disabled.
+	// When not specified, the BIRD default of Aware is used.
+	// +optional
+	LongLivedMode GracefulRestartMode `json:"longLivedMode,omitempty" validate:"omitempty,oneof=Enabled Aware Disabled"`
+
+	// StaleRouteTime is the time to retain stale routes for under long-lived graceful restart.
+	// Can only be set when LongLivedMode is Enabled. When not specified, the BIRD default of 3600s is used.
+	// +optional
+	StaleRouteTime *metav1.Duration `json:"staleRouteTime,omitempty"`
+}
+
 // BGPPassword contains ways to specify a BGP password.
 type BGPPassword struct {
 	// Selects a key of a secret in the node pod's namespace.
@@ -83,7 +83,8 @@ type IPPoolSpec struct {
 	NATOutgoingV1 bool `json:"nat-outgoing,omitempty" validate:"omitempty,mustBeFalse"`
 
 	// AllowedUse controls what the IP pool will be used for.  If not specified or empty, defaults to
-	// ["Tunnel", "Workload"] for back-compatibility
+	// ["Tunnel", "Workload"] for back-compatibility.  A pool used for "LoadBalancer" addresses cannot
+	// be used for any other purpose, and cannot have a node selector.
 	AllowedUses []IPPoolAllowedUse `json:"allowedUses,omitempty" validate:"omitempty"`
 }
 
@@ -92,6 +93,9 @@ type IPPoolAllowedUse string
 const (
 	IPPoolAllowedUseWorkload IPPoolAllowedUse = "Workload"
 	IPPoolAllowedUseTunnel   IPPoolAllowedUse = "Tunnel"
+
+	// IPPoolAllowedUseLoadBalancer marks the pool as a source of IP addresses for Services of type LoadBalancer.
+	IPPoolAllowedUseLoadBalancer IPPoolAllowedUse = "LoadBalancer"
 )
 
 type VXLANMode string
@@ -84,6 +84,12 @@ type ControllersConfig struct {
 
 	// Namespace enables and configures the namespace controller. Enabled by default, set to nil to disable.
 	Namespace *NamespaceControllerConfig `json:"namespace,omitempty"`
+
+	// RouteReflector enables and configures the route reflector controller. Disabled by default, set to nil to disable.
+	RouteReflector *RouteReflectorControllerConfig `json:"routeReflector,omitempty"`
+
+	// LoadBalancer enables and configures the LoadBalancer IP allocation controller. Disabled by default, set to nil to disable.
+	LoadBalancer *LoadBalancerControllerConfig `json:"loadBalancer,omitempty"`
 }
 
 // NodeControllerConfig configures the node controller, which automatically cleans up configuration
@@ -107,6 +113,39 @@ type NodeControllerConfig struct {
 type AutoHostEndpointConfig struct {
 	// AutoCreate enables automatic creation of host endpoints for every node. [Default: Disabled]
 	AutoCreate string `json:"autoCreate,omitempty" validate:"omitempty,oneof=Enabled Disabled"`
+
+	// CreateDefaultHostEndpoint controls whether the "*" interface host endpoint is created for
+	// every node.  Set to Disabled to only create host endpoints from Templates. [Default: Enabled]
+	CreateDefaultHostEndpoint string `json:"createDefaultHostEndpoint,omitempty" validate:"omitempty,oneof=Enabled Disabled"`
+
+	// Templates generate additional host endpoints, one for each matching interface on each
+	// selected node.
+	// +optional
+	Templates []AutoHostEndpointTemplate `json:"templates,omitempty" validate:"omitempty,dive"`
+}
+
+// AutoHostEndpointTemplate describes a set of per-interface host endpoints to create.
+type AutoHostEndpointTemplate struct {
+	// GenerateName is included in the names of the generated host endpoints, which are named
+	// <node>-<generateName>-<interface>-auto-hep.  It must be unique across templates.
+	GenerateName string `json:"generateName" validate:"name"`
+
+	// NodeSelector selects the nodes that host endpoints are created for. [Default: all()]
+	// +optional
+	NodeSelector string `json:"nodeSelector,omitempty" validate:"omitempty,selector"`
+
+	// InterfacePattern is a regular expression matched against the names of the node's
+	// interfaces.  A host endpoint is created for each matching interface.
+	InterfacePattern string `json:"interfacePattern" validate:"regexp"`
+
+	// Labels are added to the generated host endpoints, in addition to the node's labels.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty" validate:"omitempty,labels"`
+
+	// Profiles are applied to the generated host endpoints.  If not specified, the default
+	// allow profile is applied, as it is for the "*" interface host endpoint.
+	// +optional
+	Profiles []string `json:"profiles,omitempty" validate:"omitempty,dive,name"`
 }
 
 // PolicyControllerConfig configures the network policy controller, which syncs Kubernetes policies
@@ -137,6 +176,45 @@ type NamespaceControllerConfig struct {
 	ReconcilerPeriod *metav1.Duration `json:"reconcilerPeriod,omitempty" validate:"omitempty"`
 }
 
+// RouteReflectorControllerConfig configures the route reflector controller, which selects a number of
+// route reflectors in each zone, assigns them a per-zone cluster ID and maintains the BGPPeer resources
+// that peer every other node with the route reflectors in its zone. The node-to-node mesh should be
+// disabled in the BGPConfiguration when using this controller.
+type RouteReflectorControllerConfig struct {
+	// ReconcilerPeriod is the period to perform reconciliation with the Calico datastore. [Default: 5m]
+	ReconcilerPeriod *metav1.Duration `json:"reconcilerPeriod,omitempty" validate:"omitempty"`
+
+	// ZoneLabel is the node label used to group nodes into zones. Nodes without this label are
+	// treated as a single zone of their own. [Default: topology.kubernetes.io/zone]
+	ZoneLabel string `json:"zoneLabel,omitempty" validate:"omitempty"`
+
+	// ReflectorsPerZone is the number of route reflectors to select in each zone. [Default: 3]
+	ReflectorsPerZone *int `json:"reflectorsPerZone,omitempty" validate:"omitempty,gt=0"`
+
+	// NodeSelector limits the nodes that may be selected as route reflectors. If not specified,
+	// any node with BGP configured may be selected.
+	NodeSelector string `json:"nodeSelector,omitempty" validate:"omitempty,selector"`
+}
+
+// LoadBalancerControllerConfig configures the LoadBalancer IP allocation controller, which assigns
+// addresses from IP pools with the LoadBalancer allowed use to Services of type LoadBalancer.
+type LoadBalancerControllerConfig struct {
+	// ReconcilerPeriod is the period to perform reconciliation with the Calico datastore. [Default: 5m]
+	ReconcilerPeriod *metav1.Duration `json:"reconcilerPeriod,omitempty" validate:"omitempty"`
+
+	// AssignIPs controls which Services are assigned addresses. AllServices assigns addresses to every
+	// Service of type LoadBalancer; RequestedServicesOnly only assigns addresses to Services that request
+	// specific addresses. [Default: AllServices]
+	AssignIPs AssignIPs `json:"assignIPs,omitempty" validate:"omitempty,oneof=AllServices RequestedServicesOnly"`
+}
+
+type AssignIPs string
+
+const (
+	AllServices           AssignIPs = "AllServices"
+	RequestedServicesOnly AssignIPs = "RequestedServicesOnly"
+)
+
 // KubeControllersConfigurationStatus represents the status of the configuration. It's useful for admins to
 // be able to see the actual config that was applied, which can be modified by environment variables on the
 // kube-controllers process.
@@ -148,6 +226,17 @@ type KubeControllersConfigurationStatus struct {
 	// EnvironmentVars contains the environment variables on the kube-controllers that influenced
 	// the RunningConfig.
 	EnvironmentVars map[string]string `json:"environmentVars,omitempty"`
+
+	// LeaderElection contains the state of leader election between kube-controllers replicas.
+	// It is only set when leader election is enabled.
+	LeaderElection *LeaderElectionStatus `json:"leaderElection,omitempty"`
+}
+
+// LeaderElectionStatus contains the state of leader election between kube-controllers replicas.
+type LeaderElectionStatus struct {
+	// Leader is the identity of the kube-controllers replica that currently holds the leader
+	// lease, and so is running the controllers.
+	Leader string `json:"leader,omitempty"`
 }
 
 // New KubeControllersConfiguration creates a new (zeroed) KubeControllersConfiguration struct with
@@ -149,6 +149,18 @@ type CalicoNodePeer struct {
 
 	// Since the state or reason last changed.
 	Since string `json:"since,omitempty"`
+
+	// GracefulRestart contains the graceful restart capabilities advertised by the peer.
+	GracefulRestart *CalicoNodePeerGracefulRestart `json:"gracefulRestart,omitempty"`
+}
+
+// CalicoNodePeerGracefulRestart contains the graceful restart capabilities advertised by a BGP peer.
+type CalicoNodePeerGracefulRestart struct {
+	// Restart is the graceful restart capability advertised by the peer.
+	Restart GracefulRestartCapability `json:"restart,omitempty"`
+
+	// LongLivedRestart is the long-lived graceful restart capability advertised by the peer.
+	LongLivedRestart GracefulRestartCapability `json:"longLivedRestart,omitempty"`
 }
 
 // CalicoNodeRoute contains the status of BGP routes on the node.
@@ -240,3 +252,14 @@ const (
 	BGPSessionStateEstablished BGPSessionState = "Established"
 	BGPSessionStateClose       BGPSessionState = "Close"
 )
+
+type GracefulRestartCapability string
+
+const (
+	// GracefulRestartCapabilityAble means the peer can restart gracefully and retain routes while we restart.
+	GracefulRestartCapabilityAble GracefulRestartCapability = "Able"
+	// GracefulRestartCapabilityAware means the peer retains routes while we restart, but cannot restart gracefully itself.
+	GracefulRestartCapabilityAware GracefulRestartCapability = "Aware"
+	// GracefulRestartCapabilityNone means the peer did not advertise the capability.
+	GracefulRestartCapabilityNone GracefulRestartCapability = "None"
+)
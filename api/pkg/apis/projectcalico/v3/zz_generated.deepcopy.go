@@ -17,6 +17,13 @@ import (
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AutoHostEndpointConfig) DeepCopyInto(out *AutoHostEndpointConfig) {
 	*out = *in
+	if in.Templates != nil {
+		in, out := &in.Templates, &out.Templates
+		*out = make([]AutoHostEndpointTemplate, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -30,6 +37,34 @@ func (in *AutoHostEndpointConfig) DeepCopy() *AutoHostEndpointConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AutoHostEndpointTemplate) DeepCopyInto(out *AutoHostEndpointTemplate) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Profiles != nil {
+		in, out := &in.Profiles, &out.Profiles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AutoHostEndpointTemplate.
+func (in *AutoHostEndpointTemplate) DeepCopy() *AutoHostEndpointTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(AutoHostEndpointTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BGPConfiguration) DeepCopyInto(out *BGPConfiguration) {
 	*out = *in
@@ -140,6 +175,11 @@ func (in *BGPConfigurationSpec) DeepCopyInto(out *BGPConfigurationSpec) {
 		*out = new(v1.Duration)
 		**out = **in
 	}
+	if in.NodeMeshGracefulRestart != nil {
+		in, out := &in.NodeMeshGracefulRestart, &out.NodeMeshGracefulRestart
+		*out = new(BGPGracefulRestart)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.BindMode != nil {
 		in, out := &in.BindMode, &out.BindMode
 		*out = new(BindMode)
@@ -377,6 +417,32 @@ func (in *BGPFilterSpec) DeepCopy() *BGPFilterSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BGPGracefulRestart) DeepCopyInto(out *BGPGracefulRestart) {
+	*out = *in
+	if in.RestartTime != nil {
+		in, out := &in.RestartTime, &out.RestartTime
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.StaleRouteTime != nil {
+		in, out := &in.StaleRouteTime, &out.StaleRouteTime
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BGPGracefulRestart.
+func (in *BGPGracefulRestart) DeepCopy() *BGPGracefulRestart {
+	if in == nil {
+		return nil
+	}
+	out := new(BGPGracefulRestart)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BGPPassword) DeepCopyInto(out *BGPPassword) {
 	*out = *in
@@ -471,6 +537,11 @@ func (in *BGPPeerSpec) DeepCopyInto(out *BGPPeerSpec) {
 		*out = new(v1.Duration)
 		**out = **in
 	}
+	if in.GracefulRestart != nil {
+		in, out := &in.GracefulRestart, &out.GracefulRestart
+		*out = new(BGPGracefulRestart)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.NumAllowedLocalASNumbers != nil {
 		in, out := &in.NumAllowedLocalASNumbers, &out.NumAllowedLocalASNumbers
 		*out = new(int32)
@@ -625,12 +696,16 @@ func (in *CalicoNodeBGPStatus) DeepCopyInto(out *CalicoNodeBGPStatus) {
 	if in.PeersV4 != nil {
 		in, out := &in.PeersV4, &out.PeersV4
 		*out = make([]CalicoNodePeer, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	if in.PeersV6 != nil {
 		in, out := &in.PeersV6, &out.PeersV6
 		*out = make([]CalicoNodePeer, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 	return
 }
@@ -648,6 +723,11 @@ func (in *CalicoNodeBGPStatus) DeepCopy() *CalicoNodeBGPStatus {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CalicoNodePeer) DeepCopyInto(out *CalicoNodePeer) {
 	*out = *in
+	if in.GracefulRestart != nil {
+		in, out := &in.GracefulRestart, &out.GracefulRestart
+		*out = new(CalicoNodePeerGracefulRestart)
+		**out = **in
+	}
 	return
 }
 
@@ -661,6 +741,22 @@ func (in *CalicoNodePeer) DeepCopy() *CalicoNodePeer {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CalicoNodePeerGracefulRestart) DeepCopyInto(out *CalicoNodePeerGracefulRestart) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CalicoNodePeerGracefulRestart.
+func (in *CalicoNodePeerGracefulRestart) DeepCopy() *CalicoNodePeerGracefulRestart {
+	if in == nil {
+		return nil
+	}
+	out := new(CalicoNodePeerGracefulRestart)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CalicoNodeRoute) DeepCopyInto(out *CalicoNodeRoute) {
 	*out = *in
@@ -926,6 +1022,16 @@ func (in *ControllersConfig) DeepCopyInto(out *ControllersConfig) {
 		*out = new(NamespaceControllerConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RouteReflector != nil {
+		in, out := &in.RouteReflector, &out.RouteReflector
+		*out = new(RouteReflectorControllerConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.LoadBalancer != nil {
+		in, out := &in.LoadBalancer, &out.LoadBalancer
+		*out = new(LoadBalancerControllerConfig)
+		(*in).DeepCopyInto(*out)
+	}
 	return
 }
 
@@ -2366,6 +2472,11 @@ func (in *KubeControllersConfigurationStatus) DeepCopyInto(out *KubeControllersC
 			(*out)[key] = val
 		}
 	}
+	if in.LeaderElection != nil {
+		in, out := &in.LeaderElection, &out.LeaderElection
+		*out = new(LeaderElectionStatus)
+		**out = **in
+	}
 	return
 }
 
@@ -2379,6 +2490,43 @@ func (in *KubeControllersConfigurationStatus) DeepCopy() *KubeControllersConfigu
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeaderElectionStatus) DeepCopyInto(out *LeaderElectionStatus) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeaderElectionStatus.
+func (in *LeaderElectionStatus) DeepCopy() *LeaderElectionStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(LeaderElectionStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoadBalancerControllerConfig) DeepCopyInto(out *LoadBalancerControllerConfig) {
+	*out = *in
+	if in.ReconcilerPeriod != nil {
+		in, out := &in.ReconcilerPeriod, &out.ReconcilerPeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerControllerConfig.
+func (in *LoadBalancerControllerConfig) DeepCopy() *LoadBalancerControllerConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(LoadBalancerControllerConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NamespaceControllerConfig) DeepCopyInto(out *NamespaceControllerConfig) {
 	*out = *in
@@ -2597,7 +2745,7 @@ func (in *NodeControllerConfig) DeepCopyInto(out *NodeControllerConfig) {
 	if in.HostEndpoint != nil {
 		in, out := &in.HostEndpoint, &out.HostEndpoint
 		*out = new(AutoHostEndpointConfig)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.LeakGracePeriod != nil {
 		in, out := &in.LeakGracePeriod, &out.LeakGracePeriod
@@ -2772,6 +2920,32 @@ func (in *ProtoPort) DeepCopy() *ProtoPort {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RouteReflectorControllerConfig) DeepCopyInto(out *RouteReflectorControllerConfig) {
+	*out = *in
+	if in.ReconcilerPeriod != nil {
+		in, out := &in.ReconcilerPeriod, &out.ReconcilerPeriod
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ReflectorsPerZone != nil {
+		in, out := &in.ReflectorsPerZone, &out.ReflectorsPerZone
+		*out = new(int)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RouteReflectorControllerConfig.
+func (in *RouteReflectorControllerConfig) DeepCopy() *RouteReflectorControllerConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RouteReflectorControllerConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RouteTableIDRange) DeepCopyInto(out *RouteTableIDRange) {
 	*out = *in
@@ -41,9 +41,12 @@ func main() {
     get          Get a resource identified by file, directory, stdin or resource type and
                  name.
     label        Add or update labels of resources.
+    annotate     Add or update annotations of resources.
     convert      Convert config files between different API versions.
     ipam         IP address management.
+    policy       Policy analysis.
     node         Calico node management.
+    cluster      Calico cluster management.
     version      Display the version of this binary.
     datastore    Calico datastore management.
 
@@ -121,14 +124,20 @@ Description:
 			err = commands.Get(args)
 		case "label":
 			err = commands.Label(args)
+		case "annotate":
+			err = commands.Annotate(args)
 		case "convert":
 			err = commands.Convert(args)
 		case "version":
 			err = commands.Version(args)
 		case "node":
 			err = commands.Node(args)
+		case "cluster":
+			err = commands.Cluster(args)
 		case "ipam":
 			err = commands.IPAM(args)
+		case "policy":
+			err = commands.Policy(args)
 		case "datastore":
 			err = commands.Datastore(args)
 		default:
@@ -0,0 +1,108 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	docopt "github.com/docopt/docopt-go"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/common"
+	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/constants"
+	"github.com/projectcalico/calico/calicoctl/calicoctl/util"
+)
+
+func Annotate(args []string) error {
+	doc := constants.DatastoreIntro + `Usage:
+  <BINARY_NAME> annotate (<KIND> <NAME>
+                     ( <key>=<value> [--overwrite] |
+                       <key> --remove )
+                     [--config=<CONFIG>] [--namespace=<NS>] [--context=<context>]) [--allow-version-mismatch]
+  <BINARY_NAME> annotate (<KIND> (--selector=<SELECTOR> | --all)
+                     ( <key>=<value> [--overwrite] |
+                       <key> --remove )
+                     [--config=<CONFIG>] [--namespace=<NS>] [--all-namespaces] [--context=<context>]) [--allow-version-mismatch]
+
+Examples:
+  # Annotate a workload endpoint
+  <BINARY_NAME> annotate workloadendpoints nginx --namespace=default owner=team-a
+
+  # Annotate a node and overwrite the original value of key 'description'
+  <BINARY_NAME> annotate nodes node1 description="Rack 1, slot 4" --overwrite
+
+  # Remove annotation with key 'description' of the node
+  <BINARY_NAME> annotate nodes node1 description --remove
+
+  # Annotate all the host endpoints of the nodes in rack 1
+  <BINARY_NAME> annotate hostendpoints --selector='rack == "1"' owner=team-b --overwrite
+
+Options:
+  -h --help                    Show this screen.
+  -c --config=<CONFIG>         Path to the file containing connection
+                               configuration in YAML or JSON format.
+                               [default: ` + constants.DefaultConfigPath + `]
+  -n --namespace=<NS>          Namespace of the resource.
+                               Only applicable to NetworkPolicy, NetworkSet, and WorkloadEndpoint.
+                               Uses the default namespace if not specified.
+  -A --all-namespaces          Used with --selector or --all.  If present, annotate the
+                               matching resources across all namespaces.
+  -l --selector=<SELECTOR>     Annotate all the resources of the kind whose labels match
+                               the selector, instead of a named resource.
+     --all                     Annotate all the resources of the kind, instead of a
+                               named resource.
+     --overwrite               If true, overwrite the value when the key is already
+                               present in annotations. Otherwise reports error when the
+                               annotated resource already have the key in its annotations.
+                               Cannot be used with --remove.
+     --remove                  If true, remove the specified key in annotations of the
+                               resource. Reports error when specified key does not
+                               exist. Cannot be used with --overwrite.
+     --context=<context>       The name of the kubeconfig context to use.
+     --allow-version-mismatch  Allow client and cluster versions mismatch.
+
+Description:
+  The annotate command is used to add or update an annotation on a resource. Resource
+  types that can be annotated are:
+
+<RESOURCE_LIST>
+  The resource type is case-insensitive and may be pluralized.
+
+  The annotate command behaves in the same way as the label command.  See
+  '<BINARY_NAME> label --help' for details.
+  `
+	// Replace all instances of BINARY_NAME with the name of the binary.
+	binaryName, _ := util.NameAndDescription()
+	doc = strings.ReplaceAll(doc, "<BINARY_NAME>", binaryName)
+
+	// Replace <RESOURCE_LIST> with the list of resource types.
+	doc = strings.Replace(doc, "<RESOURCE_LIST>", util.Resources(), 1)
+
+	parsedArgs, err := docopt.ParseArgs(doc, args, "")
+	if err != nil {
+		return fmt.Errorf("Invalid option: 'calicoctl %s'. Use flag '--help' to read about a specific subcommand.", strings.Join(args, " "))
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+	if context := parsedArgs["--context"]; context != nil {
+		os.Setenv("K8S_CURRENT_CONTEXT", context.(string))
+	}
+
+	log.Debugf("parse args: %+v\n", parsedArgs)
+	return updateMetadata(parsedArgs, common.Annotations)
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/docopt/docopt-go"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/argutils"
 	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/common"
 	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/constants"
 	"github.com/projectcalico/calico/calicoctl/calicoctl/util"
@@ -31,6 +32,7 @@ func Apply(args []string) error {
 	doc := constants.DatastoreIntro + `Usage:
   <BINARY_NAME> apply --filename=<FILENAME> [--recursive] [--skip-empty]
                   [--config=<CONFIG>] [--namespace=<NS>] [--context=<context>] [--allow-version-mismatch]
+                  [--dry-run=<MODE>] [--diff] [--atomic]
 
 Examples:
   # Apply a policy using the data in policy.yaml.
@@ -39,6 +41,12 @@ Examples:
   # Apply a policy based on the JSON passed into stdin.
   cat policy.json | <BINARY_NAME> apply -f -
 
+  # Show what applying policy.yaml would change, without changing anything.
+  <BINARY_NAME> apply -f ./policy.yaml --dry-run --diff
+
+  # Apply a tier and its policies, applying none of them if any one fails.
+  <BINARY_NAME> apply -f ./tier-and-policies.yaml --atomic
+
 Options:
   -h --help                    Show this screen.
   -f --filename=<FILENAME>     Filename to use to apply the resource.  If set to
@@ -56,6 +64,27 @@ Options:
                                Uses the default namespace if not specified.
      --context=<context>       The name of the kubeconfig context to use.
      --allow-version-mismatch  Allow client and cluster versions mismatch.
+     --dry-run=<MODE>          Validate the resources and report what would change,
+                               without changing anything.  <MODE> is "client" (the
+                               default if --dry-run is given without a value) to
+                               validate the resources locally, or "server" to also
+                               compare the resource versions and existence of the
+                               resources with those read from the datastore.  The
+                               datastore itself is not asked to check the write.
+     --diff                    Print the changed fields of each resource and exit
+                               with a non-zero exit code if any resource would
+                               change.  Implies --dry-run=client if --dry-run is
+                               not specified.
+     --atomic                  Apply all of the resources as a single unit: if any
+                               resource cannot be applied then none of them are.
+                               On the etcd datastore the resources are applied in a
+                               single etcd transaction, which is limited to 128
+                               resources by default.  On the Kubernetes datastore
+                               the update is not atomic: the resources are applied
+                               in order, other clients can see the partly applied
+                               resources, and resources that have already been
+                               applied are reverted (on a best effort basis) if a
+                               later resource fails.
 
 Description:
   The apply command is used to create or replace a set of resources by filename
@@ -76,6 +105,8 @@ Description:
   The resources are applied in the order they are specified.  In the event of a
   failure applying a specific resource it is possible to work out which
   resource failed based on the number of resources successfully applied
+  (unless --atomic is specified, in which case the resources that were applied
+  are reverted).
 
   When applying a resource to perform an update, the complete resource spec
   must be provided, it is not sufficient to supply only the fields that are
@@ -88,7 +119,7 @@ Description:
 	// Replace <RESOURCE_LIST> with the list of resource types.
 	doc = strings.Replace(doc, "<RESOURCE_LIST>", util.Resources(), 1)
 
-	parsedArgs, err := docopt.ParseArgs(doc, args, "")
+	parsedArgs, err := docopt.ParseArgs(doc, common.NormalizeDryRunArgs(args), "")
 	if err != nil {
 		return fmt.Errorf("Invalid option: 'calicoctl %s'. Use flag '--help' to read about a specific subcommand.", strings.Join(args, " "))
 	}
@@ -102,6 +133,10 @@ Description:
 	results := common.ExecuteConfigCommand(parsedArgs, common.ActionApply)
 	log.Infof("results: %+v", results)
 
+	if results.DryRun != common.DryRunNone && results.NumResources > 0 {
+		return common.HandleDryRunResults(os.Stdout, results, argutils.ArgBoolOrFalse(parsedArgs, "--diff"))
+	}
+
 	if results.FileInvalid {
 		return fmt.Errorf("Failed to execute command: %v", results.Err)
 	} else if results.NumResources == 0 {
@@ -0,0 +1,240 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/projectcalico/go-yaml-wrapper"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// bundleFormatVersion is the version of the bundle layout.  It must be incremented if
+	// the layout changes in a way that affects the tools that parse bundles.
+	bundleFormatVersion = 1
+
+	// bundleRoot is the directory in the archive that contains the bundle.
+	bundleRoot = "calico-diagnostics"
+
+	metadataFile = "metadata.json"
+)
+
+// Metadata describes the bundle.  It is written to metadata.json in the root of the bundle.
+type Metadata struct {
+	FormatVersion    int    `json:"formatVersion"`
+	CalicoctlVersion string `json:"calicoctlVersion"`
+	CollectionTime   string `json:"collectionTime"`
+
+	ClusterGUID   string `json:"clusterGUID,omitempty"`
+	ClusterType   string `json:"clusterType,omitempty"`
+	CalicoVersion string `json:"calicoVersion,omitempty"`
+	DatastoreType string `json:"datastoreType"`
+
+	// Nodes are the nodes that node diagnostics were collected for.
+	Nodes []string `json:"nodes"`
+
+	// Files are the paths of the files in the bundle, relative to the root of the bundle.
+	Files []string `json:"files"`
+
+	// Errors are the diagnostics that could not be collected.
+	Errors []CollectionError `json:"errors"`
+}
+
+// CollectionError records a diagnostic that could not be collected.  Path is the path that
+// the diagnostic would have been written to, or the directory for a group of diagnostics.
+type CollectionError struct {
+	Path  string `json:"path"`
+	Error string `json:"error"`
+}
+
+// bundle writes the diagnostics to a temporary directory, and then archives them.  All the
+// files are redacted as they are written.  It is safe to write to a bundle from multiple
+// goroutines.
+type bundle struct {
+	dir string
+
+	lock     sync.Mutex
+	metadata Metadata
+}
+
+func newBundle(metadata Metadata) (*bundle, error) {
+	dir, err := os.MkdirTemp("", "calico-diagnostics")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	metadata.FormatVersion = bundleFormatVersion
+	metadata.Nodes = []string{}
+	metadata.Errors = []CollectionError{}
+	return &bundle{dir: dir, metadata: metadata}, nil
+}
+
+// cleanup removes the temporary directory.
+func (b *bundle) cleanup() {
+	if err := os.RemoveAll(b.dir); err != nil {
+		log.WithError(err).Warn("Failed to remove temp directory")
+	}
+}
+
+// write writes a file to the bundle.  The path uses forward slashes, and is relative to the
+// root of the bundle.
+func (b *bundle) write(p string, data []byte) {
+	fp := filepath.Join(b.dir, filepath.FromSlash(p))
+	if err := os.MkdirAll(filepath.Dir(fp), 0o755); err != nil {
+		b.fail(p, err)
+		return
+	}
+	if err := os.WriteFile(fp, redact(data), 0o644); err != nil {
+		b.fail(p, err)
+	}
+}
+
+func (b *bundle) writeYAML(p string, v interface{}) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		b.fail(p, err)
+		return
+	}
+	b.write(p, data)
+}
+
+func (b *bundle) writeJSON(p string, v interface{}) {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		b.fail(p, err)
+		return
+	}
+	b.write(p, append(data, '\n'))
+}
+
+// fail records that the diagnostic for the path could not be collected.
+func (b *bundle) fail(p string, err error) {
+	fmt.Printf("WARNING: failed to collect %s: %v\n", p, err)
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.metadata.Errors = append(b.metadata.Errors, CollectionError{Path: p, Error: string(redact([]byte(err.Error())))})
+}
+
+// addNode records that node diagnostics were collected for the node.
+func (b *bundle) addNode(name string) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.metadata.Nodes = append(b.metadata.Nodes, name)
+}
+
+// files returns the paths of the files in the bundle, in sorted order.
+func (b *bundle) files() ([]string, error) {
+	var files []string
+	err := filepath.Walk(b.dir, func(fp string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			rel, err := filepath.Rel(b.dir, fp)
+			if err != nil {
+				return err
+			}
+			files = append(files, filepath.ToSlash(rel))
+		}
+		return nil
+	})
+	sort.Strings(files)
+	return files, err
+}
+
+// archive writes the metadata, and then writes the bundle to a gzipped tar file.  The files are
+// written in sorted order, with fixed ownership, permissions and modification times, so that
+// the archive only depends on the diagnostics collected.
+func (b *bundle) archive(out io.Writer, modTime time.Time) error {
+	files, err := b.files()
+	if err != nil {
+		return err
+	}
+
+	b.lock.Lock()
+	b.metadata.Files = files
+	sort.Strings(b.metadata.Nodes)
+	sort.Slice(b.metadata.Errors, func(i, j int) bool {
+		return b.metadata.Errors[i].Path < b.metadata.Errors[j].Path
+	})
+	b.lock.Unlock()
+	b.writeJSON(metadataFile, b.metadata)
+	files = append([]string{metadataFile}, files...)
+
+	gz := gzip.NewWriter(out)
+	tw := tar.NewWriter(gz)
+	modTime = modTime.UTC().Truncate(time.Second)
+
+	// Write the directories before the files that they contain.
+	dirs := map[string]bool{}
+	writeDir := func(dir string) error {
+		if dirs[dir] {
+			return nil
+		}
+		dirs[dir] = true
+		return tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeDir,
+			Name:     dir + "/",
+			Mode:     0o755,
+			ModTime:  modTime,
+		})
+	}
+	if err := writeDir(bundleRoot); err != nil {
+		return err
+	}
+	for _, f := range files {
+		name := path.Join(bundleRoot, f)
+		var parents []string
+		for d := path.Dir(name); d != bundleRoot; d = path.Dir(d) {
+			parents = append([]string{d}, parents...)
+		}
+		for _, d := range parents {
+			if err := writeDir(d); err != nil {
+				return err
+			}
+		}
+
+		data, err := os.ReadFile(filepath.Join(b.dir, filepath.FromSlash(f)))
+		if err != nil {
+			return err
+		}
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     name,
+			Mode:     0o644,
+			Size:     int64(len(data)),
+			ModTime:  modTime,
+		}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
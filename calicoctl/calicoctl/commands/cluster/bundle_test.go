@@ -0,0 +1,113 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"io"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Diagnostics bundle", func() {
+	modTime := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	newTestBundle := func() *bundle {
+		b, err := newBundle(Metadata{CalicoctlVersion: "v3.99.0", DatastoreType: "kubernetes"})
+		Expect(err).NotTo(HaveOccurred())
+		// Write in a different order to the archive order.
+		b.write("nodes/node-b/network/ip-addr.txt", []byte("addr b\n"))
+		b.addNode("node-b")
+		b.write("nodes/node-a/network/ip-addr.txt", []byte("addr a\n"))
+		b.addNode("node-a")
+		b.writeYAML("resources/ippools.yaml", map[string]string{"password": "hunter2"})
+		b.fail("nodes/node-b/bird/routes.txt", errors.New("birdcl: not found"))
+		return b
+	}
+
+	readArchive := func(data []byte) (names []string, contents map[string]string) {
+		gz, err := gzip.NewReader(bytes.NewReader(data))
+		Expect(err).NotTo(HaveOccurred())
+		tr := tar.NewReader(gz)
+		contents = map[string]string{}
+		for {
+			hdr, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			Expect(err).NotTo(HaveOccurred())
+			Expect(hdr.ModTime.Equal(modTime)).To(BeTrue())
+			names = append(names, hdr.Name)
+			body, err := io.ReadAll(tr)
+			Expect(err).NotTo(HaveOccurred())
+			contents[hdr.Name] = string(body)
+		}
+		return
+	}
+
+	It("writes the metadata and files in a fixed order", func() {
+		b := newTestBundle()
+		defer b.cleanup()
+		var out bytes.Buffer
+		Expect(b.archive(&out, modTime)).To(Succeed())
+
+		names, contents := readArchive(out.Bytes())
+		Expect(names).To(Equal([]string{
+			"calico-diagnostics/",
+			"calico-diagnostics/metadata.json",
+			"calico-diagnostics/nodes/",
+			"calico-diagnostics/nodes/node-a/",
+			"calico-diagnostics/nodes/node-a/network/",
+			"calico-diagnostics/nodes/node-a/network/ip-addr.txt",
+			"calico-diagnostics/nodes/node-b/",
+			"calico-diagnostics/nodes/node-b/network/",
+			"calico-diagnostics/nodes/node-b/network/ip-addr.txt",
+			"calico-diagnostics/resources/",
+			"calico-diagnostics/resources/ippools.yaml",
+		}))
+		Expect(contents["calico-diagnostics/resources/ippools.yaml"]).To(Equal("password: <redacted>\n"))
+
+		var metadata Metadata
+		Expect(json.Unmarshal([]byte(contents["calico-diagnostics/metadata.json"]), &metadata)).To(Succeed())
+		Expect(metadata.FormatVersion).To(Equal(bundleFormatVersion))
+		Expect(metadata.Nodes).To(Equal([]string{"node-a", "node-b"}))
+		Expect(metadata.Files).To(Equal([]string{
+			"nodes/node-a/network/ip-addr.txt",
+			"nodes/node-b/network/ip-addr.txt",
+			"resources/ippools.yaml",
+		}))
+		Expect(metadata.Errors).To(Equal([]CollectionError{
+			{Path: "nodes/node-b/bird/routes.txt", Error: "birdcl: not found"},
+		}))
+	})
+
+	It("produces identical archives for identical diagnostics", func() {
+		var archives [][]byte
+		for i := 0; i < 2; i++ {
+			b := newTestBundle()
+			var out bytes.Buffer
+			Expect(b.archive(&out, modTime)).To(Succeed())
+			b.cleanup()
+			archives = append(archives, out.Bytes())
+		}
+		Expect(archives[0]).To(Equal(archives[1]))
+	})
+})
@@ -0,0 +1,548 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docopt/docopt-go"
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/argutils"
+	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/clientmgr"
+	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/common"
+	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/constants"
+	"github.com/projectcalico/calico/calicoctl/calicoctl/util"
+	bapi "github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/k8s"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+)
+
+// Diags collects a diagnostics bundle for the cluster.
+func Diags(args []string, version string) error {
+	doc := constants.DatastoreIntro + `Usage:
+  <BINARY_NAME> cluster diags [--config=<CONFIG>] [--context=<context>] [--output=<FILE>]
+                [--since=<SINCE>] [--nodes=<NODES>] [--max-parallelism=<N>] [--allow-version-mismatch]
+
+Examples:
+  # Collect diagnostics from the whole cluster.
+  <BINARY_NAME> cluster diags
+
+  # Collect diagnostics, with the last hour of logs, from two nodes.
+  <BINARY_NAME> cluster diags --since=1h --nodes=node-1,node-2
+
+Options:
+  -h --help                    Show this screen.
+  -o --output=<FILE>           The file to write the bundle to.  Defaults to
+                               calico-diagnostics-<TIMESTAMP>.tar.gz in the current
+                               directory.
+     --since=<SINCE>           Only collect logs newer than a relative duration,
+                               such as 10m or 2h.  All logs are collected by default.
+     --nodes=<NODES>           A comma separated list of the nodes to collect node
+                               diagnostics from.  All nodes are used by default.
+     --max-parallelism=<N>     The maximum number of nodes to collect diagnostics
+                               from at the same time.  [default: 10]
+  -c --config=<CONFIG>         Path to the file containing connection configuration in
+                               YAML or JSON format.
+                               [default: ` + constants.DefaultConfigPath + `]
+     --context=<context>       The name of the kubeconfig context to use.
+     --allow-version-mismatch  Allow client and cluster versions mismatch.
+
+Description:
+  The cluster diags command collects a diagnostics bundle for a Calico cluster.
+  The Calico resources and IPAM state are read from the datastore.  The node
+  diagnostics and logs are collected from the calico-node pods using the Kubernetes
+  API, so the command can be run from any host with access to the cluster.
+
+  Anything that looks like a secret, such as a password or private key, is
+  redacted before it is written to the bundle.
+
+  The bundle is a gzipped tar file, with the following layout under the
+  calico-diagnostics directory:
+
+    metadata.json                  Cluster details, the files in the bundle and the
+                                   diagnostics that could not be collected.
+    resources/<PLURAL>.yaml        The Calico resources of each kind.
+    ipam/blocks.json               The IPAM blocks.
+    ipam/handles.json              The IPAM handles.
+    kubernetes/pods.yaml           The Calico pods.
+    logs/<NS>/<POD>/<CONTAINER>.log
+                                   The logs of the Calico pods, other than calico-node.
+    nodes/<NODE>/<GROUP>/<NAME>.txt
+                                   The output of the node diagnostics commands.
+    nodes/<NODE>/logs/<CONTAINER>.log
+                                   The logs of the calico-node pod.
+
+  Logs of restarted containers are also collected, as <CONTAINER>.previous.log.
+  The eBPF dataplane diagnostics (nodes/<NODE>/bpf) are only collected from nodes
+  with the eBPF dataplane enabled.
+`
+	// Replace all instances of BINARY_NAME with the name of the binary.
+	name, _ := util.NameAndDescription()
+	doc = strings.ReplaceAll(doc, "<BINARY_NAME>", name)
+
+	parsedArgs, err := docopt.ParseArgs(doc, args, "")
+	if err != nil {
+		return fmt.Errorf("Invalid option: 'calicoctl %s'. Use flag '--help' to read about a specific subcommand.", strings.Join(args, " "))
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+	if context := parsedArgs["--context"]; context != nil {
+		os.Setenv("K8S_CURRENT_CONTEXT", context.(string))
+	}
+
+	var since time.Duration
+	if s := argutils.ArgStringOrBlank(parsedArgs, "--since"); s != "" {
+		if since, err = time.ParseDuration(s); err != nil || since < 0 {
+			return fmt.Errorf("invalid --since value %q: must be a positive duration, such as 10m", s)
+		}
+	}
+	parallelism, err := strconv.Atoi(parsedArgs["--max-parallelism"].(string))
+	if err != nil || parallelism < 1 {
+		return fmt.Errorf("invalid --max-parallelism value %q: must be a positive integer", parsedArgs["--max-parallelism"])
+	}
+	var nodes []string
+	if s := argutils.ArgStringOrBlank(parsedArgs, "--nodes"); s != "" {
+		nodes = strings.Split(s, ",")
+	}
+
+	err = common.CheckVersionMismatch(parsedArgs["--config"], parsedArgs["--allow-version-mismatch"])
+	if err != nil {
+		return err
+	}
+	cfg, err := clientmgr.LoadClientConfig(parsedArgs["--config"].(string))
+	if err != nil {
+		return err
+	}
+	client, err := clientmgr.NewClientFromConfig(cfg)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	outFile := argutils.ArgStringOrBlank(parsedArgs, "--output")
+	if outFile == "" {
+		outFile = fmt.Sprintf("calico-diagnostics-%s.tar.gz", now.UTC().Format("20060102-150405"))
+	}
+
+	b, err := newBundle(Metadata{
+		CalicoctlVersion: version,
+		CollectionTime:   now.UTC().Format(time.RFC3339),
+		DatastoreType:    string(cfg.Spec.DatastoreType),
+	})
+	if err != nil {
+		return err
+	}
+	defer b.cleanup()
+
+	c := &collector{
+		client:      client,
+		bundle:      b,
+		since:       since,
+		nodes:       nodes,
+		parallelism: parallelism,
+	}
+	fmt.Println("Collecting diagnostics")
+	if restConfig, cs, err := k8s.CreateKubernetesClientset(&cfg.Spec); err != nil {
+		// Without access to Kubernetes we can still collect the resources.
+		b.fail("nodes", fmt.Errorf("failed to create Kubernetes client: %w", err))
+	} else {
+		c.restConfig = restConfig
+		c.kubeClient = cs
+	}
+
+	c.collect(context.Background())
+
+	f, err := os.Create(outFile)
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %w", outFile, err)
+	}
+	if err := b.archive(f, now); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write %s: %w", outFile, err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write %s: %w", outFile, err)
+	}
+
+	fmt.Printf("\nDiags saved to %s\n", outFile)
+	if n := len(b.metadata.Errors); n > 0 {
+		fmt.Printf("%d diagnostics could not be collected; see metadata.json in the bundle for details.\n", n)
+	}
+	fmt.Println("If required, you can upload the diagnostics bundle to a file sharing service.")
+	return nil
+}
+
+// resourceLists are the Calico resources that are collected, keyed by the name of the file
+// that they are written to.
+var resourceLists = map[string]func(ctx context.Context, c clientv3.Interface) (interface{}, error){
+	"bgpconfigurations": func(ctx context.Context, c clientv3.Interface) (interface{}, error) {
+		return c.BGPConfigurations().List(ctx, options.ListOptions{})
+	},
+	"bgpfilters": func(ctx context.Context, c clientv3.Interface) (interface{}, error) {
+		return c.BGPFilter().List(ctx, options.ListOptions{})
+	},
+	"bgppeers": func(ctx context.Context, c clientv3.Interface) (interface{}, error) {
+		return c.BGPPeers().List(ctx, options.ListOptions{})
+	},
+	"blockaffinities": func(ctx context.Context, c clientv3.Interface) (interface{}, error) {
+		return c.BlockAffinities().List(ctx, options.ListOptions{})
+	},
+	"caliconodestatuses": func(ctx context.Context, c clientv3.Interface) (interface{}, error) {
+		return c.CalicoNodeStatus().List(ctx, options.ListOptions{})
+	},
+	"clusterinformations": func(ctx context.Context, c clientv3.Interface) (interface{}, error) {
+		return c.ClusterInformation().List(ctx, options.ListOptions{})
+	},
+	"felixconfigurations": func(ctx context.Context, c clientv3.Interface) (interface{}, error) {
+		return c.FelixConfigurations().List(ctx, options.ListOptions{})
+	},
+	"globalnetworkpolicies": func(ctx context.Context, c clientv3.Interface) (interface{}, error) {
+		return c.GlobalNetworkPolicies().List(ctx, options.ListOptions{})
+	},
+	"globalnetworksets": func(ctx context.Context, c clientv3.Interface) (interface{}, error) {
+		return c.GlobalNetworkSets().List(ctx, options.ListOptions{})
+	},
+	"hostendpoints": func(ctx context.Context, c clientv3.Interface) (interface{}, error) {
+		return c.HostEndpoints().List(ctx, options.ListOptions{})
+	},
+	"ipamconfigurations": func(ctx context.Context, c clientv3.Interface) (interface{}, error) {
+		return c.IPAMConfig().List(ctx, options.ListOptions{})
+	},
+	"ippools": func(ctx context.Context, c clientv3.Interface) (interface{}, error) {
+		return c.IPPools().List(ctx, options.ListOptions{})
+	},
+	"ipreservations": func(ctx context.Context, c clientv3.Interface) (interface{}, error) {
+		return c.IPReservations().List(ctx, options.ListOptions{})
+	},
+	"kubecontrollersconfigurations": func(ctx context.Context, c clientv3.Interface) (interface{}, error) {
+		return c.KubeControllersConfiguration().List(ctx, options.ListOptions{})
+	},
+	"networkpolicies": func(ctx context.Context, c clientv3.Interface) (interface{}, error) {
+		return c.NetworkPolicies().List(ctx, options.ListOptions{})
+	},
+	"networksets": func(ctx context.Context, c clientv3.Interface) (interface{}, error) {
+		return c.NetworkSets().List(ctx, options.ListOptions{})
+	},
+	"nodes": func(ctx context.Context, c clientv3.Interface) (interface{}, error) {
+		return c.Nodes().List(ctx, options.ListOptions{})
+	},
+	"profiles": func(ctx context.Context, c clientv3.Interface) (interface{}, error) {
+		return c.Profiles().List(ctx, options.ListOptions{})
+	},
+	"tiers": func(ctx context.Context, c clientv3.Interface) (interface{}, error) {
+		return c.Tiers().List(ctx, options.ListOptions{})
+	},
+	"workloadendpoints": func(ctx context.Context, c clientv3.Interface) (interface{}, error) {
+		return c.WorkloadEndpoints().List(ctx, options.ListOptions{})
+	},
+}
+
+// nodeCommand is a command that is run in the calico-node container on each node.  The output
+// is written to nodes/<NODE>/<group>/<name>.txt.
+type nodeCommand struct {
+	group   string
+	name    string
+	command []string
+	bpfOnly bool
+}
+
+var nodeCommands = []nodeCommand{
+	{group: "network", name: "ip-addr", command: []string{"ip", "-d", "addr"}},
+	{group: "network", name: "ip-link", command: []string{"ip", "-d", "-s", "link"}},
+	{group: "network", name: "ipv4-routes", command: []string{"ip", "-4", "route", "show", "table", "all"}},
+	{group: "network", name: "ipv6-routes", command: []string{"ip", "-6", "route", "show", "table", "all"}},
+	{group: "network", name: "ipv4-rules", command: []string{"ip", "-4", "rule"}},
+	{group: "network", name: "ipv6-rules", command: []string{"ip", "-6", "rule"}},
+	{group: "network", name: "ipv4-neighbors", command: []string{"ip", "-4", "neigh"}},
+	{group: "felix", name: "iptables-save", command: []string{"iptables-save", "-c"}},
+	{group: "felix", name: "ip6tables-save", command: []string{"ip6tables-save", "-c"}},
+	{group: "felix", name: "ipsets", command: []string{"ipset", "list"}},
+	{group: "felix", name: "nftables", command: []string{"nft", "list", "ruleset"}},
+	{group: "bird", name: "protocols", command: []string{"birdcl", "-s", "/var/run/calico/bird.ctl", "show", "protocols", "all"}},
+	{group: "bird", name: "routes", command: []string{"birdcl", "-s", "/var/run/calico/bird.ctl", "show", "route", "all"}},
+	{group: "bird", name: "protocols-ipv6", command: []string{"birdcl", "-s", "/var/run/calico/bird6.ctl", "show", "protocols", "all"}},
+	{group: "bird", name: "routes-ipv6", command: []string{"birdcl", "-s", "/var/run/calico/bird6.ctl", "show", "route", "all"}},
+	{group: "bpf", name: "conntrack", command: []string{"calico-node", "-bpf", "conntrack", "dump"}, bpfOnly: true},
+	{group: "bpf", name: "nat", command: []string{"calico-node", "-bpf", "nat", "dump"}, bpfOnly: true},
+	{group: "bpf", name: "routes", command: []string{"calico-node", "-bpf", "routes", "dump"}, bpfOnly: true},
+	{group: "bpf", name: "ifstate", command: []string{"calico-node", "-bpf", "ifstate", "dump"}, bpfOnly: true},
+	{group: "bpf", name: "counters", command: []string{"calico-node", "-bpf", "counters", "dump"}, bpfOnly: true},
+}
+
+// nodeCommandTimeout is the maximum time that a node command may take.
+const nodeCommandTimeout = time.Minute
+
+// calicoPodSelector selects the pods of the Calico components.
+const calicoPodSelector = "k8s-app in (calico-node, calico-typha, calico-kube-controllers, calico-apiserver)"
+
+// calicoPodNamespaces are the namespaces that the Calico pods are collected from.
+var calicoPodNamespaces = append([]string{"calico-apiserver"}, common.CalicoNamespaces...)
+
+// collector collects the diagnostics into a bundle.
+type collector struct {
+	client      clientv3.Interface
+	restConfig  *rest.Config
+	kubeClient  kubernetes.Interface
+	bundle      *bundle
+	since       time.Duration
+	nodes       []string
+	parallelism int
+
+	felixConfigs *apiv3.FelixConfigurationList
+}
+
+func (c *collector) collect(ctx context.Context) {
+	c.collectResources(ctx)
+	c.collectIPAM(ctx)
+	if c.kubeClient != nil {
+		c.collectPods(ctx)
+		c.collectNodes(ctx)
+	}
+}
+
+func (c *collector) collectResources(ctx context.Context) {
+	fmt.Println("Collecting Calico resources")
+	for name, list := range resourceLists {
+		p := "resources/" + name + ".yaml"
+		l, err := list(ctx, c.client)
+		if err != nil {
+			c.bundle.fail(p, err)
+			continue
+		}
+		c.bundle.writeYAML(p, l)
+
+		switch l := l.(type) {
+		case *apiv3.ClusterInformationList:
+			for _, ci := range l.Items {
+				if ci.Name == "default" {
+					c.bundle.metadata.ClusterGUID = ci.Spec.ClusterGUID
+					c.bundle.metadata.ClusterType = ci.Spec.ClusterType
+					c.bundle.metadata.CalicoVersion = ci.Spec.CalicoVersion
+				}
+			}
+		case *apiv3.FelixConfigurationList:
+			c.felixConfigs = l
+		}
+	}
+}
+
+func (c *collector) collectIPAM(ctx context.Context) {
+	fmt.Println("Collecting IPAM state")
+	type accessor interface {
+		Backend() bapi.Client
+	}
+	bc := c.client.(accessor).Backend()
+
+	blocks, err := bc.List(ctx, model.BlockListOptions{}, "")
+	if err != nil {
+		c.bundle.fail("ipam/blocks.json", err)
+	} else {
+		values := []interface{}{}
+		for _, kvp := range sortedKVPairs(blocks.KVPairs) {
+			values = append(values, kvp.Value)
+		}
+		c.bundle.writeJSON("ipam/blocks.json", values)
+	}
+
+	handles, err := bc.List(ctx, model.IPAMHandleListOptions{}, "")
+	if err != nil {
+		c.bundle.fail("ipam/handles.json", err)
+	} else {
+		values := []interface{}{}
+		for _, kvp := range sortedKVPairs(handles.KVPairs) {
+			values = append(values, kvp.Value)
+		}
+		c.bundle.writeJSON("ipam/handles.json", values)
+	}
+}
+
+// sortedKVPairs sorts the KVPairs by key, so that the output is deterministic.
+func sortedKVPairs(kvps []*model.KVPair) []*model.KVPair {
+	sort.Slice(kvps, func(i, j int) bool {
+		return kvps[i].Key.String() < kvps[j].Key.String()
+	})
+	return kvps
+}
+
+// collectPods collects the Calico pods, and the logs of those pods other than calico-node,
+// which are collected with the node diagnostics.
+func (c *collector) collectPods(ctx context.Context) {
+	fmt.Println("Collecting Calico pods")
+	pods := &corev1.PodList{}
+	for _, ns := range calicoPodNamespaces {
+		l, err := c.kubeClient.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{LabelSelector: calicoPodSelector})
+		if err != nil {
+			c.bundle.fail("kubernetes/pods.yaml", err)
+			return
+		}
+		for i := range l.Items {
+			pods.Items = append(pods.Items, *redactPod(&l.Items[i]))
+		}
+	}
+	sort.Slice(pods.Items, func(i, j int) bool {
+		a, b := pods.Items[i], pods.Items[j]
+		return a.Namespace+"/"+a.Name < b.Namespace+"/"+b.Name
+	})
+	c.bundle.writeYAML("kubernetes/pods.yaml", pods)
+
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if pod.Labels["k8s-app"] == "calico-node" {
+			continue
+		}
+		c.collectLogs(ctx, pod, path.Join("logs", pod.Namespace, pod.Name))
+	}
+}
+
+// collectLogs collects the logs of each container in the pod into the directory.
+func (c *collector) collectLogs(ctx context.Context, pod *corev1.Pod, dir string) {
+	restarted := map[string]bool{}
+	for _, s := range append(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses...) {
+		restarted[s.Name] = s.RestartCount > 0
+	}
+	for _, container := range append(pod.Spec.InitContainers, pod.Spec.Containers...) {
+		c.collectLog(ctx, pod, container.Name, false, path.Join(dir, container.Name+".log"))
+		if restarted[container.Name] {
+			c.collectLog(ctx, pod, container.Name, true, path.Join(dir, container.Name+".previous.log"))
+		}
+	}
+}
+
+func (c *collector) collectLog(ctx context.Context, pod *corev1.Pod, container string, previous bool, p string) {
+	opts := &corev1.PodLogOptions{
+		Container:  container,
+		Previous:   previous,
+		Timestamps: true,
+	}
+	if c.since > 0 {
+		seconds := int64(c.since.Seconds())
+		opts.SinceSeconds = &seconds
+	}
+	stream, err := c.kubeClient.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, opts).Stream(ctx)
+	if err != nil {
+		c.bundle.fail(p, err)
+		return
+	}
+	defer stream.Close()
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		c.bundle.fail(p, err)
+		return
+	}
+	c.bundle.write(p, data)
+}
+
+// collectNodes collects the node diagnostics from the calico-node pods, from several nodes at
+// the same time.
+func (c *collector) collectNodes(ctx context.Context) {
+	nodes := c.nodes
+	if len(nodes) == 0 {
+		l, err := c.client.Nodes().List(ctx, options.ListOptions{})
+		if err != nil {
+			c.bundle.fail("nodes", err)
+			return
+		}
+		for _, n := range l.Items {
+			nodes = append(nodes, n.Name)
+		}
+	}
+	sort.Strings(nodes)
+
+	pods, err := common.CalicoNodePods(ctx, c.kubeClient)
+	if err != nil {
+		c.bundle.fail("nodes", err)
+		return
+	}
+
+	fmt.Printf("Collecting diagnostics from %d nodes\n", len(nodes))
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, c.parallelism)
+	for _, n := range nodes {
+		pod := pods[n]
+		if pod == nil {
+			c.bundle.fail(path.Join("nodes", n), fmt.Errorf("no running calico-node pod found on node %s", n))
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(n string, pod *corev1.Pod) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			c.collectNode(ctx, n, pod)
+		}(n, pod)
+	}
+	wg.Wait()
+}
+
+func (c *collector) collectNode(ctx context.Context, nodeName string, pod *corev1.Pod) {
+	fmt.Printf("Collecting diagnostics from node %s\n", nodeName)
+	c.bundle.addNode(nodeName)
+	dir := path.Join("nodes", nodeName)
+	bpf := c.bpfEnabled(nodeName)
+	for _, cmd := range nodeCommands {
+		if cmd.bpfOnly && !bpf {
+			continue
+		}
+		p := path.Join(dir, cmd.group, cmd.name+".txt")
+		var stdout, stderr bytes.Buffer
+		cmdCtx, cancel := context.WithTimeout(ctx, nodeCommandTimeout)
+		err := common.ExecInPod(cmdCtx, c.restConfig, c.kubeClient, pod, common.CalicoNodeContainerName, cmd.command, &stdout, &stderr)
+		cancel()
+		if err != nil {
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				err = fmt.Errorf("%w: %s", err, msg)
+			}
+			c.bundle.fail(p, err)
+			continue
+		}
+		c.bundle.write(p, stdout.Bytes())
+	}
+	c.collectLogs(ctx, pod, path.Join(dir, "logs"))
+}
+
+// bpfEnabled returns true if the eBPF dataplane is enabled on the node, according to the
+// default and node specific FelixConfigurations.
+func (c *collector) bpfEnabled(nodeName string) bool {
+	if c.felixConfigs == nil {
+		return false
+	}
+	enabled := false
+	for _, name := range []string{"default", "node." + nodeName} {
+		for _, fc := range c.felixConfigs.Items {
+			if fc.Name == name && fc.Spec.BPFEnabled != nil {
+				enabled = *fc.Spec.BPFEnabled
+			}
+		}
+	}
+	return enabled
+}
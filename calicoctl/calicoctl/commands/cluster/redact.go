@@ -0,0 +1,85 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cluster
+
+import (
+	"regexp"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const redacted = "<redacted>"
+
+var (
+	// secretName matches the names of settings that hold secrets.
+	secretName = `(?i:password|passwd|secret|token|private[_-]?key|api[_-]?key|credentials?)`
+
+	redactions = []struct {
+		re          *regexp.Regexp
+		replacement string
+	}{
+		// PEM encoded private keys.
+		{
+			re:          regexp.MustCompile(`(?s)(-----BEGIN [A-Z ]*PRIVATE KEY-----).*?(-----END [A-Z ]*PRIVATE KEY-----)`),
+			replacement: "${1}\n" + redacted + "\n${2}",
+		},
+		// HTTP authorization headers.
+		{
+			re:          regexp.MustCompile(`(?i)(\b(?:bearer|basic)[ \t]+)[A-Za-z0-9\-._~+/]+=*`),
+			replacement: "${1}" + redacted,
+		},
+		// Settings in YAML, JSON, environment variable and key=value formats, for example
+		// `password: foo`, `"token": "foo"` and `DB_PASSWORD=foo`.  Values that start a nested
+		// object or list are not matched, so references to secrets are preserved.
+		{
+			re:          regexp.MustCompile(`(\w*` + secretName + `\w*["']?[ \t]*[:=][ \t]*["']?)([^\s"',{}\[\]]+)`),
+			replacement: "${1}" + redacted,
+		},
+		// Quoted settings in the BIRD configuration, for example `password "foo";`.
+		{
+			re:          regexp.MustCompile(`(` + secretName + `[ \t]+")[^"]*(")`),
+			replacement: "${1}" + redacted + "${2}",
+		},
+	}
+
+	envSecretName = regexp.MustCompile(secretName)
+)
+
+// redact replaces anything that looks like a secret in the data.
+func redact(data []byte) []byte {
+	for _, r := range redactions {
+		data = r.re.ReplaceAll(data, []byte(r.replacement))
+	}
+	return data
+}
+
+// redactPod returns a copy of the pod with the values of environment variables that look like
+// secrets removed.  Environment variables that refer to secrets are preserved.
+func redactPod(pod *corev1.Pod) *corev1.Pod {
+	pod = pod.DeepCopy()
+	redactContainers := func(containers []corev1.Container) {
+		for i := range containers {
+			for j := range containers[i].Env {
+				env := &containers[i].Env[j]
+				if env.Value != "" && envSecretName.MatchString(env.Name) {
+					env.Value = redacted
+				}
+			}
+		}
+	}
+	redactContainers(pod.Spec.InitContainers)
+	redactContainers(pod.Spec.Containers)
+	return pod
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	CalicoNodeLabelSelector = "k8s-app=calico-node"
+	CalicoNodeContainerName = "calico-node"
+)
+
+// CalicoNamespaces are the namespaces that the Calico components may be installed in, in the
+// order that they are searched.
+var CalicoNamespaces = []string{"calico-system", "kube-system"}
+
+// FindCalicoNodePod returns the running calico-node pod on the given node.
+func FindCalicoNodePod(ctx context.Context, cs kubernetes.Interface, nodeName string) (*corev1.Pod, error) {
+	for _, ns := range CalicoNamespaces {
+		pods, err := cs.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{
+			LabelSelector: CalicoNodeLabelSelector,
+			FieldSelector: fields.OneTermEqualSelector("spec.nodeName", nodeName).String(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list calico-node pods: %w", err)
+		}
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			// Field selectors are not supported by every client, so check the node again.
+			if pod.Spec.NodeName == nodeName && pod.Status.Phase == corev1.PodRunning {
+				return pod, nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no running calico-node pod found on node %s", nodeName)
+}
+
+// CalicoNodePods returns the running calico-node pods, keyed by node name.
+func CalicoNodePods(ctx context.Context, cs kubernetes.Interface) (map[string]*corev1.Pod, error) {
+	podsByNode := map[string]*corev1.Pod{}
+	for _, ns := range CalicoNamespaces {
+		pods, err := cs.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{LabelSelector: CalicoNodeLabelSelector})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list calico-node pods: %w", err)
+		}
+		for i := range pods.Items {
+			pod := &pods.Items[i]
+			if pod.Status.Phase != corev1.PodRunning || podsByNode[pod.Spec.NodeName] != nil {
+				continue
+			}
+			podsByNode[pod.Spec.NodeName] = pod
+		}
+	}
+	return podsByNode, nil
+}
+
+// ExecInPod runs the command in a container of the pod, copying its output to stdout and
+// stderr.
+func ExecInPod(
+	ctx context.Context,
+	config *rest.Config,
+	cs kubernetes.Interface,
+	pod *corev1.Pod,
+	container string,
+	command []string,
+	stdout, stderr io.Writer,
+) error {
+	req := cs.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(pod.Namespace).
+		Name(pod.Name).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: container,
+			Command:   command,
+			Stdout:    true,
+			Stderr:    true,
+		}, scheme.ParameterCodec)
+
+	exec, err := remotecommand.NewSPDYExecutor(config, "POST", req.URL())
+	if err != nil {
+		return fmt.Errorf("failed to exec in pod %s/%s: %w", pod.Namespace, pod.Name, err)
+	}
+	err = exec.StreamWithContext(ctx, remotecommand.StreamOptions{
+		Stdout: stdout,
+		Stderr: stderr,
+	})
+	if err != nil {
+		return fmt.Errorf("command failed in pod %s/%s: %w", pod.Namespace, pod.Name, err)
+	}
+	return nil
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func calicoNodeTestPod(namespace, name, nodeName string, labels map[string]string, phase corev1.PodPhase) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+		Spec:       corev1.PodSpec{NodeName: nodeName},
+		Status:     corev1.PodStatus{Phase: phase},
+	}
+}
+
+var _ = Describe("calico-node pods", func() {
+	calicoNodeLabels := map[string]string{"k8s-app": "calico-node"}
+	ctx := context.Background()
+	var cs *fake.Clientset
+
+	BeforeEach(func() {
+		cs = fake.NewSimpleClientset(
+			calicoNodeTestPod("calico-system", "calico-node-a", "node-a", calicoNodeLabels, corev1.PodRunning),
+			calicoNodeTestPod("calico-system", "calico-node-b", "node-b", calicoNodeLabels, corev1.PodPending),
+			calicoNodeTestPod("kube-system", "calico-node-c", "node-b", calicoNodeLabels, corev1.PodRunning),
+			calicoNodeTestPod("calico-system", "other", "node-c", nil, corev1.PodRunning),
+		)
+	})
+
+	It("should find the running calico-node pod on the node", func() {
+		pod, err := FindCalicoNodePod(ctx, cs, "node-a")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pod.Name).To(Equal("calico-node-a"))
+
+		pod, err = FindCalicoNodePod(ctx, cs, "node-b")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pod.Name).To(Equal("calico-node-c"))
+
+		_, err = FindCalicoNodePod(ctx, cs, "node-c")
+		Expect(err).To(MatchError("no running calico-node pod found on node node-c"))
+	})
+
+	It("should list the running calico-node pods by node", func() {
+		pods, err := CalicoNodePods(ctx, cs)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(pods).To(HaveLen(2))
+		Expect(pods["node-a"].Name).To(Equal("calico-node-a"))
+		Expect(pods["node-b"].Name).To(Equal("calico-node-c"))
+	})
+})
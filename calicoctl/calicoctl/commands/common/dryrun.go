@@ -0,0 +1,362 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/argutils"
+	"github.com/projectcalico/calico/calicoctl/calicoctl/resourcemgr"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	calicoErrors "github.com/projectcalico/calico/libcalico-go/lib/errors"
+	validator "github.com/projectcalico/calico/libcalico-go/lib/validator/v3"
+)
+
+// DryRunMode is the value of the --dry-run option.
+type DryRunMode string
+
+const (
+	// DryRunNone means the resources are written to the datastore.
+	DryRunNone DryRunMode = ""
+	// DryRunClient validates the resources locally and compares them with the current
+	// resources in the datastore.
+	DryRunClient DryRunMode = "client"
+	// DryRunServer also checks the existence and resource version of the resources against
+	// those read from the datastore. The checks are made by calicoctl: no write is sent to
+	// the datastore, so preconditions that only the datastore enforces are not checked.
+	DryRunServer DryRunMode = "server"
+)
+
+// NormalizeDryRunArgs rewrites a bare --dry-run flag as --dry-run=client. docopt does
+// not support options with optional values, so the commands declare --dry-run=<MODE>.
+func NormalizeDryRunArgs(args []string) []string {
+	normalized := make([]string, len(args))
+	for i, a := range args {
+		if a == "--dry-run" {
+			a = "--dry-run=" + string(DryRunClient)
+		}
+		normalized[i] = a
+	}
+	return normalized
+}
+
+// dryRunModeFromArgs returns the dry run mode requested by the --dry-run and --diff options.
+// --diff on its own implies a client dry run.
+func dryRunModeFromArgs(args map[string]interface{}) (DryRunMode, error) {
+	switch mode := DryRunMode(argutils.ArgStringOrBlank(args, "--dry-run")); mode {
+	case DryRunNone:
+		if argutils.ArgBoolOrFalse(args, "--diff") {
+			return DryRunClient, nil
+		}
+		return DryRunNone, nil
+	case DryRunClient, DryRunServer:
+		return mode, nil
+	default:
+		return DryRunNone, fmt.Errorf("invalid value for --dry-run: %q (must be %q or %q)", mode, DryRunClient, DryRunServer)
+	}
+}
+
+// DiffOperation is the change a write would make to a resource.
+type DiffOperation string
+
+const (
+	DiffCreate    DiffOperation = "created"
+	DiffUpdate    DiffOperation = "updated"
+	DiffUnchanged DiffOperation = "unchanged"
+)
+
+// FieldChange is a change to a single field of a resource. Old is nil for an added field
+// and New is nil for a removed field.
+type FieldChange struct {
+	Path string
+	Old  interface{}
+	New  interface{}
+}
+
+// ResourceDiff describes the changes a write would make to a single resource.
+type ResourceDiff struct {
+	Identifier string
+	Operation  DiffOperation
+	Changes    []FieldChange
+}
+
+// ExecuteResourceDryRun validates the resource and compares it with the current version in
+// the datastore, without writing it.
+func ExecuteResourceDryRun(args map[string]interface{}, client client.Interface, resource resourcemgr.ResourceObject, action action, mode DryRunMode) (ResourceDiff, error) {
+	rm := resourcemgr.GetResourceManager(resource)
+	if err := handleNamespace(resource, rm, args); err != nil {
+		return ResourceDiff{}, err
+	}
+	id := resourceIdentifier(resource)
+	if _, ok := resource.(*api.ClusterInformation); ok {
+		return ResourceDiff{}, calicoErrors.ErrorOperationNotSupported{
+			Operation:  "apply",
+			Identifier: "ClusterInformation",
+			Reason:     "resource is readonly",
+		}
+	}
+	resource, err := withWriteDefaults(resource)
+	if err != nil {
+		return ResourceDiff{}, err
+	}
+	if err := validator.Validate(resource); err != nil {
+		return ResourceDiff{}, err
+	}
+
+	var current resourcemgr.ResourceObject
+	ro, err := rm.GetOrList(context.Background(), client, resource.DeepCopyObject().(resourcemgr.ResourceObject))
+	switch err.(type) {
+	case nil:
+		current = ro.(resourcemgr.ResourceObject)
+	case calicoErrors.ErrorResourceDoesNotExist:
+	default:
+		return ResourceDiff{}, err
+	}
+
+	if mode == DryRunServer {
+		if err := checkWritePreconditions(resource, current, action, id); err != nil {
+			return ResourceDiff{}, err
+		}
+	}
+
+	diff := ResourceDiff{Identifier: id}
+	var currentFields map[string]interface{}
+	if current != nil {
+		if currentFields, err = comparableFields(current); err != nil {
+			return ResourceDiff{}, err
+		}
+	}
+	desiredFields, err := comparableFields(resource)
+	if err != nil {
+		return ResourceDiff{}, err
+	}
+	diff.Changes = diffFields("", currentFields, desiredFields)
+
+	switch {
+	case current == nil:
+		diff.Operation = DiffCreate
+	case len(diff.Changes) == 0:
+		diff.Operation = DiffUnchanged
+	default:
+		diff.Operation = DiffUpdate
+	}
+	return diff, nil
+}
+
+// withWriteDefaults returns a copy of the resource with the defaults and conversions the
+// client applies when the resource is written, so that the resource can be compared with the
+// version read back from the datastore.
+func withWriteDefaults(resource resourcemgr.ResourceObject) (resourcemgr.ResourceObject, error) {
+	resource = resource.DeepCopyObject().(resourcemgr.ResourceObject)
+	if err := client.SetWriteDefaults(resource); err != nil {
+		return nil, err
+	}
+	return resource, nil
+}
+
+// checkWritePreconditions returns the error the datastore would return when writing the
+// resource, given the current version of the resource (nil if it does not exist).
+func checkWritePreconditions(resource, current resourcemgr.ResourceObject, action action, id string) error {
+	switch {
+	case action == ActionCreate && current != nil:
+		return calicoErrors.ErrorResourceAlreadyExists{Identifier: id}
+	case action == ActionUpdate && current == nil:
+		return calicoErrors.ErrorResourceDoesNotExist{Identifier: id}
+	}
+
+	rv := resource.GetObjectMeta().GetResourceVersion()
+	if current != nil && rv != "" && rv != current.GetObjectMeta().GetResourceVersion() {
+		return calicoErrors.ErrorResourceUpdateConflict{
+			Err: fmt.Errorf("Resource version '%s' is out of date (latest: %s). Update the resource YAML/JSON in order to make changes.",
+				rv, current.GetObjectMeta().GetResourceVersion()),
+			Identifier: id,
+		}
+	}
+	return nil
+}
+
+func resourceIdentifier(r resourcemgr.ResourceObject) string {
+	kind := r.GetObjectKind().GroupVersionKind().Kind
+	if ns := r.GetObjectMeta().GetNamespace(); ns != "" {
+		return fmt.Sprintf("%s(%s/%s)", kind, ns, r.GetObjectMeta().GetName())
+	}
+	return fmt.Sprintf("%s(%s)", kind, r.GetObjectMeta().GetName())
+}
+
+// comparableFields returns the resource as a generic map, with the metadata populated by the
+// datastore removed. Only the name, namespace, labels and annotations are set by the user.
+func comparableFields(r resourcemgr.ResourceObject) (map[string]interface{}, error) {
+	b, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return nil, err
+	}
+	delete(fields, "status")
+	if md, ok := fields["metadata"].(map[string]interface{}); ok {
+		for k := range md {
+			switch k {
+			case "name", "namespace", "labels", "annotations":
+			default:
+				delete(md, k)
+			}
+		}
+	}
+	return fields, nil
+}
+
+// diffFields returns the changes from the old value to the new value, one per leaf field.
+func diffFields(path string, oldVal, newVal interface{}) []FieldChange {
+	switch n := newVal.(type) {
+	case map[string]interface{}:
+		o, ok := oldVal.(map[string]interface{})
+		if !ok {
+			break
+		}
+		keys := map[string]bool{}
+		for k := range o {
+			keys[k] = true
+		}
+		for k := range n {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+
+		var changes []FieldChange
+		for _, k := range sorted {
+			changes = append(changes, diffFields(joinPath(path, k), o[k], n[k])...)
+		}
+		return changes
+	case []interface{}:
+		o, ok := oldVal.([]interface{})
+		if !ok {
+			break
+		}
+		var changes []FieldChange
+		for i := 0; i < len(o) || i < len(n); i++ {
+			var ov, nv interface{}
+			if i < len(o) {
+				ov = o[i]
+			}
+			if i < len(n) {
+				nv = n[i]
+			}
+			changes = append(changes, diffFields(fmt.Sprintf("%s[%d]", path, i), ov, nv)...)
+		}
+		return changes
+	}
+
+	if oldVal == nil && newVal == nil {
+		return nil
+	}
+	if oldVal != nil && newVal != nil {
+		if isContainer(oldVal) != isContainer(newVal) {
+			// The field changed type; report it as a single change.
+			return []FieldChange{{Path: path, Old: oldVal, New: newVal}}
+		}
+		if jsonString(oldVal) == jsonString(newVal) {
+			return nil
+		}
+		return []FieldChange{{Path: path, Old: oldVal, New: newVal}}
+	}
+
+	// The field was added or removed. Report each of its leaf fields so that the output
+	// for a new resource lists all of its fields.
+	if isContainer(newVal) || isContainer(oldVal) {
+		empty := map[string]interface{}{}
+		if _, ok := newVal.([]interface{}); ok {
+			return diffFields(path, []interface{}{}, newVal)
+		}
+		if _, ok := oldVal.([]interface{}); ok {
+			return diffFields(path, oldVal, []interface{}{})
+		}
+		if newVal == nil {
+			return diffFields(path, oldVal, empty)
+		}
+		return diffFields(path, empty, newVal)
+	}
+	return []FieldChange{{Path: path, Old: oldVal, New: newVal}}
+}
+
+func isContainer(v interface{}) bool {
+	switch v.(type) {
+	case map[string]interface{}, []interface{}:
+		return true
+	}
+	return false
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func jsonString(v interface{}) string {
+	b, err := json.Marshal(v)
+	if err != nil {
+		log.WithError(err).Debug("Failed to marshal field value")
+		return fmt.Sprint(v)
+	}
+	return string(b)
+}
+
+// HandleDryRunResults writes the outcome of a dry run, including the changed fields of each
+// resource if showDiff is true. When showDiff is true it returns an error if any resource would
+// be changed, so that the command exits with a non-zero exit code.
+func HandleDryRunResults(w io.Writer, results CommandResults, showDiff bool) error {
+	changed := 0
+	for _, d := range results.Diffs {
+		if d.Operation != DiffUnchanged {
+			changed++
+		}
+		fmt.Fprintf(w, "%s would be %s (dry run)\n", d.Identifier, d.Operation)
+		if !showDiff {
+			continue
+		}
+		for _, c := range d.Changes {
+			switch {
+			case c.Old == nil:
+				fmt.Fprintf(w, "  + %s: %s\n", c.Path, jsonString(c.New))
+			case c.New == nil:
+				fmt.Fprintf(w, "  - %s: %s\n", c.Path, jsonString(c.Old))
+			default:
+				fmt.Fprintf(w, "  ~ %s: %s -> %s\n", c.Path, jsonString(c.Old), jsonString(c.New))
+			}
+		}
+	}
+
+	if len(results.ResErrs) > 0 {
+		return fmt.Errorf("Hit error(s): %v", results.ResErrs)
+	}
+	if showDiff && changed > 0 {
+		return fmt.Errorf("%d of %d resource(s) would be changed", changed, results.NumResources)
+	}
+	return nil
+}
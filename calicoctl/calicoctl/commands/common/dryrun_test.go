@@ -0,0 +1,248 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/apiconfig"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+)
+
+func dryRunPolicy(order float64, labels map[string]string, selectors ...string) *apiv3.GlobalNetworkPolicy {
+	p := apiv3.NewGlobalNetworkPolicy()
+	p.Name = "default.allow-dns"
+	p.Labels = labels
+	p.Spec.Order = &order
+	for _, s := range selectors {
+		p.Spec.Ingress = append(p.Spec.Ingress, apiv3.Rule{
+			Action: apiv3.Allow,
+			Source: apiv3.EntityRule{Selector: s},
+		})
+	}
+	return p
+}
+
+var _ = Describe("Dry run", func() {
+	It("should default a bare --dry-run to client mode", func() {
+		Expect(NormalizeDryRunArgs([]string{"apply", "--dry-run", "-f", "-"})).To(Equal(
+			[]string{"apply", "--dry-run=client", "-f", "-"}))
+		Expect(NormalizeDryRunArgs([]string{"apply", "--dry-run=server"})).To(Equal(
+			[]string{"apply", "--dry-run=server"}))
+	})
+
+	DescribeTable("should parse the dry run mode",
+		func(args map[string]interface{}, expected DryRunMode, expectErr bool) {
+			mode, err := dryRunModeFromArgs(args)
+			if expectErr {
+				Expect(err).To(HaveOccurred())
+				return
+			}
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mode).To(Equal(expected))
+		},
+		Entry("no options", map[string]interface{}{}, DryRunNone, false),
+		Entry("client", map[string]interface{}{"--dry-run": "client"}, DryRunClient, false),
+		Entry("server", map[string]interface{}{"--dry-run": "server", "--diff": true}, DryRunServer, false),
+		Entry("diff only", map[string]interface{}{"--diff": true}, DryRunClient, false),
+		Entry("invalid", map[string]interface{}{"--dry-run": "all"}, DryRunNone, true),
+	)
+
+	It("should ignore metadata populated by the datastore", func() {
+		current := dryRunPolicy(100, map[string]string{"team": "a"}, "all()")
+		current.ResourceVersion = "1234"
+		current.UID = "abcd"
+		current.CreationTimestamp = metav1.Now()
+		desired := dryRunPolicy(100, map[string]string{"team": "a"}, "all()")
+
+		currentFields, err := comparableFields(current)
+		Expect(err).NotTo(HaveOccurred())
+		desiredFields, err := comparableFields(desired)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(diffFields("", currentFields, desiredFields)).To(BeEmpty())
+	})
+
+	It("should report the changed fields", func() {
+		current := dryRunPolicy(100, map[string]string{"team": "a"}, "all()")
+		desired := dryRunPolicy(200, nil, "all()", "role == 'dns'")
+
+		currentFields, err := comparableFields(current)
+		Expect(err).NotTo(HaveOccurred())
+		desiredFields, err := comparableFields(desired)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(diffFields("", currentFields, desiredFields)).To(Equal([]FieldChange{
+			{Path: "metadata.labels.team", Old: "a"},
+			{Path: "spec.ingress[1].action", New: "Allow"},
+			{Path: "spec.ingress[1].source.selector", New: "role == 'dns'"},
+			{Path: "spec.order", Old: float64(100), New: float64(200)},
+		}))
+	})
+
+	It("should report every field of a new resource", func() {
+		desiredFields, err := comparableFields(dryRunPolicy(100, nil))
+		Expect(err).NotTo(HaveOccurred())
+		var paths []string
+		for _, c := range diffFields("", nil, desiredFields) {
+			Expect(c.Old).To(BeNil())
+			paths = append(paths, c.Path)
+		}
+		Expect(paths).To(Equal([]string{"apiVersion", "kind", "metadata.name", "spec.order"}))
+	})
+
+	It("should check the preconditions of the write", func() {
+		current := dryRunPolicy(100, nil)
+		current.ResourceVersion = "2"
+		desired := dryRunPolicy(200, nil)
+
+		Expect(checkWritePreconditions(desired, current, ActionCreate, "p")).To(HaveOccurred())
+		Expect(checkWritePreconditions(desired, nil, ActionUpdate, "p")).To(HaveOccurred())
+		Expect(checkWritePreconditions(desired, current, ActionApply, "p")).NotTo(HaveOccurred())
+
+		desired.ResourceVersion = "1"
+		Expect(checkWritePreconditions(desired, current, ActionApply, "p")).To(HaveOccurred())
+	})
+
+	It("should exit non-zero with --diff only if a resource would change", func() {
+		results := CommandResults{
+			NumResources: 2,
+			Diffs: []ResourceDiff{
+				{Identifier: "GlobalNetworkPolicy(a)", Operation: DiffUnchanged},
+				{Identifier: "GlobalNetworkPolicy(b)", Operation: DiffUpdate, Changes: []FieldChange{
+					{Path: "spec.order", Old: float64(100), New: float64(200)},
+				}},
+			},
+		}
+		var out bytes.Buffer
+		Expect(HandleDryRunResults(&out, results, true)).To(MatchError("1 of 2 resource(s) would be changed"))
+		Expect(out.String()).To(Equal("GlobalNetworkPolicy(a) would be unchanged (dry run)\n" +
+			"GlobalNetworkPolicy(b) would be updated (dry run)\n" +
+			"  ~ spec.order: 100 -> 200\n"))
+
+		out.Reset()
+		Expect(HandleDryRunResults(&out, results, false)).NotTo(HaveOccurred())
+
+		results.Diffs = results.Diffs[:1]
+		Expect(HandleDryRunResults(&out, results, true)).NotTo(HaveOccurred())
+
+		results.ResErrs = []error{errors.New("invalid")}
+		Expect(HandleDryRunResults(&out, results, false)).To(HaveOccurred())
+	})
+
+	Describe("against a datastore", func() {
+		var (
+			c   client.Interface
+			dir string
+		)
+
+		BeforeEach(func() {
+			var err error
+			dir, err = os.MkdirTemp("", "calicoctl-dryrun")
+			Expect(err).NotTo(HaveOccurred())
+			cfg := apiconfig.NewCalicoAPIConfig()
+			cfg.Spec.DatastoreType = apiconfig.File
+			cfg.Spec.DatastoreFile = filepath.Join(dir, "datastore.json")
+			c, err = client.New(*cfg)
+			Expect(err).NotTo(HaveOccurred())
+
+			for name, order := range map[string]float64{"default": apiv3.DefaultTierOrder, "security": 100} {
+				tier := apiv3.NewTier()
+				tier.Name = name
+				tier.Spec.Order = &order
+				_, err = c.Tiers().Create(context.Background(), tier, options.SetOptions{})
+				Expect(err).NotTo(HaveOccurred())
+			}
+		})
+
+		AfterEach(func() {
+			Expect(os.RemoveAll(dir)).To(Succeed())
+		})
+
+		It("should report no changes for policies that have just been applied", func() {
+			gnp := apiv3.NewGlobalNetworkPolicy()
+			gnp.Name = "allow-dns"
+			gnp.Spec.Ingress = []apiv3.Rule{{Action: apiv3.Allow}}
+			_, err := c.GlobalNetworkPolicies().Create(context.Background(), gnp.DeepCopy(), options.SetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			np := apiv3.NewNetworkPolicy()
+			np.Name = "security.allow-dns"
+			np.Namespace = "default"
+			np.Spec.Tier = "security"
+			np.Spec.Egress = []apiv3.Rule{{Action: apiv3.Allow}}
+			_, err = c.NetworkPolicies().Create(context.Background(), np.DeepCopy(), options.SetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, mode := range []DryRunMode{DryRunClient, DryRunServer} {
+				diff, err := ExecuteResourceDryRun(map[string]interface{}{}, c, gnp.DeepCopy(), ActionApply, mode)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(diff.Changes).To(BeEmpty())
+				Expect(diff.Operation).To(Equal(DiffUnchanged))
+
+				diff, err = ExecuteResourceDryRun(map[string]interface{}{}, c, np.DeepCopy(), ActionApply, mode)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(diff.Changes).To(BeEmpty())
+				Expect(diff.Operation).To(Equal(DiffUnchanged))
+			}
+
+			gnp.Spec.Types = []apiv3.PolicyType{apiv3.PolicyTypeIngress, apiv3.PolicyTypeEgress}
+			diff, err := ExecuteResourceDryRun(map[string]interface{}{}, c, gnp, ActionApply, DryRunClient)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diff.Operation).To(Equal(DiffUpdate))
+			Expect(diff.Changes).To(Equal([]FieldChange{{Path: "spec.types[1]", New: "Egress"}}))
+		})
+
+		It("should report no changes for resources with defaulted fields that have just been applied", func() {
+			pool := apiv3.NewIPPool()
+			pool.Name = "pool-1"
+			pool.Spec.CIDR = "10.10.0.0/16"
+			_, err := c.IPPools().Create(context.Background(), pool.DeepCopy(), options.SetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			fc := apiv3.NewFelixConfiguration()
+			fc.Name = "default"
+			_, err = c.FelixConfigurations().Create(context.Background(), fc.DeepCopy(), options.SetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+
+			for _, mode := range []DryRunMode{DryRunClient, DryRunServer} {
+				diff, err := ExecuteResourceDryRun(map[string]interface{}{}, c, pool.DeepCopy(), ActionApply, mode)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(diff.Changes).To(BeEmpty())
+				Expect(diff.Operation).To(Equal(DiffUnchanged))
+
+				diff, err = ExecuteResourceDryRun(map[string]interface{}{}, c, fc.DeepCopy(), ActionApply, mode)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(diff.Changes).To(BeEmpty())
+				Expect(diff.Operation).To(Equal(DiffUnchanged))
+			}
+
+			pool.Spec.BlockSize = 24
+			diff, err := ExecuteResourceDryRun(map[string]interface{}{}, c, pool, ActionApply, DryRunClient)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diff.Operation).To(Equal(DiffUpdate))
+			Expect(diff.Changes).To(Equal([]FieldChange{{Path: "spec.blockSize", Old: float64(26), New: float64(24)}}))
+		})
+	})
+})
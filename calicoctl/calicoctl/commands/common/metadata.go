@@ -0,0 +1,189 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	"github.com/projectcalico/calico/calicoctl/calicoctl/resourcemgr"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	calicoErrors "github.com/projectcalico/calico/libcalico-go/lib/errors"
+	"github.com/projectcalico/calico/libcalico-go/lib/selector"
+)
+
+// MetadataField is the map in the object metadata that a MetadataChange applies to.
+type MetadataField string
+
+const (
+	Labels      MetadataField = "label"
+	Annotations MetadataField = "annotation"
+)
+
+func (f MetadataField) get(r resourcemgr.ResourceObject) map[string]string {
+	if f == Labels {
+		return r.GetObjectMeta().GetLabels()
+	}
+	return r.GetObjectMeta().GetAnnotations()
+}
+
+func (f MetadataField) set(r resourcemgr.ResourceObject, m map[string]string) {
+	if f == Labels {
+		r.GetObjectMeta().SetLabels(m)
+	} else {
+		r.GetObjectMeta().SetAnnotations(m)
+	}
+}
+
+// MetadataChange is a change to a single label or annotation.
+type MetadataChange struct {
+	Field     MetadataField
+	Key       string
+	Value     string
+	Remove    bool
+	Overwrite bool
+
+	// IgnoreMissing skips, rather than fails, objects that do not have the key being removed.
+	IgnoreMissing bool
+}
+
+// MetadataResult is the outcome of a MetadataChange on a single object.
+type MetadataResult string
+
+const (
+	MetadataSet       MetadataResult = "set"
+	MetadataUpdated   MetadataResult = "updated"
+	MetadataRemoved   MetadataResult = "removed"
+	MetadataUnchanged MetadataResult = "unchanged"
+)
+
+// metadataUpdateAttempts is the number of times an update is attempted when it conflicts with
+// another update of the object.
+const metadataUpdateAttempts = 5
+
+// Apply applies the change to the map, which may be nil, and returns the updated map.
+func (c MetadataChange) Apply(m map[string]string) (map[string]string, MetadataResult, error) {
+	oldValue, exists := m[c.Key]
+	if c.Remove {
+		if !exists {
+			if c.IgnoreMissing {
+				return m, MetadataUnchanged, nil
+			}
+			return nil, "", fmt.Errorf("cannot remove %s, key %s does not exist", c.Field, c.Key)
+		}
+		delete(m, c.Key)
+		return m, MetadataRemoved, nil
+	}
+
+	if exists && oldValue != c.Value && !c.Overwrite {
+		return nil, "", fmt.Errorf("%s key %s is already present. please use '--overwrite' to set a new value.", c.Field, c.Key)
+	}
+	if m == nil {
+		m = map[string]string{}
+	}
+	m[c.Key] = c.Value
+	if exists {
+		return m, MetadataUpdated, nil
+	}
+	return m, MetadataSet, nil
+}
+
+// MetadataUpdate is the outcome of a MetadataChange on a single object, as returned by
+// UpdateMetadata.
+type MetadataUpdate struct {
+	Resource resourcemgr.ResourceObject
+	Result   MetadataResult
+	Err      error
+}
+
+// SelectResources lists the resources of the same kind as the given resource whose labels
+// match the selector.  An empty selector matches all resources.  Namespaced resources are
+// listed from the namespace given by the --namespace and --all-namespaces arguments.
+func SelectResources(ctx context.Context, args map[string]interface{}, c client.Interface, resource resourcemgr.ResourceObject, sel string) ([]resourcemgr.ResourceObject, error) {
+	parsedSel, err := selector.Parse(sel)
+	if err != nil {
+		return nil, fmt.Errorf("invalid selector %q: %w", sel, err)
+	}
+
+	resource = resource.DeepCopyObject().(resourcemgr.ResourceObject)
+	resource.GetObjectMeta().SetName("")
+	rm := resourcemgr.GetResourceManager(resource)
+	if err := handleNamespace(resource, rm, args); err != nil {
+		return nil, err
+	}
+	list, err := rm.GetOrList(ctx, c, resource)
+	if err != nil {
+		return nil, err
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []resourcemgr.ResourceObject
+	for _, item := range items {
+		r := item.(resourcemgr.ResourceObject)
+		if parsedSel.Evaluate(r.GetObjectMeta().GetLabels()) {
+			selected = append(selected, r)
+		}
+	}
+	return selected, nil
+}
+
+// UpdateMetadata applies the change to each of the resources.  Resources are updated one at a
+// time, and if an update conflicts with another update of the same resource, the resource is
+// fetched again and the change is reapplied.  A failure to update one resource does not stop
+// the others from being updated.
+func UpdateMetadata(ctx context.Context, c client.Interface, resources []resourcemgr.ResourceObject, change MetadataChange) []MetadataUpdate {
+	var updates []MetadataUpdate
+	for _, r := range resources {
+		result, err := updateResourceMetadata(ctx, c, r, change)
+		updates = append(updates, MetadataUpdate{Resource: r, Result: result, Err: err})
+	}
+	return updates
+}
+
+func updateResourceMetadata(ctx context.Context, c client.Interface, r resourcemgr.ResourceObject, change MetadataChange) (MetadataResult, error) {
+	rm := resourcemgr.GetResourceManager(r)
+	r = r.DeepCopyObject().(resourcemgr.ResourceObject)
+	for attempt := 1; ; attempt++ {
+		m, result, err := change.Apply(change.Field.get(r))
+		if err != nil || result == MetadataUnchanged {
+			return result, err
+		}
+		change.Field.set(r, m)
+
+		_, err = rm.Update(ctx, c, r)
+		if err == nil {
+			return result, nil
+		}
+		if _, ok := err.(calicoErrors.ErrorResourceUpdateConflict); !ok || attempt >= metadataUpdateAttempts {
+			return "", err
+		}
+
+		// Another update got there first, so get the latest revision and try again.  Clear the
+		// resource version first, since otherwise the get reads the same, stale, revision.
+		log.WithError(err).Infof("Conflict updating %s, retrying", r.GetObjectMeta().GetName())
+		r.GetObjectMeta().SetResourceVersion("")
+		latest, err := rm.GetOrList(ctx, c, r)
+		if err != nil {
+			return "", err
+		}
+		r = latest.(resourcemgr.ResourceObject)
+	}
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+
+	"github.com/projectcalico/calico/calicoctl/calicoctl/resourcemgr"
+	"github.com/projectcalico/calico/libcalico-go/lib/apiconfig"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+)
+
+// revisionRecordingClient records the resource versions that GlobalNetworkSets are read at.
+type revisionRecordingClient struct {
+	client.Interface
+	reads []string
+}
+
+func (c *revisionRecordingClient) GlobalNetworkSets() client.GlobalNetworkSetInterface {
+	return revisionRecordingGlobalNetworkSets{c.Interface.GlobalNetworkSets(), c}
+}
+
+type revisionRecordingGlobalNetworkSets struct {
+	client.GlobalNetworkSetInterface
+	c *revisionRecordingClient
+}
+
+func (r revisionRecordingGlobalNetworkSets) Get(ctx context.Context, name string, opts options.GetOptions) (*apiv3.GlobalNetworkSet, error) {
+	r.c.reads = append(r.c.reads, opts.ResourceVersion)
+	return r.GlobalNetworkSetInterface.Get(ctx, name, opts)
+}
+
+var _ = Describe("MetadataChange", func() {
+	DescribeTable("Apply",
+		func(change MetadataChange, m, expected map[string]string, expectedResult MetadataResult, expectedErr string) {
+			result, r, err := change.Apply(m)
+			if expectedErr != "" {
+				Expect(err).To(MatchError(ContainSubstring(expectedErr)))
+				return
+			}
+			Expect(err).NotTo(HaveOccurred())
+			Expect(r).To(Equal(expectedResult))
+			Expect(result).To(Equal(expected))
+		},
+		Entry("sets a key in a nil map",
+			MetadataChange{Field: Labels, Key: "app", Value: "web"},
+			nil, map[string]string{"app": "web"}, MetadataSet, ""),
+		Entry("sets a new key",
+			MetadataChange{Field: Labels, Key: "app", Value: "web"},
+			map[string]string{"zone": "a"}, map[string]string{"zone": "a", "app": "web"}, MetadataSet, ""),
+		Entry("refuses to change an existing key",
+			MetadataChange{Field: Labels, Key: "app", Value: "web"},
+			map[string]string{"app": "db"}, nil, MetadataResult(""), "label key app is already present"),
+		Entry("allows setting an existing key to the same value",
+			MetadataChange{Field: Labels, Key: "app", Value: "web"},
+			map[string]string{"app": "web"}, map[string]string{"app": "web"}, MetadataUpdated, ""),
+		Entry("overwrites an existing key",
+			MetadataChange{Field: Annotations, Key: "app", Value: "web", Overwrite: true},
+			map[string]string{"app": "db"}, map[string]string{"app": "web"}, MetadataUpdated, ""),
+		Entry("removes a key",
+			MetadataChange{Field: Labels, Key: "app", Remove: true},
+			map[string]string{"app": "db", "zone": "a"}, map[string]string{"zone": "a"}, MetadataRemoved, ""),
+		Entry("refuses to remove a missing key",
+			MetadataChange{Field: Annotations, Key: "app", Remove: true},
+			map[string]string{"zone": "a"}, nil, MetadataResult(""), "cannot remove annotation, key app does not exist"),
+		Entry("skips a missing key when ignoring missing keys",
+			MetadataChange{Field: Labels, Key: "app", Remove: true, IgnoreMissing: true},
+			map[string]string{"zone": "a"}, map[string]string{"zone": "a"}, MetadataUnchanged, ""),
+	)
+})
+
+var _ = Describe("UpdateMetadata", func() {
+	var (
+		c   *revisionRecordingClient
+		dir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "calicoctl-metadata")
+		Expect(err).NotTo(HaveOccurred())
+		cfg := apiconfig.NewCalicoAPIConfig()
+		cfg.Spec.DatastoreType = apiconfig.File
+		cfg.Spec.DatastoreFile = filepath.Join(dir, "datastore.json")
+		cc, err := client.New(*cfg)
+		Expect(err).NotTo(HaveOccurred())
+		c = &revisionRecordingClient{Interface: cc}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	It("should read the latest revision and retry after a conflict", func() {
+		ctx := context.Background()
+		gns := apiv3.NewGlobalNetworkSet()
+		gns.Name = "nets"
+		stale, err := c.GlobalNetworkSets().Create(ctx, gns, options.SetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		// Update the set behind the back of the metadata update, so that its first update
+		// conflicts.
+		latest := stale.DeepCopy()
+		latest.Labels = map[string]string{"zone": "a"}
+		_, err = c.GlobalNetworkSets().Update(ctx, latest, options.SetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		c.reads = nil
+		updates := UpdateMetadata(ctx, c, []resourcemgr.ResourceObject{stale},
+			MetadataChange{Field: Labels, Key: "app", Value: "web"})
+		Expect(updates).To(HaveLen(1))
+		Expect(updates[0].Err).NotTo(HaveOccurred())
+		Expect(updates[0].Result).To(Equal(MetadataSet))
+
+		// The retry must not ask for the stale revision: the etcd datastore would return it.
+		Expect(c.reads).NotTo(BeEmpty())
+		Expect(c.reads).To(HaveEach(""))
+		current, err := c.GlobalNetworkSets().Get(ctx, "nets", options.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(current.Labels).To(Equal(map[string]string{"zone": "a", "app": "web"}))
+	})
+})
@@ -18,6 +18,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"reflect"
 	"sort"
@@ -100,42 +101,10 @@ type ResourcePrinterTable struct {
 func (r ResourcePrinterTable) Print(client client.Interface, resources []runtime.Object) error {
 	log.Infof("Output in table format (wide=%v)", r.Wide)
 	for _, resource := range resources {
-		// Get the resource manager for the resource type.
-		rm := resourcemgr.GetResourceManager(resource)
-
-		// If no headings have been specified then we must be using the default
-		// headings for that resource type.
-		headings := r.Headings
-		if r.Headings == nil {
-			headings = rm.GetTableDefaultHeadings(r.Wide)
-		}
-
-		// Look up the template string for the specific resource type.
-		tpls, err := rm.GetTableTemplate(headings, r.PrintNamespace)
-		if err != nil {
-			return err
-		}
-		log.WithField("template", tpls).Debug("Got resource template")
-
-		// Convert the template string into a template - we need to include the join
-		// function.
-		fns := yamltemplate.FuncMap{
-			"join":            join,
-			"joinAndTruncate": joinAndTruncate,
-			"config":          config(client),
-		}
-		tmpl, err := yamltemplate.New("get").Funcs(fns).Parse(tpls)
-		if err != nil {
-			panic(err)
-		}
-
 		// Use a tabwriter to write out the template - this provides better formatting.
 		writer := tabwriter.NewWriter(os.Stdout, 5, 1, 3, ' ', 0)
-		err = tmpl.Execute(writer, resource)
-		// Templates for ps format are internally defined and therefore we should not
-		// hit errors writing the table formats.
-		if err != nil {
-			panic(err)
+		if err := r.execute(client, writer, resource); err != nil {
+			return err
 		}
 		writer.Flush()
 
@@ -145,6 +114,46 @@ func (r ResourcePrinterTable) Print(client client.Interface, resources []runtime
 	return nil
 }
 
+// execute writes the table for a single resource (or resource list) to the writer.  The
+// columns are separated by tabs.
+func (r ResourcePrinterTable) execute(client client.Interface, writer io.Writer, resource runtime.Object) error {
+	// Get the resource manager for the resource type.
+	rm := resourcemgr.GetResourceManager(resource)
+
+	// If no headings have been specified then we must be using the default
+	// headings for that resource type.
+	headings := r.Headings
+	if r.Headings == nil {
+		headings = rm.GetTableDefaultHeadings(r.Wide)
+	}
+
+	// Look up the template string for the specific resource type.
+	tpls, err := rm.GetTableTemplate(headings, r.PrintNamespace)
+	if err != nil {
+		return err
+	}
+	log.WithField("template", tpls).Debug("Got resource template")
+
+	// Convert the template string into a template - we need to include the join
+	// function.
+	fns := yamltemplate.FuncMap{
+		"join":            join,
+		"joinAndTruncate": joinAndTruncate,
+		"config":          config(client),
+	}
+	tmpl, err := yamltemplate.New("get").Funcs(fns).Parse(tpls)
+	if err != nil {
+		panic(err)
+	}
+
+	// Templates for ps format are internally defined and therefore we should not
+	// hit errors writing the table formats.
+	if err := tmpl.Execute(writer, resource); err != nil {
+		panic(err)
+	}
+	return nil
+}
+
 // ResourcePrinterTemplateFile implements the ResourcePrinter interface and is used to display
 // a slice of resources using a user-defined go-lang template specified in a file.
 type ResourcePrinterTemplateFile struct {
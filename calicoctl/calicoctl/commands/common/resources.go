@@ -108,6 +108,11 @@ type CommandResults struct {
 	// The Calico API client used for the requests (useful if required
 	// again).
 	Client client.Interface
+
+	// The dry run mode, and the changes the command would have made if it is
+	// a dry run.
+	DryRun DryRunMode
+	Diffs  []ResourceDiff
 }
 
 type fileError struct {
@@ -136,6 +141,11 @@ func ExecuteConfigCommand(args map[string]interface{}, action action) CommandRes
 
 	errorOnEmpty := !argutils.ArgBoolOrFalse(args, "--skip-empty")
 
+	dryRun, err := dryRunModeFromArgs(args)
+	if err != nil {
+		return CommandResults{Err: err}
+	}
+
 	if filename := args["--filename"]; filename != nil {
 		// Filename is specified.  Use the file iterator to handle the fact that this may be a directory rather than a
 		// single file. For each file load the resources from the file and convert to a single slice of resources for
@@ -221,7 +231,7 @@ func ExecuteConfigCommand(args map[string]interface{}, action action) CommandRes
 
 	// Initialise the command results with the number of resources and the name of the
 	// kind of resource (if only dealing with a single resource).
-	results := CommandResults{Client: cclient}
+	results := CommandResults{Client: cclient, DryRun: dryRun}
 	var kind string
 	count := make(map[string]int)
 	for _, r := range resources {
@@ -236,7 +246,9 @@ func ExecuteConfigCommand(args map[string]interface{}, action action) CommandRes
 	// For commands that modify config, first attempt to initialize the datastore.
 	switch action {
 	case ActionApply, ActionCreate, ActionUpdate:
-		tryEnsureInitialized(context.Background(), cclient)
+		if dryRun == DryRunNone {
+			tryEnsureInitialized(context.Background(), cclient)
+		}
 	}
 
 	// Now execute the command on each resource in order, exiting as soon as we hit an
@@ -262,8 +274,31 @@ func ExecuteConfigCommand(args map[string]interface{}, action action) CommandRes
 		return CommandResults{Err: fmt.Errorf("resource name may not be empty")}
 	}
 
+	// If the resources are to be applied atomically, stage the changes in a transaction and
+	// only commit it if every resource could be applied.
+	execClient := cclient
+	var txn client.Transaction
+	if action == ActionApply && dryRun == DryRunNone && argutils.ArgBoolOrFalse(args, "--atomic") {
+		txn = cclient.Transaction()
+		execClient = txn.Client()
+	}
+
 	for _, r := range resources {
-		res, err := ExecuteResourceAction(args, cclient, r, action)
+		if dryRun != DryRunNone {
+			// Nothing is written in a dry run, so continue after errors to report on
+			// every resource.
+			diff, err := ExecuteResourceDryRun(args, cclient, r, action, dryRun)
+			if err != nil {
+				results.ResErrs = append(results.ResErrs, err)
+				continue
+			}
+			results.Diffs = append(results.Diffs, diff)
+			results.Resources = append(results.Resources, r)
+			results.NumHandled = results.NumHandled + 1
+			continue
+		}
+
+		res, err := ExecuteResourceAction(args, execClient, r, action)
 		if err != nil {
 			switch action {
 			case ActionApply, ActionCreate, ActionDelete, ActionGetOrList:
@@ -293,6 +328,19 @@ func ExecuteConfigCommand(args map[string]interface{}, action action) CommandRes
 		results.NumHandled = results.NumHandled + len(res)
 	}
 
+	if txn != nil {
+		if len(results.ResErrs) == 0 {
+			if err := txn.Commit(context.Background()); err != nil {
+				results.ResErrs = append(results.ResErrs, err)
+			}
+		}
+		if len(results.ResErrs) != 0 {
+			// None of the resources have been applied.
+			results.Resources = nil
+			results.NumHandled = 0
+		}
+	}
+
 	return results
 }
 
@@ -0,0 +1,246 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+
+	"github.com/projectcalico/go-json/json"
+	"github.com/projectcalico/go-yaml-wrapper"
+	log "github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/argutils"
+	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/clientmgr"
+	"github.com/projectcalico/calico/calicoctl/calicoctl/resourcemgr"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/watch"
+)
+
+// ExecuteWatchCommand watches the resources specified by the command line arguments and
+// prints each event with the resource printer, until it is interrupted or the watch fails.
+//
+// If --since-revision is specified, only the changes made after that revision are printed.
+// Otherwise the existing resources are printed as added events, followed by the changes.
+func ExecuteWatchCommand(args map[string]interface{}, rp ResourcePrinter) error {
+	if err := CheckVersionMismatch(args["--config"], args["--allow-version-mismatch"]); err != nil {
+		return err
+	}
+
+	resources, err := resourcemgr.GetResourcesFromArgs(args)
+	if err != nil {
+		return err
+	}
+	if len(resources) == 0 {
+		return fmt.Errorf("No resources specified")
+	}
+
+	cf := args["--config"].(string)
+	cclient, err := clientmgr.NewClient(cf)
+	if err != nil {
+		return fmt.Errorf("Failed to create Calico API client: %s", err)
+	}
+
+	revision := argutils.ArgStringOrBlank(args, "--since-revision")
+	for _, r := range resources {
+		if err := handleNamespace(r, resourcemgr.GetResourceManager(r), args); err != nil {
+			return err
+		}
+		r.GetObjectMeta().SetResourceVersion(revision)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	events := make(chan watch.Event)
+	for _, r := range resources {
+		go watchResource(ctx, cclient, r, events)
+	}
+
+	wp := &watchPrinter{rp: rp, client: cclient, out: os.Stdout}
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case e := <-events:
+			if e.Type == watch.Error {
+				return fmt.Errorf("Failed to watch resources: %v", e.Error)
+			}
+			if err := wp.print(e); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// watchResource watches the resource and sends the events on the events channel.  The watch
+// is restarted from the last revision seen if the datastore closes it.  Errors are sent as
+// error events, after which the watch is not restarted.
+func watchResource(ctx context.Context, c client.Interface, resource resourcemgr.ResourceObject, events chan<- watch.Event) {
+	rm := resourcemgr.GetResourceManager(resource)
+	gvk := resource.GetObjectKind().GroupVersionKind()
+	send := func(e watch.Event) bool {
+		select {
+		case events <- e:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+
+	for {
+		w, err := rm.Watch(ctx, c, resource)
+		if err != nil {
+			send(watch.Event{Type: watch.Error, Error: err})
+			return
+		}
+		for e := range w.ResultChan() {
+			if e.Type == watch.Error {
+				w.Stop()
+				send(e)
+				return
+			}
+
+			// Make sure the resource kind is set so that the printer can find the resource
+			// manager, and remember the revision so that we can restart the watch.
+			for _, o := range []runtime.Object{e.Object, e.Previous} {
+				if o != nil && o.GetObjectKind().GroupVersionKind().Empty() {
+					o.GetObjectKind().SetGroupVersionKind(gvk)
+				}
+			}
+			if o, ok := eventObject(e).(resourcemgr.ResourceObject); ok {
+				resource.GetObjectMeta().SetResourceVersion(o.GetObjectMeta().GetResourceVersion())
+			}
+			if !send(e) {
+				w.Stop()
+				return
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		log.WithField("revision", resource.GetObjectMeta().GetResourceVersion()).Info("Watch closed by datastore, restarting")
+	}
+}
+
+// eventObject returns the resource that a watch event refers to.  For a deleted event, this
+// is the resource before it was deleted.
+func eventObject(e watch.Event) runtime.Object {
+	if e.Object != nil {
+		return e.Object
+	}
+	return e.Previous
+}
+
+// watchEventOutput is the format of a watch event in JSON and YAML output.
+type watchEventOutput struct {
+	Type   watch.EventType `json:"type"`
+	Object runtime.Object  `json:"object"`
+}
+
+// watchPrinter prints watch events using a resource printer.  Table output has the event type
+// as an additional first column, and only includes the headings before the first event.  JSON
+// and YAML output include the event type with each resource.  Templates are executed for
+// each resource in turn.
+type watchPrinter struct {
+	rp     ResourcePrinter
+	client client.Interface
+	out    io.Writer
+
+	// The widths of the table columns.  The events are printed as they are received, so the
+	// columns are only widened when a value does not fit.
+	widths []int
+}
+
+func (p *watchPrinter) print(e watch.Event) error {
+	obj := eventObject(e)
+	if obj == nil {
+		return nil
+	}
+
+	switch rp := p.rp.(type) {
+	case ResourcePrinterTable:
+		// The table template outputs a headings line followed by the resource line, with
+		// the columns separated by tabs.
+		table := new(bytes.Buffer)
+		if err := rp.execute(p.client, table, obj); err != nil {
+			return err
+		}
+		lines := strings.SplitN(strings.TrimSuffix(table.String(), "\n"), "\n", 2)
+		if len(lines) != 2 {
+			return fmt.Errorf("unexpected table output for %s", obj.GetObjectKind().GroupVersionKind().Kind)
+		}
+		headings := append([]string{"EVENT"}, strings.Split(strings.TrimSuffix(lines[0], "\t"), "\t")...)
+		row := append([]string{string(e.Type)}, strings.Split(strings.TrimSuffix(lines[1], "\t"), "\t")...)
+		if p.widths == nil {
+			// Allow for the longest event type in the first column.
+			p.updateWidths([]string{string(watch.Modified)})
+			p.updateWidths(headings)
+			p.updateWidths(row)
+			if err := p.writeRow(headings); err != nil {
+				return err
+			}
+		}
+		p.updateWidths(row)
+		return p.writeRow(row)
+	case ResourcePrinterJSON:
+		output, err := json.MarshalIndent(watchEventOutput{Type: e.Type, Object: obj}, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(p.out, "%s\n", string(output))
+		return err
+	case ResourcePrinterYAML:
+		output, err := yaml.Marshal(watchEventOutput{Type: e.Type, Object: obj})
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(p.out, "---\n%s", string(output))
+		return err
+	default:
+		return p.rp.Print(p.client, []runtime.Object{obj})
+	}
+}
+
+func (p *watchPrinter) updateWidths(cells []string) {
+	for i, c := range cells {
+		if i == len(p.widths) {
+			p.widths = append(p.widths, 0)
+		}
+		if len(c) > p.widths[i] {
+			p.widths[i] = len(c)
+		}
+	}
+}
+
+func (p *watchPrinter) writeRow(cells []string) error {
+	line := new(strings.Builder)
+	for i, c := range cells {
+		line.WriteString(c)
+		if i < len(cells)-1 {
+			line.WriteString(strings.Repeat(" ", p.widths[i]-len(c)+3))
+		}
+	}
+	line.WriteString("\n")
+	_, err := io.WriteString(p.out, line.String())
+	return err
+}
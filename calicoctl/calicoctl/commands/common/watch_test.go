@@ -0,0 +1,67 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"bytes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/watch"
+)
+
+var _ = Describe("Watch printer", func() {
+	var out *bytes.Buffer
+	pool := func(name, cidr string) *apiv3.IPPool {
+		p := apiv3.NewIPPool()
+		p.ObjectMeta = metav1.ObjectMeta{Name: name}
+		p.Spec.CIDR = cidr
+		return p
+	}
+
+	BeforeEach(func() {
+		out = new(bytes.Buffer)
+	})
+
+	It("prints the table headings once, with the event type in the first column", func() {
+		wp := &watchPrinter{rp: ResourcePrinterTable{Headings: []string{"NAME", "CIDR"}}, client: newMockClient(), out: out}
+		Expect(wp.print(watch.Event{Type: watch.Added, Object: pool("pool-1", "10.0.0.0/16")})).To(Succeed())
+		Expect(wp.print(watch.Event{Type: watch.Modified, Object: pool("pool-1", "10.1.0.0/16")})).To(Succeed())
+		Expect(wp.print(watch.Event{Type: watch.Deleted, Previous: pool("pool-1", "10.1.0.0/16")})).To(Succeed())
+		Expect(out.String()).To(Equal(
+			"EVENT      NAME     CIDR\n" +
+				"ADDED      pool-1   10.0.0.0/16\n" +
+				"MODIFIED   pool-1   10.1.0.0/16\n" +
+				"DELETED    pool-1   10.1.0.0/16\n",
+		))
+	})
+
+	It("includes the event type in JSON output", func() {
+		wp := &watchPrinter{rp: ResourcePrinterJSON{}, client: newMockClient(), out: out}
+		Expect(wp.print(watch.Event{Type: watch.Deleted, Previous: pool("pool-1", "10.0.0.0/16")})).To(Succeed())
+		Expect(out.String()).To(ContainSubstring(`"type": "DELETED"`))
+		Expect(out.String()).To(ContainSubstring(`"name": "pool-1"`))
+	})
+
+	It("includes the event type in YAML output", func() {
+		wp := &watchPrinter{rp: ResourcePrinterYAML{}, client: newMockClient(), out: out}
+		Expect(wp.print(watch.Event{Type: watch.Added, Object: pool("pool-1", "10.0.0.0/16")})).To(Succeed())
+		Expect(out.String()).To(HavePrefix("---\n"))
+		Expect(out.String()).To(ContainSubstring("type: ADDED"))
+	})
+})
@@ -15,7 +15,9 @@
 package commands
 
 import (
+	"errors"
 	"fmt"
+	"os"
 	"strings"
 
 	"github.com/docopt/docopt-go"
@@ -24,14 +26,17 @@ import (
 	networkingv1 "k8s.io/api/networking/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	adminpolicy "sigs.k8s.io/network-policy-api/apis/v1alpha1"
 
 	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/argutils"
 	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/common"
 	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/constants"
 	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/resourceloader"
+	"github.com/projectcalico/calico/calicoctl/calicoctl/resourcemgr"
 	"github.com/projectcalico/calico/calicoctl/calicoctl/util"
 	"github.com/projectcalico/calico/libcalico-go/lib/apis/v1/unversioned"
 	cconversion "github.com/projectcalico/calico/libcalico-go/lib/backend/k8s/conversion"
+	cerrors "github.com/projectcalico/calico/libcalico-go/lib/errors"
 	"github.com/projectcalico/calico/libcalico-go/lib/names"
 	"github.com/projectcalico/calico/libcalico-go/lib/upgrade/converters"
 	validator "github.com/projectcalico/calico/libcalico-go/lib/validator/v3"
@@ -40,7 +45,7 @@ import (
 func Convert(args []string) error {
 	doc := constants.DatastoreIntro + `Usage:
   <BINARY_NAME> convert --filename=<FILENAME>
-                [--output=<OUTPUT>] [--ignore-validation] [--allow-version-mismatch]
+                [--output=<OUTPUT>] [--to=<FORMAT>] [--ignore-validation] [--allow-version-mismatch]
 
 Examples:
   # Convert the contents of policy.yaml to a Calico v3 policy.
@@ -49,18 +54,36 @@ Examples:
   # Convert a policy based on the JSON passed into stdin.
   cat policy.json | <BINARY_NAME> convert -f -
 
+  # Convert Calico v3 policies to Kubernetes policies.
+  <BINARY_NAME> convert -f ./calico-policy.yaml --to=k8s
+
 Options:
   -h --help                     Show this screen.
   -f --filename=<FILENAME>      Filename to use to create the resource. If set to
                                 "-" loads from stdin.
   -o --output=<OUTPUT FORMAT>   Output format. One of: yaml or json.
                                 [Default: yaml]
+     --to=<FORMAT>              Format to convert to. One of: calico or k8s.
+                                [Default: calico]
      --ignore-validation        Skip validation on the converted manifest.
      --allow-version-mismatch   Allow client and cluster versions mismatch.
 
 
 Description:
-  Convert config files from Calico v1 or Kubernetes to Calico v3 API versions. Both YAML and JSON formats are accepted.
+  Convert config files from Calico v1 or Kubernetes to Calico v3 API versions,
+  or from Calico v3 to Kubernetes API versions. Both YAML and JSON formats are
+  accepted.
+
+  With --to=calico (the default), Calico v1 resources, Kubernetes
+  NetworkPolicies and Kubernetes AdminNetworkPolicies are converted to Calico v3
+  resources.
+
+  With --to=k8s, Calico v3 NetworkPolicies are converted to Kubernetes
+  NetworkPolicies, and Calico v3 GlobalNetworkPolicies are converted to
+  Kubernetes AdminNetworkPolicies. Calico policy features that can't be
+  expressed in the target format are reported on stderr; rules that use them
+  are left out, so that the converted policy never allows more traffic than the
+  original.
 
   The default output will be printed to stdout in YAML format.
 `
@@ -91,18 +114,52 @@ Description:
 	}
 
 	filename := argutils.ArgStringOrBlank(parsedArgs, "--filename")
+	ignoreValidation := argutils.ArgBoolOrFalse(parsedArgs, "--ignore-validation")
+
+	var results []runtime.Object
+	switch to := parsedArgs["--to"].(string); to {
+	case "calico":
+		results, err = convertToCalico(filename, ignoreValidation)
+	case "k8s":
+		results, err = convertToK8s(filename)
+	default:
+		return fmt.Errorf("unrecognized conversion format '%s'", to)
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Infof("results: %+v", results)
+
+	if len(results) > 1 {
+		results, err = createV1List(results)
+		if err != nil {
+			return fmt.Errorf("Failed to create v1.List: %w", err)
+		}
+	}
 
+	err = rp.Print(nil, results)
+	if err != nil {
+		return fmt.Errorf("Failed to print results: %w", err)
+	}
+
+	return nil
+}
+
+// convertToCalico converts the Calico v1 and Kubernetes resources in the file to Calico v3
+// resources.
+func convertToCalico(filename string, ignoreValidation bool) ([]runtime.Object, error) {
 	// Load the resource from file and convert to a slice
 	// of resources for easier handling.
 	convRes, err := resourceloader.CreateResourcesFromFile(filename)
 	if err != nil {
-		return fmt.Errorf("Failed to create resources from file: %w", err)
+		return nil, fmt.Errorf("Failed to create resources from file: %w", err)
 	}
 
 	// Unpack list resources (if any) into the slice
 	convRes, err = unpackResourceLists(convRes)
 	if err != nil {
-		return fmt.Errorf("Failed to unpack lists: %w", err)
+		return nil, fmt.Errorf("Failed to unpack lists: %w", err)
 	}
 
 	var results []runtime.Object
@@ -110,7 +167,7 @@ Description:
 	for _, convResource := range convRes {
 		v3Resource, err := convertResource(convResource)
 		if err != nil {
-			return fmt.Errorf("Failed to convert resource: %w", err)
+			return nil, fmt.Errorf("Failed to convert resource: %w", err)
 		}
 
 		// Remove any extra metadata the object might have.
@@ -121,10 +178,9 @@ Description:
 		rom.SetDeletionTimestamp(nil)
 		rom.SetDeletionGracePeriodSeconds(nil)
 
-		ignoreValidation := argutils.ArgBoolOrFalse(parsedArgs, "--ignore-validation")
 		if !ignoreValidation {
 			if err := validator.Validate(v3Resource); err != nil {
-				return fmt.Errorf("Converted manifest resource(s) failed validation: %s"+
+				return nil, fmt.Errorf("Converted manifest resource(s) failed validation: %s"+
 					"Re-run the command with '--ignore-validation' flag to see the converted output.\n", err)
 			}
 		}
@@ -132,29 +188,70 @@ Description:
 		results = append(results, v3Resource)
 	}
 
-	log.Infof("results: %+v", results)
+	return results, nil
+}
 
-	if len(results) > 1 {
-		results, err = createV1List(results)
-		if err != nil {
-			return fmt.Errorf("Failed to create v1.List: %w", err)
+// convertToK8s converts the Calico v3 policies in the file to Kubernetes policies, reporting
+// the policy features that can't be converted.
+func convertToK8s(filename string) ([]runtime.Object, error) {
+	resources, err := resourcemgr.CreateResourcesFromFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("Failed to create resources from file: %w", err)
+	}
+
+	// Unpack list resources (if any).
+	var policies []runtime.Object
+	for _, r := range resources {
+		switch list := r.(type) {
+		case *apiv3.NetworkPolicyList:
+			for i := range list.Items {
+				policies = append(policies, &list.Items[i])
+			}
+		case *apiv3.GlobalNetworkPolicyList:
+			for i := range list.Items {
+				policies = append(policies, &list.Items[i])
+			}
+		default:
+			policies = append(policies, r)
 		}
 	}
 
-	err = rp.Print(nil, results)
-	if err != nil {
-		return fmt.Errorf("Failed to print results: %w", err)
+	var results []runtime.Object
+	for _, r := range policies {
+		var converted runtime.Object
+		var unsupported []string
+		var name string
+		switch p := r.(type) {
+		case *apiv3.NetworkPolicy:
+			name = p.Namespace + "/" + p.Name
+			converted, unsupported, err = cconversion.CalicoNetworkPolicyToK8s(p)
+		case *apiv3.GlobalNetworkPolicy:
+			name = p.Name
+			converted, unsupported, err = cconversion.CalicoGlobalNetworkPolicyToK8sAdmin(p)
+		default:
+			return nil, fmt.Errorf("conversion to Kubernetes is not supported for the resource type '%s'",
+				r.GetObjectKind().GroupVersionKind().Kind)
+		}
+		kind := r.GetObjectKind().GroupVersionKind().Kind
+		if err != nil {
+			return nil, fmt.Errorf("Failed to convert %s %s: %w", kind, name, err)
+		}
+		for _, u := range unsupported {
+			fmt.Fprintf(os.Stderr, "Warning: %s %s: unsupported feature: %s\n", kind, name, u)
+		}
+		results = append(results, converted)
 	}
 
-	return nil
+	return results, nil
 }
 
 // convertResource converts a k8s or a calico v1 resource into a calico v3 resource.
 func convertResource(convResource unversioned.Resource) (converters.Resource, error) {
 	var res converters.Resource
 
-	if strings.EqualFold(convResource.GetTypeMetadata().APIVersion, resourceloader.VersionK8sNetworkingV1) {
-		// Convert K8s resource to v3 (currently only NetworkPolicy is supported)
+	if strings.EqualFold(convResource.GetTypeMetadata().APIVersion, resourceloader.VersionK8sNetworkingV1) ||
+		strings.EqualFold(convResource.GetTypeMetadata().APIVersion, resourceloader.VersionK8sPolicyV1Alpha1) {
+		// Convert K8s resource to v3 (NetworkPolicy and AdminNetworkPolicy are supported)
 		var err error
 
 		res, err = convertK8sResource(convResource)
@@ -184,7 +281,7 @@ func convertResource(convResource unversioned.Resource) (converters.Resource, er
 	return res, nil
 }
 
-// Convert K8s resource to v3 (currently only NetworkPolicy is supported)
+// Convert K8s resource to v3 (NetworkPolicy and AdminNetworkPolicy are supported)
 func convertK8sResource(convResource unversioned.Resource) (converters.Resource, error) {
 	var res converters.Resource
 
@@ -218,6 +315,46 @@ func convertK8sResource(convResource unversioned.Resource) (converters.Resource,
 		// function adds it for when it is used for coexisting calico/k8s policies).
 		k8snp.Name = strings.TrimPrefix(k8snp.Name, names.K8sNetworkPolicyNamePrefix)
 		res = k8snp
+	case "adminnetworkpolicy":
+		k8sAdminNetworkPolicy, ok := convResource.(*resourceloader.K8sAdminNetworkPolicy)
+		if !ok {
+			return nil, fmt.Errorf("failed to convert resource to K8sAdminNetworkPolicy")
+		}
+
+		anp := k8sAdminNetworkPolicy.AdminNetworkPolicy
+		c := cconversion.NewConverter()
+
+		// Rules that can't be converted are replaced so that the policy fails closed, and
+		// returned in the error along with the converted policy.
+		kvp, err := c.K8sAdminNetworkPolicyToCalico(&anp)
+		var convErr cerrors.ErrorAdminPolicyConversion
+		if errors.As(err, &convErr) && kvp != nil {
+			for _, r := range convErr.Rules {
+				ruleName := "unnamed rule"
+				if r.IngressRule != nil && r.IngressRule.Name != "" {
+					ruleName = "ingress rule " + r.IngressRule.Name
+				} else if r.EgressRule != nil && r.EgressRule.Name != "" {
+					ruleName = "egress rule " + r.EgressRule.Name
+				}
+				fmt.Fprintf(os.Stderr, "Warning: AdminNetworkPolicy %s: unsupported feature: %s: %s\n", anp.Name, ruleName, r.Reason)
+			}
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to convert k8s resource: %w", err)
+		}
+
+		for _, ruleName := range anpRulesWithNamedPorts(&anp) {
+			fmt.Fprintf(os.Stderr, "Warning: AdminNetworkPolicy %s: unsupported feature: %s: named ports are "+
+				"not supported, the rule matches all ports\n", anp.Name, ruleName)
+		}
+
+		gnp, ok := kvp.Value.(*apiv3.GlobalNetworkPolicy)
+		if !ok {
+			return nil, fmt.Errorf("failed to convert kvp to apiv3.GlobalNetworkPolicy")
+		}
+
+		// Trim K8sAdminNetworkPolicyNamePrefix from the policy name, as for NetworkPolicy.
+		gnp.Name = strings.TrimPrefix(gnp.Name, names.K8sAdminNetworkPolicyNamePrefix)
+		res = gnp
 	default:
 		return nil, fmt.Errorf("conversion for the k8s resource type '%s' is not supported", k8sResKind)
 	}
@@ -225,6 +362,35 @@ func convertK8sResource(convResource unversioned.Resource) (converters.Resource,
 	return res, nil
 }
 
+// anpRulesWithNamedPorts returns the names of the rules of the AdminNetworkPolicy that match
+// named ports, which the conversion to Calico doesn't support.
+func anpRulesWithNamedPorts(anp *adminpolicy.AdminNetworkPolicy) []string {
+	hasNamedPort := func(ports *[]adminpolicy.AdminNetworkPolicyPort) bool {
+		if ports == nil {
+			return false
+		}
+		for _, p := range *ports {
+			if p.NamedPort != nil {
+				return true
+			}
+		}
+		return false
+	}
+
+	var rules []string
+	for i, r := range anp.Spec.Ingress {
+		if hasNamedPort(r.Ports) {
+			rules = append(rules, fmt.Sprintf("ingress rule %d (%s)", i+1, r.Name))
+		}
+	}
+	for i, r := range anp.Spec.Egress {
+		if hasNamedPort(r.Ports) {
+			rules = append(rules, fmt.Sprintf("egress rule %d (%s)", i+1, r.Name))
+		}
+	}
+	return rules
+}
+
 // getTypeConverter returns a type specific converter for a given v1 resource.
 func getTypeConverter(resKind string) (converters.Converter, error) {
 	switch strings.ToLower(resKind) {
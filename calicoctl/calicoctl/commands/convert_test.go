@@ -0,0 +1,130 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package commands
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	networkingv1 "k8s.io/api/networking/v1"
+	adminpolicy "sigs.k8s.io/network-policy-api/apis/v1alpha1"
+)
+
+var _ = Describe("calicoctl convert", func() {
+	var dir string
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "calicoctl-convert")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		_ = os.RemoveAll(dir)
+	})
+
+	writeFile := func(content string) string {
+		f := filepath.Join(dir, "input.yaml")
+		Expect(os.WriteFile(f, []byte(content), 0o644)).To(Succeed())
+		return f
+	}
+
+	It("should convert an AdminNetworkPolicy to a GlobalNetworkPolicy", func() {
+		f := writeFile(`apiVersion: policy.networking.k8s.io/v1alpha1
+kind: AdminNetworkPolicy
+metadata:
+  name: cluster-control
+spec:
+  priority: 34
+  subject:
+    namespaces: {}
+  egress:
+  - name: deny-metadata
+    action: Deny
+    to:
+    - networks:
+      - 169.254.169.254/32
+`)
+		results, err := convertToCalico(f, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(1))
+		gnp := results[0].(*apiv3.GlobalNetworkPolicy)
+		Expect(gnp.Name).To(Equal("cluster-control"))
+		Expect(gnp.Spec.Tier).To(Equal("adminnetworkpolicy"))
+		Expect(*gnp.Spec.Order).To(Equal(34.0))
+		Expect(gnp.Spec.Egress).To(HaveLen(1))
+		Expect(gnp.Spec.Egress[0].Destination.Nets).To(Equal([]string{"169.254.169.254/32"}))
+	})
+
+	It("should convert Calico policies to Kubernetes policies", func() {
+		f := writeFile(`apiVersion: projectcalico.org/v3
+kind: NetworkPolicy
+metadata:
+  name: allow-web
+  namespace: prod
+spec:
+  selector: app == 'db'
+  ingress:
+  - action: Allow
+    source:
+      selector: app == 'web'
+  - action: Deny
+---
+apiVersion: projectcalico.org/v3
+kind: GlobalNetworkPolicy
+metadata:
+  name: deny-metadata
+spec:
+  tier: adminnetworkpolicy
+  order: 10
+  namespaceSelector: all()
+  egress:
+  - action: Deny
+    destination:
+      nets: [169.254.169.254/32]
+`)
+		results, err := convertToK8s(f)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(2))
+
+		np := results[0].(*networkingv1.NetworkPolicy)
+		Expect(np.Name).To(Equal("allow-web"))
+		Expect(np.Namespace).To(Equal("prod"))
+		Expect(np.Spec.PodSelector.MatchLabels).To(Equal(map[string]string{"app": "db"}))
+		// The Deny rule can't be converted, so it is dropped.
+		Expect(np.Spec.Ingress).To(HaveLen(1))
+
+		anp := results[1].(*adminpolicy.AdminNetworkPolicy)
+		Expect(anp.Name).To(Equal("deny-metadata"))
+		Expect(anp.Spec.Priority).To(Equal(int32(10)))
+		Expect(anp.Spec.Egress).To(HaveLen(1))
+		Expect(anp.Spec.Egress[0].To[0].Networks).To(Equal([]adminpolicy.CIDR{"169.254.169.254/32"}))
+	})
+
+	It("should reject resources that can't be converted to Kubernetes", func() {
+		f := writeFile(`apiVersion: projectcalico.org/v3
+kind: IPPool
+metadata:
+  name: pool
+spec:
+  cidr: 10.0.0.0/16
+`)
+		_, err := convertToK8s(f)
+		Expect(err).To(HaveOccurred())
+	})
+})
@@ -22,6 +22,7 @@ import (
 	"github.com/docopt/docopt-go"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/argutils"
 	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/common"
 	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/constants"
 	"github.com/projectcalico/calico/calicoctl/calicoctl/util"
@@ -31,6 +32,7 @@ func Create(args []string) error {
 	doc := constants.DatastoreIntro + `Usage:
   <BINARY_NAME> create --filename=<FILENAME> [--recursive] [--skip-empty]
                    [--skip-exists] [--config=<CONFIG>] [--namespace=<NS>] [--context=<context>] [--allow-version-mismatch]
+                   [--dry-run=<MODE>] [--diff]
 
 Examples:
   # Create a policy using the data in policy.yaml.
@@ -39,6 +41,9 @@ Examples:
   # Create a policy based on the JSON passed into stdin.
   cat policy.json | <BINARY_NAME> create -f -
 
+  # Show what creating policy.yaml would change, without changing anything.
+  <BINARY_NAME> create -f ./policy.yaml --dry-run --diff
+
 Options:
   -h --help                    Show this screen.
   -f --filename=<FILENAME>     Filename to use to create the resource.  If set to
@@ -58,6 +63,17 @@ Options:
                                Uses the default namespace if not specified.
      --context=<context>       The name of the kubeconfig context to use.
      --allow-version-mismatch  Allow client and cluster versions mismatch.
+     --dry-run=<MODE>          Validate the resources and report what would change,
+                               without changing anything.  <MODE> is "client" (the
+                               default if --dry-run is given without a value) to
+                               validate the resources locally, or "server" to also
+                               compare the resource versions and existence of the
+                               resources with those read from the datastore.  The
+                               datastore itself is not asked to check the write.
+     --diff                    Print the changed fields of each resource and exit
+                               with a non-zero exit code if any resource would
+                               change.  Implies --dry-run=client if --dry-run is
+                               not specified.
 
 Description:
   The create command is used to create a set of resources by filename or stdin.
@@ -85,7 +101,7 @@ Description:
 	// Replace <RESOURCE_LIST> with the list of resource types.
 	doc = strings.Replace(doc, "<RESOURCE_LIST>", util.Resources(), 1)
 
-	parsedArgs, err := docopt.ParseArgs(doc, args, "")
+	parsedArgs, err := docopt.ParseArgs(doc, common.NormalizeDryRunArgs(args), "")
 	if err != nil {
 		return fmt.Errorf("Invalid option: 'calicoctl %s'. Use flag '--help' to read about a specific subcommand.", strings.Join(args, " "))
 	}
@@ -99,6 +115,10 @@ Description:
 	results := common.ExecuteConfigCommand(parsedArgs, common.ActionCreate)
 	log.Infof("results: %+v", results)
 
+	if results.DryRun != common.DryRunNone && results.NumResources > 0 {
+		return common.HandleDryRunResults(os.Stdout, results, argutils.ArgBoolOrFalse(parsedArgs, "--diff"))
+	}
+
 	if results.FileInvalid {
 		return fmt.Errorf("Failed to execute command: %v", results.Err)
 	} else if results.NumResources == 0 {
@@ -185,6 +185,10 @@ func (c *MockIPAMClient) Tiers() client.TierInterface {
 	return nil
 }
 
+func (c *MockIPAMClient) Transaction() client.Transaction {
+	return nil
+}
+
 func (c *MockIPAMClient) Backend() bapi.Client {
 	return c.backend
 }
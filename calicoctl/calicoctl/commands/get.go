@@ -34,6 +34,7 @@ func Get(args []string) error {
   <BINARY_NAME> get ( (<KIND> [<NAME>...]) |
                 --filename=<FILENAME> [--recursive] [--skip-empty] )
                 [--output=<OUTPUT>] [--config=<CONFIG>] [--namespace=<NS>] [--all-namespaces] [--export] [--context=<context>] [--allow-version-mismatch]
+                [--watch] [--since-revision=<REV>]
 
 Examples:
   # List all policy in default output format.
@@ -42,6 +43,9 @@ Examples:
   # List specific policies in YAML format
   <BINARY_NAME> get -o yaml policy my-policy-1 my-policy-2
 
+  # Watch for changes to IP pools.
+  <BINARY_NAME> get ippools --watch
+
 Options:
   -h --help                    Show this screen.
   -f --filename=<FILENAME>     Filename to use to get the resource.  If set to
@@ -66,6 +70,13 @@ Options:
                                if <NAME> is not specified.
      --context=<context>       The name of the kubeconfig context to use.
      --allow-version-mismatch  Allow client and cluster versions mismatch.
+  -w --watch                   After printing the requested object(s), watch for
+                               changes and print an event for each resource that
+                               is added, modified or deleted.  Not supported with
+                               --filename.
+     --since-revision=<REV>    Used with --watch.  Only print the changes made after
+                               the given resource revision, for example to resume
+                               an earlier watch.
 
 Description:
   The get command is used to display a set of resources by filename or stdin,
@@ -104,6 +115,10 @@ Description:
   input to all of the resource management commands (create, apply, replace,
   delete, get).
 
+  When watching resources, the ps, wide and custom-columns formats include the
+  event type (ADDED, MODIFIED or DELETED) as the first column, and the YAML and
+  JSON formats output each event as an object with "type" and "object" fields.
+
   Please refer to the docs at https://docs.projectcalico.org for more details on
   the output formats, including example outputs, resource structure (required
   for the golang template definitions) and the valid column names (required for
@@ -186,6 +201,15 @@ Description:
 		return fmt.Errorf("unrecognized output format '%s'", output)
 	}
 
+	if argutils.ArgBoolOrFalse(parsedArgs, "--watch") {
+		if parsedArgs["--filename"] != nil {
+			return fmt.Errorf("--watch cannot be used with --filename")
+		}
+		return common.ExecuteWatchCommand(parsedArgs, rp)
+	} else if argutils.ArgStringOrBlank(parsedArgs, "--since-revision") != "" {
+		return fmt.Errorf("--since-revision can only be used with --watch")
+	}
+
 	results := common.ExecuteConfigCommand(parsedArgs, common.ActionGetOrList)
 
 	log.Infof("results: %+v", results)
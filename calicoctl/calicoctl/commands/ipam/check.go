@@ -22,11 +22,13 @@ import (
 	"os"
 	"sort"
 	"strings"
+	"time"
 
 	docopt "github.com/docopt/docopt-go"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 
+	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/argutils"
 	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/clientmgr"
 	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/common"
 	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/constants"
@@ -45,13 +47,19 @@ import (
 // IPAM takes keyword with an IP address then calls the subcommands.
 func Check(args []string, version string) error {
 	doc := constants.DatastoreIntro + `Usage:
-  <BINARY_NAME> ipam check [--config=<CONFIG>] [--show-all-ips] [--show-problem-ips] [-o <FILE>] [--allow-version-mismatch]
+  <BINARY_NAME> ipam check [--config=<CONFIG>] [--show-all-ips] [--show-problem-ips] [-o <FILE>] [--fix [--journal=<FILE>]] [--force] [--allow-version-mismatch]
+  <BINARY_NAME> ipam check --undo=<JOURNAL> [--config=<CONFIG>] [--force] [--allow-version-mismatch]
 
 Options:
   -h --help                    Show this screen.
   -o --output=<FILE>           Path to output report file.
      --show-all-ips            Print all IPs that are checked.
      --show-problem-ips        Print all IPs that are leaked or not allocated properly.
+     --fix                     Repair the problems that are found, other than leaked IPs.
+     --journal=<FILE>          Path to the journal of the repairs made by --fix.  Defaults to
+                               calico-ipam-fix-<TIMESTAMP>.json in the current directory.
+     --undo=<JOURNAL>          Revert the repairs recorded in a journal written by --fix.
+     --force                   Repair, or revert repairs, even if the data store is not locked.
   -c --config=<CONFIG>         Path to the file containing connection configuration in
                                YAML or JSON format.
                                [default: ` + constants.DefaultConfigPath + `]
@@ -59,6 +67,17 @@ Options:
 
 Description:
   The ipam check command checks the integrity of the IPAM datastructures against Kubernetes.
+
+  With --fix, the problems that are found are repaired once the check is complete:
+  -  IPAM handles with no matching IPs are released.
+  -  Block affinities for nodes that do not exist are deleted.
+  -  Blocks with inconsistent allocations or attributes are corrected.
+  -  Node tunnel addresses that do not match their IPAM allocations are corrected.
+  Leaked IPs are not released; use the ipam release command for those.
+
+  The data store should be locked while the repairs are made (see the datastore migrate
+  lock command).  Each repair is recorded in a journal file, which can be used to audit
+  the repairs, and to revert them using --undo.
 `
 	// Replace all instances of BINARY_NAME with the name of the binary.
 	name, _ := util.NameAndDescription()
@@ -92,6 +111,11 @@ Description:
 	}
 	bc := client.(accessor).Backend()
 
+	force := parsedArgs["--force"].(bool)
+	if journalFile := argutils.ArgStringOrBlank(parsedArgs, "--undo"); journalFile != "" {
+		return undoFix(ctx, client, bc, journalFile, force)
+	}
+
 	// Get a kube-client. If this is a kdd cluster, we can pull this from the backend.
 	// Otherwise, we need to build one ourselves.
 	var kubeClient *kubernetes.Clientset
@@ -112,6 +136,13 @@ Description:
 
 	// Build the checker.
 	checker := NewIPAMChecker(kubeClient, client, bc, showAllIPs, showProblemIPs, outFile, version)
+	if parsedArgs["--fix"].(bool) {
+		journalFile := argutils.ArgStringOrBlank(parsedArgs, "--journal")
+		if journalFile == "" {
+			journalFile = fmt.Sprintf("calico-ipam-fix-%s.json", time.Now().UTC().Format("20060102-150405"))
+		}
+		checker.EnableFix(journalFile, force)
+	}
 	return checker.checkIPAM(ctx)
 }
 
@@ -129,6 +160,8 @@ func NewIPAMChecker(k8sClient kubernetes.Interface,
 
 		inUseIPs:     map[string][]ownerRecord{},
 		inUseHandles: set.New[string](),
+		blocks:       map[string]*model.KVPair{},
+		nodes:        map[string]*apiv3.Node{},
 
 		k8sClient:     k8sClient,
 		v3Client:      v3Client,
@@ -149,6 +182,12 @@ type IPAMChecker struct {
 	leakedHandles     []HandleInfo
 	inUseIPs          map[string][]ownerRecord
 	inUseHandles      set.Set[string]
+	blocks            map[string]*model.KVPair
+	nodes             map[string]*apiv3.Node
+
+	orphanedAffinities    []AffinityInfo
+	inconsistentBlocks    []BlockInfo
+	tunnelAddressProblems []TunnelAddressInfo
 
 	clusterType         string
 	clusterInfoRevision string
@@ -164,6 +203,20 @@ type IPAMChecker struct {
 
 	version string
 	outFile string
+
+	// Repair options.
+	fix         bool
+	force       bool
+	journalFile string
+}
+
+// EnableFix configures the checker to repair the problems that it finds, recording the repairs
+// in the journal file.  If force is true, the repairs are made even if the data store is not
+// locked.
+func (c *IPAMChecker) EnableFix(journalFile string, force bool) {
+	c.fix = true
+	c.force = force
+	c.journalFile = journalFile
 }
 
 func (c *IPAMChecker) checkIPAM(ctx context.Context) error {
@@ -180,6 +233,15 @@ func (c *IPAMChecker) checkIPAM(ctx context.Context) error {
 	c.datastoreLocked = clusterInfo.Spec.DatastoreReady != nil && !*clusterInfo.Spec.DatastoreReady
 	c.clusterGUID = clusterInfo.Spec.ClusterGUID
 
+	// Repairs should only be made while nothing else is changing IPAM.
+	if c.fix && !c.datastoreLocked {
+		if !c.force {
+			return fmt.Errorf("Data store is not locked. Either lock the data store, or re-run with --force.")
+		}
+		fmt.Println("WARNING: Data store is not locked. Ignoring due to --force option")
+		fmt.Println()
+	}
+
 	var numAllocs int
 	{
 		fmt.Println("Loading all IPAM blocks...")
@@ -191,6 +253,7 @@ func (c *IPAMChecker) checkIPAM(ctx context.Context) error {
 
 		for _, kvp := range blocks.KVPairs {
 			b := kvp.Value.(*model.AllocationBlock)
+			c.blocks[b.CIDR.String()] = kvp
 			affinity := "<none>"
 			if b.Affinity != nil {
 				affinity = *b.Affinity
@@ -236,7 +299,8 @@ func (c *IPAMChecker) checkIPAM(ctx context.Context) error {
 			return fmt.Errorf("failed to list nodes: %w", err)
 		}
 		numNodeIPs := 0
-		for _, n := range nodes.Items {
+		for i, n := range nodes.Items {
+			c.nodes[n.Name] = &nodes.Items[i]
 			ips, err := getNodeIPs(n)
 			if err != nil {
 				return err
@@ -413,12 +477,80 @@ func (c *IPAMChecker) checkIPAM(ctx context.Context) error {
 		fmt.Printf("Found %d handles mentioned in blocks with no matching handle resource.\n", len(missingHandles))
 	}
 
+	{
+		fmt.Printf("Scanning for block affinities for nodes that don't exist...\n")
+		affinities, err := c.backendClient.List(ctx, model.BlockAffinityListOptions{}, "")
+		if err != nil {
+			return fmt.Errorf("failed to list block affinities: %w", err)
+		}
+		for _, kvp := range affinities.KVPairs {
+			k := kvp.Key.(model.BlockAffinityKey)
+			if _, ok := c.nodes[k.Host]; ok || k.Host == ipam.LoadBalancerHost {
+				// LoadBalancer blocks are affine to a virtual host rather than a node.
+				continue
+			}
+			if c.showProblemIPs {
+				fmt.Printf("  Block %s is affine to node %s, which doesn't exist.\n", k.CIDR, k.Host)
+			}
+			c.orphanedAffinities = append(c.orphanedAffinities, AffinityInfo{
+				Host:     k.Host,
+				CIDR:     k.CIDR.String(),
+				Revision: kvp.Revision,
+			})
+		}
+		numProblems += len(c.orphanedAffinities)
+		fmt.Printf("Found %d block affinities for nodes that don't exist.\n", len(c.orphanedAffinities))
+	}
+
+	{
+		fmt.Printf("Scanning for blocks with inconsistent allocations...\n")
+		for _, kvp := range c.blocks {
+			b := kvp.Value.(*model.AllocationBlock)
+			problems := checkBlock(b)
+			if len(problems) == 0 {
+				continue
+			}
+			if c.showProblemIPs {
+				for _, p := range problems {
+					fmt.Printf("  Block %s: %s.\n", b.CIDR, p)
+				}
+			}
+			c.inconsistentBlocks = append(c.inconsistentBlocks, BlockInfo{
+				CIDR:     b.CIDR.String(),
+				Revision: kvp.Revision,
+				Problems: problems,
+			})
+		}
+		sort.Slice(c.inconsistentBlocks, func(i, j int) bool {
+			return c.inconsistentBlocks[i].CIDR < c.inconsistentBlocks[j].CIDR
+		})
+		numProblems += len(c.inconsistentBlocks)
+		fmt.Printf("Found %d blocks with inconsistent allocations.\n", len(c.inconsistentBlocks))
+	}
+
+	{
+		fmt.Printf("Scanning for node tunnel addresses that don't match their IPAM allocations...\n")
+		c.tunnelAddressProblems = c.checkTunnelAddresses(activeIPPools)
+		if c.showProblemIPs {
+			for _, p := range c.tunnelAddressProblems {
+				fmt.Printf("  Node %s: %s %s %s.\n", p.Node, p.Type, p.IP, p.Problem)
+			}
+		}
+		numProblems += len(c.tunnelAddressProblems)
+		fmt.Printf("Found %d node tunnel addresses that don't match their IPAM allocations.\n", len(c.tunnelAddressProblems))
+	}
+
 	fmt.Printf("Check complete; found %d problems.\n", numProblems)
 
 	if c.outFile != "" {
 		// Print out a machine readable report.
 		c.printReport()
 	}
+
+	if c.fix {
+		fmt.Println()
+		return c.fixIPAM(ctx)
+	}
 	return nil
 }
 
@@ -448,6 +580,10 @@ type Report struct {
 	// Allocations is a map of IP address to list of allocation data.
 	Allocations   map[string][]*Allocation `json:"allocations"`
 	LeakedHandles []HandleInfo             `json:"leakedHandles,omitempty"`
+
+	OrphanedAffinities    []AffinityInfo      `json:"orphanedAffinities,omitempty"`
+	InconsistentBlocks    []BlockInfo         `json:"inconsistentBlocks,omitempty"`
+	TunnelAddressProblems []TunnelAddressInfo `json:"tunnelAddressProblems,omitempty"`
 }
 
 func (c *IPAMChecker) printReport() {
@@ -459,6 +595,10 @@ func (c *IPAMChecker) printReport() {
 		DatastoreLocked:     c.datastoreLocked,
 		Allocations:         c.allocations,
 		LeakedHandles:       c.leakedHandles,
+
+		OrphanedAffinities:    c.orphanedAffinities,
+		InconsistentBlocks:    c.inconsistentBlocks,
+		TunnelAddressProblems: c.tunnelAddressProblems,
 	}
 	bytes, _ := json.MarshalIndent(r, "", "  ")
 	_ = os.WriteFile(c.outFile, bytes, 0777)
@@ -526,6 +666,18 @@ func (c *IPAMChecker) recordAllocation(b *model.AllocationBlock, ord int) {
 		c.allocationsByPod[pod] = append(c.allocationsByPod[pod], &alloc)
 	}
 
+	// LoadBalancer addresses are used by Services, which the checker doesn't load, so treat them
+	// as in use; the LoadBalancer controller releases them when their Services are deleted.
+	if node == ipam.LoadBalancerHost {
+		owner := "LoadBalancer"
+		if len(b.Attributes) > attrIdx {
+			if svc := b.Attributes[attrIdx].AttrSecondary[ipam.AttributeService]; svc != "" {
+				owner = fmt.Sprintf("LoadBalancer(%s/%s)", alloc.Namespace, svc)
+			}
+		}
+		c.recordInUseIP(ip, b, owner)
+	}
+
 	if c.showAllIPs {
 		fmt.Printf("  %s allocated; attrs %s\n", ip, alloc.GetAttrString())
 	}
@@ -635,6 +787,28 @@ type HandleInfo struct {
 	Revision string
 }
 
+// AffinityInfo identifies a block affinity for a node that doesn't exist.
+type AffinityInfo struct {
+	Host     string `json:"host"`
+	CIDR     string `json:"cidr"`
+	Revision string `json:"revision"`
+}
+
+// BlockInfo identifies a block with inconsistent allocations.
+type BlockInfo struct {
+	CIDR     string   `json:"cidr"`
+	Revision string   `json:"revision"`
+	Problems []string `json:"problems"`
+}
+
+// TunnelAddressInfo describes a node tunnel address that doesn't match its IPAM allocation.
+type TunnelAddressInfo struct {
+	Node    string `json:"node"`
+	Type    string `json:"type"`
+	IP      string `json:"ip"`
+	Problem string `json:"problem"`
+}
+
 func formatAttrs(attribute model.AllocationAttribute) string {
 	primary := "<none>"
 	if attribute.AttrPrimary != nil {
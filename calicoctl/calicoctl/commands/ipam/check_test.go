@@ -0,0 +1,117 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipam
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/apiconfig"
+	bapi "github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+	clientv3 "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/ipam"
+	cnet "github.com/projectcalico/calico/libcalico-go/lib/net"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+)
+
+var _ = Describe("IPAM check", func() {
+	var (
+		ctx context.Context
+		c   clientv3.Interface
+		bc  bapi.Client
+		dir string
+	)
+
+	BeforeEach(func() {
+		ctx = context.Background()
+		var err error
+		dir, err = os.MkdirTemp("", "calicoctl-ipam-check")
+		Expect(err).NotTo(HaveOccurred())
+		cfg := apiconfig.NewCalicoAPIConfig()
+		cfg.Spec.DatastoreType = apiconfig.File
+		cfg.Spec.DatastoreFile = filepath.Join(dir, "datastore.json")
+		c, err = clientv3.New(*cfg)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.EnsureInitialized(ctx, "", "")).To(Succeed())
+		bc = c.(interface{ Backend() bapi.Client }).Backend()
+
+		for cidr, use := range map[string]apiv3.IPPoolAllowedUse{
+			"10.0.0.0/24": apiv3.IPPoolAllowedUseLoadBalancer,
+			"10.0.1.0/24": apiv3.IPPoolAllowedUseWorkload,
+		} {
+			pool := apiv3.NewIPPool()
+			pool.Name = strings.ToLower(string(use))
+			pool.Spec.CIDR = cidr
+			pool.Spec.AllowedUses = []apiv3.IPPoolAllowedUse{use}
+			_, err = c.IPPools().Create(ctx, pool, options.SetOptions{})
+			Expect(err).NotTo(HaveOccurred())
+		}
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	assignIP := func(ip, host, handle string, attrs map[string]string) {
+		Expect(c.IPAM().AssignIP(ctx, ipam.AssignIPArgs{
+			IP:       cnet.MustParseIP(ip),
+			HandleID: &handle,
+			Attrs:    attrs,
+			Hostname: host,
+		})).To(Succeed())
+	}
+
+	affineHosts := func() []string {
+		affinities, err := bc.List(ctx, model.BlockAffinityListOptions{}, "")
+		Expect(err).NotTo(HaveOccurred())
+		var hosts []string
+		for _, kvp := range affinities.KVPairs {
+			hosts = append(hosts, kvp.Key.(model.BlockAffinityKey).Host)
+		}
+		return hosts
+	}
+
+	It("should leave LoadBalancer addresses and blocks alone", func() {
+		assignIP("10.0.0.1", ipam.LoadBalancerHost, "lb-handle", map[string]string{
+			ipam.AttributeNamespace: "default",
+			ipam.AttributeService:   "frontend",
+		})
+		assignIP("10.0.1.1", "deleted-node", "leaked-handle", nil)
+		Expect(affineHosts()).To(ConsistOf(ipam.LoadBalancerHost, "deleted-node"))
+
+		checker := NewIPAMChecker(nil, c, bc, false, false, "", "test")
+		checker.EnableFix(filepath.Join(dir, "journal.json"), true)
+		Expect(checker.checkIPAM(ctx)).To(Succeed())
+
+		Expect(checker.orphanedAffinities).To(HaveLen(1))
+		Expect(checker.orphanedAffinities[0].Host).To(Equal("deleted-node"))
+		Expect(checker.inUseIPs).To(HaveKey("10.0.0.1"))
+		Expect(checker.inUseIPs).NotTo(HaveKey("10.0.1.1"))
+		Expect(checker.leakedHandles).To(BeEmpty())
+
+		By("keeping the LoadBalancer affinity and allocation after the repairs")
+		Expect(affineHosts()).To(ConsistOf(ipam.LoadBalancerHost))
+		ips, err := c.IPAM().IPsByHandle(ctx, "lb-handle")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ips).To(ConsistOf(cnet.MustParseIP("10.0.0.1")))
+	})
+})
@@ -0,0 +1,770 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipam
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+
+	apiv3 "github.com/projectcalico/calico/libcalico-go/lib/apis/v3"
+	bapi "github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	cerrors "github.com/projectcalico/calico/libcalico-go/lib/errors"
+	"github.com/projectcalico/calico/libcalico-go/lib/ipam"
+	cnet "github.com/projectcalico/calico/libcalico-go/lib/net"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+)
+
+// Descriptions of the node tunnel address problems.
+const (
+	tunnelAddressUnallocated = "is not allocated in IPAM"
+	tunnelAddressOtherOwner  = "is allocated in IPAM to something else"
+	tunnelAddressStale       = "is allocated in IPAM but is no longer used by the node"
+)
+
+// tunnelAddressType describes one of the tunnel addresses that calico-node allocates for a node.
+// The handles must match those used by calico-node (see node/pkg/allocateip).
+type tunnelAddressType struct {
+	attrType     string
+	handlePrefix string
+	get          func(n *apiv3.Node) string
+	clear        func(n *apiv3.Node)
+}
+
+var tunnelAddressTypes = []tunnelAddressType{
+	{
+		attrType:     ipam.AttributeTypeIPIP,
+		handlePrefix: "ipip-tunnel-addr-",
+		get: func(n *apiv3.Node) string {
+			if n.Spec.BGP == nil {
+				return ""
+			}
+			return n.Spec.BGP.IPv4IPIPTunnelAddr
+		},
+		clear: func(n *apiv3.Node) { n.Spec.BGP.IPv4IPIPTunnelAddr = "" },
+	},
+	{
+		attrType:     ipam.AttributeTypeVXLAN,
+		handlePrefix: "vxlan-tunnel-addr-",
+		get:          func(n *apiv3.Node) string { return n.Spec.IPv4VXLANTunnelAddr },
+		clear:        func(n *apiv3.Node) { n.Spec.IPv4VXLANTunnelAddr = "" },
+	},
+	{
+		attrType:     ipam.AttributeTypeVXLANV6,
+		handlePrefix: "vxlan-v6-tunnel-addr-",
+		get:          func(n *apiv3.Node) string { return n.Spec.IPv6VXLANTunnelAddr },
+		clear:        func(n *apiv3.Node) { n.Spec.IPv6VXLANTunnelAddr = "" },
+	},
+	{
+		attrType:     ipam.AttributeTypeWireguard,
+		handlePrefix: "wireguard-tunnel-addr-",
+		get: func(n *apiv3.Node) string {
+			if n.Spec.Wireguard == nil {
+				return ""
+			}
+			return n.Spec.Wireguard.InterfaceIPv4Address
+		},
+		clear: func(n *apiv3.Node) { n.Spec.Wireguard.InterfaceIPv4Address = "" },
+	},
+	{
+		attrType:     ipam.AttributeTypeWireguardV6,
+		handlePrefix: "wireguard-v6-tunnel-addr-",
+		get: func(n *apiv3.Node) string {
+			if n.Spec.Wireguard == nil {
+				return ""
+			}
+			return n.Spec.Wireguard.InterfaceIPv6Address
+		},
+		clear: func(n *apiv3.Node) { n.Spec.Wireguard.InterfaceIPv6Address = "" },
+	},
+}
+
+func lookupTunnelAddressType(attrType string) *tunnelAddressType {
+	for i := range tunnelAddressTypes {
+		if tunnelAddressTypes[i].attrType == attrType {
+			return &tunnelAddressTypes[i]
+		}
+	}
+	return nil
+}
+
+// checkTunnelAddresses cross references the tunnel addresses of the nodes with the IPAM
+// allocations, returning the tunnel addresses that don't match.
+func (c *IPAMChecker) checkTunnelAddresses(activeIPPools []*cnet.IPNet) []TunnelAddressInfo {
+	var problems []TunnelAddressInfo
+
+	var nodeNames []string
+	for name := range c.nodes {
+		nodeNames = append(nodeNames, name)
+	}
+	sort.Strings(nodeNames)
+	for _, name := range nodeNames {
+		n := c.nodes[name]
+		for _, t := range tunnelAddressTypes {
+			ip, err := normaliseIP(t.get(n))
+			if err != nil {
+				continue
+			}
+			allocs := c.allocations[ip]
+			if len(allocs) == 0 {
+				if inPools(ip, activeIPPools) {
+					problems = append(problems, TunnelAddressInfo{Node: name, Type: t.attrType, IP: ip, Problem: tunnelAddressUnallocated})
+				}
+				continue
+			}
+			owned := false
+			for _, a := range allocs {
+				// Allocations without a handle or attributes were made by old versions of
+				// calico-node, which corrects them itself.
+				if (a.Type == t.attrType && a.Node == name) || (a.Handle == "" && a.Type == "") {
+					owned = true
+				}
+			}
+			if !owned {
+				problems = append(problems, TunnelAddressInfo{Node: name, Type: t.attrType, IP: ip, Problem: tunnelAddressOtherOwner})
+			}
+		}
+	}
+
+	var ips []string
+	for ip := range c.allocations {
+		ips = append(ips, ip)
+	}
+	sort.Strings(ips)
+	for _, ip := range ips {
+		for _, a := range c.allocations[ip] {
+			t := lookupTunnelAddressType(a.Type)
+			n := c.nodes[a.Node]
+			if t == nil || n == nil || a.InUse {
+				// Allocations for nodes that don't exist are reported as leaked.
+				continue
+			}
+			if addr, _ := normaliseIP(t.get(n)); addr != ip {
+				problems = append(problems, TunnelAddressInfo{Node: a.Node, Type: a.Type, IP: ip, Problem: tunnelAddressStale})
+			}
+		}
+	}
+	return problems
+}
+
+func inPools(ip string, pools []*cnet.IPNet) bool {
+	parsedIP := cnet.ParseIP(ip)
+	if parsedIP == nil {
+		return false
+	}
+	for _, p := range pools {
+		if p.Contains(parsedIP.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkBlock returns a description of each inconsistency between the allocations, attributes and
+// free list of the block.
+func checkBlock(b *model.AllocationBlock) []string {
+	var problems []string
+	used := make([]bool, len(b.Attributes))
+	for ord, idx := range b.Allocations {
+		if idx == nil {
+			continue
+		}
+		if *idx < 0 || *idx >= len(b.Attributes) {
+			problems = append(problems, fmt.Sprintf("%s refers to attributes %d, which don't exist", b.OrdinalToIP(ord), *idx))
+			continue
+		}
+		used[*idx] = true
+	}
+	for idx, u := range used {
+		if !u {
+			problems = append(problems, fmt.Sprintf("attributes %d are not used by any allocation", idx))
+		}
+	}
+
+	free := map[int]bool{}
+	for _, ord := range b.Unallocated {
+		switch {
+		case ord < 0 || ord >= len(b.Allocations):
+			problems = append(problems, fmt.Sprintf("ordinal %d is outside the block but is in the unallocated list", ord))
+		case free[ord]:
+			problems = append(problems, fmt.Sprintf("%s is in the unallocated list more than once", b.OrdinalToIP(ord)))
+		case b.Allocations[ord] != nil:
+			problems = append(problems, fmt.Sprintf("%s is allocated but is in the unallocated list", b.OrdinalToIP(ord)))
+		}
+		free[ord] = true
+	}
+	for ord, idx := range b.Allocations {
+		if idx == nil && !free[ord] {
+			problems = append(problems, fmt.Sprintf("%s is neither allocated nor in the unallocated list", b.OrdinalToIP(ord)))
+		}
+	}
+	return problems
+}
+
+// repairBlock returns a copy of the block with the inconsistencies reported by checkBlock
+// corrected.  Allocations that refer to attributes that don't exist are released, unless inUse
+// reports that the IP is in use, in which case they are left for the owner to clean up.
+func repairBlock(b *model.AllocationBlock, inUse func(ip string) bool) *model.AllocationBlock {
+	nb := *b
+	nb.Allocations = make([]*int, len(b.Allocations))
+	nb.SequenceNumberForAllocation = map[string]uint64{}
+	for k, v := range b.SequenceNumberForAllocation {
+		nb.SequenceNumberForAllocation[k] = v
+	}
+
+	// Release the allocations with missing attributes, and work out which attributes are used.
+	used := make([]bool, len(b.Attributes))
+	for ord, idx := range b.Allocations {
+		if idx == nil {
+			continue
+		}
+		if *idx < 0 || *idx >= len(b.Attributes) {
+			if !inUse(b.OrdinalToIP(ord).String()) {
+				nb.ClearSequenceNumberForOrdinal(ord)
+				continue
+			}
+		} else {
+			used[*idx] = true
+		}
+		i := *idx
+		nb.Allocations[ord] = &i
+	}
+
+	// Remove the unused attributes, preserving the order of the others.
+	newIndex := make([]int, len(b.Attributes))
+	nb.Attributes = nil
+	for idx, attr := range b.Attributes {
+		if used[idx] {
+			newIndex[idx] = len(nb.Attributes)
+			nb.Attributes = append(nb.Attributes, attr)
+		}
+	}
+	for _, idx := range nb.Allocations {
+		if idx != nil && *idx >= 0 && *idx < len(b.Attributes) {
+			*idx = newIndex[*idx]
+		}
+	}
+
+	// Rebuild the unallocated list, keeping the existing order of the free ordinals so that
+	// recently released IPs are not reused sooner than they would have been.
+	nb.Unallocated = nil
+	free := map[int]bool{}
+	for _, ord := range b.Unallocated {
+		if ord >= 0 && ord < len(nb.Allocations) && nb.Allocations[ord] == nil && !free[ord] {
+			nb.Unallocated = append(nb.Unallocated, ord)
+			free[ord] = true
+		}
+	}
+	for ord, idx := range nb.Allocations {
+		if idx == nil && !free[ord] {
+			nb.Unallocated = append(nb.Unallocated, ord)
+		}
+	}
+	return &nb
+}
+
+// journalAction is the type of change recorded in a journal entry.
+type journalAction string
+
+const (
+	// A datastore entry was deleted.  Undone by re-creating the previous value.
+	journalDelete journalAction = "delete"
+
+	// A datastore entry was updated.  Undone by restoring the previous value, provided the
+	// entry has not been modified since.
+	journalUpdate journalAction = "update"
+
+	// An IP was assigned using the IPAM API.  Undone by releasing the IP.
+	journalAssignIP journalAction = "assignIP"
+
+	// An IP was released using the IPAM API.  Undone by assigning the IP again.
+	journalReleaseIP journalAction = "releaseIP"
+)
+
+// Journal records the repairs made by ipam check --fix.
+type Journal struct {
+	// Version of the code that made the repairs.
+	Version     string         `json:"version"`
+	ClusterGUID string         `json:"clusterGUID"`
+	StartTime   string         `json:"startTime"`
+	Entries     []JournalEntry `json:"entries"`
+}
+
+// JournalEntry records a single change made by ipam check --fix.
+type JournalEntry struct {
+	Time        string        `json:"time"`
+	Description string        `json:"description"`
+	Action      journalAction `json:"action"`
+
+	// For delete and update, the datastore key and the value before the change.  For update,
+	// the revision after the change.
+	Key           string          `json:"key,omitempty"`
+	PreviousValue json.RawMessage `json:"previousValue,omitempty"`
+	Revision      string          `json:"revision,omitempty"`
+
+	// For assignIP and releaseIP, the details of the allocation.
+	IP     string            `json:"ip,omitempty"`
+	Handle string            `json:"handle,omitempty"`
+	Node   string            `json:"node,omitempty"`
+	Attrs  map[string]string `json:"attrs,omitempty"`
+}
+
+// journalWriter writes the journal file, rewriting it as each entry is recorded so that the
+// journal is complete even if the repairs are interrupted.
+type journalWriter struct {
+	file    string
+	journal Journal
+}
+
+// errJournal wraps a failure to write the journal.  No further changes should be made.
+type errJournal struct {
+	err error
+}
+
+func (e errJournal) Error() string {
+	return fmt.Sprintf("failed to write journal: %v", e.err)
+}
+
+func (w *journalWriter) write() error {
+	bytes, err := json.MarshalIndent(w.journal, "", "  ")
+	if err != nil {
+		return errJournal{err}
+	}
+	if err := os.WriteFile(w.file, bytes, 0644); err != nil {
+		return errJournal{err}
+	}
+	return nil
+}
+
+func (w *journalWriter) record(e JournalEntry) error {
+	e.Time = time.Now().UTC().Format(time.RFC3339)
+	w.journal.Entries = append(w.journal.Entries, e)
+	return w.write()
+}
+
+// recordKV records a delete or update of the datastore entry prev.
+func (w *journalWriter) recordKV(action journalAction, prev *model.KVPair, revision, description string) error {
+	key, err := model.KeyToDefaultPath(prev.Key)
+	if err != nil {
+		return errJournal{err}
+	}
+	value, err := model.SerializeValue(prev)
+	if err != nil {
+		return errJournal{err}
+	}
+	return w.record(JournalEntry{
+		Description:   description,
+		Action:        action,
+		Key:           key,
+		PreviousValue: value,
+		Revision:      revision,
+	})
+}
+
+// fixIPAM repairs the problems found by the check, other than leaked IPs, recording each
+// change in the journal.
+func (c *IPAMChecker) fixIPAM(ctx context.Context) error {
+	w := &journalWriter{
+		file: c.journalFile,
+		journal: Journal{
+			Version:     c.version,
+			ClusterGUID: c.clusterGUID,
+			StartTime:   time.Now().UTC().Format(time.RFC3339),
+			Entries:     []JournalEntry{},
+		},
+	}
+	if err := w.write(); err != nil {
+		return err
+	}
+	fmt.Printf("Repairing IPAM; recording the repairs in %s\n", c.journalFile)
+
+	var numFixed, numSkipped, numErrors int
+	result := func(description string, err error) error {
+		var skip errSkip
+		switch {
+		case err == nil:
+			numFixed++
+			fmt.Printf("  %s\n", description)
+		case errors.As(err, &skip):
+			numSkipped++
+			fmt.Printf("  Skipped: %s: %v\n", description, err)
+		case errors.As(err, &errJournal{}):
+			return fmt.Errorf("stopping repairs: %w", err)
+		default:
+			numErrors++
+			fmt.Printf("  Failed: %s: %v\n", description, err)
+		}
+		return nil
+	}
+
+	// Fix the tunnel addresses first, since they change the blocks.
+	for _, p := range c.tunnelAddressProblems {
+		var description string
+		var err error
+		switch p.Problem {
+		case tunnelAddressStale:
+			description = fmt.Sprintf("Release %s %s, which is no longer used by node %s", p.Type, p.IP, p.Node)
+			err = c.releaseTunnelAddress(ctx, w, p, description)
+		case tunnelAddressUnallocated:
+			description = fmt.Sprintf("Allocate %s %s to node %s", p.Type, p.IP, p.Node)
+			err = c.assignTunnelAddress(ctx, w, p, description)
+		case tunnelAddressOtherOwner:
+			description = fmt.Sprintf("Clear %s %s of node %s, so that calico-node allocates a new one", p.Type, p.IP, p.Node)
+			err = c.clearTunnelAddress(ctx, w, p, description)
+		}
+		if err := result(description, err); err != nil {
+			return err
+		}
+	}
+
+	for _, bi := range c.inconsistentBlocks {
+		description := fmt.Sprintf("Correct the allocations in block %s", bi.CIDR)
+		if err := result(description, c.repairBlock(ctx, w, bi, description)); err != nil {
+			return err
+		}
+	}
+
+	for _, ai := range c.orphanedAffinities {
+		description := fmt.Sprintf("Release the affinity of block %s for node %s", ai.CIDR, ai.Host)
+		if err := result(description, c.releaseAffinity(ctx, w, ai, description)); err != nil {
+			return err
+		}
+	}
+
+	for _, hi := range c.leakedHandles {
+		description := fmt.Sprintf("Release handle %s", hi.ID)
+		if err := result(description, c.releaseHandle(ctx, w, hi, description)); err != nil {
+			return err
+		}
+	}
+
+	fmt.Printf("Made %d repairs; %d skipped; %d errors.\n", numFixed, numSkipped, numErrors)
+	fmt.Printf("The repairs are recorded in %s, and can be reverted using --undo.\n", c.journalFile)
+	if numErrors > 0 {
+		return fmt.Errorf("failed to make %d repairs", numErrors)
+	}
+	fmt.Println("You may now unlock the data store.")
+	return nil
+}
+
+// errSkip indicates that a repair was not made because it is no longer needed, or because the
+// resource has changed since the check.
+type errSkip struct {
+	reason string
+}
+
+func (e errSkip) Error() string {
+	return e.reason
+}
+
+// tunnelAllocation returns the allocation of a stale tunnel address.
+func (c *IPAMChecker) tunnelAllocation(p TunnelAddressInfo) *Allocation {
+	for _, a := range c.allocations[p.IP] {
+		if a.Type == p.Type && a.Node == p.Node {
+			return a
+		}
+	}
+	return nil
+}
+
+func (c *IPAMChecker) releaseTunnelAddress(ctx context.Context, w *journalWriter, p TunnelAddressInfo, description string) error {
+	a := c.tunnelAllocation(p)
+	if a == nil {
+		return errSkip{"allocation not found"}
+	}
+	var attrs map[string]string
+	if idx := *a.Block.Allocations[a.Ordinal]; idx < len(a.Block.Attributes) {
+		attrs = a.Block.Attributes[idx].AttrSecondary
+	}
+
+	unallocated, err := c.v3Client.IPAM().ReleaseIPs(ctx, ipam.ReleaseOptions{
+		Address:        p.IP,
+		Handle:         a.Handle,
+		SequenceNumber: a.SequenceNumber,
+	})
+	if err != nil {
+		return err
+	}
+	if len(unallocated) != 0 {
+		return errSkip{"the IP is no longer allocated"}
+	}
+	return w.record(JournalEntry{
+		Description: description,
+		Action:      journalReleaseIP,
+		IP:          p.IP,
+		Handle:      a.Handle,
+		Node:        p.Node,
+		Attrs:       attrs,
+	})
+}
+
+func (c *IPAMChecker) assignTunnelAddress(ctx context.Context, w *journalWriter, p TunnelAddressInfo, description string) error {
+	t := lookupTunnelAddressType(p.Type)
+	handle := t.handlePrefix + p.Node
+	attrs := map[string]string{
+		ipam.AttributeNode: p.Node,
+		ipam.AttributeType: p.Type,
+	}
+	err := c.v3Client.IPAM().AssignIP(ctx, ipam.AssignIPArgs{
+		IP:       *cnet.ParseIP(p.IP),
+		HandleID: &handle,
+		Attrs:    attrs,
+		Hostname: p.Node,
+	})
+	if err != nil {
+		return err
+	}
+	return w.record(JournalEntry{
+		Description: description,
+		Action:      journalAssignIP,
+		IP:          p.IP,
+		Handle:      handle,
+		Node:        p.Node,
+		Attrs:       attrs,
+	})
+}
+
+func (c *IPAMChecker) clearTunnelAddress(ctx context.Context, w *journalWriter, p TunnelAddressInfo, description string) error {
+	t := lookupTunnelAddressType(p.Type)
+	kvp, err := c.backendClient.Get(ctx, model.ResourceKey{Kind: apiv3.KindNode, Name: p.Node}, "")
+	if err != nil {
+		return err
+	}
+	node := kvp.Value.(*apiv3.Node).DeepCopy()
+	if addr, _ := normaliseIP(t.get(node)); addr != p.IP {
+		return errSkip{"the node's tunnel address has changed"}
+	}
+	t.clear(node)
+
+	updated, err := c.backendClient.Update(ctx, &model.KVPair{Key: kvp.Key, Value: node, Revision: kvp.Revision, UID: kvp.UID})
+	if err != nil {
+		return err
+	}
+	return w.recordKV(journalUpdate, kvp, updated.Revision, description)
+}
+
+func (c *IPAMChecker) repairBlock(ctx context.Context, w *journalWriter, bi BlockInfo, description string) error {
+	// The tunnel address repairs may have changed the block, so get the current version.
+	_, cidr, err := cnet.ParseCIDR(bi.CIDR)
+	if err != nil {
+		return err
+	}
+	kvp, err := c.backendClient.Get(ctx, model.BlockKey{CIDR: *cidr}, "")
+	if err != nil {
+		return err
+	}
+	b := kvp.Value.(*model.AllocationBlock)
+	repaired := repairBlock(b, func(ip string) bool {
+		_, ok := c.inUseIPs[ip]
+		return ok
+	})
+	for _, p := range checkBlock(repaired) {
+		fmt.Printf("  WARNING: Block %s: %s; the IP is in use, so it has not been released.\n", bi.CIDR, p)
+	}
+	if reflect.DeepEqual(b, repaired) {
+		return errSkip{"nothing to repair"}
+	}
+
+	updated, err := c.backendClient.Update(ctx, &model.KVPair{Key: kvp.Key, Value: repaired, Revision: kvp.Revision, UID: kvp.UID})
+	if err != nil {
+		return err
+	}
+	return w.recordKV(journalUpdate, kvp, updated.Revision, description)
+}
+
+func (c *IPAMChecker) releaseAffinity(ctx context.Context, w *journalWriter, ai AffinityInfo, description string) error {
+	_, cidr, err := cnet.ParseCIDR(ai.CIDR)
+	if err != nil {
+		return err
+	}
+	kvp, err := c.backendClient.Get(ctx, model.BlockAffinityKey{CIDR: *cidr, Host: ai.Host}, "")
+	if err != nil {
+		return err
+	}
+	if kvp.Revision != ai.Revision {
+		return errSkip{"the affinity has changed"}
+	}
+	if _, err := c.backendClient.DeleteKVP(ctx, kvp); err != nil {
+		return err
+	}
+	if err := w.recordKV(journalDelete, kvp, "", description); err != nil {
+		return err
+	}
+
+	// Remove the affinity from the block too.  An empty block is deleted, in the same way as
+	// when IPAM releases an affinity.
+	kvp, err = c.backendClient.Get(ctx, model.BlockKey{CIDR: *cidr}, "")
+	if err != nil {
+		if _, ok := err.(cerrors.ErrorResourceDoesNotExist); ok {
+			return nil
+		}
+		return err
+	}
+	b := kvp.Value.(*model.AllocationBlock)
+	if b.Host() != ai.Host {
+		return nil
+	}
+	empty := true
+	for _, idx := range b.Allocations {
+		if idx != nil {
+			empty = false
+			break
+		}
+	}
+	if empty {
+		if _, err := c.backendClient.DeleteKVP(ctx, kvp); err != nil {
+			return err
+		}
+		return w.recordKV(journalDelete, kvp, "", fmt.Sprintf("Delete empty block %s", ai.CIDR))
+	}
+	nb := *b
+	nb.Affinity = nil
+	updated, err := c.backendClient.Update(ctx, &model.KVPair{Key: kvp.Key, Value: &nb, Revision: kvp.Revision, UID: kvp.UID})
+	if err != nil {
+		return err
+	}
+	return w.recordKV(journalUpdate, kvp, updated.Revision, fmt.Sprintf("Remove the affinity of block %s", ai.CIDR))
+}
+
+func (c *IPAMChecker) releaseHandle(ctx context.Context, w *journalWriter, hi HandleInfo, description string) error {
+	kvp, err := c.backendClient.Get(ctx, model.IPAMHandleKey{HandleID: hi.ID}, "")
+	if err != nil {
+		return err
+	}
+	if kvp.Revision != hi.Revision || !uidsEqual(hi.UID, kvp.UID) {
+		return errSkip{"the handle has changed"}
+	}
+	// Must use DeleteKVP for IPAM handles (not Delete) since KDD requires the UID information from the KVP struct.
+	if _, err := c.backendClient.DeleteKVP(ctx, kvp); err != nil {
+		return err
+	}
+	return w.recordKV(journalDelete, kvp, "", description)
+}
+
+// undoFix reverts the repairs recorded in a journal, in the reverse order to which they were
+// made.
+func undoFix(ctx context.Context, c clientv3.Interface, bc bapi.Client, journalFile string, force bool) error {
+	bytes, err := os.ReadFile(journalFile)
+	if err != nil {
+		return err
+	}
+	var j Journal
+	if err := json.Unmarshal(bytes, &j); err != nil {
+		return fmt.Errorf("failed to parse journal %s: %w", journalFile, err)
+	}
+
+	clusterInfo, err := c.ClusterInformation().Get(ctx, "default", options.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if clusterInfo.Spec.ClusterGUID != j.ClusterGUID {
+		// This check cannot be overridden using the --force option, because it is critical.
+		return fmt.Errorf("Cluster does not match the provided journal (%s): mismatched cluster GUID. Refusing to revert.", journalFile)
+	}
+	if clusterInfo.Spec.DatastoreReady == nil || *clusterInfo.Spec.DatastoreReady {
+		if !force {
+			return fmt.Errorf("Data store is not locked. Either lock the data store, or re-run with --force.")
+		}
+		fmt.Println("WARNING: Data store is not locked. Ignoring due to --force option")
+	}
+
+	fmt.Printf("Reverting %d repairs from %s\n", len(j.Entries), journalFile)
+	var numReverted, numSkipped, numErrors int
+	for i := len(j.Entries) - 1; i >= 0; i-- {
+		e := j.Entries[i]
+		err := undoJournalEntry(ctx, c, bc, e)
+		var skip errSkip
+		switch {
+		case err == nil:
+			numReverted++
+			fmt.Printf("  Reverted: %s\n", e.Description)
+		case errors.As(err, &skip):
+			numSkipped++
+			fmt.Printf("  Skipped: %s: %v\n", e.Description, err)
+		default:
+			numErrors++
+			fmt.Printf("  Failed to revert: %s: %v\n", e.Description, err)
+		}
+	}
+	fmt.Printf("Reverted %d repairs; %d skipped; %d errors.\n", numReverted, numSkipped, numErrors)
+	if numErrors > 0 {
+		return fmt.Errorf("failed to revert %d repairs", numErrors)
+	}
+	fmt.Println("You may now unlock the data store.")
+	return nil
+}
+
+func undoJournalEntry(ctx context.Context, c clientv3.Interface, bc bapi.Client, e JournalEntry) error {
+	switch e.Action {
+	case journalDelete, journalUpdate:
+		key := model.KeyFromDefaultPath(e.Key)
+		if key == nil {
+			return fmt.Errorf("unrecognised key %s", e.Key)
+		}
+		value, err := model.ParseValue(key, e.PreviousValue)
+		if err != nil {
+			return err
+		}
+		if e.Action == journalDelete {
+			_, err = bc.Create(ctx, &model.KVPair{Key: key, Value: value})
+			if _, ok := err.(cerrors.ErrorResourceAlreadyExists); ok {
+				return errSkip{"it has been re-created"}
+			}
+			return err
+		}
+		current, err := bc.Get(ctx, key, "")
+		if err != nil {
+			return err
+		}
+		if current.Revision != e.Revision {
+			return errSkip{"it has been modified since the repair"}
+		}
+		_, err = bc.Update(ctx, &model.KVPair{Key: key, Value: value, Revision: current.Revision, UID: current.UID})
+		return err
+	case journalAssignIP:
+		unallocated, err := c.IPAM().ReleaseIPs(ctx, ipam.ReleaseOptions{Address: e.IP, Handle: e.Handle})
+		if err != nil {
+			return err
+		}
+		if len(unallocated) != 0 {
+			return errSkip{"the IP is no longer allocated"}
+		}
+		return nil
+	case journalReleaseIP:
+		ip := cnet.ParseIP(e.IP)
+		if ip == nil {
+			return fmt.Errorf("invalid IP %s", e.IP)
+		}
+		handle := e.Handle
+		err := c.IPAM().AssignIP(ctx, ipam.AssignIPArgs{
+			IP:       *ip,
+			HandleID: &handle,
+			Attrs:    e.Attrs,
+			Hostname: e.Node,
+		})
+		if _, ok := err.(cerrors.ErrorResourceAlreadyExists); ok {
+			return errSkip{"the IP has been allocated again"}
+		}
+		return err
+	}
+	return fmt.Errorf("unknown action %q", e.Action)
+}
@@ -0,0 +1,93 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ipam
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+	cnet "github.com/projectcalico/calico/libcalico-go/lib/net"
+)
+
+var _ = Describe("IPAM block repair", func() {
+	var block *model.AllocationBlock
+	handle := func(h string) model.AllocationAttribute {
+		return model.AllocationAttribute{AttrPrimary: &h}
+	}
+	idx := func(i int) *int {
+		return &i
+	}
+	notInUse := func(string) bool { return false }
+
+	BeforeEach(func() {
+		_, cidr, err := cnet.ParseCIDR("10.0.0.0/30")
+		Expect(err).NotTo(HaveOccurred())
+		block = &model.AllocationBlock{
+			CIDR:        *cidr,
+			Allocations: []*int{idx(0), nil, idx(1), nil},
+			Unallocated: []int{3, 1},
+			Attributes:  []model.AllocationAttribute{handle("a"), handle("b")},
+			SequenceNumberForAllocation: map[string]uint64{
+				"0": 1,
+				"2": 2,
+			},
+		}
+	})
+
+	It("should find no problems in a consistent block", func() {
+		Expect(checkBlock(block)).To(BeEmpty())
+		Expect(repairBlock(block, notInUse)).To(Equal(block))
+	})
+
+	It("should rebuild the unallocated list, preserving its order", func() {
+		block.Unallocated = []int{3, 3, 2, 7}
+		Expect(checkBlock(block)).To(ConsistOf(
+			"10.0.0.3 is in the unallocated list more than once",
+			"10.0.0.2 is allocated but is in the unallocated list",
+			"ordinal 7 is outside the block but is in the unallocated list",
+			"10.0.0.1 is neither allocated nor in the unallocated list",
+		))
+		repaired := repairBlock(block, notInUse)
+		Expect(repaired.Unallocated).To(Equal([]int{3, 1}))
+		Expect(checkBlock(repaired)).To(BeEmpty())
+	})
+
+	It("should remove unused attributes", func() {
+		block.Attributes = []model.AllocationAttribute{handle("unused"), handle("a"), handle("b")}
+		block.Allocations = []*int{idx(1), nil, idx(2), nil}
+		Expect(checkBlock(block)).To(ConsistOf("attributes 0 are not used by any allocation"))
+		repaired := repairBlock(block, notInUse)
+		Expect(repaired.Attributes).To(Equal([]model.AllocationAttribute{handle("a"), handle("b")}))
+		Expect(repaired.Allocations).To(Equal([]*int{idx(0), nil, idx(1), nil}))
+		Expect(checkBlock(repaired)).To(BeEmpty())
+
+		By("not modifying the original block")
+		Expect(block.Allocations).To(Equal([]*int{idx(1), nil, idx(2), nil}))
+	})
+
+	It("should release allocations with missing attributes, unless the IP is in use", func() {
+		block.Allocations = []*int{idx(0), idx(5), idx(1), idx(5)}
+		block.Unallocated = nil
+		Expect(checkBlock(block)).To(ConsistOf(
+			"10.0.0.1 refers to attributes 5, which don't exist",
+			"10.0.0.3 refers to attributes 5, which don't exist",
+		))
+		repaired := repairBlock(block, func(ip string) bool { return ip == "10.0.0.3" })
+		Expect(repaired.Allocations).To(Equal([]*int{idx(0), nil, idx(1), idx(5)}))
+		Expect(repaired.Unallocated).To(Equal([]int{1}))
+		Expect(checkBlock(repaired)).To(ConsistOf("10.0.0.3 refers to attributes 5, which don't exist"))
+	})
+})
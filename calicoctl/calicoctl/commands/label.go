@@ -15,6 +15,7 @@
 package commands
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
@@ -22,6 +23,8 @@ import (
 	docopt "github.com/docopt/docopt-go"
 	log "github.com/sirupsen/logrus"
 
+	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/argutils"
+	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/clientmgr"
 	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/common"
 	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/constants"
 	"github.com/projectcalico/calico/calicoctl/calicoctl/resourcemgr"
@@ -34,6 +37,10 @@ func Label(args []string) error {
   	              ( <key>=<value> [--overwrite] |
   	                <key> --remove )
                   [--config=<CONFIG>] [--namespace=<NS>] [--context=<context>]) [--allow-version-mismatch]
+  <BINARY_NAME> label (<KIND> (--selector=<SELECTOR> | --all)
+  	              ( <key>=<value> [--overwrite] |
+  	                <key> --remove )
+                  [--config=<CONFIG>] [--namespace=<NS>] [--all-namespaces] [--context=<context>]) [--allow-version-mismatch]
 
 
 
@@ -48,6 +55,12 @@ Examples:
   # Remove label with key 'cluster' of the node
   <BINARY_NAME> label nodes node1 cluster --remove
 
+  # Label all the host endpoints of the nodes in rack 1
+  <BINARY_NAME> label hostendpoints --selector='rack == "1"' zone=east --overwrite
+
+  # Remove label with key 'deprecated' from every network set in every namespace
+  <BINARY_NAME> label networksets --all --all-namespaces deprecated --remove
+
 Options:
   -h --help                    Show this screen.
   -c --config=<CONFIG>         Path to the file containing connection
@@ -56,6 +69,12 @@ Options:
   -n --namespace=<NS>          Namespace of the resource.
                                Only applicable to NetworkPolicy, NetworkSet, and WorkloadEndpoint.
                                Uses the default namespace if not specified.
+  -A --all-namespaces          Used with --selector or --all.  If present, label the
+                               matching resources across all namespaces.
+  -l --selector=<SELECTOR>     Label all the resources of the kind whose labels match
+                               the selector, instead of a named resource.
+     --all                     Label all the resources of the kind, instead of a named
+                               resource.
      --overwrite               If true, overwrite the value when the key is already
                                present in labels. Otherwise reports error when the
                                labeled resource already have the key in its labels.
@@ -80,6 +99,13 @@ Description:
   When labeling a resource on an existing key:
   - gets an error if option --overwrite is not provided.
   - value of the key updates to specified value if option --overwrite is provided.
+
+  With --selector or --all, every matching resource is labeled and the result for
+  each resource is reported.  Resources that do not have the label being removed are
+  skipped.  A resource that fails to update does not stop the others from being
+  updated, but the command reports an error if any resource failed.  If a resource is
+  modified by someone else while it is being labeled, the label is applied again to
+  the latest version of the resource.
   `
 	// Replace all instances of BINARY_NAME with the name of the binary.
 	binaryName, _ := util.NameAndDescription()
@@ -100,27 +126,86 @@ Description:
 	}
 
 	log.Debugf("parse args: %+v\n", parsedArgs)
-	// get results.
+	return updateMetadata(parsedArgs, common.Labels)
+}
+
+// updateMetadata implements the label and annotate commands, which add, update or remove a
+// single key in the labels or annotations of either a named resource, or all the resources of
+// a kind that match a selector.
+func updateMetadata(parsedArgs map[string]interface{}, field common.MetadataField) error {
 	kind := parsedArgs["<KIND>"].(string)
-	name := parsedArgs["<NAME>"].(string)
 	// TODO: convert kind into the formal format
 
 	// parse key/value.
-	var key, value string
-	remove := parsedArgs["--remove"].(bool)
-	if remove {
-		key = parsedArgs["<key>"].(string)
+	change := common.MetadataChange{
+		Field:     field,
+		Remove:    parsedArgs["--remove"].(bool),
+		Overwrite: parsedArgs["--overwrite"].(bool),
+	}
+	if change.Remove {
+		change.Key = parsedArgs["<key>"].(string)
 	} else {
-		kv := strings.Split(parsedArgs["<key>=<value>"].(string), "=")
+		kv := strings.SplitN(parsedArgs["<key>=<value>"].(string), "=", 2)
 		if len(kv) != 2 {
-			return fmt.Errorf("invalid label %s", parsedArgs["<key>=<value>"])
+			return fmt.Errorf("invalid %s %s", field, parsedArgs["<key>=<value>"])
 		}
-		key = kv[0]
-		value = kv[1]
+		change.Key = kv[0]
+		change.Value = kv[1]
 	}
 
 	// TODO: add more validation on key/value?
 
+	selector := argutils.ArgStringOrBlank(parsedArgs, "--selector")
+	if selector == "" && !argutils.ArgBoolOrFalse(parsedArgs, "--all") {
+		return updateNamedResourceMetadata(parsedArgs, kind, change)
+	}
+
+	// Objects that do not have the key are expected when removing it from a set of objects.
+	change.IgnoreMissing = true
+
+	err := common.CheckVersionMismatch(parsedArgs["--config"], parsedArgs["--allow-version-mismatch"])
+	if err != nil {
+		return err
+	}
+	c, err := clientmgr.NewClient(parsedArgs["--config"].(string))
+	if err != nil {
+		return err
+	}
+
+	parsedArgs["<NAME>"] = ""
+	resources, err := resourcemgr.GetResourcesFromArgs(parsedArgs)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	selected, err := common.SelectResources(ctx, parsedArgs, c, resources[0], selector)
+	if err != nil {
+		return fmt.Errorf("failed to list %s: %w", kind, err)
+	}
+	if len(selected) == 0 {
+		fmt.Printf("No %s matched\n", kind)
+		return nil
+	}
+
+	failed := 0
+	for _, u := range common.UpdateMetadata(ctx, c, selected, change) {
+		name := resourceName(u.Resource)
+		if u.Err != nil {
+			failed++
+			fmt.Printf("Failed to update %s %s on %s %s: %v\n", field, change.Key, kind, name, u.Err)
+			continue
+		}
+		printMetadataResult(u.Result, change, kind, name)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to update %s %s on %d of %d %s", field, change.Key, failed, len(selected), kind)
+	}
+	return nil
+}
+
+// updateNamedResourceMetadata applies the change to a single named resource.
+func updateNamedResourceMetadata(parsedArgs map[string]interface{}, kind string, change common.MetadataChange) error {
+	name := parsedArgs["<NAME>"].(string)
 	results := common.ExecuteConfigCommand(parsedArgs, common.ActionGetOrList)
 	if results.FileInvalid {
 		return fmt.Errorf("Failed to execute command: %v", results.Err)
@@ -132,52 +217,31 @@ Description:
 	}
 
 	resource := results.Resources[0].(resourcemgr.ResourceObject)
-	labels := resource.GetObjectMeta().GetLabels()
-	overwrite := parsedArgs["--overwrite"].(bool)
-	overwritten := false
-	client := results.Client
-	if labels == nil {
-		labels = make(map[string]string)
-	}
-
-	if remove {
-		// remove label.
-		_, ok := labels[key]
-		if !ok {
-			// raise error if the key does not exist.
-			return fmt.Errorf("cannot remove label of %s %s, key %s does not exist",
-				kind, name, key)
-		} else {
-			delete(labels, key)
-		}
-	} else {
-		// add or update label.
-		oldValue, ok := labels[key]
-		if ok {
-			if overwrite || value == oldValue {
-				labels[key] = value
-				overwritten = true
-			} else {
-				return fmt.Errorf("failed to update label of %s %s, key %s is already present. please use '--overwrite' to set a new value.",
-					kind, name, key)
-			}
-		} else {
-			labels[key] = value
-		}
+	u := common.UpdateMetadata(context.Background(), results.Client, []resourcemgr.ResourceObject{resource}, change)[0]
+	if u.Err != nil {
+		return fmt.Errorf("failed to update %s of %s %s: %w", change.Field, kind, name, u.Err)
 	}
+	printMetadataResult(u.Result, change, kind, name)
+	return nil
+}
 
-	resource.GetObjectMeta().SetLabels(labels)
-	_, err = common.ExecuteResourceAction(parsedArgs, client, resource, common.ActionUpdate)
-	if err != nil {
-		return fmt.Errorf("failed to update %s %s, label not changed", kind, name)
+func printMetadataResult(result common.MetadataResult, change common.MetadataChange, kind, name string) {
+	switch result {
+	case common.MetadataRemoved:
+		fmt.Printf("Successfully removed %s %s from %s %s\n", change.Field, change.Key, kind, name)
+	case common.MetadataUpdated:
+		fmt.Printf("Successfully updated %s %s on %s %s\n", change.Field, change.Key, kind, name)
+	case common.MetadataSet:
+		fmt.Printf("Successfully set %s %s on %s %s\n", change.Field, change.Key, kind, name)
+	case common.MetadataUnchanged:
+		fmt.Printf("Skipped %s %s, %s %s is not present\n", kind, name, change.Field, change.Key)
 	}
+}
 
-	if remove {
-		fmt.Printf("Successfully removed label %s from %s %s\n", key, kind, name)
-	} else if overwritten {
-		fmt.Printf("Successfully updated label %s on %s %s\n", key, kind, name)
-	} else {
-		fmt.Printf("Successfully set label %s on %s %s\n", key, kind, name)
+// resourceName returns the name of the resource, qualified by its namespace if it has one.
+func resourceName(r resourcemgr.ResourceObject) string {
+	if ns := r.GetObjectMeta().GetNamespace(); ns != "" {
+		return ns + "/" + r.GetObjectMeta().GetName()
 	}
-	return nil
+	return r.GetObjectMeta().GetName()
 }
@@ -0,0 +1,147 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/docopt/docopt-go"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/clientmgr"
+	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/common"
+	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/constants"
+	"github.com/projectcalico/calico/calicoctl/calicoctl/util"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/k8s"
+)
+
+// CalcGraph prints felix's calculation graph view of the endpoints on a node, by running
+// calico-node in the calico-node pod on that node.
+func CalcGraph(args []string) error {
+	doc := constants.DatastoreIntro + `Usage:
+  <BINARY_NAME> node calc-graph [<NODE>] [--workload=<NAMESPACE/NAME>] [--host-endpoint=<NAME>]
+                [--config=<CONFIG>] [--context=<context>] [--allow-version-mismatch]
+
+Examples:
+  # Show felix's view of a pod.
+  <BINARY_NAME> node calc-graph --workload=default/frontend-5f8d7c9b6-x2x4z
+
+  # Show felix's view of all the local endpoints on a node.
+  <BINARY_NAME> node calc-graph node-1
+
+Options:
+  -h --help                        Show this screen.
+     --workload=<NAMESPACE/NAME>   Only show the endpoints of the given pod.  If <NODE>
+                                   is not specified, the pod's node is used.
+     --host-endpoint=<NAME>        Only show the given host endpoint.
+  -c --config=<CONFIG>             Path to the file containing connection
+                                   configuration in YAML or JSON format.
+                                   [default: ` + constants.DefaultConfigPath + `]
+     --context=<context>           The name of the kubeconfig context to use.
+     --allow-version-mismatch      Allow client and cluster versions mismatch.
+
+Description:
+  The calc-graph command prints, as JSON, felix's calculation graph view of the
+  endpoints on a node: their labels, the tiers and policies that select them,
+  their active profiles, and the members of the IP sets their rules refer to.
+
+  The command runs calico-node in the calico-node pod on the node, using the
+  Kubernetes API, so it can be run from any host with access to the cluster.
+  Felix's debug port must be enabled on the node, for example by setting the
+  FELIX_DEBUGPORT environment variable on the calico-node container.
+`
+	// Replace all instances of BINARY_NAME with the name of the binary.
+	name, _ := util.NameAndDescription()
+	doc = strings.ReplaceAll(doc, "<BINARY_NAME>", name)
+
+	parsedArgs, err := docopt.ParseArgs(doc, args, "")
+	if err != nil {
+		return fmt.Errorf("Invalid option: 'calicoctl %s'. Use flag '--help' to read about a specific subcommand.", strings.Join(args, " "))
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+	if context := parsedArgs["--context"]; context != nil {
+		os.Setenv("K8S_CURRENT_CONTEXT", context.(string))
+	}
+
+	nodeName, _ := parsedArgs["<NODE>"].(string)
+	workload, _ := parsedArgs["--workload"].(string)
+	hostEndpoint, _ := parsedArgs["--host-endpoint"].(string)
+	if workload != "" && hostEndpoint != "" {
+		return fmt.Errorf("only one of --workload and --host-endpoint may be specified")
+	}
+	if nodeName == "" && workload == "" {
+		return fmt.Errorf("<NODE> must be specified unless --workload is specified")
+	}
+
+	err = common.CheckVersionMismatch(parsedArgs["--config"], parsedArgs["--allow-version-mismatch"])
+	if err != nil {
+		return err
+	}
+	cfg, err := clientmgr.LoadClientConfig(parsedArgs["--config"].(string))
+	if err != nil {
+		return err
+	}
+	restConfig, cs, err := k8s.CreateKubernetesClientset(&cfg.Spec)
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	ctx := context.Background()
+	if nodeName == "" {
+		if nodeName, err = workloadNodeName(ctx, cs, workload); err != nil {
+			return err
+		}
+	}
+	pod, err := common.FindCalicoNodePod(ctx, cs, nodeName)
+	if err != nil {
+		return err
+	}
+	return common.ExecInPod(ctx, restConfig, cs, pod, common.CalicoNodeContainerName,
+		calcGraphCommand(workload, hostEndpoint), os.Stdout, os.Stderr)
+}
+
+// calcGraphCommand returns the calico-node command that queries felix's calculation graph.
+func calcGraphCommand(workload, hostEndpoint string) []string {
+	cmd := []string{"calico-node", "-show-calc-graph"}
+	if workload != "" {
+		cmd = append(cmd, "-workload", workload)
+	}
+	if hostEndpoint != "" {
+		cmd = append(cmd, "-host-endpoint", hostEndpoint)
+	}
+	return cmd
+}
+
+// workloadNodeName returns the name of the node that the given pod (namespace/name) runs on.
+func workloadNodeName(ctx context.Context, cs kubernetes.Interface, workload string) (string, error) {
+	namespace, name, ok := strings.Cut(workload, "/")
+	if !ok || namespace == "" || name == "" {
+		return "", fmt.Errorf("invalid workload %q: must be in the form <NAMESPACE>/<NAME>", workload)
+	}
+	pod, err := cs.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return "", fmt.Errorf("failed to get pod %s: %w", workload, err)
+	}
+	if pod.Spec.NodeName == "" {
+		return "", fmt.Errorf("pod %s is not scheduled to a node", workload)
+	}
+	return pod.Spec.NodeName, nil
+}
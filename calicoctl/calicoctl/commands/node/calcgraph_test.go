@@ -0,0 +1,61 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func calcGraphTestPod(namespace, name, nodeName string, labels map[string]string, phase corev1.PodPhase) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+		Spec:       corev1.PodSpec{NodeName: nodeName},
+		Status:     corev1.PodStatus{Phase: phase},
+	}
+}
+
+var _ = Describe("calc-graph", func() {
+	ctx := context.Background()
+
+	It("should find the node of a workload", func() {
+		cs := fake.NewSimpleClientset(
+			calcGraphTestPod("default", "frontend", "node-a", nil, corev1.PodRunning),
+			calcGraphTestPod("default", "pending", "", nil, corev1.PodPending),
+		)
+
+		nodeName, err := workloadNodeName(ctx, cs, "default/frontend")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(nodeName).To(Equal("node-a"))
+
+		_, err = workloadNodeName(ctx, cs, "default/pending")
+		Expect(err).To(HaveOccurred())
+		_, err = workloadNodeName(ctx, cs, "frontend")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should build the calico-node command", func() {
+		Expect(calcGraphCommand("", "")).To(Equal([]string{"calico-node", "-show-calc-graph"}))
+		Expect(calcGraphCommand("default/frontend", "")).To(Equal(
+			[]string{"calico-node", "-show-calc-graph", "-workload", "default/frontend"}))
+		Expect(calcGraphCommand("", "eth0")).To(Equal(
+			[]string{"calico-node", "-show-calc-graph", "-host-endpoint", "eth0"}))
+	})
+})
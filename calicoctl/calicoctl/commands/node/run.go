@@ -46,6 +46,8 @@ const (
 	AUTODETECTION_METHOD_CAN_REACH      = "can-reach="
 	AUTODETECTION_METHOD_INTERFACE      = "interface="
 	AUTODETECTION_METHOD_SKIP_INTERFACE = "skip-interface="
+	AUTODETECTION_METHOD_ROUTE_TO       = "route-to="
+	AUTODETECTION_METHOD_SEPARATOR      = ";"
 )
 
 var (
@@ -117,6 +119,15 @@ Options:
                              above) that does NOT match with any of the
                              specified interface name regexes. Regexes are
                              separated by commas (e.g. eth.*,enp0s.*).
+                           > route-to=<CIDR>
+                             Use the interface determined by your host routing
+                             tables that will be used to reach the supplied
+                             prefix.  Only the route to the first address in
+                             the prefix is used.
+                           Several methods may be separated by semicolons
+                           (e.g. interface=bond0;first-found), in which case
+                           they are tried in order until one of them detects
+                           an address.
                            [default: first-found]
      --ip6-autodetection-method=<IP6_AUTODETECTION_METHOD>
                            Specify the autodetection method for detecting the
@@ -487,8 +498,22 @@ func setNFConntrackMax() {
 	}
 }
 
-// Validate the IP autodection method string.
+// Validate the IP autodection method string.  This may be a chain of methods
+// separated by ";", each of which must be valid.
 func validateIpAutodetectionMethod(method string, version int) error {
+	for _, m := range strings.Split(method, AUTODETECTION_METHOD_SEPARATOR) {
+		if m = strings.TrimSpace(m); m == "" {
+			continue
+		}
+		if err := validateSingleIpAutodetectionMethod(m, version); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Validate a single IP autodection method.
+func validateSingleIpAutodetectionMethod(method string, version int) error {
 	if method == AUTODETECTION_METHOD_FIRST {
 		// Auto-detection method is "first-found", no additional validation
 		// required.
@@ -535,6 +560,18 @@ func validateIpAutodetectionMethod(method string, version int) error {
 			}
 		}
 		return nil
+	} else if strings.HasPrefix(method, AUTODETECTION_METHOD_ROUTE_TO) {
+		// Auto-detection method is "route-to", validate that the prefix is
+		// a valid CIDR of the required version.
+		prefixStr := strings.TrimPrefix(method, AUTODETECTION_METHOD_ROUTE_TO)
+		_, prefix, err := net.ParseCIDR(prefixStr)
+		if err != nil {
+			return fmt.Errorf("Error executing command: invalid prefix specified for IP autodetection: %s", prefixStr)
+		}
+		if prefix.Version() != version {
+			return fmt.Errorf("Error executing command: prefix for IP autodetection is not an IPv%d prefix: %s", version, prefixStr)
+		}
+		return nil
 	}
 
 	return fmt.Errorf("Error executing command: invalid IP autodetection method: %s", method)
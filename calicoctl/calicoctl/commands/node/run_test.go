@@ -0,0 +1,40 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = DescribeTable("Validate IP autodetection methods",
+	func(method string, version int, valid bool) {
+		err := validateIpAutodetectionMethod(method, version)
+		if valid {
+			Expect(err).NotTo(HaveOccurred())
+		} else {
+			Expect(err).To(HaveOccurred())
+		}
+	},
+	Entry("first-found", "first-found", 4, true),
+	Entry("interface", "interface=eth.*,en.*", 4, true),
+	Entry("route-to IPv4", "route-to=10.0.0.0/8", 4, true),
+	Entry("route-to IPv6", "route-to=fd00::/8", 6, true),
+	Entry("route-to wrong version", "route-to=10.0.0.0/8", 6, false),
+	Entry("route-to invalid prefix", "route-to=10.0.0", 4, false),
+	Entry("chain", "route-to=10.0.0.0/8; interface=bond0;first-found", 4, true),
+	Entry("chain with an invalid method", "interface=bond0;bogus", 4, false),
+	Entry("invalid method", "bogus", 4, false),
+)
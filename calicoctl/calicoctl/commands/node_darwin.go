@@ -16,9 +16,16 @@ package commands
 
 import (
 	"fmt"
+
+	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/node"
 )
 
 // Node function is a switch to node related sub-commands
 func Node(args []string) error {
+	// calc-graph uses the Kubernetes API rather than the local host, so it is available
+	// on every OS.
+	if len(args) > 1 && args[1] == "calc-graph" {
+		return node.CalcGraph(args)
+	}
 	return fmt.Errorf("Error executing command: 'calicoctl node' commands are not available on this OS")
 }
@@ -35,13 +35,14 @@ func Node(args []string) error {
     status       View the current status of a Calico node.
     diags        Gather a diagnostics bundle for a Calico node.
     checksystem  Verify the compute host is able to run a Calico node instance.
+    calc-graph   View felix's calculation graph view of the endpoints on a node.
 
 Options:
   -h --help      Show this screen.
 
 Description:
-  Node specific commands for <BINARY_NAME>.  These commands must be run directly on
-  the compute host running the Calico node instance.
+  Node specific commands for <BINARY_NAME>.  Except for calc-graph, these commands
+  must be run directly on the compute host running the Calico node instance.
 
   See '<BINARY_NAME> node <command> --help' to read about a specific subcommand.
 `
@@ -74,6 +75,8 @@ Description:
 		return node.Checksystem(args)
 	case "run":
 		return node.Run(args)
+	case "calc-graph":
+		return node.CalcGraph(args)
 	default:
 		fmt.Println(doc)
 	}
@@ -0,0 +1,509 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"github.com/projectcalico/api/pkg/lib/numorstring"
+
+	cnet "github.com/projectcalico/calico/libcalico-go/lib/net"
+	"github.com/projectcalico/calico/libcalico-go/lib/selector"
+)
+
+// Check identifies the kind of problem reported by a Finding.
+type Check string
+
+const (
+	// CheckShadowed is reported for a rule that can never match because an earlier rule with the
+	// same action matches all of its traffic.
+	CheckShadowed Check = "shadowed"
+	// CheckConflicting is reported for a rule that can never match because an earlier rule with a
+	// different action matches all of its traffic.
+	CheckConflicting Check = "conflicting"
+	// CheckUnusedPolicy is reported for a policy whose selector matches no endpoints.
+	CheckUnusedPolicy Check = "no-endpoints"
+	// CheckPassInLastTier is reported for a Pass rule in the last tier.
+	CheckPassInLastTier Check = "pass-in-last-tier"
+	// CheckNeverMatches is reported for a selector that can never match anything.
+	CheckNeverMatches Check = "never-matches"
+	// CheckInvalidSelector is reported for a selector that cannot be parsed.
+	CheckInvalidSelector Check = "invalid-selector"
+)
+
+// Finding is a single problem found in a policy.
+type Finding struct {
+	// Policy identifies the policy, for example "GlobalNetworkPolicy(default.allow-dns)".
+	Policy string
+	// Location is the field of the policy that has the problem, for example "spec.ingress[2]".
+	Location string
+	Check    Check
+	Message  string
+}
+
+// lintPolicy holds the fields of a GlobalNetworkPolicy or NetworkPolicy that are linted.
+type lintPolicy struct {
+	id        string
+	name      string
+	namespace string
+	tier      string
+	order     *float64
+
+	selector               string
+	namespaceSelector      string
+	serviceAccountSelector string
+
+	types   []apiv3.PolicyType
+	ingress []apiv3.Rule
+	egress  []apiv3.Rule
+}
+
+func fromGlobalNetworkPolicy(p *apiv3.GlobalNetworkPolicy) lintPolicy {
+	return lintPolicy{
+		id:                     fmt.Sprintf("GlobalNetworkPolicy(%s)", p.Name),
+		name:                   p.Name,
+		tier:                   p.Spec.Tier,
+		order:                  p.Spec.Order,
+		selector:               p.Spec.Selector,
+		namespaceSelector:      p.Spec.NamespaceSelector,
+		serviceAccountSelector: p.Spec.ServiceAccountSelector,
+		types:                  p.Spec.Types,
+		ingress:                p.Spec.Ingress,
+		egress:                 p.Spec.Egress,
+	}
+}
+
+func fromNetworkPolicy(p *apiv3.NetworkPolicy) lintPolicy {
+	return lintPolicy{
+		id:                     fmt.Sprintf("NetworkPolicy(%s/%s)", p.Namespace, p.Name),
+		name:                   p.Name,
+		namespace:              p.Namespace,
+		tier:                   p.Spec.Tier,
+		order:                  p.Spec.Order,
+		selector:               p.Spec.Selector,
+		serviceAccountSelector: p.Spec.ServiceAccountSelector,
+		types:                  p.Spec.Types,
+		ingress:                p.Spec.Ingress,
+		egress:                 p.Spec.Egress,
+	}
+}
+
+func (p lintPolicy) tierName() string {
+	if p.tier == "" {
+		return "default"
+	}
+	return p.tier
+}
+
+// appliesTo returns true if the policy applies to traffic in the given direction. Policies
+// without explicit types apply to ingress, and also to egress if they have egress rules.
+func (p lintPolicy) appliesTo(t apiv3.PolicyType) bool {
+	if len(p.types) == 0 {
+		return t == apiv3.PolicyTypeIngress || len(p.egress) > 0
+	}
+	for _, pt := range p.types {
+		if pt == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (p lintPolicy) rules(t apiv3.PolicyType) []apiv3.Rule {
+	if t == apiv3.PolicyTypeIngress {
+		return p.ingress
+	}
+	return p.egress
+}
+
+// lintEndpoint holds the fields of a WorkloadEndpoint or HostEndpoint used to check whether
+// policies select it.
+type lintEndpoint struct {
+	// namespace is empty for host endpoints.
+	namespace string
+	labels    map[string]string
+}
+
+// linter holds the resources to lint.
+type linter struct {
+	tiers    []apiv3.Tier
+	policies []lintPolicy
+
+	// endpoints is nil if the endpoints are not known, in which case policies are not checked
+	// for matching no endpoints.
+	endpoints []lintEndpoint
+
+	findings []Finding
+}
+
+func (l *linter) report(p lintPolicy, location string, check Check, format string, args ...interface{}) {
+	l.findings = append(l.findings, Finding{
+		Policy:   p.id,
+		Location: location,
+		Check:    check,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// Lint runs all of the checks and returns the findings, ordered by tier and policy order.
+func (l *linter) Lint() []Finding {
+	tierOrder := l.tierOrder()
+	sort.SliceStable(l.policies, func(i, j int) bool {
+		pi, pj := l.policies[i], l.policies[j]
+		if ti, tj := tierOrder[pi.tierName()], tierOrder[pj.tierName()]; ti != tj {
+			return ti < tj
+		}
+		if oi, oj := orderOf(pi.order), orderOf(pj.order); oi != oj {
+			return oi < oj
+		}
+		return pi.name < pj.name
+	})
+
+	lastTier := l.lastTier(tierOrder)
+	for i, p := range l.policies {
+		l.checkSelectors(p)
+		l.checkEndpoints(p)
+		for _, t := range []apiv3.PolicyType{apiv3.PolicyTypeIngress, apiv3.PolicyTypeEgress} {
+			if !p.appliesTo(t) {
+				continue
+			}
+			l.checkShadowedRules(p, l.earlierPoliciesInTier(i), t)
+			if p.tierName() == lastTier {
+				l.checkPassRules(p, t)
+			}
+		}
+	}
+	return l.findings
+}
+
+// tierOrder returns the position of each tier in the order the tiers are applied.
+func (l *linter) tierOrder() map[string]int {
+	tiers := append([]apiv3.Tier(nil), l.tiers...)
+	known := map[string]bool{}
+	for _, t := range tiers {
+		known[t.Name] = true
+	}
+	// Policies may refer to tiers that were not loaded, for example when linting files.
+	// The default tier always exists.
+	for _, p := range l.policies {
+		if !known[p.tierName()] {
+			t := apiv3.Tier{}
+			t.Name = p.tierName()
+			if t.Name == "default" {
+				order := apiv3.DefaultTierOrder
+				t.Spec.Order = &order
+			}
+			tiers = append(tiers, t)
+			known[t.Name] = true
+		}
+	}
+	sort.SliceStable(tiers, func(i, j int) bool {
+		if oi, oj := orderOf(tiers[i].Spec.Order), orderOf(tiers[j].Spec.Order); oi != oj {
+			return oi < oj
+		}
+		return tiers[i].Name < tiers[j].Name
+	})
+
+	order := map[string]int{}
+	for i, t := range tiers {
+		order[t.Name] = i
+	}
+	return order
+}
+
+func (l *linter) lastTier(tierOrder map[string]int) string {
+	last, pos := "", -1
+	for name, i := range tierOrder {
+		if i > pos {
+			last, pos = name, i
+		}
+	}
+	return last
+}
+
+// earlierPoliciesInTier returns the policies in the same tier as the i'th policy that are
+// applied before it.
+func (l *linter) earlierPoliciesInTier(i int) []lintPolicy {
+	var earlier []lintPolicy
+	for j := 0; j < i; j++ {
+		if l.policies[j].tierName() == l.policies[i].tierName() {
+			earlier = append(earlier, l.policies[j])
+		}
+	}
+	return earlier
+}
+
+func orderOf(order *float64) float64 {
+	if order == nil {
+		return math.Inf(1)
+	}
+	return *order
+}
+
+// checkSelectors reports selectors that cannot be parsed or can never match.
+func (l *linter) checkSelectors(p lintPolicy) {
+	check := func(location, sel string) {
+		if sel == "" {
+			return
+		}
+		parsed, err := selector.Parse(sel)
+		if err != nil {
+			l.report(p, location, CheckInvalidSelector, "selector %q is not valid: %v", sel, err)
+			return
+		}
+		for label, r := range parsed.LabelRestrictions() {
+			if !r.PossibleToSatisfy() {
+				l.report(p, location, CheckNeverMatches,
+					"selector %q can never match: its conditions on label %q contradict each other", sel, label)
+				return
+			}
+		}
+	}
+
+	check("spec.selector", p.selector)
+	check("spec.namespaceSelector", p.namespaceSelector)
+	check("spec.serviceAccountSelector", p.serviceAccountSelector)
+	for _, t := range []apiv3.PolicyType{apiv3.PolicyTypeIngress, apiv3.PolicyTypeEgress} {
+		for i, r := range p.rules(t) {
+			for _, e := range []struct {
+				name   string
+				entity apiv3.EntityRule
+			}{{"source", r.Source}, {"destination", r.Destination}} {
+				loc := fmt.Sprintf("%s.%s", ruleLocation(t, i), e.name)
+				check(loc+".selector", e.entity.Selector)
+				check(loc+".notSelector", e.entity.NotSelector)
+				check(loc+".namespaceSelector", e.entity.NamespaceSelector)
+			}
+		}
+	}
+}
+
+// checkEndpoints reports policies that select no endpoints. Policies with a namespace or
+// service account selector are only checked against their main selector, since the labels of
+// namespaces and service accounts are not loaded.
+func (l *linter) checkEndpoints(p lintPolicy) {
+	if l.endpoints == nil {
+		return
+	}
+	sel, err := selector.Parse(p.selector)
+	if err != nil {
+		// Reported by checkSelectors.
+		return
+	}
+	for _, ep := range l.endpoints {
+		if p.namespace != "" && ep.namespace != p.namespace {
+			continue
+		}
+		if sel.Evaluate(ep.labels) {
+			return
+		}
+	}
+	l.report(p, "spec.selector", CheckUnusedPolicy, "selector %q matches no endpoints", selector.Normalise(p.selector))
+}
+
+// checkPassRules reports Pass rules in the last tier, which skip straight to the profiles.
+func (l *linter) checkPassRules(p lintPolicy, t apiv3.PolicyType) {
+	for i, r := range p.rules(t) {
+		if r.Action == apiv3.Pass {
+			l.report(p, ruleLocation(t, i), CheckPassInLastTier,
+				"Pass action in the last tier %q skips any remaining policy in the tier and applies the profiles", p.tierName())
+		}
+	}
+}
+
+// checkShadowedRules reports rules that match no traffic because an earlier rule, in the same
+// policy or in an earlier policy in the tier that applies to all of the same endpoints,
+// matches all of the traffic that the rule would match.
+func (l *linter) checkShadowedRules(p lintPolicy, earlier []lintPolicy, t apiv3.PolicyType) {
+	rules := p.rules(t)
+	for i, r := range rules {
+		// Look in this policy first, then in earlier policies, nearest first.
+		shadowedBy, by := -1, p
+		for j := 0; j < i; j++ {
+			if ruleCovers(rules[j], r) {
+				shadowedBy = j
+				break
+			}
+		}
+		for k := len(earlier) - 1; shadowedBy < 0 && k >= 0; k-- {
+			e := earlier[k]
+			if !e.appliesTo(t) || !policyCovers(e, p) {
+				continue
+			}
+			for j, er := range e.rules(t) {
+				if ruleCovers(er, r) {
+					shadowedBy, by = j, e
+					break
+				}
+			}
+		}
+		if shadowedBy < 0 {
+			continue
+		}
+
+		earlierRule := by.rules(t)[shadowedBy]
+		where := ruleLocation(t, shadowedBy)
+		if by.id != p.id {
+			where += " of " + by.id
+		}
+		if earlierRule.Action == r.Action {
+			l.report(p, ruleLocation(t, i), CheckShadowed,
+				"rule never matches: %s matches all of its traffic first", where)
+		} else {
+			l.report(p, ruleLocation(t, i), CheckConflicting,
+				"%s rule never matches: %s %s matches all of its traffic first", r.Action, earlierRule.Action, where)
+		}
+	}
+}
+
+func ruleLocation(t apiv3.PolicyType, i int) string {
+	if t == apiv3.PolicyTypeIngress {
+		return fmt.Sprintf("spec.ingress[%d]", i)
+	}
+	return fmt.Sprintf("spec.egress[%d]", i)
+}
+
+// policyCovers returns true if the earlier policy applies to every endpoint that the later
+// policy applies to. Only simple cases are detected: the selectors must be identical or the
+// earlier policy must select all endpoints.
+func policyCovers(earlier, later lintPolicy) bool {
+	if earlier.namespace != "" && earlier.namespace != later.namespace {
+		return false
+	}
+	if !selectorCovers(earlier.namespaceSelector, later.namespaceSelector) ||
+		!selectorCovers(earlier.serviceAccountSelector, later.serviceAccountSelector) {
+		return false
+	}
+	es := selector.Normalise(earlier.selector)
+	return es == "all()" || es == selector.Normalise(later.selector)
+}
+
+// ruleCovers returns true if the earlier rule ends processing for all of the traffic that the
+// later rule matches. It errs on the side of returning false: every match criterion of the
+// earlier rule must be absent, identical to the later rule's, or (for ports and nets) a
+// superset of it.
+func ruleCovers(earlier, later apiv3.Rule) bool {
+	switch earlier.Action {
+	case apiv3.Allow, apiv3.Deny, apiv3.Pass:
+	default:
+		// Log rules don't end processing.
+		return false
+	}
+	return optionalCovers(earlier.IPVersion, later.IPVersion) &&
+		protocolCovers(earlier.Protocol, later.Protocol) &&
+		optionalCovers(earlier.ICMP, later.ICMP) &&
+		protocolCovers(earlier.NotProtocol, later.NotProtocol) &&
+		optionalCovers(earlier.NotICMP, later.NotICMP) &&
+		optionalCovers(earlier.HTTP, later.HTTP) &&
+		entityCovers(earlier.Source, later.Source) &&
+		entityCovers(earlier.Destination, later.Destination)
+}
+
+func entityCovers(earlier, later apiv3.EntityRule) bool {
+	return netsCover(earlier.Nets, later.Nets) &&
+		selectorCovers(earlier.Selector, later.Selector) &&
+		selectorCovers(earlier.NamespaceSelector, later.NamespaceSelector) &&
+		optionalCovers(earlier.Services, later.Services) &&
+		portsCover(earlier.Ports, later.Ports) &&
+		optionalCovers(earlier.NotNets, later.NotNets) &&
+		selectorCovers(earlier.NotSelector, later.NotSelector) &&
+		optionalCovers(earlier.NotPorts, later.NotPorts) &&
+		optionalCovers(earlier.ServiceAccounts, later.ServiceAccounts)
+}
+
+// optionalCovers returns true if the earlier match criterion is unset or identical to the later one.
+func optionalCovers(earlier, later interface{}) bool {
+	v := reflect.ValueOf(earlier)
+	if !v.IsValid() || v.IsZero() || ((v.Kind() == reflect.Slice || v.Kind() == reflect.Map) && v.Len() == 0) {
+		return true
+	}
+	return reflect.DeepEqual(earlier, later)
+}
+
+func protocolCovers(earlier, later *numorstring.Protocol) bool {
+	if earlier == nil {
+		return true
+	}
+	return later != nil && earlier.String() == later.String()
+}
+
+func selectorCovers(earlier, later string) bool {
+	if earlier == "" {
+		return true
+	}
+	return selector.Normalise(earlier) == selector.Normalise(later)
+}
+
+func portsCover(earlier, later []numorstring.Port) bool {
+	if len(earlier) == 0 {
+		return true
+	}
+	if len(later) == 0 {
+		return false
+	}
+	for _, lp := range later {
+		covered := false
+		for _, ep := range earlier {
+			if ep.PortName != "" || lp.PortName != "" {
+				covered = ep.PortName == lp.PortName
+			} else {
+				covered = ep.MinPort <= lp.MinPort && lp.MaxPort <= ep.MaxPort
+			}
+			if covered {
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
+
+func netsCover(earlier, later []string) bool {
+	if len(earlier) == 0 {
+		return true
+	}
+	if len(later) == 0 {
+		return false
+	}
+	for _, ln := range later {
+		_, lnet, err := cnet.ParseCIDROrIP(ln)
+		if err != nil {
+			return false
+		}
+		lones, _ := lnet.Mask.Size()
+		covered := false
+		for _, en := range earlier {
+			_, enet, err := cnet.ParseCIDROrIP(en)
+			if err != nil {
+				continue
+			}
+			eones, _ := enet.Mask.Size()
+			if eones <= lones && enet.Contains(lnet.IP) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			return false
+		}
+	}
+	return true
+}
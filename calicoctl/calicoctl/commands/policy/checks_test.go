@@ -0,0 +1,180 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"github.com/projectcalico/api/pkg/lib/numorstring"
+)
+
+var (
+	tcp = numorstring.ProtocolFromString("TCP")
+	udp = numorstring.ProtocolFromString("UDP")
+)
+
+func gnp(name, tier string, order float64, sel string, ingress ...apiv3.Rule) *apiv3.GlobalNetworkPolicy {
+	p := apiv3.NewGlobalNetworkPolicy()
+	p.Name = name
+	p.Spec.Tier = tier
+	p.Spec.Order = &order
+	p.Spec.Selector = sel
+	p.Spec.Ingress = ingress
+	return p
+}
+
+func allowTCP(ports ...numorstring.Port) apiv3.Rule {
+	return apiv3.Rule{Action: apiv3.Allow, Protocol: &tcp, Destination: apiv3.EntityRule{Ports: ports}}
+}
+
+func lint(objs ...interface{}) []Finding {
+	l := &linter{}
+	for _, o := range objs {
+		switch r := o.(type) {
+		case *apiv3.GlobalNetworkPolicy:
+			Expect(l.add(r)).To(Succeed())
+		case *apiv3.NetworkPolicy:
+			Expect(l.add(r)).To(Succeed())
+		case *apiv3.Tier:
+			Expect(l.add(r)).To(Succeed())
+		case lintEndpoint:
+			l.endpoints = append(l.endpoints, r)
+		}
+	}
+	return l.Lint()
+}
+
+var _ = Describe("Policy lint", func() {
+	It("should report a rule shadowed by an earlier rule in the same policy", func() {
+		findings := lint(gnp("default.p", "", 1, "all()",
+			allowTCP(numorstring.SinglePort(80), numorstring.SinglePort(443)),
+			apiv3.Rule{Action: apiv3.Log},
+			allowTCP(numorstring.SinglePort(443)),
+			apiv3.Rule{Action: apiv3.Deny, Protocol: &tcp, Destination: apiv3.EntityRule{Ports: []numorstring.Port{numorstring.SinglePort(80)}}},
+			allowTCP(numorstring.SinglePort(8080)),
+		))
+		Expect(findings).To(Equal([]Finding{
+			{
+				Policy:   "GlobalNetworkPolicy(default.p)",
+				Location: "spec.ingress[2]",
+				Check:    CheckShadowed,
+				Message:  "rule never matches: spec.ingress[0] matches all of its traffic first",
+			},
+			{
+				Policy:   "GlobalNetworkPolicy(default.p)",
+				Location: "spec.ingress[3]",
+				Check:    CheckConflicting,
+				Message:  "Deny rule never matches: Allow spec.ingress[0] matches all of its traffic first",
+			},
+		}))
+	})
+
+	It("should report a rule shadowed by an earlier policy in the tier", func() {
+		findings := lint(
+			gnp("default.later", "", 200, "app == 'db'", allowTCP(numorstring.SinglePort(5432))),
+			gnp("default.earlier", "", 100, "all()", apiv3.Rule{Action: apiv3.Deny, Protocol: &tcp}),
+		)
+		Expect(findings).To(ConsistOf(Finding{
+			Policy:   "GlobalNetworkPolicy(default.later)",
+			Location: "spec.ingress[0]",
+			Check:    CheckConflicting,
+			Message:  "Allow rule never matches: Deny spec.ingress[0] of GlobalNetworkPolicy(default.earlier) matches all of its traffic first",
+		}))
+	})
+
+	It("should not compare policies in different tiers or with different selectors", func() {
+		order := float64(50)
+		tier := apiv3.NewTier()
+		tier.Name = "security"
+		tier.Spec.Order = &order
+		findings := lint(
+			tier,
+			gnp("security.p", "security", 100, "all()", apiv3.Rule{Action: apiv3.Pass, Protocol: &tcp}),
+			gnp("default.p", "", 100, "app == 'db'", allowTCP()),
+			gnp("default.q", "", 200, "app == 'web'", allowTCP()),
+		)
+		Expect(findings).To(BeEmpty())
+	})
+
+	DescribeTable("should only report rules whose traffic is fully matched",
+		func(earlier, later apiv3.Rule, covers bool) {
+			Expect(ruleCovers(earlier, later)).To(Equal(covers))
+		},
+		Entry("empty rule", apiv3.Rule{Action: apiv3.Allow}, allowTCP(numorstring.SinglePort(80)), true),
+		Entry("log rule", apiv3.Rule{Action: apiv3.Log}, allowTCP(), false),
+		Entry("different protocol", apiv3.Rule{Action: apiv3.Allow, Protocol: &udp}, allowTCP(), false),
+		Entry("port range", allowTCP(numorstring.Port{MinPort: 1000, MaxPort: 2000}), allowTCP(numorstring.SinglePort(1500)), true),
+		Entry("port outside range", allowTCP(numorstring.Port{MinPort: 1000, MaxPort: 2000}), allowTCP(numorstring.SinglePort(2500)), false),
+		Entry("later rule has no ports", allowTCP(numorstring.SinglePort(80)), allowTCP(), false),
+		Entry("containing net",
+			apiv3.Rule{Action: apiv3.Deny, Source: apiv3.EntityRule{Nets: []string{"10.0.0.0/8"}}},
+			apiv3.Rule{Action: apiv3.Allow, Source: apiv3.EntityRule{Nets: []string{"10.1.0.0/16", "10.2.0.1"}}},
+			true),
+		Entry("overlapping net",
+			apiv3.Rule{Action: apiv3.Deny, Source: apiv3.EntityRule{Nets: []string{"10.1.0.0/16"}}},
+			apiv3.Rule{Action: apiv3.Allow, Source: apiv3.EntityRule{Nets: []string{"10.0.0.0/8"}}},
+			false),
+		Entry("equivalent selectors",
+			apiv3.Rule{Action: apiv3.Allow, Source: apiv3.EntityRule{Selector: "app=='a'"}},
+			apiv3.Rule{Action: apiv3.Allow, Source: apiv3.EntityRule{Selector: "app == 'a'"}},
+			true),
+		Entry("different selectors",
+			apiv3.Rule{Action: apiv3.Allow, Source: apiv3.EntityRule{Selector: "app == 'a'"}},
+			apiv3.Rule{Action: apiv3.Allow, Source: apiv3.EntityRule{Selector: "app == 'b'"}},
+			false),
+	)
+
+	It("should report selectors that can never match", func() {
+		findings := lint(gnp("default.p", "", 1, "app == 'a' && app == 'b'",
+			apiv3.Rule{Action: apiv3.Allow, Source: apiv3.EntityRule{Selector: "has(x) && !has(x)"}},
+		))
+		Expect(findings).To(HaveLen(2))
+		Expect(findings[0].Location).To(Equal("spec.selector"))
+		Expect(findings[0].Check).To(Equal(CheckNeverMatches))
+		Expect(findings[1].Location).To(Equal("spec.ingress[0].source.selector"))
+		Expect(findings[1].Check).To(Equal(CheckNeverMatches))
+	})
+
+	It("should report Pass rules in the last tier", func() {
+		findings := lint(gnp("default.p", "", 1, "all()", apiv3.Rule{Action: apiv3.Pass, Protocol: &udp}))
+		Expect(findings).To(ConsistOf(Finding{
+			Policy:   "GlobalNetworkPolicy(default.p)",
+			Location: "spec.ingress[0]",
+			Check:    CheckPassInLastTier,
+			Message:  `Pass action in the last tier "default" skips any remaining policy in the tier and applies the profiles`,
+		}))
+	})
+
+	It("should report policies that select no endpoints", func() {
+		np := apiv3.NewNetworkPolicy()
+		np.Name = "default.np"
+		np.Namespace = "prod"
+		np.Spec.Selector = "app == 'web'"
+
+		findings := lint(
+			np,
+			gnp("default.db", "", 1, "app == 'db'"),
+			gnp("default.web", "", 2, "app == 'web'"),
+			lintEndpoint{namespace: "dev", labels: map[string]string{"app": "web"}},
+		)
+		Expect(findings).To(HaveLen(2))
+		Expect(findings[0].Policy).To(Equal("GlobalNetworkPolicy(default.db)"))
+		Expect(findings[0].Check).To(Equal(CheckUnusedPolicy))
+		Expect(findings[1].Policy).To(Equal("NetworkPolicy(prod/default.np)"))
+		Expect(findings[1].Check).To(Equal(CheckUnusedPolicy))
+	})
+})
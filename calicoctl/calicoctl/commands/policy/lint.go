@@ -0,0 +1,260 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package policy
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/docopt/docopt-go"
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/argutils"
+	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/clientmgr"
+	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/common"
+	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/constants"
+	"github.com/projectcalico/calico/calicoctl/calicoctl/commands/file"
+	"github.com/projectcalico/calico/calicoctl/calicoctl/resourcemgr"
+	"github.com/projectcalico/calico/calicoctl/calicoctl/util"
+	libapi "github.com/projectcalico/calico/libcalico-go/lib/apis/v3"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+)
+
+// Lint loads tiers and policies and reports rules and selectors that have no effect.
+func Lint(args []string) error {
+	doc := constants.DatastoreIntro + `Usage:
+  <BINARY_NAME> policy lint [--filename=<FILENAME>] [--recursive] [--skip-endpoints]
+                [--config=<CONFIG>] [--context=<context>] [--allow-version-mismatch]
+
+Examples:
+  # Lint the tiers and policies in the cluster.
+  <BINARY_NAME> policy lint
+
+  # Lint the tiers and policies in a directory, against the endpoints in the cluster.
+  <BINARY_NAME> policy lint -f ./policies/ -R
+
+  # Lint the tiers and policies in a file without connecting to the cluster.
+  <BINARY_NAME> policy lint -f ./policy.yaml --skip-endpoints
+
+Options:
+  -h --help                    Show this screen.
+  -f --filename=<FILENAME>     Filename to load the tiers and policies from, instead of
+                               the cluster.  If set to "-" loads from stdin.  If filename
+                               is a directory, the tiers and policies are loaded from each
+                               .json .yaml and .yml file within that directory.  Any
+                               WorkloadEndpoints and HostEndpoints in the files are used
+                               in addition to those in the cluster.
+  -R --recursive               Process the filename specified in -f or --filename recursively.
+     --skip-endpoints          Do not load endpoints from the cluster, and do not check
+                               for policies that select no endpoints.
+  -c --config=<CONFIG>         Path to the file containing connection
+                               configuration in YAML or JSON format.
+                               [default: ` + constants.DefaultConfigPath + `]
+     --context=<context>       The name of the kubeconfig context to use.
+     --allow-version-mismatch  Allow client and cluster versions mismatch.
+
+Description:
+  The policy lint command checks GlobalNetworkPolicies and NetworkPolicies for:
+  -  rules that never match because an earlier rule in the same policy, or in an
+     earlier policy in the tier that applies to the same endpoints, matches all
+     of their traffic.  These are reported as "shadowed" if the earlier rule has
+     the same action, and "conflicting" if it has a different action.
+  -  policies whose selector matches no endpoints.
+  -  Pass rules in the last tier.
+  -  selectors that are valid but can never match, for example
+     "app == 'a' && app == 'b'".
+
+  The checks err on the side of not reporting problems: a rule is only reported
+  as shadowed if every match criterion of the earlier rule is absent, identical,
+  or (for ports and nets) a superset of the criterion of the later rule.
+
+  The command exits with a non-zero exit code if any problems are found.
+`
+	// Replace all instances of BINARY_NAME with the name of the binary.
+	name, _ := util.NameAndDescription()
+	doc = strings.ReplaceAll(doc, "<BINARY_NAME>", name)
+
+	parsedArgs, err := docopt.ParseArgs(doc, args, "")
+	if err != nil {
+		return fmt.Errorf("Invalid option: 'calicoctl %s'. Use flag '--help' to read about a specific subcommand.", strings.Join(args, " "))
+	}
+	if len(parsedArgs) == 0 {
+		return nil
+	}
+	if context := parsedArgs["--context"]; context != nil {
+		os.Setenv("K8S_CURRENT_CONTEXT", context.(string))
+	}
+
+	l := &linter{}
+	fromFiles := parsedArgs["--filename"] != nil
+	skipEndpoints := argutils.ArgBoolOrFalse(parsedArgs, "--skip-endpoints")
+	if fromFiles {
+		if err := l.loadFiles(parsedArgs); err != nil {
+			return err
+		}
+	}
+
+	if !fromFiles || !skipEndpoints {
+		err = common.CheckVersionMismatch(parsedArgs["--config"], parsedArgs["--allow-version-mismatch"])
+		if err != nil {
+			return err
+		}
+		c, err := clientmgr.NewClient(parsedArgs["--config"].(string))
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+		if !fromFiles {
+			if err := l.loadPolicies(ctx, c); err != nil {
+				return err
+			}
+		}
+		if !skipEndpoints {
+			if err := l.loadEndpoints(ctx, c); err != nil {
+				return err
+			}
+		}
+	}
+	if skipEndpoints {
+		l.endpoints = nil
+	}
+
+	findings := l.Lint()
+	if len(findings) == 0 {
+		fmt.Printf("No problems found in %d policies\n", len(l.policies))
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "POLICY\tLOCATION\tCHECK\tMESSAGE")
+	for _, f := range findings {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", f.Policy, f.Location, f.Check, f.Message)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	return fmt.Errorf("Found %d problem(s) in %d policies", len(findings), len(l.policies))
+}
+
+// loadFiles adds the resources in the files named by the --filename argument.
+func (l *linter) loadFiles(parsedArgs map[string]interface{}) error {
+	return file.Iter(parsedArgs, func(modifiedArgs map[string]interface{}) error {
+		objs, err := resourcemgr.CreateResourcesFromFile(modifiedArgs["--filename"].(string))
+		if err != nil {
+			return err
+		}
+		for _, obj := range objs {
+			if err := l.add(obj); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// add adds a resource, or each resource in a list, to be linted. Resources of other kinds
+// are ignored.
+func (l *linter) add(obj runtime.Object) error {
+	switch r := obj.(type) {
+	case *apiv3.Tier:
+		l.tiers = append(l.tiers, *r)
+	case *apiv3.GlobalNetworkPolicy:
+		l.policies = append(l.policies, fromGlobalNetworkPolicy(r))
+	case *apiv3.NetworkPolicy:
+		if r.Namespace == "" {
+			r.Namespace = "default"
+		}
+		l.policies = append(l.policies, fromNetworkPolicy(r))
+	case *libapi.WorkloadEndpoint:
+		l.addWorkloadEndpoint(r)
+	case *apiv3.HostEndpoint:
+		l.endpoints = append(l.endpoints, lintEndpoint{labels: r.Labels})
+	case resourcemgr.ResourceListObject:
+		items, err := meta.ExtractList(r)
+		if err != nil {
+			return err
+		}
+		for _, item := range items {
+			if err := l.add(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (l *linter) addWorkloadEndpoint(wep *libapi.WorkloadEndpoint) {
+	// The datastore adds the namespace and orchestrator labels, but endpoints loaded from
+	// files may not have them.
+	labels := map[string]string{
+		apiv3.LabelNamespace:    wep.Namespace,
+		apiv3.LabelOrchestrator: wep.Spec.Orchestrator,
+	}
+	for k, v := range wep.Labels {
+		labels[k] = v
+	}
+	l.endpoints = append(l.endpoints, lintEndpoint{namespace: wep.Namespace, labels: labels})
+}
+
+// loadPolicies adds the tiers and policies in the cluster.
+func (l *linter) loadPolicies(ctx context.Context, c client.Interface) error {
+	tiers, err := c.Tiers().List(ctx, options.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list tiers: %w", err)
+	}
+	gnps, err := c.GlobalNetworkPolicies().List(ctx, options.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list global network policies: %w", err)
+	}
+	nps, err := c.NetworkPolicies().List(ctx, options.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list network policies: %w", err)
+	}
+	for _, list := range []runtime.Object{tiers, gnps, nps} {
+		if err := l.add(list); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// loadEndpoints adds the workload and host endpoints in the cluster.
+func (l *linter) loadEndpoints(ctx context.Context, c client.Interface) error {
+	weps, err := c.WorkloadEndpoints().List(ctx, options.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list workload endpoints: %w", err)
+	}
+	heps, err := c.HostEndpoints().List(ctx, options.ListOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to list host endpoints: %w", err)
+	}
+	if err := l.add(weps); err != nil {
+		return err
+	}
+	if err := l.add(heps); err != nil {
+		return err
+	}
+	if l.endpoints == nil {
+		// Record that the endpoints are known, even though there are none.
+		l.endpoints = []lintEndpoint{}
+	}
+	return nil
+}
@@ -23,6 +23,7 @@ import (
 	"github.com/projectcalico/go-yaml-wrapper"
 	log "github.com/sirupsen/logrus"
 	networkingv1 "k8s.io/api/networking/v1"
+	adminpolicy "sigs.k8s.io/network-policy-api/apis/v1alpha1"
 
 	yamlsep "github.com/projectcalico/calico/calicoctl/calicoctl/util/yaml"
 	apiv1 "github.com/projectcalico/calico/libcalico-go/lib/apis/v1"
@@ -33,6 +34,7 @@ import (
 var KindK8sListV1 = "List"
 var VersionK8sListV1 = "v1"
 var VersionK8sNetworkingV1 = "networking.k8s.io/v1"
+var VersionK8sPolicyV1Alpha1 = adminpolicy.GroupVersion.String()
 
 // Store a resourceHelper for each resource unversioned.TypeMetadata.
 var resourceToType map[unversioned.TypeMetadata]reflect.Type
@@ -54,6 +56,7 @@ func populateResourceTypes() {
 		apiv1.NewWorkloadEndpoint(),
 		NewK8sNetworkPolicy(),
 		NewK8sNetworkPolicyList(),
+		NewK8sAdminNetworkPolicy(),
 	}
 
 	for _, rt := range resTypes {
@@ -223,6 +226,22 @@ func NewK8sNetworkPolicy() *K8sNetworkPolicy {
 	}
 }
 
+// Kubernetes AdminNetworkPolicy helper struct used for conversion from
+// Kubernetes API to Calico v3 API
+type K8sAdminNetworkPolicy struct {
+	unversioned.TypeMetadata
+	adminpolicy.AdminNetworkPolicy
+}
+
+func NewK8sAdminNetworkPolicy() *K8sAdminNetworkPolicy {
+	return &K8sAdminNetworkPolicy{
+		TypeMetadata: unversioned.TypeMetadata{
+			Kind:       "AdminNetworkPolicy",
+			APIVersion: VersionK8sPolicyV1Alpha1,
+		},
+	}
+}
+
 type K8sListMetadata struct {
 	ResourceVersion string `json:"resourceVersion"`
 	SelfLink        string `json:"selfLink"`
@@ -38,6 +38,7 @@ import (
 	yamlsep "github.com/projectcalico/calico/calicoctl/calicoctl/util/yaml"
 	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
 	cerrors "github.com/projectcalico/calico/libcalico-go/lib/errors"
+	"github.com/projectcalico/calico/libcalico-go/lib/watch"
 )
 
 // ResourceManager provides a useful function for each resource type.  This includes:
@@ -54,6 +55,7 @@ type ResourceManager interface {
 	Delete(ctx context.Context, client client.Interface, resource ResourceObject) (ResourceObject, error)
 	GetOrList(ctx context.Context, client client.Interface, resource ResourceObject) (runtime.Object, error)
 	Patch(ctx context.Context, client client.Interface, resource ResourceObject, patch string) (ResourceObject, error)
+	Watch(ctx context.Context, client client.Interface, resource ResourceObject) (watch.Interface, error)
 }
 
 // ResourceObject is implemented by all Calico resources
@@ -71,6 +73,7 @@ type ResourceListObject interface {
 type (
 	ResourceActionCommand     func(context.Context, client.Interface, ResourceObject) (ResourceObject, error)
 	ResourceListActionCommand func(context.Context, client.Interface, ResourceObject) (ResourceListObject, error)
+	ResourceWatchCommand      func(context.Context, client.Interface, ResourceObject) (watch.Interface, error)
 )
 
 // ResourceHelper encapsulates details about a specific version of a specific resource:
@@ -79,7 +82,7 @@ type (
 //     though they are not strictly resources themselves).
 //   - The concrete resource struct for this version
 //   - Template strings used to format output for each resource type.
-//   - Functions to handle resource management actions (apply, create, update, delete, list).
+//   - Functions to handle resource management actions (apply, create, update, delete, list, watch).
 //     These functions are an untyped interface (generic Resource interfaces) that map through
 //     to the Calico clients typed interface.
 type resourceHelper struct {
@@ -96,6 +99,7 @@ type resourceHelper struct {
 	delete            ResourceActionCommand
 	get               ResourceActionCommand
 	list              ResourceListActionCommand
+	watch             ResourceWatchCommand
 }
 
 func (rh resourceHelper) String() string {
@@ -119,7 +123,7 @@ func ValidResources() []string {
 
 func registerResource(res ResourceObject, resList ResourceListObject, isNamespaced bool, names []string,
 	tableHeadings []string, tableHeadingsWide []string, headingsMap map[string]string,
-	create, update, delete, get ResourceActionCommand, list ResourceListActionCommand,
+	create, update, delete, get ResourceActionCommand, list ResourceListActionCommand, watch ResourceWatchCommand,
 ) {
 	if helpers == nil {
 		helpers = make(map[schema.GroupVersionKind]resourceHelper)
@@ -138,6 +142,7 @@ func registerResource(res ResourceObject, resList ResourceListObject, isNamespac
 		delete:            delete,
 		get:               get,
 		list:              list,
+		watch:             watch,
 	}
 	helpers[res.GetObjectKind().GroupVersionKind()] = rh
 
@@ -281,6 +286,13 @@ func (rh resourceHelper) Delete(ctx context.Context, client client.Interface, re
 	return rh.delete(ctx, client, resource)
 }
 
+// Watch is an un-typed method to watch resources.  This calls directly through to the
+// resource helper specific Watch method, which watches the named resource (or all
+// resources if the name is empty) from the resource version of the supplied resource.
+func (rh resourceHelper) Watch(ctx context.Context, client client.Interface, resource ResourceObject) (watch.Interface, error) {
+	return rh.watch(ctx, client, resource)
+}
+
 // GetOrList is an un-typed method to get an existing resource. This calls directly
 // through to the resource helper specific Get (if the resource name is set)
 // or List (if the resource name is empty) method which will map the untyped call to
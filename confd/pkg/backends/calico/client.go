@@ -29,6 +29,7 @@ import (
 	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
 	"github.com/projectcalico/api/pkg/lib/numorstring"
 	log "github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/projectcalico/calico/confd/pkg/buildinfo"
 	"github.com/projectcalico/calico/confd/pkg/config"
@@ -456,11 +457,17 @@ type bgpPeer struct {
 	Port            uint16               `json:"port"`
 	KeepNextHop     bool                 `json:"keep_next_hop"`
 	RestartTime     string               `json:"restart_time"`
+	GracefulRestart string               `json:"graceful_restart,omitempty"`
+	LLGR            string               `json:"llgr,omitempty"`
+	LLGRStaleTime   string               `json:"llgr_stale_time,omitempty"`
 	CalicoNode      bool                 `json:"calico_node"`
 	NumAllowLocalAS int32                `json:"num_allow_local_as"`
 	TTLSecurity     uint8                `json:"ttl_security"`
 	ReachableBy     string               `json:"reachable_by"`
 	Filters         []string             `json:"filters"`
+	Interface       string               `json:"interface,omitempty"`
+	CIDR            string               `json:"cidr,omitempty"`
+	Name            string               `json:"name,omitempty"`
 }
 
 type bgpPrefix struct {
@@ -487,17 +494,9 @@ func (c *client) updatePeersV1() {
 	// value form as c.peeringCache.
 	peersV1 := make(map[string]string)
 
-	// Common subroutine for emitting both global and node-specific peerings.
-	emit := func(key model.Key, peer *bgpPeer) {
-		log.WithFields(log.Fields{"key": key, "peer": peer}).Debug("Maybe emit peering")
-
-		// Compute etcd v1 path for this peering key.
-		k, err := model.KeyToDefaultPath(key)
-		if err != nil {
-			log.Errorf("Ignoring update: unable to create path from Key %v: %v", key, err)
-			return
-		}
-
+	// Common subroutine for storing a peering at the given path.  globalPath is the
+	// path that the equivalent global peering would have.
+	emitPath := func(k, globalPath string, peer *bgpPeer) {
 		// If we already have an entry for that path, it wins.  When we're
 		// emitting reverse peerings to ensure symmetry, this is what ensures
 		// that an explicit forwards peering is not overwritten by an implicit
@@ -507,13 +506,10 @@ func (c *client) updatePeersV1() {
 			return
 		}
 
-		// If we would be emitting a node-specific peering to a peer IP, and we
-		// already have a global peering to that IP, skip emitting the node-specific
-		// one.
-		if nodeKey, ok := key.(model.NodeBGPPeerKey); ok {
-			globalKey := model.GlobalBGPPeerKey{PeerIP: nodeKey.PeerIP, Port: nodeKey.Port}
-			globalPath, _ := model.KeyToDefaultPath(globalKey)
-			if _, ok = peersV1[globalPath]; ok {
+		// If this is a node-specific peering and we already have the equivalent
+		// global peering, skip emitting the node-specific one.
+		if globalPath != k {
+			if _, ok := peersV1[globalPath]; ok {
 				log.Debug("Global peering already exists")
 				return
 			}
@@ -528,6 +524,28 @@ func (c *client) updatePeersV1() {
 		peersV1[k] = string(value)
 	}
 
+	// Common subroutine for emitting both global and node-specific peerings.
+	emit := func(key model.Key, peer *bgpPeer) {
+		log.WithFields(log.Fields{"key": key, "peer": peer}).Debug("Maybe emit peering")
+
+		// Compute etcd v1 path for this peering key.
+		k, err := model.KeyToDefaultPath(key)
+		if err != nil {
+			log.Errorf("Ignoring update: unable to create path from Key %v: %v", key, err)
+			return
+		}
+
+		// If we would be emitting a node-specific peering to a peer IP, and we
+		// already have a global peering to that IP, skip emitting the node-specific
+		// one.
+		globalPath := k
+		if nodeKey, ok := key.(model.NodeBGPPeerKey); ok {
+			globalKey := model.GlobalBGPPeerKey{PeerIP: nodeKey.PeerIP, Port: nodeKey.Port}
+			globalPath, _ = model.KeyToDefaultPath(globalKey)
+		}
+		emitPath(k, globalPath, peer)
+	}
+
 	// Loop through v3 BGPPeers twice, first to emit global peerings, then for
 	// node-specific ones.  The point here is to emit all of the possible global peerings
 	// _first_, so that we can then skip emitting any node-specific peerings that would
@@ -548,6 +566,24 @@ func (c *client) updatePeersV1() {
 			}
 			log.Debugf("Local nodes %#v", localNodeNames)
 
+			// Peerings over an interface or from a listen range are not to a specific
+			// peer IP, so they are stored under their own paths.
+			if v3res.Spec.PeerInterface != "" || v3res.Spec.PeerCIDR != "" {
+				peer, peerPath := c.dynamicBGPPeer(v3res)
+				if peer == nil {
+					continue
+				}
+				globalPath := "/calico/bgp/v1/global/" + peerPath
+				if globalPass {
+					emitPath(globalPath, globalPath, peer)
+				} else {
+					for _, localNodeName := range localNodeNames {
+						emitPath(fmt.Sprintf("/calico/bgp/v1/host/%s/%s", localNodeName, peerPath), globalPath, peer)
+					}
+				}
+				continue
+			}
+
 			var peers []*bgpPeer
 			if v3res.Spec.PeerSelector != "" {
 				for _, peerNodeName := range c.nodeLabelManager.nodesMatching(v3res.Spec.PeerSelector) {
@@ -646,6 +682,12 @@ func (c *client) updatePeersV1() {
 	for _, v3res := range c.bgpPeers {
 		log.WithField("peer", v3res).Debug("Second pass with v3 BGPPeer")
 
+		// Peerings over an interface or from a listen range have no specific remote
+		// node, so there is no reverse peering to add.
+		if v3res.Spec.PeerInterface != "" || v3res.Spec.PeerCIDR != "" {
+			continue
+		}
+
 		// This time, the "local" nodes are actually those matching the remote fields
 		// in BGPPeer, i.e. PeerIP, ASNumber and PeerSelector...
 		var localNodeNames []string
@@ -1107,6 +1149,7 @@ func (c *client) updateBGPConfigCache(resName string, v3res *apiv3.BGPConfigurat
 		c.getNodeToNodeMeshKVPair(v3res, model.GlobalBGPConfigKey{})
 		c.getLogSeverityKVPair(v3res, model.GlobalBGPConfigKey{})
 		c.getNodeMeshRestartTimeKVPair(v3res, model.GlobalBGPConfigKey{})
+		c.getNodeMeshGracefulRestartKVPairs(v3res, model.GlobalBGPConfigKey{})
 		c.getNodeMeshPasswordKVPair(v3res, model.GlobalBGPConfigKey{})
 		c.getIgnoredInterfacesKVPair(v3res, model.GlobalBGPConfigKey{})
 
@@ -1361,14 +1404,69 @@ func (c *client) getLogSeverityKVPair(v3res *apiv3.BGPConfiguration, key interfa
 func (c *client) getNodeMeshRestartTimeKVPair(v3res *apiv3.BGPConfiguration, key interface{}) {
 	meshRestartKey := getBGPConfigKey("node_mesh_restart_time", key)
 
-	if v3res != nil && v3res.Spec.NodeMeshMaxRestartTime != nil {
-		restartTime := *v3res.Spec.NodeMeshMaxRestartTime
-		c.updateCache(api.UpdateTypeKVUpdated, getKVPair(meshRestartKey, fmt.Sprintf("%v", int(math.Round(restartTime.Duration.Seconds())))))
+	if v3res != nil && v3res.Spec.NodeMeshGracefulRestart != nil && v3res.Spec.NodeMeshGracefulRestart.RestartTime != nil {
+		c.updateCache(api.UpdateTypeKVUpdated, getKVPair(meshRestartKey, durationSeconds(v3res.Spec.NodeMeshGracefulRestart.RestartTime)))
+	} else if v3res != nil && v3res.Spec.NodeMeshMaxRestartTime != nil {
+		c.updateCache(api.UpdateTypeKVUpdated, getKVPair(meshRestartKey, durationSeconds(v3res.Spec.NodeMeshMaxRestartTime)))
 	} else {
 		c.updateCache(api.UpdateTypeKVDeleted, getKVPair(meshRestartKey))
 	}
 }
 
+func (c *client) getNodeMeshGracefulRestartKVPairs(v3res *apiv3.BGPConfiguration, key interface{}) {
+	grKey := getBGPConfigKey("node_mesh_graceful_restart", key)
+	llgrKey := getBGPConfigKey("node_mesh_llgr", key)
+	staleTimeKey := getBGPConfigKey("node_mesh_llgr_stale_time", key)
+
+	var gr *apiv3.BGPGracefulRestart
+	if v3res != nil {
+		gr = v3res.Spec.NodeMeshGracefulRestart
+	}
+
+	if gr != nil {
+		c.updateCache(api.UpdateTypeKVUpdated, getKVPair(grKey, birdGracefulRestartMode(gracefulRestartModeOrDefault(gr.Mode))))
+	} else {
+		c.updateCache(api.UpdateTypeKVDeleted, getKVPair(grKey))
+	}
+	if gr != nil && gr.LongLivedMode != "" {
+		c.updateCache(api.UpdateTypeKVUpdated, getKVPair(llgrKey, birdGracefulRestartMode(gr.LongLivedMode)))
+	} else {
+		c.updateCache(api.UpdateTypeKVDeleted, getKVPair(llgrKey))
+	}
+	if gr != nil && gr.StaleRouteTime != nil {
+		c.updateCache(api.UpdateTypeKVUpdated, getKVPair(staleTimeKey, durationSeconds(gr.StaleRouteTime)))
+	} else {
+		c.updateCache(api.UpdateTypeKVDeleted, getKVPair(staleTimeKey))
+	}
+}
+
+// gracefulRestartModeOrDefault returns the given graceful restart mode, or the documented
+// default of Enabled if no mode is set.
+func gracefulRestartModeOrDefault(mode apiv3.GracefulRestartMode) apiv3.GracefulRestartMode {
+	if mode == "" {
+		return apiv3.GracefulRestartEnabled
+	}
+	return mode
+}
+
+// birdGracefulRestartMode returns the BIRD keyword for the given graceful restart mode.
+func birdGracefulRestartMode(mode apiv3.GracefulRestartMode) string {
+	switch mode {
+	case apiv3.GracefulRestartEnabled:
+		return "on"
+	case apiv3.GracefulRestartAware:
+		return "aware"
+	case apiv3.GracefulRestartDisabled:
+		return "off"
+	}
+	return ""
+}
+
+// durationSeconds returns the given duration as a whole number of seconds, as used in the BIRD config.
+func durationSeconds(d *metav1.Duration) string {
+	return fmt.Sprintf("%v", int(math.Round(d.Duration.Seconds())))
+}
+
 func (c *client) getNodeMeshPasswordKVPair(v3res *apiv3.BGPConfiguration, key interface{}) {
 	meshPasswordKey := getBGPConfigKey("node_mesh_password", key)
 
@@ -1849,6 +1947,39 @@ func (c *client) DeleteStaticRoutes(cidrs []string) {
 	c.onNewUpdates()
 }
 
+// dynamicBGPPeer returns the v1 peering for a BGPPeer that peers over an interface
+// (BGP unnumbered) or accepts sessions from a listen range, along with the path
+// (relative to the global or per-host prefix) under which it should be stored.
+// Returns nil if the BGPPeer is malformed.
+func (c *client) dynamicBGPPeer(v3res *apiv3.BGPPeer) (*bgpPeer, string) {
+	peer := &bgpPeer{
+		ASNum:       v3res.Spec.ASNumber,
+		KeepNextHop: v3res.Spec.KeepOriginalNextHop,
+		Filters:     v3res.Spec.Filters,
+		Name:        v3res.Name,
+	}
+	if v3res.Spec.NumAllowedLocalASNumbers != nil {
+		peer.NumAllowLocalAS = *v3res.Spec.NumAllowedLocalASNumbers
+	}
+	if v3res.Spec.TTLSecurity != nil {
+		peer.TTLSecurity = *v3res.Spec.TTLSecurity
+	}
+	c.setPeerConfigFieldsFromV3Resource([]*bgpPeer{peer}, v3res)
+
+	if v3res.Spec.PeerInterface != "" {
+		peer.Interface = v3res.Spec.PeerInterface
+		return peer, "peer_interface/" + v3res.Spec.PeerInterface
+	}
+
+	_, cidr, err := cnet.ParseCIDROrIP(v3res.Spec.PeerCIDR)
+	if err != nil {
+		log.WithError(err).Errorf("PeerCIDR of BGPPeer %v is malformed", v3res.Name)
+		return nil, ""
+	}
+	peer.CIDR = cidr.String()
+	return peer, fmt.Sprintf("peer_range_v%d/%s", cidr.Version(), strings.Replace(peer.CIDR, "/", "-", 1))
+}
+
 func (c *client) setPeerConfigFieldsFromV3Resource(peers []*bgpPeer, v3res *apiv3.BGPPeer) {
 	// Get the password, if one is configured
 	password := c.getPassword(v3res)
@@ -1857,7 +1988,17 @@ func (c *client) setPeerConfigFieldsFromV3Resource(peers []*bgpPeer, v3res *apiv
 		peer.Password = password
 		peer.SourceAddr = withDefault(string(v3res.Spec.SourceAddress), string(apiv3.SourceAddressUseNodeIP))
 		if v3res.Spec.MaxRestartTime != nil {
-			peer.RestartTime = fmt.Sprintf("%v", int(math.Round(v3res.Spec.MaxRestartTime.Duration.Seconds())))
+			peer.RestartTime = durationSeconds(v3res.Spec.MaxRestartTime)
+		}
+		if gr := v3res.Spec.GracefulRestart; gr != nil {
+			if gr.RestartTime != nil {
+				peer.RestartTime = durationSeconds(gr.RestartTime)
+			}
+			peer.GracefulRestart = birdGracefulRestartMode(gracefulRestartModeOrDefault(gr.Mode))
+			peer.LLGR = birdGracefulRestartMode(gr.LongLivedMode)
+			if gr.StaleRouteTime != nil {
+				peer.LLGRStaleTime = durationSeconds(gr.StaleRouteTime)
+			}
 		}
 	}
 }
@@ -5,6 +5,7 @@ import (
 	"net"
 	"strings"
 	"sync"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -722,4 +723,90 @@ var _ = Describe("Update BGP Config Cache", func() {
 		c.getIgnoredInterfacesKVPair(res, model.GlobalBGPConfigKey{})
 		Expect(c.cache["/calico/bgp/v1/global/ignored_interfaces"]).To(Equal("iface-1,iface-2"))
 	})
+
+	It("should default the node mesh graceful restart mode to Enabled", func() {
+		res := &apiv3.BGPConfiguration{
+			Spec: apiv3.BGPConfigurationSpec{
+				NodeMeshGracefulRestart: &apiv3.BGPGracefulRestart{
+					RestartTime: &metav1.Duration{Duration: 300 * time.Second},
+				},
+			},
+		}
+		c.getNodeMeshGracefulRestartKVPairs(res, model.GlobalBGPConfigKey{})
+		Expect(c.cache["/calico/bgp/v1/global/node_mesh_graceful_restart"]).To(Equal("on"))
+		Expect(c.cache).NotTo(HaveKey("/calico/bgp/v1/global/node_mesh_llgr"))
+
+		c.getNodeMeshGracefulRestartKVPairs(&apiv3.BGPConfiguration{}, model.GlobalBGPConfigKey{})
+		Expect(c.cache).NotTo(HaveKey("/calico/bgp/v1/global/node_mesh_graceful_restart"))
+	})
+})
+
+var _ = Describe("BGPPeers by interface and listen range", func() {
+	var c *client
+
+	BeforeEach(func() {
+		c = &client{
+			peeringCache:      make(map[string]string),
+			revisionsByPrefix: make(map[string]uint64),
+			nodeLabelManager:  newNodeLabelManager(),
+			bgpPeers:          make(map[string]*apiv3.BGPPeer),
+			nodeListenPorts:   make(map[string]uint16),
+			nodeIPs:           make(map[string]struct{}),
+		}
+	})
+
+	addPeer := func(name string, spec apiv3.BGPPeerSpec) {
+		c.bgpPeers[name] = &apiv3.BGPPeer{ObjectMeta: metav1.ObjectMeta{Name: name}, Spec: spec}
+	}
+
+	It("should emit a global interface peering", func() {
+		addPeer("unnumbered", apiv3.BGPPeerSpec{PeerInterface: "eth0", ASNumber: 65001})
+		c.updatePeersV1()
+
+		Expect(c.peeringCache).To(HaveLen(1))
+		Expect(c.peeringCache).To(HaveKey("/calico/bgp/v1/global/peer_interface/eth0"))
+		Expect(c.peeringCache["/calico/bgp/v1/global/peer_interface/eth0"]).To(ContainSubstring(`"interface":"eth0"`))
+		Expect(c.peeringCache["/calico/bgp/v1/global/peer_interface/eth0"]).To(ContainSubstring(`"as_num":"65001"`))
+		Expect(c.peeringCache["/calico/bgp/v1/global/peer_interface/eth0"]).To(ContainSubstring(`"name":"unnumbered"`))
+	})
+
+	It("should emit node-specific listen range peerings for each IP version", func() {
+		addPeer("range-v4", apiv3.BGPPeerSpec{Node: "node1", PeerCIDR: "10.1.0.0/24", ASNumber: 65002})
+		addPeer("range-v6", apiv3.BGPPeerSpec{Node: "node1", PeerCIDR: "fd00::/64", ASNumber: 65002})
+		c.updatePeersV1()
+
+		Expect(c.peeringCache).To(HaveLen(2))
+		Expect(c.peeringCache["/calico/bgp/v1/host/node1/peer_range_v4/10.1.0.0-24"]).To(ContainSubstring(`"cidr":"10.1.0.0/24"`))
+		Expect(c.peeringCache["/calico/bgp/v1/host/node1/peer_range_v6/fd00::-64"]).To(ContainSubstring(`"cidr":"fd00::/64"`))
+	})
+
+	It("should skip a node-specific peering that duplicates a global one", func() {
+		addPeer("global", apiv3.BGPPeerSpec{PeerCIDR: "10.1.0.0/24", ASNumber: 65002})
+		addPeer("node", apiv3.BGPPeerSpec{Node: "node1", PeerCIDR: "10.1.0.0/24", ASNumber: 65002})
+		c.updatePeersV1()
+
+		Expect(c.peeringCache).To(HaveLen(1))
+		Expect(c.peeringCache).To(HaveKey("/calico/bgp/v1/global/peer_range_v4/10.1.0.0-24"))
+	})
+
+	It("should default the peer graceful restart mode to Enabled", func() {
+		addPeer("unnumbered", apiv3.BGPPeerSpec{
+			PeerInterface:   "eth0",
+			ASNumber:        65001,
+			GracefulRestart: &apiv3.BGPGracefulRestart{},
+		})
+		c.updatePeersV1()
+
+		Expect(c.peeringCache["/calico/bgp/v1/global/peer_interface/eth0"]).To(ContainSubstring(`"graceful_restart":"on"`))
+	})
+
+	It("should remove the peering when the BGPPeer is deleted", func() {
+		addPeer("unnumbered", apiv3.BGPPeerSpec{PeerInterface: "eth0", ASNumber: 65001})
+		c.updatePeersV1()
+		Expect(c.peeringCache).To(HaveLen(1))
+
+		delete(c.bgpPeers, "unnumbered")
+		c.updatePeersV1()
+		Expect(c.peeringCache).To(BeEmpty())
+	})
 })
@@ -0,0 +1,112 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+const birdConfDir = "../../../etc/calico/confd"
+
+// Test_BIRDTemplateNodeIPChange checks that the BIRD configuration follows a change to the
+// nodes' BGP IPv4 addresses, as happens when calico/node re-detects a node's address: the
+// router ID, the mesh source address and the mesh neighbors all move to the new addresses.
+func Test_BIRDTemplateNodeIPChange(t *testing.T) {
+	oldNodeName := NodeName
+	NodeName = frrTestNodeName
+	defer func() { NodeName = oldNodeName }()
+	t.Setenv("NODENAME", frrTestNodeName)
+	t.Setenv("CALICO_ROUTER_ID", "")
+
+	kvs := loadTestKeys(t, "mesh")
+	storeClient := &frrTestStoreClient{kvs: kvs}
+
+	checkRendered := func(expected, unexpected []string) {
+		t.Helper()
+		rendered := renderTemplate(t, birdConfDir, "bird.toml", storeClient)
+		for _, s := range expected {
+			if !strings.Contains(rendered, s) {
+				t.Errorf("Rendered BIRD config does not contain %q:\n%s", s, rendered)
+			}
+		}
+		for _, s := range unexpected {
+			if strings.Contains(rendered, s) {
+				t.Errorf("Rendered BIRD config contains %q:\n%s", s, rendered)
+			}
+		}
+	}
+
+	checkRendered([]string{
+		"router id 10.192.0.2;",
+		"source address 10.192.0.2;",
+		"neighbor 10.192.0.3 as 64512;",
+	}, nil)
+
+	// Move both the local node and one of its mesh peers to new addresses.
+	kvs["/calico/bgp/v1/host/kube-master/ip_addr_v4"] = "10.192.1.2"
+	kvs["/calico/bgp/v1/host/kube-node-1/ip_addr_v4"] = "10.192.1.3"
+	checkRendered([]string{
+		"router id 10.192.1.2;",
+		"source address 10.192.1.2;",
+		"neighbor 10.192.1.3 as 64512;",
+		"neighbor 10.192.0.4 as 64513;",
+	}, []string{
+		"10.192.0.2",
+		"10.192.0.3",
+	})
+}
+
+// Test_BIRDTemplateSkippedPeers checks that the BIRD configuration names the BGPPeers that it
+// skips because BIRD does not support listen range or interface peers.  calico/node's BIRD
+// readiness check reports the skipped BGPPeers from these comments.
+func Test_BIRDTemplateSkippedPeers(t *testing.T) {
+	oldNodeName := NodeName
+	NodeName = frrTestNodeName
+	defer func() { NodeName = oldNodeName }()
+	t.Setenv("NODENAME", frrTestNodeName)
+	t.Setenv("CALICO_ROUTER_ID", "")
+
+	storeClient := &frrTestStoreClient{kvs: loadTestKeys(t, "dynamic_peers")}
+	for tomlName, expected := range map[string][]string{
+		"bird.toml": {
+			"# Skipped BGPPeer leaf-range: listen range 10.193.0.0/24\n",
+		},
+		"bird6.toml": {
+			"# Skipped BGPPeer leaf-range-v6: listen range fd5f:1802::/64\n",
+			"# Skipped BGPPeer unnumbered-eth1: interface eth1\n",
+		},
+	} {
+		rendered := renderTemplate(t, birdConfDir, tomlName, storeClient)
+		for _, s := range expected {
+			if !strings.Contains(rendered, s) {
+				t.Errorf("Rendered %s config does not contain %q:\n%s", tomlName, s, rendered)
+			}
+		}
+		// The interface peer configured for another node is not skipped by this node.
+		if strings.Contains(rendered, "unnumbered-eth2") {
+			t.Errorf("Rendered %s config contains another node's peer:\n%s", tomlName, rendered)
+		}
+	}
+}
+
+// loadTestKeys returns the confd key space of the given test case in tests/frr, with the
+// /calico prefix added.
+func loadTestKeys(t *testing.T, testCase string) map[string]string {
+	t.Helper()
+	data, err := os.ReadFile(filepath.Join(frrTestDataDir, testCase, "keys.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var keys map[string]string
+	if err := yaml.Unmarshal(data, &keys); err != nil {
+		t.Fatal(err)
+	}
+	kvs := map[string]string{}
+	for k, v := range keys {
+		kvs["/calico"+k] = v
+	}
+	return kvs
+}
@@ -0,0 +1,146 @@
+package template
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	frrConfDir      = "../../../etc/calico/confd-frr"
+	frrTestDataDir  = "../../../tests/frr"
+	frrTestNodeName = "kube-master"
+)
+
+// frrTestStoreClient is a StoreClient that serves a fixed set of keys.
+type frrTestStoreClient struct {
+	kvs map[string]string
+}
+
+func (c *frrTestStoreClient) SetPrefixes(keys []string) error {
+	return nil
+}
+
+func (c *frrTestStoreClient) GetValues(keys []string) (map[string]string, error) {
+	values := map[string]string{}
+	for k, v := range c.kvs {
+		for _, prefix := range keys {
+			if strings.HasPrefix(k, prefix) {
+				values[k] = v
+				break
+			}
+		}
+	}
+	return values, nil
+}
+
+func (c *frrTestStoreClient) WatchPrefix(prefix string, keys []string, waitIndex uint64, stopChan chan bool) (string, error) {
+	<-stopChan
+	return "", nil
+}
+
+func (c *frrTestStoreClient) GetCurrentRevision() uint64 {
+	return 0
+}
+
+// Test_FRRTemplate renders the FRR template for each of the test cases in tests/frr and compares the result with
+// the expected frr.conf in the same directory.  Each test case has a keys.yaml file containing the confd key space
+// (without the /calico prefix) as seen by node kube-master, and either the expected frr.conf or an error.txt
+// file with the expected rendering error.  Set UPDATE_EXPECTED_DATA=true to update the expected results.
+func Test_FRRTemplate(t *testing.T) {
+	oldNodeName := NodeName
+	NodeName = frrTestNodeName
+	defer func() { NodeName = oldNodeName }()
+	t.Setenv("NODENAME", frrTestNodeName)
+	t.Setenv("CALICO_ROUTER_ID", "")
+
+	testCases, err := filepath.Glob(filepath.Join(frrTestDataDir, "*", "keys.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(testCases) == 0 {
+		t.Fatal("No FRR test cases found")
+	}
+
+	for _, keysFile := range testCases {
+		testDir := filepath.Dir(keysFile)
+		t.Run(filepath.Base(testDir), func(t *testing.T) {
+			data, err := os.ReadFile(keysFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var keys map[string]string
+			if err := yaml.Unmarshal(data, &keys); err != nil {
+				t.Fatalf("Failed to parse %s: %s", keysFile, err)
+			}
+			kvs := map[string]string{}
+			for k, v := range keys {
+				kvs["/calico"+k] = v
+			}
+
+			errorFile := filepath.Join(testDir, "error.txt")
+			if expectedErr, err := os.ReadFile(errorFile); err == nil {
+				_, err := tryRenderTemplate(t, frrConfDir, "frr.toml", &frrTestStoreClient{kvs: kvs})
+				if err == nil || !strings.Contains(err.Error(), strings.TrimSpace(string(expectedErr))) {
+					t.Errorf("Expected rendering to fail with %q (from %s), got: %v", strings.TrimSpace(string(expectedErr)), errorFile, err)
+				}
+				return
+			}
+
+			rendered := renderTemplate(t, frrConfDir, "frr.toml", &frrTestStoreClient{kvs: kvs})
+
+			expectedFile := filepath.Join(testDir, "frr.conf")
+			if os.Getenv("UPDATE_EXPECTED_DATA") == "true" {
+				if err := os.WriteFile(expectedFile, []byte(rendered), 0644); err != nil {
+					t.Fatal(err)
+				}
+				return
+			}
+			expected, err := os.ReadFile(expectedFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if rendered != string(expected) {
+				t.Errorf("Rendered FRR config differs from %s:\n Generated =\n%s\n Expected =\n%s",
+					expectedFile, rendered, expected)
+			}
+		})
+	}
+}
+
+// renderTemplate renders the template resource in the given confd directory (for example,
+// "frr.toml" in frrConfDir) using the keys from storeClient.
+func renderTemplate(t *testing.T, confDir, tomlName string, storeClient *frrTestStoreClient) string {
+	rendered, err := tryRenderTemplate(t, confDir, tomlName, storeClient)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rendered
+}
+
+// tryRenderTemplate is like renderTemplate, but returns the error if the template fails to render.
+func tryRenderTemplate(t *testing.T, confDir, tomlName string, storeClient *frrTestStoreClient) (string, error) {
+	tr, err := NewTemplateResource(filepath.Join(confDir, "conf.d", tomlName), Config{
+		StoreClient: storeClient,
+		TemplateDir: filepath.Join(confDir, "templates"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr.Dest = filepath.Join(t.TempDir(), filepath.Base(tr.Dest))
+	if err := tr.setVars(); err != nil {
+		t.Fatal(err)
+	}
+	if err := tr.createStageFile(); err != nil {
+		return "", err
+	}
+	defer os.Remove(tr.StageFile.Name())
+	rendered, err := os.ReadFile(tr.StageFile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(rendered), nil
+}
@@ -16,6 +16,7 @@ import (
 
 	"github.com/kelseyhightower/memkv"
 	v3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	log "github.com/sirupsen/logrus"
 )
 
 func newFuncMap() map[string]interface{} {
@@ -42,6 +43,9 @@ func newFuncMap() map[string]interface{} {
 	m["hashToIPv4"] = hashToIPv4
 	m["bgpFilterFunctionName"] = BGPFilterFunctionName
 	m["bgpFilterBIRDFuncs"] = BGPFilterBIRDFuncs
+	m["bgpFilterFRRRouteMap"] = BGPFilterFRRRouteMap
+	m["fail"] = RenderError
+	m["logWarning"] = LogWarning
 	return m
 }
 
@@ -389,6 +393,248 @@ func BGPFilterBIRDFuncs(pairs memkv.KVPairs, version int) ([]string, error) {
 	return lines, nil
 }
 
+// frrRouteMapTailSeq is the sequence number of the final entry of the route-maps generated by
+// BGPFilterFRRRouteMap.  It applies the default behaviour to routes that aren't matched by any
+// BGPFilter rule.
+const frrRouteMapTailSeq = 65535
+
+// BGPFilterFRRRouteMap generates an FRR route-map, along with the prefix-lists that it uses, that applies the
+// given BGPFilters to the routes imported from, or exported to, a BGP peer.  It is the FRR equivalent of calling
+// the functions generated by BGPFilterBIRDFuncs from a BIRD import or export filter.
+//
+// The rules of all the filters are flattened into a single route-map, in order, so that the first matching rule
+// accepts or rejects the route, just like BIRD.  Routes that aren't matched by any rule are accepted on import.
+// On export, they are passed to the calico-export-v4 (or v6) route-map, which applies the same rules as the
+// calico_export_to_bgp_peers BIRD function.
+//
+// e.g. for the BGPFilter in the BGPFilterBIRDFuncs example, routeMapName "Node_10_0_0_1_import" and direction
+// "import", the output is:
+//
+//	[]string{
+//	  "! v4 BGPFilter test-bgpfilter",
+//	  "ip prefix-list Node_10_0_0_1_import_10 seq 10 permit 44.0.0.0/16 le 32",
+//	  "route-map Node_10_0_0_1_import permit 10",
+//	  " match ip address prefix-list Node_10_0_0_1_import_10",
+//	  "ip prefix-list Node_10_0_0_1_import_20 seq 10 permit 44.1.0.0/16 le 32",
+//	  "route-map Node_10_0_0_1_import deny 20",
+//	  " match ip address prefix-list Node_10_0_0_1_import_20",
+//	  "route-map Node_10_0_0_1_import permit 65535",
+//	}
+func BGPFilterFRRRouteMap(routeMapName, direction string, version int, filterNames interface{}, pairs memkv.KVPairs) ([]string, error) {
+	if version != 4 && version != 6 {
+		return []string{}, fmt.Errorf("version must be either 4 or 6")
+	}
+	normalizedDirection := strings.ToLower(direction)
+	if normalizedDirection != "import" && normalizedDirection != "export" {
+		return []string{}, fmt.Errorf("provided direction '%s' does not map to either 'import' or 'export'", direction)
+	}
+
+	// The filter names come from the peer's JSON, where they may be null.
+	names, _ := filterNames.([]interface{})
+	filtersByName := map[string]string{}
+	for _, kvp := range pairs {
+		filtersByName[path.Base(kvp.Key)] = kvp.Value
+	}
+
+	lines := []string{}
+	seq := 0
+	for _, n := range names {
+		filterName := fmt.Sprint(n)
+		value, ok := filtersByName[filterName]
+		if !ok {
+			// Same as BIRD: filters that don't exist (yet) are ignored.
+			continue
+		}
+		var filter v3.BGPFilter
+		err := json.Unmarshal([]byte(value), &filter)
+		if err != nil {
+			return []string{}, fmt.Errorf("error unmarshalling JSON: %s", err)
+		}
+
+		var ruleFields []filterArgs
+		switch {
+		case version == 4 && normalizedDirection == "import":
+			for _, r := range filter.Spec.ImportV4 {
+				ruleFields = append(ruleFields, filterArgsV4(r))
+			}
+		case version == 4:
+			for _, r := range filter.Spec.ExportV4 {
+				ruleFields = append(ruleFields, filterArgsV4(r))
+			}
+		case normalizedDirection == "import":
+			for _, r := range filter.Spec.ImportV6 {
+				ruleFields = append(ruleFields, filterArgsV6(r))
+			}
+		default:
+			for _, r := range filter.Spec.ExportV6 {
+				ruleFields = append(ruleFields, filterArgsV6(r))
+			}
+		}
+		if len(ruleFields) == 0 {
+			continue
+		}
+
+		lines = append(lines, fmt.Sprintf("! v%d BGPFilter %s", version, filterName))
+		for _, fields := range ruleFields {
+			seq += 10
+			if seq >= frrRouteMapTailSeq {
+				return []string{}, fmt.Errorf("too many BGPFilter rules for route-map %s", routeMapName)
+			}
+			ruleLines, err := frrRouteMapEntry(routeMapName, seq, version, fields)
+			if err != nil {
+				return []string{}, err
+			}
+			lines = append(lines, ruleLines...)
+		}
+	}
+
+	lines = append(lines, fmt.Sprintf("route-map %s permit %d", routeMapName, frrRouteMapTailSeq))
+	if normalizedDirection == "export" {
+		lines = append(lines, fmt.Sprintf(" call calico-export-v%d", version))
+	}
+	return lines, nil
+}
+
+func filterArgsV4(r v3.BGPFilterRuleV4) filterArgs {
+	return filterArgs{
+		operator:       r.MatchOperator,
+		cidr:           r.CIDR,
+		prefixLengthV4: r.PrefixLength,
+		source:         r.Source,
+		iface:          r.Interface,
+		action:         r.Action,
+	}
+}
+
+func filterArgsV6(r v3.BGPFilterRuleV6) filterArgs {
+	return filterArgs{
+		operator:       r.MatchOperator,
+		cidr:           r.CIDR,
+		prefixLengthV6: r.PrefixLength,
+		source:         r.Source,
+		iface:          r.Interface,
+		action:         r.Action,
+	}
+}
+
+// frrRouteMapEntry produces the route-map entry, and the prefix-list that it uses, for a single BGPFilter rule.
+// Since route-maps can't negate a match, the NotIn and NotEqual operators are implemented by a prefix-list that
+// denies the CIDR and permits everything else.  Similarly, the RemotePeers source is implemented by an extra
+// entry, just before the rule, that skips over the rule for locally originated routes.
+func frrRouteMapEntry(routeMapName string, seq, version int, fields filterArgs) ([]string, error) {
+	var action string
+	switch fields.action {
+	case v3.Accept:
+		action = "permit"
+	case v3.Reject:
+		action = "deny"
+	default:
+		return nil, fmt.Errorf("unexpected action found in BGPFilter: %s", fields.action)
+	}
+
+	var lines, matches []string
+	if fields.cidr != "" {
+		if fields.operator == "" {
+			return nil, fmt.Errorf("operator not included in BGPFilter")
+		}
+		prefixListName := fmt.Sprintf("%s_%d", routeMapName, seq)
+		prefixListLines, err := frrPrefixList(prefixListName, version, fields)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, prefixListLines...)
+		if version == 4 {
+			matches = append(matches, fmt.Sprintf(" match ip address prefix-list %s", prefixListName))
+		} else {
+			matches = append(matches, fmt.Sprintf(" match ipv6 address prefix-list %s", prefixListName))
+		}
+	}
+
+	if fields.source != "" {
+		if fields.source != v3.BGPFilterSourceRemotePeers {
+			return nil, fmt.Errorf("unexpected source found in BGPFilter: %s", fields.source)
+		}
+		lines = append(lines,
+			fmt.Sprintf("route-map %s permit %d", routeMapName, seq-5),
+			" match peer local",
+			fmt.Sprintf(" on-match goto %d", seq+5),
+		)
+	}
+
+	if fields.iface != "" {
+		if strings.Contains(fields.iface, "*") {
+			return nil, fmt.Errorf("interface wildcards are not supported by FRR: %s", fields.iface)
+		}
+		matches = append(matches, fmt.Sprintf(" match interface %s", fields.iface))
+	}
+
+	lines = append(lines, fmt.Sprintf("route-map %s %s %d", routeMapName, action, seq))
+	lines = append(lines, matches...)
+	return lines, nil
+}
+
+// frrPrefixList produces a prefix-list that matches the CIDR of a BGPFilter rule.
+func frrPrefixList(name string, version int, fields filterArgs) ([]string, error) {
+	_, cidrNet, err := net.ParseCIDR(fields.cidr)
+	if err != nil {
+		return nil, fmt.Errorf("unexpected error when parsing cidr %s: %s", fields.cidr, err)
+	}
+	mask, bits := cidrNet.Mask.Size()
+	minLength, maxLength := int32(mask), int32(bits)
+	prefixListCmd := "ip prefix-list"
+	anyPrefix := "0.0.0.0/0"
+	if version == 6 {
+		prefixListCmd = "ipv6 prefix-list"
+		anyPrefix = "::/0"
+	}
+
+	var prefixMin, prefixMax *int32
+	if fields.prefixLengthV4 != nil {
+		prefixMin, prefixMax = fields.prefixLengthV4.Min, fields.prefixLengthV4.Max
+	} else if fields.prefixLengthV6 != nil {
+		prefixMin, prefixMax = fields.prefixLengthV6.Min, fields.prefixLengthV6.Max
+	}
+	if prefixMin != nil {
+		minLength = max(minLength, *prefixMin)
+	}
+	if prefixMax != nil {
+		maxLength = min(maxLength, *prefixMax)
+	}
+
+	var action string
+	var exact bool
+	switch fields.operator {
+	case v3.Equal:
+		action, exact = "permit", true
+	case v3.NotEqual:
+		action, exact = "deny", true
+	case v3.In:
+		action = "permit"
+	case v3.NotIn:
+		action = "deny"
+	default:
+		return nil, fmt.Errorf("unexpected operator found in BGPFilter: %s", fields.operator)
+	}
+	if exact && fields.prefixLengthV4 == nil && fields.prefixLengthV6 == nil {
+		// Same as BIRD, where "=" and "!=" only compare the whole prefix if the rule has no prefix lengths.
+		minLength, maxLength = int32(mask), int32(mask)
+	}
+
+	match := cidrNet.String()
+	if minLength > int32(mask) {
+		match += fmt.Sprintf(" ge %d", minLength)
+	}
+	if maxLength > minLength || (maxLength == minLength && minLength > int32(mask)) {
+		match += fmt.Sprintf(" le %d", maxLength)
+	}
+
+	lines := []string{fmt.Sprintf("%s %s seq 10 %s %s", prefixListCmd, name, action, match)}
+	if action == "deny" {
+		lines = append(lines, fmt.Sprintf("%s %s seq 20 permit %s le %d", prefixListCmd, name, anyPrefix, bits))
+	}
+	return lines, nil
+}
+
 // The maximum length of a k8s resource (253 bytes) is longer than the maximum length of BIRD symbols (64 chars).
 // This function provides a way to map the k8s resource name to a BIRD symbol name that accounts
 // for the length difference in a way that minimizes the chance of collisions
@@ -522,6 +768,18 @@ func LookupSRV(service, proto, name string) []*net.SRV {
 	return addrs
 }
 
+// RenderError returns an error with the given message, which stops the template from being rendered.
+func RenderError(msg string) (string, error) {
+	return "", errors.New(msg)
+}
+
+// LogWarning logs the given message as a warning and returns an empty string, so that a template
+// can report configuration that it cannot render.
+func LogWarning(msg string) string {
+	log.Warning(msg)
+	return ""
+}
+
 func Base64Encode(data string) string {
 	return base64.StdEncoding.EncodeToString([]byte(data))
 }
@@ -185,6 +185,103 @@ func Test_BGPFilterBIRDFuncs(t *testing.T) {
 	}
 }
 
+func Test_BGPFilterFRRRouteMap(t *testing.T) {
+	testFilter := v3.BGPFilter{}
+	testFilter.ObjectMeta.Name = "test-bgpfilter"
+	testFilter.Spec = v3.BGPFilterSpec{
+		ImportV4: []v3.BGPFilterRuleV4{
+			{Action: "Accept", MatchOperator: "In", CIDR: "44.0.0.0/16"},
+			{Action: "Reject", MatchOperator: "NotIn", CIDR: "55.4.0.0/16", PrefixLength: &v3.BGPFilterPrefixLengthV4{Min: int32Helper(16), Max: int32Helper(24)}},
+			{Action: "Reject", MatchOperator: "Equal", CIDR: "44.4.0.0/16"},
+			{Action: "Accept", Source: "RemotePeers", Interface: "eth0"},
+		},
+		ExportV6: []v3.BGPFilterRuleV6{
+			{Action: "Accept", MatchOperator: "NotEqual", CIDR: "7000:1::/64", PrefixLength: &v3.BGPFilterPrefixLengthV6{Max: int32Helper(96)}},
+			{Action: "Reject"},
+		},
+	}
+	expectedImportV4 := []string{
+		"! v4 BGPFilter test-bgpfilter",
+		"ip prefix-list peer_import_10 seq 10 permit 44.0.0.0/16 le 32",
+		"route-map peer_import permit 10",
+		" match ip address prefix-list peer_import_10",
+		"ip prefix-list peer_import_20 seq 10 deny 55.4.0.0/16 le 24",
+		"ip prefix-list peer_import_20 seq 20 permit 0.0.0.0/0 le 32",
+		"route-map peer_import deny 20",
+		" match ip address prefix-list peer_import_20",
+		"ip prefix-list peer_import_30 seq 10 permit 44.4.0.0/16",
+		"route-map peer_import deny 30",
+		" match ip address prefix-list peer_import_30",
+		"route-map peer_import permit 35",
+		" match peer local",
+		" on-match goto 45",
+		"route-map peer_import permit 40",
+		" match interface eth0",
+		"route-map peer_import permit 65535",
+	}
+	expectedExportV6 := []string{
+		"! v6 BGPFilter test-bgpfilter",
+		"ipv6 prefix-list peer_export_10 seq 10 deny 7000:1::/64 le 96",
+		"ipv6 prefix-list peer_export_10 seq 20 permit ::/0 le 128",
+		"route-map peer_export permit 10",
+		" match ipv6 address prefix-list peer_export_10",
+		"route-map peer_export deny 20",
+		"route-map peer_export permit 65535",
+		" call calico-export-v6",
+	}
+	expectedNoFilters := []string{
+		"route-map peer_export permit 65535",
+		" call calico-export-v4",
+	}
+
+	jsonFilter, err := json.Marshal(testFilter)
+	if err != nil {
+		t.Errorf("Error formatting BGPFilter into JSON: %s", err)
+	}
+	kvps := []memkv.KVPair{
+		{Key: "/resources/v3/projectcalico.org/bgpfilters/test-bgpfilter", Value: string(jsonFilter)},
+	}
+	filterNames := []interface{}{"test-bgpfilter", "missing-bgpfilter"}
+
+	result, err := BGPFilterFRRRouteMap("peer_import", "import", 4, filterNames, kvps)
+	if err != nil {
+		t.Errorf("Unexpected error while generating v4 FRR import route-map: %s", err)
+	}
+	if !reflect.DeepEqual(result, expectedImportV4) {
+		t.Errorf("Generated v4 FRR import route-map differs from expectation:\n Generated = %s,\n Expected = %s",
+			result, expectedImportV4)
+	}
+
+	result, err = BGPFilterFRRRouteMap("peer_export", "export", 6, filterNames, kvps)
+	if err != nil {
+		t.Errorf("Unexpected error while generating v6 FRR export route-map: %s", err)
+	}
+	if !reflect.DeepEqual(result, expectedExportV6) {
+		t.Errorf("Generated v6 FRR export route-map differs from expectation:\n Generated = %s,\n Expected = %s",
+			result, expectedExportV6)
+	}
+
+	// The peer's filters are null in its JSON if it has none.
+	result, err = BGPFilterFRRRouteMap("peer_export", "export", 4, nil, kvps)
+	if err != nil {
+		t.Errorf("Unexpected error while generating v4 FRR export route-map: %s", err)
+	}
+	if !reflect.DeepEqual(result, expectedNoFilters) {
+		t.Errorf("Generated v4 FRR export route-map differs from expectation:\n Generated = %s,\n Expected = %s",
+			result, expectedNoFilters)
+	}
+
+	testFilter.Spec.ImportV4 = []v3.BGPFilterRuleV4{{Action: "Accept", Interface: "eth*"}}
+	jsonFilter, err = json.Marshal(testFilter)
+	if err != nil {
+		t.Errorf("Error formatting BGPFilter into JSON: %s", err)
+	}
+	kvps[0].Value = string(jsonFilter)
+	if _, err = BGPFilterFRRRouteMap("peer_import", "import", 4, filterNames, kvps); err == nil {
+		t.Errorf("Expected an error for an interface wildcard")
+	}
+}
+
 func Test_ValidateHashToIpv4Method(t *testing.T) {
 	expectedRouterId := "207.94.5.27"
 	nodeName := "Testrobin123"
@@ -591,6 +591,7 @@ execute_tests_daemon() {
         run_individual_test 'explicit_peering/global'
         run_individual_test 'explicit_peering/global-external'
         run_individual_test 'explicit_peering/global-ipv6'
+        run_individual_test 'explicit_peering/dynamic_peers'
         run_individual_test 'explicit_peering/specific_node'
         run_individual_test 'explicit_peering/selectors'
         run_individual_test 'explicit_peering/route_reflector'
@@ -939,6 +940,27 @@ EOF
     # Expect "graceful restart time 10".
     test_confd_templates sourceaddr_gracefulrestart/step3
 
+    # Change the peering to configure graceful restart and long-lived graceful restart.
+    $CALICOCTL apply -f - <<EOF
+kind: BGPPeer
+apiVersion: projectcalico.org/v3
+metadata:
+  name: bgppeer-1
+spec:
+  node: node1
+  peerIP: 172.17.0.6
+  asNumber: 64512
+  sourceAddress: None
+  gracefulRestart:
+    mode: Aware
+    restartTime: 20s
+    longLivedMode: Enabled
+    staleRouteTime: 1h
+EOF
+
+    # Expect "graceful restart aware", "graceful restart time 20" and the long-lived settings.
+    test_confd_templates sourceaddr_gracefulrestart/step4
+
     # Kill confd.
     kill -9 $CONFD_PID
 
@@ -85,6 +85,10 @@ type AsyncCalcGraph struct {
 	dirty            bool
 
 	debugHangC <-chan time.Time
+
+	// debugRequests carries requests from the debug HTTP API, which are served from the
+	// calculation graph's goroutine so that they see a consistent view of its state.
+	debugRequests chan debugRequest
 }
 
 const (
@@ -104,6 +108,7 @@ func NewAsyncCalcGraph(
 		outputChannels:   outputChannels,
 		eventSequencer:   eventSequencer,
 		healthAggregator: healthAggregator,
+		debugRequests:    make(chan debugRequest),
 	}
 	g.CalcGraph = NewCalculationGraph(eventSequencer, conf, g.reportHealth)
 	if conf.DebugSimulateCalcGraphHangAfter != 0 {
@@ -183,6 +188,8 @@ func (acg *AsyncCalcGraph) loop() {
 			}
 		case <-acg.healthTicks:
 			acg.reportHealth()
+		case req := <-acg.debugRequests:
+			req.resultC <- acg.CalcGraph.DescribeEndpoints(req.query)
 		case <-acg.debugHangC:
 			log.Warning("Debug hang simulation timer popped, hanging the calculation graph!!")
 			time.Sleep(1 * time.Hour)
@@ -350,3 +350,58 @@ var _ = Describe("specific scenario tests", func() {
 		Expect(mockDataplane.NumEventsRecorded()).To(Equal(numEventsBeforeSendingDupe))
 	})
 })
+
+var _ = Describe("calculation graph debug API", func() {
+	var validationFilter *ValidationFilter
+	var calcGraph *CalcGraph
+
+	BeforeEach(func() {
+		mockDataplane := mock.NewMockDataplane()
+		eventBuf := NewEventSequencer(mockDataplane)
+		eventBuf.Callback = mockDataplane.OnEvent
+		conf := config.New()
+		conf.FelixHostname = localHostname
+		calcGraph = NewCalculationGraph(eventBuf, conf, func() {})
+		validationFilter = NewValidationFilter(calcGraph.AllUpdDispatcher, conf)
+
+		validationFilter.OnUpdates(localEp1WithPolicy.KVDeltas(empty))
+		validationFilter.OnStatusUpdated(api.InSync)
+		calcGraph.Flush()
+	})
+
+	It("should describe a local workload endpoint", func() {
+		infos := calcGraph.DescribeEndpoints(EndpointQuery{Workload: "wl1"})
+		Expect(infos).To(HaveLen(1))
+		info := infos[0]
+		Expect(info.Key).To(Equal(localWlEpKey1.String()))
+		Expect(info.Labels).To(HaveKeyWithValue("id", "loc-ep-1"))
+		Expect(info.Profiles).To(Equal([]string{"prof-1", "prof-2", "prof-missing"}))
+		Expect(info.Tiers).To(Equal([]TierDebugInfo{{
+			Name:          "default",
+			Order:         &order20,
+			Policies: []PolicyDebugInfo{{
+				Name:     "pol-1",
+				Order:    &order20,
+				Selector: "a == 'a'",
+				Types:    []string{"ingress", "egress"},
+			}},
+		}}))
+
+		ipSets := map[string]IPSetDebugInfo{}
+		for _, s := range info.IPSets {
+			ipSets[s.ID] = s
+		}
+		Expect(ipSets).To(HaveKey(allSelectorId))
+		Expect(ipSets[allSelectorId].Selector).To(Equal(allSelector))
+		Expect(ipSets[allSelectorId].UsedBy).To(Equal([]string{"policy pol-1"}))
+		Expect(ipSets[allSelectorId].Members).To(ConsistOf(
+			"10.0.0.1/32", "10.0.0.2/32", "fc00:fe11::1/128", "fc00:fe11::2/128"))
+		Expect(ipSets).To(HaveKey(bEqBSelectorId))
+	})
+
+	It("should only return matching endpoints", func() {
+		Expect(calcGraph.DescribeEndpoints(EndpointQuery{})).To(HaveLen(1))
+		Expect(calcGraph.DescribeEndpoints(EndpointQuery{Workload: "wl2"})).To(BeEmpty())
+		Expect(calcGraph.DescribeEndpoints(EndpointQuery{HostEndpoint: "wl1"})).To(BeEmpty())
+	})
+})
@@ -0,0 +1,242 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package calc
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+)
+
+// DebugEndpointsPath is the path, on the debug port, of the HTTP API that returns the
+// calculation graph's view of the local endpoints.  It accepts the query parameters
+// "workload" (the namespace/name of a pod) and "hostEndpoint" (the name of a host endpoint);
+// with neither, it returns all local endpoints.
+const DebugEndpointsPath = "/debug/calc-graph/endpoints"
+
+const debugRequestTimeout = 10 * time.Second
+
+// EndpointQuery selects the local endpoints to describe.  An empty query selects all of them.
+type EndpointQuery struct {
+	Workload     string
+	HostEndpoint string
+}
+
+func (q EndpointQuery) matches(key model.Key) bool {
+	switch key := key.(type) {
+	case model.WorkloadEndpointKey:
+		return q.HostEndpoint == "" && (q.Workload == "" || q.Workload == key.WorkloadID)
+	case model.HostEndpointKey:
+		return q.Workload == "" && (q.HostEndpoint == "" || q.HostEndpoint == key.EndpointID)
+	}
+	return false
+}
+
+// EndpointDebugInfo is the calculation graph's view of a local endpoint.
+type EndpointDebugInfo struct {
+	Key      string            `json:"key"`
+	Labels   map[string]string `json:"labels"`
+	Profiles []string          `json:"profiles"`
+	Tiers    []TierDebugInfo   `json:"tiers"`
+	IPSets   []IPSetDebugInfo  `json:"ipSets"`
+}
+
+// TierDebugInfo is a tier containing the policies that apply to an endpoint, in the order
+// that they are applied.
+type TierDebugInfo struct {
+	Name          string            `json:"name"`
+	Order         *float64          `json:"order,omitempty"`
+	DefaultAction string            `json:"defaultAction"`
+	Policies      []PolicyDebugInfo `json:"policies"`
+}
+
+type PolicyDebugInfo struct {
+	Name     string   `json:"name"`
+	Order    *float64 `json:"order,omitempty"`
+	Selector string   `json:"selector"`
+	Types    []string `json:"types,omitempty"`
+}
+
+// IPSetDebugInfo is an IP set that is referenced by the policies or profiles of an endpoint.
+// Members is only populated for selector and named port IP sets.
+type IPSetDebugInfo struct {
+	ID        string   `json:"id"`
+	Selector  string   `json:"selector,omitempty"`
+	NamedPort string   `json:"namedPort,omitempty"`
+	Service   string   `json:"service,omitempty"`
+	UsedBy    []string `json:"usedBy"`
+	Members   []string `json:"members,omitempty"`
+}
+
+// DescribeEndpoints returns the calculation graph's view of the local endpoints that match the
+// query, sorted by key.  It reads the calculation graph's internal state so it must be called
+// from the goroutine that owns the calculation graph.
+func (cg *CalcGraph) DescribeEndpoints(q EndpointQuery) []EndpointDebugInfo {
+	var keys []model.Key
+	for key := range cg.policyResolver.endpoints {
+		if q.matches(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].String() < keys[j].String()
+	})
+
+	infos := []EndpointDebugInfo{}
+	for _, key := range keys {
+		infos = append(infos, cg.describeEndpoint(key))
+	}
+	return infos
+}
+
+func (cg *CalcGraph) describeEndpoint(key model.Key) EndpointDebugInfo {
+	info := EndpointDebugInfo{
+		Key:      key.String(),
+		Profiles: []string{},
+		Tiers:    []TierDebugInfo{},
+		IPSets:   []IPSetDebugInfo{},
+	}
+	info.Labels, _ = cg.ipsetMemberIndex.EndpointLabels(key)
+
+	// The IDs of the policies and profiles that apply to the endpoint, as used by the rule
+	// scanner, along with their names.
+	var rulesIDs []any
+	rulesNames := map[any]string{}
+
+	for _, tier := range cg.policyResolver.tiersForEndpoint(key) {
+		tierInfo := TierDebugInfo{
+			Name:          tier.Name,
+			Order:         tier.Order,
+			DefaultAction: string(tier.DefaultAction),
+			Policies:      []PolicyDebugInfo{},
+		}
+		for _, pol := range tier.OrderedPolicies {
+			tierInfo.Policies = append(tierInfo.Policies, PolicyDebugInfo{
+				Name:     pol.Key.Name,
+				Order:    pol.Value.Order,
+				Selector: pol.Value.Selector,
+				Types:    pol.Value.Types,
+			})
+			rulesIDs = append(rulesIDs, pol.Key)
+			rulesNames[pol.Key] = "policy " + pol.Key.Name
+		}
+		info.Tiers = append(info.Tiers, tierInfo)
+	}
+
+	for _, profileID := range cg.activeRulesCalculator.endpointKeyToProfileIDs.endpointKeyToProfileIDs[key] {
+		info.Profiles = append(info.Profiles, profileID)
+		rulesID := model.ProfileRulesKey{ProfileKey: model.ProfileKey{Name: profileID}}
+		rulesIDs = append(rulesIDs, rulesID)
+		rulesNames[rulesID] = "profile " + profileID
+	}
+
+	ipSets := map[string]*IPSetDebugInfo{}
+	var ipSetIDs []string
+	for _, rulesID := range rulesIDs {
+		cg.ruleScanner.rulesIDToUIDs.Iter(rulesID, func(uid string) {
+			ipSet, ok := ipSets[uid]
+			if !ok {
+				ipSet = cg.describeIPSet(uid)
+				ipSets[uid] = ipSet
+				ipSetIDs = append(ipSetIDs, uid)
+			}
+			ipSet.UsedBy = append(ipSet.UsedBy, rulesNames[rulesID])
+		})
+	}
+	sort.Strings(ipSetIDs)
+	for _, id := range ipSetIDs {
+		info.IPSets = append(info.IPSets, *ipSets[id])
+	}
+	return info
+}
+
+func (cg *CalcGraph) describeIPSet(uid string) *IPSetDebugInfo {
+	info := &IPSetDebugInfo{ID: uid}
+	if data := cg.ruleScanner.ipSetsByUID[uid]; data != nil {
+		if data.Selector != nil {
+			info.Selector = data.Selector.String()
+		}
+		info.NamedPort = data.NamedPort
+		info.Service = data.Service
+	}
+	if members, ok := cg.ipsetMemberIndex.IPSetMembers(uid); ok {
+		for _, m := range members {
+			info.Members = append(info.Members, memberToProto(m))
+		}
+		sort.Strings(info.Members)
+	}
+	return info
+}
+
+type debugRequest struct {
+	query   EndpointQuery
+	resultC chan []EndpointDebugInfo
+}
+
+// DescribeEndpoints asks the calculation graph's goroutine for its view of the local endpoints
+// that match the query.
+func (acg *AsyncCalcGraph) DescribeEndpoints(ctx context.Context, q EndpointQuery) ([]EndpointDebugInfo, error) {
+	req := debugRequest{query: q, resultC: make(chan []EndpointDebugInfo, 1)}
+	select {
+	case acg.debugRequests <- req:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	select {
+	case infos := <-req.resultC:
+		return infos, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// DebugHandler returns the handler for DebugEndpointsPath.
+func (acg *AsyncCalcGraph) DebugHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		q := EndpointQuery{
+			Workload:     r.URL.Query().Get("workload"),
+			HostEndpoint: r.URL.Query().Get("hostEndpoint"),
+		}
+		if q.Workload != "" && q.HostEndpoint != "" {
+			http.Error(w, "only one of workload and hostEndpoint may be specified", http.StatusBadRequest)
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), debugRequestTimeout)
+		defer cancel()
+		infos, err := acg.DescribeEndpoints(ctx, q)
+		if err != nil {
+			http.Error(w, "timed out waiting for the calculation graph: "+err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		if len(infos) == 0 && q != (EndpointQuery{}) {
+			http.Error(w, "no matching local endpoint", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(infos); err != nil {
+			log.WithError(err).Warn("Failed to write calculation graph debug response")
+		}
+	})
+}
@@ -25,7 +25,11 @@ import (
 	"github.com/projectcalico/calico/felix/calc"
 	"github.com/projectcalico/calico/felix/config"
 	"github.com/projectcalico/calico/felix/proto"
+	libapiv3 "github.com/projectcalico/calico/libcalico-go/lib/apis/v3"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/api"
 	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/syncersv1/updateprocessors"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/watchersyncer"
 	"github.com/projectcalico/calico/libcalico-go/lib/net"
 )
 
@@ -370,6 +374,55 @@ var _ = Describe("IPPool update/remove", func() {
 	})
 })
 
+var _ = Describe("Node IP address change", func() {
+	var uut *calc.EventSequencer
+	var passthru *calc.DataplanePassthru
+	var nodeProcessor watchersyncer.SyncerUpdateProcessor
+	var recorder *dataplaneRecorder
+
+	BeforeEach(func() {
+		uut = calc.NewEventSequencer(&dummyConfigInterface{})
+		recorder = &dataplaneRecorder{}
+		uut.Callback = recorder.record
+		passthru = calc.NewDataplanePassthru(uut, false)
+		nodeProcessor = updateprocessors.NewFelixNodeUpdateProcessor(false)
+	})
+
+	// updateNode sends a Node with the given BGP IPv4 address through the same conversion as
+	// the Felix syncer, and then through the pass-through to the event sequencer.
+	updateNode := func(ipv4 string) {
+		node := libapiv3.NewNode()
+		node.Name = "host1"
+		node.Spec.BGP = &libapiv3.NodeBGPSpec{IPv4Address: ipv4}
+		kvp := &model.KVPair{
+			Key:   model.ResourceKey{Kind: libapiv3.KindNode, Name: "host1"},
+			Value: node,
+		}
+		kvps, err := nodeProcessor.Process(kvp)
+		Expect(err).NotTo(HaveOccurred())
+		for _, kv := range append(kvps, kvp) {
+			passthru.OnUpdate(api.Update{KVPair: *kv, UpdateType: api.UpdateTypeKVUpdated})
+		}
+		uut.Flush()
+	}
+
+	It("should send the new address when the node's BGP IPv4 address changes", func() {
+		updateNode("10.0.0.1/24")
+		Expect(recorder.Messages).To(ConsistOf(
+			&proto.HostMetadataUpdate{Hostname: "host1", Ipv4Addr: "10.0.0.1"},
+			&proto.HostMetadataV4V6Update{Hostname: "host1", Ipv4Addr: "10.0.0.1/24"},
+		))
+
+		// A re-detected address, for example after a DHCP change, replaces the old one.
+		recorder.Messages = nil
+		updateNode("10.0.1.1/24")
+		Expect(recorder.Messages).To(ConsistOf(
+			&proto.HostMetadataUpdate{Hostname: "host1", Ipv4Addr: "10.0.1.1"},
+			&proto.HostMetadataV4V6Update{Hostname: "host1", Ipv4Addr: "10.0.1.1/24"},
+		))
+	})
+})
+
 type dataplaneRecorder struct {
 	Messages []interface{}
 }
@@ -189,6 +189,17 @@ func (pr *PolicyResolver) sendEndpointUpdate(endpointID interface{}) error {
 		return nil
 	}
 
+	applicableTiers := pr.tiersForEndpoint(endpointID)
+	log.Debugf("Endpoint tier update: %v -> %v", endpointID, applicableTiers)
+	for _, cb := range pr.Callbacks {
+		cb.OnEndpointTierUpdate(endpointID.(model.Key), endpoint, applicableTiers)
+	}
+	return nil
+}
+
+// tiersForEndpoint returns the tiers that contain at least one policy that applies to the
+// endpoint, with each tier filtered to the policies that apply.
+func (pr *PolicyResolver) tiersForEndpoint(endpointID any) []TierInfo {
 	applicableTiers := []TierInfo{}
 	for _, tier := range pr.sortedTierData {
 		if !tier.Valid {
@@ -215,10 +226,5 @@ func (pr *PolicyResolver) sendEndpointUpdate(endpointID interface{}) error {
 			applicableTiers = append(applicableTiers, filteredTier)
 		}
 	}
-
-	log.Debugf("Endpoint tier update: %v -> %v", endpointID, applicableTiers)
-	for _, cb := range pr.Callbacks {
-		cb.OnEndpointTierUpdate(endpointID.(model.Key), endpoint, applicableTiers)
-	}
-	return nil
+	return applicableTiers
 }
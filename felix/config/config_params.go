@@ -220,8 +220,9 @@ type Config struct {
 
 	// DatastoreType controls which datastore driver Felix will use.  Typically, this is detected from the environment
 	// and it does not need to be set manually. (For example, if `KUBECONFIG` is set, the kubernetes datastore driver
-	// will be used by default).
-	DatastoreType string `config:"oneof(kubernetes,etcdv3);etcdv3;non-zero,die-on-fail,local"`
+	// will be used by default).  The file datastore driver stores resources in a local file, for nodes that run
+	// without etcd or Kubernetes; the file is set with the `DATASTORE_FILE` environment variable.
+	DatastoreType string `config:"oneof(kubernetes,etcdv3,file);etcdv3;non-zero,die-on-fail,local"`
 
 	// FelixHostname is the name of this node, used to identify resources in the datastore that belong to this node.
 	// Auto-detected from the node's hostname if not provided.
@@ -274,6 +275,19 @@ type Config struct {
 	// TyphaURISAN URI SAN to use when authenticating to Typha over TLS. If any TLS parameters are specified then one of
 	// TyphaCN and TyphaURISAN must be set.
 	TyphaURISAN string `config:"string;;local"`
+	// TyphaClientCN is the common name that Typha expects in Felix's certificate (Typha's ClientCN).  Optional;
+	// if it or TyphaClientURISAN is set, a rotated TyphaCertFile is only used if it matches one of them.
+	// Otherwise, a rotated certificate must have the same CN and URI SANs as the certificate that it replaces.
+	TyphaClientCN string `config:"string;;local"`
+	// TyphaClientURISAN is the URI SAN that Typha expects in Felix's certificate (Typha's ClientURISAN).  See
+	// TyphaClientCN.
+	TyphaClientURISAN string `config:"string;;local"`
+	// TyphaFilterRemoteEndpoints tells Felix to ask Typha for a node-scoped stream, in which the workload endpoints
+	// on other nodes only include the fields that Felix needs for them: their IPs, labels, named ports and
+	// profiles.  This reduces Felix's memory usage and Typha's bandwidth in large clusters.  Ignored by Typha
+	// versions that don't support it, and by Typha unless its SnapshotCacheReduceRemoteEndpoints parameter is
+	// enabled.
+	TyphaFilterRemoteEndpoints bool `config:"bool;false;local"`
 
 	Ipv6Support bool `config:"bool;true"`
 
@@ -870,13 +884,15 @@ func (config *Config) DatastoreConfig() apiconfig.CalicoAPIConfig {
 	// to configure the other etcdv3 options. As of the time of this code change, the etcd options
 	// have no affect if the DatastoreType is not etcdv3.
 
-	// Datastore type, either etcdv3 or kubernetes
+	// Datastore type, either etcdv3, kubernetes or file
 	if config.setByConfigFileOrEnvironment("DatastoreType") {
 		log.Infof("Overriding DatastoreType from felix config to %s", config.DatastoreType)
 		if config.DatastoreType == string(apiconfig.EtcdV3) {
 			cfg.Spec.DatastoreType = apiconfig.EtcdV3
 		} else if config.DatastoreType == string(apiconfig.Kubernetes) {
 			cfg.Spec.DatastoreType = apiconfig.Kubernetes
+		} else if config.DatastoreType == string(apiconfig.File) {
+			cfg.Spec.DatastoreType = apiconfig.File
 		}
 	}
 
@@ -18,6 +18,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/http"
 	"os"
 	"os/exec"
 	"os/signal"
@@ -511,6 +512,10 @@ configRetry:
 				CAFile:       configParams.TyphaCAFile,
 				ServerCN:     configParams.TyphaCN,
 				ServerURISAN: configParams.TyphaURISAN,
+				ClientCN:     configParams.TyphaClientCN,
+				ClientURISAN: configParams.TyphaClientURISAN,
+
+				FilterRemoteEndpoints: configParams.TyphaFilterRemoteEndpoints,
 			},
 		)
 	} else {
@@ -637,6 +642,10 @@ configRetry:
 
 	go syncerToValidator.SendToSinkForever(validator)
 	asyncCalcGraph.Start()
+	if configParams.DebugPort != 0 {
+		// Serve the calculation graph's view of the local endpoints alongside pprof.
+		http.Handle(calc.DebugEndpointsPath, asyncCalcGraph.DebugHandler())
+	}
 	log.Infof("Started the processing graph")
 	var stopSignalChans []chan<- *sync.WaitGroup
 	if configParams.EndpointReportingEnabled {
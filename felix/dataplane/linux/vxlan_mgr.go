@@ -231,6 +231,13 @@ func (m *vxlanManager) OnUpdate(protoBufMsg interface{}) {
 
 		m.logCtx.WithField("msg", msg).Debug("VXLAN data plane received VTEP update")
 		if msg.Node == m.hostname {
+			if m.parentDeviceIP(m.getLocalVTEP()) != m.parentDeviceIP(msg) {
+				// Our host IP has moved (for example, after a DHCP renewal).  Forget the
+				// old parent so that CompleteDeferredWork re-resolves it rather than
+				// leaving same-subnet routes on an interface that may no longer have the
+				// address.
+				m.resetParentIface()
+			}
 			m.setLocalVTEP(msg)
 		} else {
 			m.vtepsByNode[msg.Node] = msg
@@ -526,6 +533,29 @@ func (m *vxlanManager) OnParentNameUpdate(name string) {
 	m.routesDirty = true
 }
 
+// resetParentIface removes any same-subnet routes from the current parent interface and
+// clears it so that it is looked up again on the next call to CompleteDeferredWork.
+func (m *vxlanManager) resetParentIface() {
+	if m.parentIfaceName == "" {
+		return
+	}
+	m.logCtx.WithField("parent", m.parentIfaceName).Info("Local VTEP parent IP changed, re-resolving parent interface.")
+	m.routeTable.SetRoutes(routetable.RouteClassVXLANSameSubnet, m.parentIfaceName, nil)
+	m.parentIfaceName = ""
+	m.routesDirty = true
+}
+
+// parentDeviceIP returns the parent device IP of the given VTEP for this manager's IP version.
+func (m *vxlanManager) parentDeviceIP(vtep *proto.VXLANTunnelEndpointUpdate) string {
+	if vtep == nil {
+		return ""
+	}
+	if m.ipVersion == 6 {
+		return vtep.ParentDeviceIpv6
+	}
+	return vtep.ParentDeviceIp
+}
+
 // KeepVXLANDeviceInSync is a goroutine that configures the VXLAN tunnel device, then periodically
 // checks that it is still correctly configured.
 func (m *vxlanManager) KeepVXLANDeviceInSync(
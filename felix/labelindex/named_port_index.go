@@ -923,6 +923,41 @@ func (idx *SelectorAndNamedPortIndex) discardParentIfEmpty(id string) {
 	}
 }
 
+// EndpointLabels returns the labels of the given endpoint, including those inherited from its
+// parents, or false if the endpoint is not known.
+func (idx *SelectorAndNamedPortIndex) EndpointLabels(id any) (map[string]string, bool) {
+	epData, ok := idx.endpointKVIdx.Get(id)
+	if !ok {
+		return nil, false
+	}
+	labels := map[string]string{}
+	// Earlier parents take precedence over later ones, and the endpoint's own labels take
+	// precedence over all of them; see endpointData.Get().
+	for i := len(epData.parents) - 1; i >= 0; i-- {
+		for k, v := range epData.parents[i].labels {
+			labels[k] = v
+		}
+	}
+	for k, v := range epData.labels {
+		labels[k] = v
+	}
+	return labels, true
+}
+
+// IPSetMembers returns the current members of the given IP set, or false if the IP set is
+// not active.
+func (idx *SelectorAndNamedPortIndex) IPSetMembers(ipSetID string) ([]IPSetMember, bool) {
+	ipSetData, ok := idx.ipSetDataByID[ipSetID]
+	if !ok {
+		return nil, false
+	}
+	members := make([]IPSetMember, 0, len(ipSetData.memberToRefCount))
+	for m := range ipSetData.memberToRefCount {
+		members = append(members, m)
+	}
+	return members, true
+}
+
 func (idx *SelectorAndNamedPortIndex) maybeReportLive() {
 	// We report from some tight loops so rate limit our reports.
 	if time.Since(idx.lastLiveReport) < 100*time.Millisecond {
@@ -0,0 +1,135 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nftables
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"sigs.k8s.io/knftables"
+
+	"github.com/projectcalico/calico/felix/environment"
+	"github.com/projectcalico/calico/felix/generictables"
+)
+
+// interfaceVMapType is the nftables type of the verdict maps used for interface dispatch.
+const interfaceVMapType = "ifname : verdict"
+
+// multiReferrer is implemented by actions that may refer to more than one chain.
+type multiReferrer interface {
+	ReferencedChains() []string
+}
+
+// InterfaceVMapAction dispatches a packet using an nftables verdict map keyed on interface name. The
+// verdict for a packet whose interface is in the map is the member action for that interface; other
+// packets fall through to the next rule in the chain.
+//
+// The table programs the map along with the chain that contains the rule. Only the map name is
+// rendered into the rule, so a change to the members is programmed by adding and deleting map
+// elements, without rewriting the chain.
+type InterfaceVMapAction struct {
+	// Map is the name of the verdict map.
+	Map string
+
+	// Out causes the map to be keyed on the outgoing interface rather than the incoming one.
+	Out bool
+
+	// Members maps from interface name to the action for packets on that interface. Each action
+	// must be a GotoAction or a JumpAction.
+	Members map[string]generictables.Action
+
+	TypeInterfaceVMap struct{}
+}
+
+func (a InterfaceVMapAction) ToFragment(features *environment.Features) string {
+	if a.Out {
+		return "oifname vmap @" + a.Map
+	}
+	return "iifname vmap @" + a.Map
+}
+
+func (a InterfaceVMapAction) String() string {
+	return fmt.Sprintf("InterfaceVMap->%s(%d members)", a.Map, len(a.Members))
+}
+
+func (a InterfaceVMapAction) ReferencedChains() []string {
+	chains := make([]string, 0, len(a.Members))
+	for _, m := range a.Members {
+		if ref, ok := m.(Referrer); ok {
+			chains = append(chains, ref.ReferencedChain())
+		}
+	}
+	sort.Strings(chains)
+	return chains
+}
+
+func (a InterfaceVMapAction) Namespace(ns string) generictables.Action {
+	n := a
+	if !strings.HasPrefix(a.Map, ns) {
+		n.Map = ns + "-" + a.Map
+	}
+	n.Members = make(map[string]generictables.Action, len(a.Members))
+	for iface, m := range a.Members {
+		if nm, ok := m.(namespaceable); ok {
+			m = nm.Namespace(ns)
+		}
+		n.Members[iface] = m
+	}
+	return n
+}
+
+// renderMembers returns the map elements for the members, keyed on interface name.
+func (a InterfaceVMapAction) renderMembers(features *environment.Features) map[string]string {
+	elems := make(map[string]string, len(a.Members))
+	for iface, m := range a.Members {
+		elems[iface] = m.ToFragment(features)
+	}
+	return elems
+}
+
+var (
+	_ multiReferrer = InterfaceVMapAction{}
+	_ namespaceable = InterfaceVMapAction{}
+)
+
+// vmapsInRules returns the verdict map actions used by the given rules.
+func vmapsInRules(rules []generictables.Rule) []InterfaceVMapAction {
+	var vmaps []InterfaceVMapAction
+	for _, r := range rules {
+		if m, ok := r.Action.(InterfaceVMapAction); ok {
+			vmaps = append(vmaps, m)
+		}
+	}
+	return vmaps
+}
+
+// addMapUpdates adds the operations needed to change the named map from the previous elements to the
+// desired elements to the transaction. A nil previous map means the map doesn't exist yet.
+func addMapUpdates(tx *knftables.Transaction, name string, previous, desired map[string]string) {
+	if previous == nil {
+		tx.Add(&knftables.Map{Name: name, Type: interfaceVMapType})
+	}
+	for iface, verdict := range previous {
+		if d, ok := desired[iface]; !ok || d != verdict {
+			tx.Delete(&knftables.Element{Map: name, Key: []string{iface}, Value: []string{verdict}})
+		}
+	}
+	for iface, verdict := range desired {
+		if p, ok := previous[iface]; !ok || p != verdict {
+			tx.Add(&knftables.Element{Map: name, Key: []string{iface}, Value: []string{verdict}})
+		}
+	}
+}
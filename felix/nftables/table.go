@@ -200,6 +200,10 @@ type nftablesTable struct {
 	chainRefCounts map[string]int
 	dirtyChains    set.Set[string]
 
+	// chainToVMaps contains the verdict maps used by the rules of each chain.  The maps are
+	// programmed along with the chain that uses them.
+	chainToVMaps map[string][]InterfaceVMapAction
+
 	inSyncWithDataPlane bool
 
 	// chainToDataplaneHashes contains the rule hashes that we think are in the dataplane.
@@ -211,6 +215,10 @@ type nftablesTable struct {
 	// to slices of rules in that chain.
 	chainToFullRules map[string][]*knftables.Rule
 
+	// mapToDataplaneElements contains the elements of the verdict maps that we think are in
+	// the dataplane, mapped from map name to interface name to verdict.
+	mapToDataplaneElements map[string]map[string]string
+
 	// hashCommentPrefix holds the prefix that we prepend to our rule-tracking hashes.
 	hashCommentPrefix string
 
@@ -350,6 +358,8 @@ func NewTable(
 		dirtyChains:            set.New[string](),
 		chainToDataplaneHashes: map[string][]string{},
 		chainToFullRules:       map[string][]*knftables.Rule{},
+		chainToVMaps:           map[string][]InterfaceVMapAction{},
+		mapToDataplaneElements: map[string]map[string]string{},
 		logCxt:                 log.WithFields(logFields),
 		updateRateLimitedLog: logutilslc.NewRateLimitedLogger(
 			logutilslc.OptInterval(30*time.Second),
@@ -447,6 +457,11 @@ func (t *nftablesTable) UpdateChain(chain *generictables.Chain) {
 		t.maybeDecrefReferredChains(chain.Name, oldChain.Rules)
 	}
 	t.chainNameToChain[chain.Name] = chain
+	if vmaps := vmapsInRules(chain.Rules); len(vmaps) > 0 {
+		t.chainToVMaps[chain.Name] = vmaps
+	} else {
+		delete(t.chainToVMaps, chain.Name)
+	}
 	numRulesDelta := len(chain.Rules) - oldNumRules
 	t.gaugeNumRules.Add(float64(numRulesDelta))
 	if t.chainIsReferenced(chain.Name) {
@@ -466,6 +481,7 @@ func (t *nftablesTable) RemoveChainByName(name string) {
 		t.gaugeNumRules.Sub(float64(len(oldChain.Rules)))
 		t.maybeDecrefReferredChains(name, oldChain.Rules)
 		delete(t.chainNameToChain, name)
+		delete(t.chainToVMaps, name)
 		if t.chainIsReferenced(name) {
 			t.dirtyChains.Add(name)
 		}
@@ -487,6 +503,11 @@ func (t *nftablesTable) maybeIncrefReferredChains(chainName string, rules []gene
 		if ref, ok := r.Action.(Referrer); ok {
 			t.increfChain(ref.ReferencedChain())
 		}
+		if ref, ok := r.Action.(multiReferrer); ok {
+			for _, c := range ref.ReferencedChains() {
+				t.increfChain(c)
+			}
+		}
 	}
 }
 
@@ -501,6 +522,11 @@ func (t *nftablesTable) maybeDecrefReferredChains(chainName string, rules []gene
 		if ref, ok := r.Action.(Referrer); ok {
 			t.decrefChain(ref.ReferencedChain())
 		}
+		if ref, ok := r.Action.(multiReferrer); ok {
+			for _, c := range ref.ReferencedChains() {
+				t.decrefChain(c)
+			}
+		}
 	}
 }
 
@@ -602,7 +628,30 @@ func (t *nftablesTable) loadDataplaneState() {
 		t.dirtyChains.Add(chainName)
 	}
 
+	// Check that the verdict maps used by our chains have the expected elements.  If not, mark
+	// the chain that uses the map as dirty so that the map is resynced along with it.
+	dataplaneMaps := t.getMapsFromDataplane()
+	features := t.featureDetector.GetFeatures()
+	for mapName, chainName := range t.desiredMaps() {
+		if t.dirtyChains.Contains(chainName) {
+			continue
+		}
+		for _, m := range t.chainToVMaps[chainName] {
+			if m.Map != mapName {
+				continue
+			}
+			if !reflect.DeepEqual(dataplaneMaps[mapName], m.renderMembers(features)) {
+				t.logCxt.WithFields(log.Fields{
+					"chainName": chainName,
+					"mapName":   mapName,
+				}).Warn("Detected out-of-sync Calico verdict map, marking for resync")
+				t.dirtyChains.Add(chainName)
+			}
+		}
+	}
+
 	t.logCxt.Debug("Finished loading nftables state")
+	t.mapToDataplaneElements = dataplaneMaps
 	t.chainToDataplaneHashes = dataplaneHashes
 	t.chainToFullRules = dataplaneRules
 	t.inSyncWithDataPlane = true
@@ -736,6 +785,79 @@ func (t *nftablesTable) attemptToGetHashesAndRulesFromDataplane() (hashes map[st
 	return
 }
 
+// getMapsFromDataplane loads the elements of the verdict maps in our table, mapped from map name to
+// interface name to verdict.
+func (t *nftablesTable) getMapsFromDataplane() map[string]map[string]string {
+	retries := 3
+	retryDelay := 100 * time.Millisecond
+	for {
+		t.onStillAlive()
+		maps, err := t.attemptToGetMapsFromDataplane()
+		if err != nil {
+			countNumListErrors.Inc()
+			t.logCxt.WithError(err).Warn("nftables command failed")
+			if retries > 0 {
+				retries--
+				t.timeSleep(retryDelay)
+				retryDelay *= 2
+			} else {
+				t.logCxt.Panic("nftables command failed after retries")
+			}
+			continue
+		}
+		return maps
+	}
+}
+
+func (t *nftablesTable) attemptToGetMapsFromDataplane() (map[string]map[string]string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), t.contextTimeout)
+	defer cancel()
+
+	maps := map[string]map[string]string{}
+	countNumListCalls.Inc()
+	names, err := t.nft.List(ctx, "map")
+	if err != nil {
+		if knftables.IsNotFound(err) {
+			return maps, nil
+		}
+		return nil, err
+	}
+	for _, name := range names {
+		countNumListCalls.Inc()
+		elems, err := t.nft.ListElements(ctx, "map", name)
+		if err != nil {
+			return nil, err
+		}
+		maps[name] = map[string]string{}
+		for _, e := range elems {
+			maps[name][strings.Join(e.Key, " . ")] = strings.Join(e.Value, " . ")
+		}
+	}
+	return maps, nil
+}
+
+// desiredMaps returns the verdict maps that should be programmed, mapped from map name to the
+// name of the chain that uses the map.  Only maps used by chains that we're programming are included.
+func (t *nftablesTable) desiredMaps() map[string]string {
+	maps := map[string]string{}
+	for chainName, vmaps := range t.chainToVMaps {
+		if !t.chainIsReferenced(chainName) {
+			continue
+		}
+		for _, m := range vmaps {
+			if other, ok := maps[m.Map]; ok && other != chainName {
+				t.logCxt.WithFields(log.Fields{
+					"mapName": m.Map,
+					"chains":  []string{other, chainName},
+				}).Warn("Verdict map used by more than one chain, ignoring all but one")
+				continue
+			}
+			maps[m.Map] = chainName
+		}
+	}
+	return maps
+}
+
 func (t *nftablesTable) InvalidateDataplaneCache(reason string) {
 	logCxt := t.logCxt.WithField("reason", reason)
 	if !t.inSyncWithDataPlane {
@@ -880,6 +1002,30 @@ func (t *nftablesTable) applyUpdates() error {
 		return nil
 	})
 
+	// Sync the verdict maps used by dirty chains.  This comes after the forward references, since
+	// map elements may refer to newly-created chains, and before the rules that refer to the maps.
+	desiredMaps := t.desiredMaps()
+	newMapElements := map[string]map[string]string{}
+	for mapName, chainName := range desiredMaps {
+		previous, exists := t.mapToDataplaneElements[mapName]
+		if exists && !t.dirtyChains.Contains(chainName) {
+			continue
+		}
+		for _, m := range t.chainToVMaps[chainName] {
+			if m.Map != mapName {
+				continue
+			}
+			desired := m.renderMembers(features)
+			t.logCxt.WithFields(logrus.Fields{
+				"mapName":    mapName,
+				"numMembers": len(desired),
+			}).Debug("Syncing verdict map")
+			addMapUpdates(tx, mapName, previous, desired)
+			newMapElements[mapName] = desired
+			break
+		}
+	}
+
 	// Make a second pass over the dirty chains.  This time, we write out the rule changes.
 	newHashes := map[string][]string{}
 	t.dirtyChains.Iter(func(chainName string) error {
@@ -1002,7 +1148,14 @@ func (t *nftablesTable) applyUpdates() error {
 
 	// Do deletions at the end.  This ensures that we don't try to delete any chains that
 	// are still referenced (because we'll have removed the references in the modify pass
-	// above).
+	// above).  Maps go first, since their elements may refer to chains that we're deleting.
+	for mapName := range t.mapToDataplaneElements {
+		if _, ok := desiredMaps[mapName]; !ok {
+			t.logCxt.WithField("mapName", mapName).Debug("Deleting verdict map that is no longer needed")
+			tx.Delete(&knftables.Map{Name: mapName})
+			newMapElements[mapName] = nil
+		}
+	}
 	t.dirtyChains.Iter(func(chainName string) error {
 		if _, ok := t.desiredStateOfChain(chainName); !ok {
 			// Chain deletion
@@ -1046,6 +1199,13 @@ func (t *nftablesTable) applyUpdates() error {
 		}
 	}
 	t.chainToFullRules = newChainToFullRules
+	for mapName, elems := range newMapElements {
+		if elems == nil {
+			delete(t.mapToDataplaneElements, mapName)
+		} else {
+			t.mapToDataplaneElements[mapName] = elems
+		}
+	}
 
 	// Invalidate the in-memory dataplane state so that we reload on the next write. This ensures we have the correct handles
 	// in-memory for each of the objects we've just written. nftables requires an object's handle in order to
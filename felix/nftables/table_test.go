@@ -626,6 +626,88 @@ var _ = Describe("Table with an empty dataplane", func() {
 			})
 		})
 
+		Describe("after adding a chain that dispatches via a verdict map", func() {
+			vmapChain := func(members map[string]generictables.Action) *generictables.Chain {
+				return &generictables.Chain{
+					Name: "cali-from-wl-dispatch",
+					Rules: []generictables.Rule{
+						{Action: InterfaceVMapAction{Map: "cali-from-wl-dispatch", Members: members}},
+						{Action: DropAction{}},
+					},
+				}
+			}
+			listElements := func() map[string]string {
+				elems, err := f.ListElements(context.TODO(), "map", "cali-from-wl-dispatch")
+				Expect(err).NotTo(HaveOccurred())
+				m := map[string]string{}
+				for _, e := range elems {
+					m[e.Key[0]] = e.Value[0]
+				}
+				return m
+			}
+
+			BeforeEach(func() {
+				table.UpdateChains([]*generictables.Chain{
+					{Name: "cali-fw-cali1", Rules: []generictables.Rule{{Action: AcceptAction{}}}},
+					{Name: "cali-fw-cali2", Rules: []generictables.Rule{{Action: AcceptAction{}}}},
+					vmapChain(map[string]generictables.Action{
+						"cali1": GotoAction{Target: "cali-fw-cali1"},
+					}),
+				})
+				table.InsertOrAppendRules("filter-FORWARD", []generictables.Rule{
+					{Action: JumpAction{Target: "cali-from-wl-dispatch"}},
+				})
+				table.Apply()
+			})
+
+			It("should program the map and the chains it refers to", func() {
+				chains, err := f.List(context.TODO(), "chain")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(chains).To(ConsistOf(append(expectedBaseChains, "cali-from-wl-dispatch", "cali-fw-cali1")))
+
+				maps, err := f.List(context.TODO(), "map")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(maps).To(ConsistOf("cali-from-wl-dispatch"))
+				Expect(listElements()).To(Equal(map[string]string{"cali1": "goto cali-fw-cali1"}))
+			})
+
+			Describe("after changing the members", func() {
+				BeforeEach(func() {
+					f.Reset()
+					table.UpdateChain(vmapChain(map[string]generictables.Action{
+						"cali2": GotoAction{Target: "cali-fw-cali2"},
+					}))
+					table.Apply()
+				})
+
+				It("should update the map elements without rewriting the chain", func() {
+					Expect(listElements()).To(Equal(map[string]string{"cali2": "goto cali-fw-cali2"}))
+					chains, err := f.List(context.TODO(), "chain")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(chains).To(ConsistOf(append(expectedBaseChains, "cali-from-wl-dispatch", "cali-fw-cali2")))
+
+					Expect(f.transactions).To(HaveLen(1))
+					Expect(f.transactions[0].String()).NotTo(ContainSubstring("rule ip calico cali-from-wl-dispatch"))
+				})
+			})
+
+			Describe("after removing the reference to the chain", func() {
+				BeforeEach(func() {
+					table.InsertOrAppendRules("filter-FORWARD", nil)
+					table.Apply()
+				})
+
+				It("should remove the map and the chains", func() {
+					chains, err := f.List(context.TODO(), "chain")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(chains).To(ConsistOf(expectedBaseChains))
+					maps, err := f.List(context.TODO(), "map")
+					Expect(err).NotTo(HaveOccurred())
+					Expect(maps).To(BeEmpty())
+				})
+			})
+		})
+
 		Describe("applying updates when underlying rules have changed in a approved chain", func() {
 			BeforeEach(func() {
 				table.InsertOrAppendRules("filter-FORWARD", []generictables.Rule{
@@ -20,6 +20,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/projectcalico/calico/felix/generictables"
+	"github.com/projectcalico/calico/felix/nftables"
 	"github.com/projectcalico/calico/felix/proto"
 	"github.com/projectcalico/calico/felix/stringutils"
 )
@@ -85,7 +86,7 @@ func (r *DefaultRuleRenderer) WorkloadInterfaceAllowChains(
 		prefixes,
 		prefixToNames,
 		WorkloadPfxSpecialAllow,
-		func(name string) generictables.MatchCriteria { return r.NewMatch().OutInterface(name) },
+		true,
 		func(pfx, name string) generictables.Action {
 			return r.Allow()
 		},
@@ -297,7 +298,7 @@ func (r *DefaultRuleRenderer) interfaceNameDispatchChains(
 			prefixes,
 			prefixToNames,
 			fromEndpointPfx,
-			func(name string) generictables.MatchCriteria { return r.NewMatch().InInterface(name) },
+			false,
 			func(pfx, name string) generictables.Action {
 				return r.GoTo(EndpointChainName(pfx, name, r.maxNameLength))
 			},
@@ -315,7 +316,7 @@ func (r *DefaultRuleRenderer) interfaceNameDispatchChains(
 			prefixes,
 			prefixToNames,
 			toEndpointPfx,
-			func(name string) generictables.MatchCriteria { return r.NewMatch().OutInterface(name) },
+			true,
 			func(pfx, name string) generictables.Action {
 				return r.GoTo(EndpointChainName(pfx, name, r.maxNameLength))
 			},
@@ -350,7 +351,12 @@ func (r *DefaultRuleRenderer) endpointMarkDispatchChains(
 
 	// The workload and host endpoint share the same root chain. We also need to put an non-cali mark rules at the end.
 	// Work out child chains and root rules for workload and host endpoint separately and merge them back together.
-	for _, names := range [][]string{wlNames, hepNames} {
+	nameGroups := [][]string{wlNames, hepNames}
+	if r.NFTables {
+		// With nftables, a single verdict map covers both workload and host endpoints.
+		nameGroups = [][]string{append(append([]string(nil), wlNames...), hepNames...)}
+	}
+	for _, names := range nameGroups {
 		if len(names) > 0 {
 			commonPrefix, prefixes, prefixToNames := r.sortAndDivideEndpointNamesToPrefixTree(names)
 
@@ -360,7 +366,7 @@ func (r *DefaultRuleRenderer) endpointMarkDispatchChains(
 				prefixes,
 				prefixToNames,
 				setMarkPfx,
-				func(name string) generictables.MatchCriteria { return r.NewMatch().InInterface(name) },
+				false,
 				func(pfx, name string) generictables.Action {
 					return r.GoTo(EndpointChainName(pfx, name, r.maxNameLength))
 				},
@@ -452,10 +458,20 @@ func (r *DefaultRuleRenderer) buildSingleDispatchChains(
 	prefixes []string,
 	prefixToNames map[string][]string,
 	endpointPfx string,
-	getMatchForEndpoint func(name string) generictables.MatchCriteria,
+	outIface bool,
 	getActionForEndpoint func(pfx, name string) generictables.Action,
 	endRules []generictables.Rule,
 ) ([]*generictables.Chain, *generictables.Chain, []generictables.Rule) {
+	if r.NFTables {
+		return r.buildSingleDispatchVMap(chainName, prefixes, prefixToNames, endpointPfx, outIface, getActionForEndpoint, endRules)
+	}
+
+	getMatchForEndpoint := func(name string) generictables.MatchCriteria {
+		if outIface {
+			return r.NewMatch().OutInterface(name)
+		}
+		return r.NewMatch().InInterface(name)
+	}
 	childChains := make([]*generictables.Chain, 0)
 	rootRules := make([]generictables.Rule, 0)
 
@@ -536,6 +552,49 @@ func (r *DefaultRuleRenderer) buildSingleDispatchChains(
 	return childChains, rootChain, rootRules
 }
 
+// buildSingleDispatchVMap is the nftables equivalent of buildSingleDispatchChains.  Rather than a
+// tree of chains, it renders a single rule that looks the interface up in a verdict map, followed by
+// the end rules.  The map is named after the chain, and the nftables table adds and removes map
+// elements as endpoints come and go, so the chain itself only changes if the end rules change.
+func (r *DefaultRuleRenderer) buildSingleDispatchVMap(
+	chainName string,
+	prefixes []string,
+	prefixToNames map[string][]string,
+	endpointPfx string,
+	outIface bool,
+	getActionForEndpoint func(pfx, name string) generictables.Action,
+	endRules []generictables.Rule,
+) ([]*generictables.Chain, *generictables.Chain, []generictables.Rule) {
+	members := map[string]generictables.Action{}
+	for _, prefix := range prefixes {
+		for _, name := range prefixToNames[prefix] {
+			members[name] = getActionForEndpoint(endpointPfx, name)
+		}
+	}
+	log.WithFields(log.Fields{
+		"chainName":  chainName,
+		"numMembers": len(members),
+	}).Debug("Rendering dispatch verdict map")
+
+	rootRules := []generictables.Rule{
+		{
+			Match: r.NewMatch(),
+			Action: nftables.InterfaceVMapAction{
+				Map:     chainName,
+				Out:     outIface,
+				Members: members,
+			},
+		},
+	}
+	rootRules = append(rootRules, endRules...)
+
+	rootChain := &generictables.Chain{
+		Name:  chainName,
+		Rules: rootRules,
+	}
+	return nil, rootChain, rootRules
+}
+
 // Divide endpoint names into shallow tree.
 // Return common prefix, list of prefix and map of prefix to list of interface names.
 func (r *DefaultRuleRenderer) sortAndDivideEndpointNamesToPrefixTree(names []string) (string, []string, map[string][]string) {
@@ -24,6 +24,7 @@ import (
 	"github.com/projectcalico/calico/felix/generictables"
 	"github.com/projectcalico/calico/felix/ipsets"
 	"github.com/projectcalico/calico/felix/iptables"
+	"github.com/projectcalico/calico/felix/nftables"
 	"github.com/projectcalico/calico/felix/proto"
 	. "github.com/projectcalico/calico/felix/rules"
 )
@@ -1122,6 +1123,74 @@ var _ = Describe("Dispatch chains", func() {
 	}
 })
 
+var _ = Describe("Dispatch verdict maps in nftables mode", func() {
+	var renderer RuleRenderer
+	BeforeEach(func() {
+		renderer = NewRenderer(Config{
+			NFTables:              true,
+			IPSetConfigV4:         ipsets.NewIPVersionConfig(ipsets.IPFamilyV4, "cali", nil, nil),
+			IPSetConfigV6:         ipsets.NewIPVersionConfig(ipsets.IPFamilyV6, "cali", nil, nil),
+			MarkAccept:            0x8,
+			MarkPass:              0x10,
+			MarkScratch0:          0x20,
+			MarkScratch1:          0x40,
+			MarkEndpoint:          0xff00,
+			MarkNonCaliEndpoint:   0x0100,
+			WorkloadIfacePrefixes: []string{"cali", "tap"},
+		})
+	})
+
+	It("should render workload dispatch as a single verdict map per direction", func() {
+		input := map[proto.WorkloadEndpointID]*proto.WorkloadEndpoint{}
+		for _, name := range []string{"cali1234", "cali5678", "tapabcd"} {
+			input[proto.WorkloadEndpointID{OrchestratorId: "k8s", WorkloadId: name, EndpointId: name}] = &proto.WorkloadEndpoint{Name: name}
+		}
+		dropRule := generictables.Rule{
+			Match:   nftables.Match(),
+			Action:  nftables.DropAction{},
+			Comment: []string{"Unknown interface"},
+		}
+
+		Expect(renderer.WorkloadDispatchChains(input)).To(Equal([]*generictables.Chain{
+			{
+				Name: "cali-from-wl-dispatch",
+				Rules: []generictables.Rule{
+					{
+						Match: nftables.Match(),
+						Action: nftables.InterfaceVMapAction{
+							Map: "cali-from-wl-dispatch",
+							Members: map[string]generictables.Action{
+								"cali1234": &nftables.GotoAction{Target: "cali-fw-cali1234"},
+								"cali5678": &nftables.GotoAction{Target: "cali-fw-cali5678"},
+								"tapabcd":  &nftables.GotoAction{Target: "cali-fw-tapabcd"},
+							},
+						},
+					},
+					dropRule,
+				},
+			},
+			{
+				Name: "cali-to-wl-dispatch",
+				Rules: []generictables.Rule{
+					{
+						Match: nftables.Match(),
+						Action: nftables.InterfaceVMapAction{
+							Map: "cali-to-wl-dispatch",
+							Out: true,
+							Members: map[string]generictables.Action{
+								"cali1234": &nftables.GotoAction{Target: "cali-tw-cali1234"},
+								"cali5678": &nftables.GotoAction{Target: "cali-tw-cali5678"},
+								"tapabcd":  &nftables.GotoAction{Target: "cali-tw-tapabcd"},
+							},
+						},
+					},
+					dropRule,
+				},
+			},
+		}))
+	})
+})
+
 func gotoRule(target string) generictables.Rule {
 	return generictables.Rule{
 		Match:  iptables.Match(),
@@ -58,8 +58,15 @@ func main() {
 		}
 
 		// Check if all components are ready
+		// Standby replicas are ready; report which replica is the leader.
 		if st.GetReadiness() {
-			fmt.Println("Ready")
+			if le := st.GetLeaderElection(); le == nil {
+				fmt.Println("Ready")
+			} else if le.IsLeader {
+				fmt.Println("Ready (leader)")
+			} else {
+				fmt.Printf("Ready (standby, leader is %q)\n", le.Leader)
+			}
 			os.Exit(0)
 		}
 
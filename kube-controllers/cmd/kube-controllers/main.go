@@ -38,11 +38,14 @@ import (
 	"github.com/projectcalico/calico/kube-controllers/pkg/config"
 	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/controller"
 	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/flannelmigration"
+	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/loadbalancer"
 	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/namespace"
 	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/networkpolicy"
 	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/node"
 	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/pod"
+	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/routereflector"
 	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/serviceaccount"
+	"github.com/projectcalico/calico/kube-controllers/pkg/leaderelection"
 	"github.com/projectcalico/calico/kube-controllers/pkg/status"
 	"github.com/projectcalico/calico/libcalico-go/lib/apiconfig"
 	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
@@ -150,6 +153,8 @@ func main() {
 	}
 
 	var runCfg config.RunConfig
+	var cCtrlr *config.RunConfigController
+	initControllers := func() {}
 	// flannelmigration doesn't use the datastore config API
 	v, ok := os.LookupEnv(config.EnvEnabledControllers)
 	if ok && strings.Contains(v, "flannelmigration") {
@@ -176,13 +181,15 @@ func main() {
 		controllerCtrl.restart = make(chan config.RunConfig)
 	} else {
 		log.Info("Getting initial config snapshot from datastore")
-		cCtrlr := config.NewRunConfigController(ctx, *cfg, calicoClient.KubeControllersConfiguration())
+		cCtrlr = config.NewRunConfigController(ctx, *cfg, calicoClient.KubeControllersConfiguration())
 		runCfg = <-cCtrlr.ConfigChan()
 		log.Info("Got initial config snapshot")
 
 		// any subsequent changes trigger a restart
 		controllerCtrl.restart = cCtrlr.ConfigChan()
-		controllerCtrl.InitControllers(ctx, runCfg, k8sClientset, calicoClient)
+		initControllers = func() {
+			controllerCtrl.InitControllers(ctx, runCfg, k8sClientset, calicoClient)
+		}
 	}
 
 	if cfg.DatastoreType == "etcdv3" {
@@ -217,7 +224,12 @@ func main() {
 	}
 
 	// Run the controllers. This runs until a config change triggers a restart
-	controllerCtrl.RunControllers()
+	if cfg.LeaderElection {
+		runWithLeaderElection(ctx, cfg, s, k8sClientset, cCtrlr, controllerCtrl, initControllers)
+	} else {
+		initControllers()
+		controllerCtrl.RunControllers()
+	}
 
 	// Shut down compaction, healthChecks, and configController
 	cancel()
@@ -228,6 +240,106 @@ func main() {
 	//       the stop channel passed to the controllers.
 }
 
+// runWithLeaderElection campaigns for leadership and runs the controllers once this replica is
+// elected.  It returns when the controllers stop, or if the config changes while this replica
+// is waiting to be elected.  The lease is released before returning so that another replica
+// can take over immediately.
+func runWithLeaderElection(
+	ctx context.Context,
+	cfg *config.Config,
+	s *status.Status,
+	k8sClientset *kubernetes.Clientset,
+	cCtrlr *config.RunConfigController,
+	cc *controllerControl,
+	initControllers func(),
+) {
+	identity := cfg.LeaderElectionIdentity
+	if identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			log.WithError(err).Fatal("Failed to determine leader election identity")
+		}
+		identity = hostname
+	}
+	leCfg := leaderelection.Config{
+		Name:          cfg.LeaderElectionLeaseName,
+		Namespace:     cfg.LeaderElectionNamespace,
+		Identity:      identity,
+		LeaseDuration: cfg.LeaderElectionLeaseDuration,
+		RenewDeadline: cfg.LeaderElectionRenewDeadline,
+		RetryPeriod:   cfg.LeaderElectionRetryPeriod,
+	}
+
+	leCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	started := make(chan struct{})
+	stopped := make(chan struct{})
+	controllersDone := make(chan struct{})
+	callbacks := leaderelection.Callbacks{
+		OnStartedLeading: func(context.Context) {
+			log.WithField("identity", identity).Info("Became leader, starting controllers")
+			s.SetLeader(true, identity)
+			close(started)
+			initControllers()
+			cc.RunControllers()
+			close(controllersDone)
+		},
+		OnStoppedLeading: func() {
+			select {
+			case <-started:
+				if leCtx.Err() == nil {
+					// The controllers can't be stopped cleanly, so exit and let the new
+					// leader take over.
+					log.Fatal("Lost leadership, exiting")
+				}
+			default:
+			}
+			close(stopped)
+		},
+		OnNewLeader: func(leader string) {
+			log.WithField("leader", leader).Info("Leader changed")
+			s.SetLeader(leader == identity, leader)
+			if cCtrlr != nil {
+				cCtrlr.SetLeader(leader == identity, leader)
+			}
+		},
+	}
+
+	var elector leaderelection.Elector
+	var err error
+	if cfg.DatastoreType == "etcdv3" {
+		var etcdClient *clientv3.Client
+		etcdClient, err = newEtcdV3Client()
+		if err != nil {
+			log.WithError(err).Fatal("Failed to create etcd client for leader election")
+		}
+		defer etcdClient.Close()
+		elector, err = leaderelection.NewEtcdElector(leCfg, etcdClient, callbacks)
+	} else {
+		elector, err = leaderelection.NewKubernetesElector(leCfg, k8sClientset, callbacks)
+	}
+	if err != nil {
+		log.WithError(err).Fatal("Failed to start leader election")
+	}
+
+	log.WithField("identity", identity).Info("Waiting to be elected leader")
+	s.SetLeader(false, "")
+	go elector.Run(leCtx)
+
+	select {
+	case <-started:
+		<-controllersDone
+	case <-cc.restart:
+		// The controllers haven't started, so we can just restart with the new config.
+		log.Warn("configuration changed; restarting")
+	case <-stopped:
+	}
+
+	// Release the lease.
+	cancel()
+	<-stopped
+}
+
 // Run the controller health checks.
 func runHealthChecks(ctx context.Context, s *status.Status, k8sClientset *kubernetes.Clientset, calicoClient client.Interface) {
 	s.SetReady("CalicoDatastore", false, "initialized to false")
@@ -454,6 +566,17 @@ func (cc *controllerControl) InitControllers(ctx context.Context, cfg config.Run
 		serviceAccountController := serviceaccount.NewServiceAccountController(ctx, k8sClientset, calicoClient, *cfg.Controllers.ServiceAccount)
 		cc.controllers["ServiceAccount"] = serviceAccountController
 	}
+	if cfg.Controllers.RouteReflector != nil {
+		routeReflectorController := routereflector.NewRouteReflectorController(ctx, calicoClient, *cfg.Controllers.RouteReflector, nodeInformer)
+		cc.controllers["RouteReflector"] = routeReflectorController
+		cc.registerInformers(nodeInformer)
+	}
+	if cfg.Controllers.LoadBalancer != nil {
+		serviceInformer := factory.Core().V1().Services().Informer()
+		loadBalancerController := loadbalancer.NewLoadBalancerController(ctx, k8sClientset, calicoClient, *cfg.Controllers.LoadBalancer, serviceInformer)
+		cc.controllers["LoadBalancer"] = loadBalancerController
+		cc.registerInformers(serviceInformer)
+	}
 }
 
 // registerInformers registers the given informers, if not already registered. Registered informers
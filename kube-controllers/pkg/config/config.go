@@ -15,6 +15,8 @@
 package config
 
 import (
+	"time"
+
 	"github.com/kelseyhightower/envconfig"
 )
 
@@ -28,6 +30,11 @@ const (
 	EnvAutoHostEndpoints  = "AUTO_HOST_ENDPOINTS"
 )
 
+const (
+	DefaultRouteReflectorZoneLabel = "topology.kubernetes.io/zone"
+	DefaultRouteReflectorsPerZone  = 3
+)
+
 var AllEnvs = []string{EnvLogLevel, EnvReconcilerPeriod, EnvEnabledControllers, EnvCompactionPeriod, EnvHealthEnabled, EnvSyncNodeLabels, EnvAutoHostEndpoints}
 
 // Config represents the configuration we load from the environment variables
@@ -46,6 +53,17 @@ type Config struct {
 
 	// etcdv3 or kubernetes
 	DatastoreType string `default:"etcdv3" split_words:"true"`
+
+	// Leader election between replicas.  When enabled, only the replica that holds the
+	// leader lease runs the controllers.  The lease is a Kubernetes Lease in
+	// LeaderElectionNamespace, or an etcd lease when using the etcdv3 datastore.
+	LeaderElection              bool          `default:"false" split_words:"true"`
+	LeaderElectionLeaseName     string        `default:"calico-kube-controllers" split_words:"true"`
+	LeaderElectionNamespace     string        `default:"kube-system" split_words:"true"`
+	LeaderElectionIdentity      string        `default:"" split_words:"true"`
+	LeaderElectionLeaseDuration time.Duration `default:"15s" split_words:"true"`
+	LeaderElectionRenewDeadline time.Duration `default:"10s" split_words:"true"`
+	LeaderElectionRetryPeriod   time.Duration `default:"2s" split_words:"true"`
 }
 
 // Parse parses envconfig and stores in Config struct
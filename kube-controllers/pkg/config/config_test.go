@@ -17,6 +17,8 @@ package config_test
 import (
 	"context"
 	"os"
+	"strconv"
+	"sync"
 	"time"
 
 	. "github.com/onsi/ginkgo"
@@ -218,10 +220,11 @@ var _ = Describe("Config", func() {
 
 				rc := runCfg.Controllers
 				Expect(rc.Node).To(Equal(&config.NodeControllerConfig{
-					SyncLabels:        false,
-					AutoHostEndpoints: true,
-					DeleteNodes:       true,
-					LeakGracePeriod:   &v1.Duration{Duration: 20 * time.Minute},
+					SyncLabels:                false,
+					AutoHostEndpoints:         true,
+					CreateDefaultHostEndpoint: true,
+					DeleteNodes:               true,
+					LeakGracePeriod:           &v1.Duration{Duration: 20 * time.Minute},
 				}))
 				Expect(rc.Policy).To(Equal(&config.GenericControllerConfig{
 					ReconcilerPeriod: time.Second * 30,
@@ -255,6 +258,55 @@ var _ = Describe("Config", func() {
 			})
 		})
 
+		Context("with host endpoint templates", func() {
+			var m *mockKCC
+			var ctrl *config.RunConfigController
+			var ctx context.Context
+			var cancel context.CancelFunc
+			var templates []v3.AutoHostEndpointTemplate
+
+			BeforeEach(func() {
+				templates = []v3.AutoHostEndpointTemplate{{
+					GenerateName:     "storage",
+					NodeSelector:     "has(storage)",
+					InterfacePattern: "^bond",
+					Labels:           map[string]string{"network": "storage"},
+				}}
+				kcc := v3.NewKubeControllersConfiguration()
+				kcc.Name = "default"
+				kcc.Spec = v3.KubeControllersConfigurationSpec{
+					Controllers: v3.ControllersConfig{
+						Node: &v3.NodeControllerConfig{
+							HostEndpoint: &v3.AutoHostEndpointConfig{
+								AutoCreate:                v3.Enabled,
+								CreateDefaultHostEndpoint: v3.Disabled,
+								Templates:                 templates,
+							},
+						},
+					},
+				}
+				m = &mockKCC{get: kcc}
+				ctx, cancel = context.WithCancel(context.Background())
+				ctrl = config.NewRunConfigController(ctx, *cfg, m)
+			})
+
+			AfterEach(func() {
+				cancel()
+			})
+
+			It("should pass the templates to the node controller", func(done Done) {
+				runCfg := <-ctrl.ConfigChan()
+				rc := runCfg.Controllers
+				Expect(rc.Node.AutoHostEndpoints).To(BeTrue())
+				Expect(rc.Node.CreateDefaultHostEndpoint).To(BeFalse())
+				Expect(rc.Node.HostEndpointTemplates).To(Equal(templates))
+
+				Expect(m.update).ToNot(BeNil())
+				Expect(m.update.Status.RunningConfig.Controllers.Node.HostEndpoint).To(Equal(m.get.Spec.Controllers.Node.HostEndpoint))
+				close(done)
+			})
+		})
+
 		Context("with no API values", func() {
 			var m *mockKCC
 			var ctrl *config.RunConfigController
@@ -441,10 +493,11 @@ var _ = Describe("Config", func() {
 
 				rc := runCfg.Controllers
 				Expect(rc.Node).To(Equal(&config.NodeControllerConfig{
-					SyncLabels:        false,
-					AutoHostEndpoints: true,
-					DeleteNodes:       true,
-					LeakGracePeriod:   &v1.Duration{Duration: 15 * time.Minute},
+					SyncLabels:                false,
+					AutoHostEndpoints:         true,
+					CreateDefaultHostEndpoint: true,
+					DeleteNodes:               true,
+					LeakGracePeriod:           &v1.Duration{Duration: 15 * time.Minute},
 				}))
 				Expect(rc.Policy).To(Equal(&config.GenericControllerConfig{
 					ReconcilerPeriod: time.Second * 105,
@@ -534,9 +587,10 @@ var _ = Describe("Config", func() {
 
 				rc := runCfg.Controllers
 				Expect(rc.Node).To(Equal(&config.NodeControllerConfig{
-					SyncLabels:        false,
-					AutoHostEndpoints: true,
-					DeleteNodes:       true,
+					SyncLabels:                false,
+					AutoHostEndpoints:         true,
+					CreateDefaultHostEndpoint: true,
+					DeleteNodes:               true,
 				}))
 				Expect(rc.Policy).To(Equal(&config.GenericControllerConfig{
 					ReconcilerPeriod: time.Second * 105,
@@ -651,8 +705,198 @@ var _ = Describe("Config", func() {
 			Expect(runCfg.Controllers.WorkloadEndpoint.ReconcilerPeriod).To(Equal(time.Second * 31))
 			Expect(runCfg.Controllers.Namespace.ReconcilerPeriod).To(Equal(time.Second * 32))
 			Expect(runCfg.Controllers.ServiceAccount.ReconcilerPeriod).To(Equal(time.Second * 33))
+			Expect(runCfg.Controllers.RouteReflector).To(BeNil())
+			close(done)
+		})
+	})
+
+	Context("with the route reflector controller enabled", func() {
+
+		BeforeEach(func() {
+			unsetEnv()
+		})
+
+		AfterEach(func() {
+			unsetEnv()
+		})
+
+		It("should use defaults for unset route reflector fields", func(done Done) {
+			cfg := new(config.Config)
+			err := cfg.Parse()
+			Expect(err).ToNot(HaveOccurred())
+			kcc := config.DefaultKCC.DeepCopy()
+			kcc.Spec.Controllers.RouteReflector = &v3.RouteReflectorControllerConfig{
+				NodeSelector: "has(rr-candidate)",
+			}
+			m := &mockKCC{get: kcc}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ctrl := config.NewRunConfigController(ctx, *cfg, m)
+			runCfg := <-ctrl.ConfigChan()
+			Expect(runCfg.Controllers.RouteReflector).To(Equal(&config.RouteReflectorControllerConfig{
+				ReconcilerPeriod:  time.Minute * 5,
+				ZoneLabel:         "topology.kubernetes.io/zone",
+				ReflectorsPerZone: 3,
+				NodeSelector:      "has(rr-candidate)",
+			}))
+
+			three := 3
+			Expect(m.update.Status.RunningConfig.Controllers.RouteReflector).To(Equal(&v3.RouteReflectorControllerConfig{
+				ZoneLabel:         "topology.kubernetes.io/zone",
+				ReflectorsPerZone: &three,
+				NodeSelector:      "has(rr-candidate)",
+			}))
+			close(done)
+		})
+
+		It("should use route reflector values from API", func(done Done) {
+			cfg := new(config.Config)
+			err := cfg.Parse()
+			Expect(err).ToNot(HaveOccurred())
+			two := 2
+			kcc := config.DefaultKCC.DeepCopy()
+			kcc.Spec.Controllers.RouteReflector = &v3.RouteReflectorControllerConfig{
+				ReconcilerPeriod:  &v1.Duration{Duration: time.Second * 34},
+				ZoneLabel:         "rack",
+				ReflectorsPerZone: &two,
+			}
+			m := &mockKCC{get: kcc}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ctrl := config.NewRunConfigController(ctx, *cfg, m)
+			runCfg := <-ctrl.ConfigChan()
+			Expect(runCfg.Controllers.RouteReflector).To(Equal(&config.RouteReflectorControllerConfig{
+				ReconcilerPeriod:  time.Second * 34,
+				ZoneLabel:         "rack",
+				ReflectorsPerZone: 2,
+			}))
+			close(done)
+		})
+	})
+
+	Context("with the LoadBalancer controller enabled", func() {
+
+		BeforeEach(func() {
+			unsetEnv()
+		})
+
+		AfterEach(func() {
+			unsetEnv()
+		})
+
+		It("should default to assigning addresses to all services", func(done Done) {
+			cfg := new(config.Config)
+			err := cfg.Parse()
+			Expect(err).ToNot(HaveOccurred())
+			kcc := config.DefaultKCC.DeepCopy()
+			kcc.Spec.Controllers.LoadBalancer = &v3.LoadBalancerControllerConfig{}
+			m := &mockKCC{get: kcc}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ctrl := config.NewRunConfigController(ctx, *cfg, m)
+			runCfg := <-ctrl.ConfigChan()
+			Expect(runCfg.Controllers.LoadBalancer).To(Equal(&config.LoadBalancerControllerConfig{
+				ReconcilerPeriod: time.Minute * 5,
+				AssignIPs:        v3.AllServices,
+			}))
+			Expect(m.update.Status.RunningConfig.Controllers.LoadBalancer).To(Equal(&v3.LoadBalancerControllerConfig{
+				AssignIPs: v3.AllServices,
+			}))
+			close(done)
+		})
+
+		It("should use LoadBalancer values from API", func(done Done) {
+			cfg := new(config.Config)
+			err := cfg.Parse()
+			Expect(err).ToNot(HaveOccurred())
+			kcc := config.DefaultKCC.DeepCopy()
+			kcc.Spec.Controllers.LoadBalancer = &v3.LoadBalancerControllerConfig{
+				ReconcilerPeriod: &v1.Duration{Duration: time.Second * 35},
+				AssignIPs:        v3.RequestedServicesOnly,
+			}
+			m := &mockKCC{get: kcc}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ctrl := config.NewRunConfigController(ctx, *cfg, m)
+			runCfg := <-ctrl.ConfigChan()
+			Expect(runCfg.Controllers.LoadBalancer).To(Equal(&config.LoadBalancerControllerConfig{
+				ReconcilerPeriod: time.Second * 35,
+				AssignIPs:        v3.RequestedServicesOnly,
+			}))
+			close(done)
+		})
+
+		It("should enable the LoadBalancer controller from the environment", func(done Done) {
+			Expect(os.Setenv("ENABLED_CONTROLLERS", "loadbalancer")).To(Succeed())
+			cfg := new(config.Config)
+			err := cfg.Parse()
+			Expect(err).ToNot(HaveOccurred())
+			m := &mockKCC{get: config.DefaultKCC.DeepCopy()}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ctrl := config.NewRunConfigController(ctx, *cfg, m)
+			runCfg := <-ctrl.ConfigChan()
+			Expect(runCfg.Controllers.Node).To(BeNil())
+			Expect(runCfg.Controllers.LoadBalancer).To(Equal(&config.LoadBalancerControllerConfig{
+				ReconcilerPeriod: time.Minute * 5,
+				AssignIPs:        v3.AllServices,
+			}))
+			close(done)
+		})
+	})
+
+	Context("with leader election", func() {
+		var cfg *config.Config
+
+		BeforeEach(func() {
+			unsetEnv()
+			cfg = new(config.Config)
+			Expect(cfg.Parse()).To(Succeed())
+		})
+
+		It("should not report leader election status when disabled", func(done Done) {
+			m := &mockKCC{get: config.DefaultKCC.DeepCopy()}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ctrl := config.NewRunConfigController(ctx, *cfg, m)
+			<-ctrl.ConfigChan()
+			Expect(m.update.Status.LeaderElection).To(BeNil())
 			close(done)
 		})
+
+		It("should report the current leader in the status", func() {
+			cfg.LeaderElection = true
+			m := &mockKCC{get: config.DefaultKCC.DeepCopy()}
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			ctrl := config.NewRunConfigController(ctx, *cfg, m)
+			<-ctrl.ConfigChan()
+			Expect(m.update).To(BeNil())
+
+			ctrl.SetLeader(true, "replica-1")
+			Eventually(func() *v3.LeaderElectionStatus {
+				if m.update == nil {
+					return nil
+				}
+				return m.update.Status.LeaderElection
+			}).Should(Equal(&v3.LeaderElectionStatus{Leader: "replica-1"}))
+		})
+
+		It("should only write the status from the leader", func() {
+			cfg.LeaderElection = true
+			kcc := newSharedKCC(config.DefaultKCC.DeepCopy())
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			leader := config.NewRunConfigController(ctx, *cfg, kcc.client("replica-1"))
+			standby := config.NewRunConfigController(ctx, *cfg, kcc.client("replica-2"))
+			<-leader.ConfigChan()
+			<-standby.ConfigChan()
+
+			leader.SetLeader(true, "replica-1")
+			standby.SetLeader(false, "replica-1")
+			Eventually(kcc.status).Should(Equal(&v3.LeaderElectionStatus{Leader: "replica-1"}))
+			Consistently(kcc.writers, "500ms").Should(Equal([]string{"replica-1"}))
+		})
 	})
 })
 
@@ -711,3 +955,76 @@ func (m *mockWatch) Stop() {
 func (m *mockWatch) ResultChan() <-chan watch.Event {
 	return m.r
 }
+
+// sharedKCC is a fake KubeControllersConfiguration store shared by several clients.  Each
+// update is sent to the watches of all of the clients, and the client that made it is
+// recorded.
+type sharedKCC struct {
+	lock     sync.Mutex
+	kcc      *v3.KubeControllersConfiguration
+	rev      int
+	watches  []chan watch.Event
+	updaters []string
+}
+
+func newSharedKCC(kcc *v3.KubeControllersConfiguration) *sharedKCC {
+	return &sharedKCC{kcc: kcc}
+}
+
+func (s *sharedKCC) client(name string) *sharedKCCClient {
+	return &sharedKCCClient{mockKCC: &mockKCC{}, store: s, name: name}
+}
+
+func (s *sharedKCC) status() *v3.LeaderElectionStatus {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.kcc.Status.LeaderElection
+}
+
+func (s *sharedKCC) writers() []string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]string(nil), s.updaters...)
+}
+
+type sharedKCCClient struct {
+	*mockKCC
+	store *sharedKCC
+	name  string
+}
+
+func (c *sharedKCCClient) Update(ctx context.Context, res *v3.KubeControllersConfiguration, opts options.SetOptions) (*v3.KubeControllersConfiguration, error) {
+	s := c.store
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.rev++
+	s.kcc = res.DeepCopy()
+	s.kcc.ResourceVersion = strconv.Itoa(s.rev)
+	s.updaters = append(s.updaters, c.name)
+	for _, w := range s.watches {
+		w <- watch.Event{Type: watch.Modified, Object: s.kcc.DeepCopy()}
+	}
+	return s.kcc.DeepCopy(), nil
+}
+
+func (c *sharedKCCClient) Get(ctx context.Context, name string, opts options.GetOptions) (*v3.KubeControllersConfiguration, error) {
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+	return c.store.kcc.DeepCopy(), nil
+}
+
+func (c *sharedKCCClient) List(ctx context.Context, opts options.ListOptions) (*v3.KubeControllersConfigurationList, error) {
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+	return &v3.KubeControllersConfigurationList{
+		Items: []v3.KubeControllersConfiguration{*c.store.kcc.DeepCopy()},
+	}, nil
+}
+
+func (c *sharedKCCClient) Watch(ctx context.Context, opts options.ListOptions) (watch.Interface, error) {
+	c.store.lock.Lock()
+	defer c.store.lock.Unlock()
+	w := make(chan watch.Event, 100)
+	c.store.watches = append(c.store.watches, w)
+	return &mockWatch{r: w}, nil
+}
@@ -19,6 +19,7 @@ import (
 	"reflect"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	v3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
@@ -33,8 +34,6 @@ import (
 	"github.com/projectcalico/calico/libcalico-go/lib/watch"
 )
 
-var title = cases.Title(language.English)
-
 // Export for testing purposes
 var DefaultKCC *v3.KubeControllersConfiguration
 
@@ -88,6 +87,8 @@ type ControllersConfig struct {
 	WorkloadEndpoint *GenericControllerConfig
 	ServiceAccount   *GenericControllerConfig
 	Namespace        *GenericControllerConfig
+	RouteReflector   *RouteReflectorControllerConfig
+	LoadBalancer     *LoadBalancerControllerConfig
 }
 
 type GenericControllerConfig struct {
@@ -99,6 +100,14 @@ type NodeControllerConfig struct {
 	SyncLabels        bool
 	AutoHostEndpoints bool
 
+	// Whether to create the "*" interface host endpoint for each node, when AutoHostEndpoints
+	// is enabled.
+	CreateDefaultHostEndpoint bool
+
+	// Templates used to create per-interface host endpoints, when AutoHostEndpoints is
+	// enabled.
+	HostEndpointTemplates []v3.AutoHostEndpointTemplate
+
 	// Should the Node controller delete Calico nodes?  Generally, this is
 	// true for etcdv3 datastores.
 	DeleteNodes bool
@@ -108,8 +117,35 @@ type NodeControllerConfig struct {
 	LeakGracePeriod *v1.Duration
 }
 
+type RouteReflectorControllerConfig struct {
+	ReconcilerPeriod time.Duration
+
+	// The node label used to group nodes into zones.
+	ZoneLabel string
+
+	// The number of route reflectors to select in each zone.
+	ReflectorsPerZone int
+
+	// Selector limiting the nodes that may become route reflectors.
+	NodeSelector string
+}
+
+type LoadBalancerControllerConfig struct {
+	ReconcilerPeriod time.Duration
+
+	// Which Services are assigned LoadBalancer addresses.
+	AssignIPs v3.AssignIPs
+}
+
 type RunConfigController struct {
 	out chan RunConfig
+
+	// The identity of the current leader, when leader election is enabled, whether this
+	// replica is the leader, and a channel used to signal that they have changed.
+	leaderLock    sync.Mutex
+	leader        string
+	isLeader      bool
+	leaderUpdates chan struct{}
 }
 
 // ConfigChan returns a channel that sends an initial config snapshot at start
@@ -124,12 +160,60 @@ func (r *RunConfigController) ConfigChan() <-chan RunConfig {
 // to push config out to the rest of the controllers.  It also handles setting the
 // KubeControllersConfiguration.Status with the current running configuration
 func NewRunConfigController(ctx context.Context, cfg Config, client clientv3.KubeControllersConfigurationInterface) *RunConfigController {
-	ctrl := &RunConfigController{out: make(chan RunConfig)}
-	go syncDatastore(ctx, cfg, client, ctrl.out)
+	ctrl := &RunConfigController{
+		out:           make(chan RunConfig),
+		leaderUpdates: make(chan struct{}, 1),
+	}
+	go ctrl.syncDatastore(ctx, cfg, client)
 	return ctrl
 }
 
-func syncDatastore(ctx context.Context, cfg Config, client clientv3.KubeControllersConfigurationInterface, out chan<- RunConfig) {
+// SetLeader records whether this replica is the leader and the identity of the
+// kube-controllers replica that currently holds the leader lease, so that it is reported in
+// the KubeControllersConfiguration.Status.  When leader election is enabled only the leader
+// writes the status; otherwise every replica would write it.
+func (r *RunConfigController) SetLeader(isLeader bool, identity string) {
+	r.leaderLock.Lock()
+	r.isLeader = isLeader
+	r.leader = identity
+	r.leaderLock.Unlock()
+
+	select {
+	case r.leaderUpdates <- struct{}{}:
+	default:
+		// An update is already pending.
+	}
+}
+
+func (r *RunConfigController) currentLeader() string {
+	r.leaderLock.Lock()
+	defer r.leaderLock.Unlock()
+	return r.leader
+}
+
+// shouldWriteStatus returns true if this replica should write the status, which is when
+// leader election is disabled or this replica is the leader.
+func (r *RunConfigController) shouldWriteStatus(cfg Config) bool {
+	if !cfg.LeaderElection {
+		return true
+	}
+	r.leaderLock.Lock()
+	defer r.leaderLock.Unlock()
+	return r.isLeader
+}
+
+// mergeStatus merges the config and returns the status to report, including the leader
+// election state.
+func (r *RunConfigController) mergeStatus(env map[string]string, cfg Config, apiCfg v3.KubeControllersConfigurationSpec) (RunConfig, v3.KubeControllersConfigurationStatus) {
+	new, status := mergeConfig(env, cfg, apiCfg)
+	if cfg.LeaderElection {
+		status.LeaderElection = &v3.LeaderElectionStatus{Leader: r.currentLeader()}
+	}
+	return new, status
+}
+
+func (r *RunConfigController) syncDatastore(ctx context.Context, cfg Config, client clientv3.KubeControllersConfigurationInterface) {
+	out := r.out
 	var snapshot *v3.KubeControllersConfiguration
 	var err error
 	var current RunConfig
@@ -170,17 +254,19 @@ MAINLOOP:
 
 		// Ok, we should now have a snapshot.  Combine it with the environment variable
 		// config to get the running config.
-		new, status := mergeConfig(env, cfg, snapshot.Spec)
+		new, status := r.mergeStatus(env, cfg, snapshot.Spec)
 
 		// Write the status back to the API datastore, so that end users can inspect the current
 		// running config.
-		snapshot.Status = status
-		snapshot, err = client.Update(ctx, snapshot, options.SetOptions{})
-		if err != nil {
-			log.WithError(err).Warn("unable to perform status update on KubeControllersConfiguration(default)")
-			snapshot = nil
-			time.Sleep(datastoreBackoff)
-			continue MAINLOOP
+		if r.shouldWriteStatus(cfg) && !reflect.DeepEqual(snapshot.Status, status) {
+			snapshot.Status = status
+			snapshot, err = client.Update(ctx, snapshot, options.SetOptions{})
+			if err != nil {
+				log.WithError(err).Warn("unable to perform status update on KubeControllersConfiguration(default)")
+				snapshot = nil
+				time.Sleep(datastoreBackoff)
+				continue MAINLOOP
+			}
 		}
 
 		// With the snapshot updated, get a list of
@@ -214,7 +300,32 @@ MAINLOOP:
 			continue MAINLOOP
 		}
 		defer w.Stop()
-		for e := range w.ResultChan() {
+		for {
+			var e watch.Event
+			var ok bool
+			select {
+			case <-ctx.Done():
+				return
+			case <-r.leaderUpdates:
+				// The leader has changed; update the status.
+				_, status = r.mergeStatus(env, cfg, snapshot.Spec)
+				if r.shouldWriteStatus(cfg) && !reflect.DeepEqual(snapshot.Status, status) {
+					snapshot.Status = status
+					snapshot, err = client.Update(ctx, snapshot, options.SetOptions{})
+					if err != nil {
+						log.WithError(err).Warn("unable to perform status update on KubeControllersConfiguration(default)")
+						snapshot = nil
+						time.Sleep(datastoreBackoff)
+						continue MAINLOOP
+					}
+				}
+				continue
+			case e, ok = <-w.ResultChan():
+				if !ok {
+					// Watch terminated; resync.
+					continue MAINLOOP
+				}
+			}
 			switch e.Type {
 			case watch.Error:
 				// Watch error; restart from beginning. Note that k8s watches terminate periodically but these
@@ -234,11 +345,11 @@ MAINLOOP:
 					continue
 				}
 				snapshot = newKCC
-				new, status = mergeConfig(env, cfg, snapshot.Spec)
+				new, status = r.mergeStatus(env, cfg, snapshot.Spec)
 
 				// Update the status, but only if it's different, otherwise
 				// our update will trigger a watch update in an infinite loop
-				if !reflect.DeepEqual(snapshot.Status, status) {
+				if r.shouldWriteStatus(cfg) && !reflect.DeepEqual(snapshot.Status, status) {
 					snapshot.Status = status
 					snapshot, err = client.Update(ctx, snapshot, options.SetOptions{})
 					if err != nil {
@@ -340,6 +451,16 @@ func mergeConfig(envVars map[string]string, envCfg Config, apiCfg v3.KubeControl
 		}
 	}
 
+	// There are no env vars for the route reflector topology, so always merge from the API config.
+	if rc.RouteReflector != nil {
+		mergeRouteReflector(&status, &rCfg, apiCfg)
+	}
+
+	// Likewise, there are no env vars for the LoadBalancer controller's settings.
+	if rc.LoadBalancer != nil {
+		mergeLoadBalancer(&status, &rCfg, apiCfg)
+	}
+
 	// Number of workers is not exposed on the API, so just use the envCfg for it
 	// NOTE: NodeController doesn't actually use number of workers config, so don't
 	//       bother setting it.
@@ -380,11 +501,57 @@ func mergeAutoHostEndpoints(envVars map[string]string, status *v3.KubeController
 	}
 	if rc.Node.AutoHostEndpoints {
 		sc.Node.HostEndpoint = &v3.AutoHostEndpointConfig{AutoCreate: v3.Enabled}
+
+		// The default host endpoint and templates can only be configured through the API.
+		rc.Node.CreateDefaultHostEndpoint = true
+		if ac.Node != nil && ac.Node.HostEndpoint != nil {
+			rc.Node.CreateDefaultHostEndpoint = ac.Node.HostEndpoint.CreateDefaultHostEndpoint != v3.Disabled
+			sc.Node.HostEndpoint.CreateDefaultHostEndpoint = ac.Node.HostEndpoint.CreateDefaultHostEndpoint
+			for _, t := range ac.Node.HostEndpoint.Templates {
+				rc.Node.HostEndpointTemplates = append(rc.Node.HostEndpointTemplates, *t.DeepCopy())
+				sc.Node.HostEndpoint.Templates = append(sc.Node.HostEndpoint.Templates, *t.DeepCopy())
+			}
+		}
 	} else {
 		sc.Node.HostEndpoint = &v3.AutoHostEndpointConfig{AutoCreate: v3.Disabled}
 	}
 }
 
+func mergeRouteReflector(status *v3.KubeControllersConfigurationStatus, rCfg *RunConfig, apiCfg v3.KubeControllersConfigurationSpec) {
+	// make these names shorter
+	rc := rCfg.Controllers.RouteReflector
+	sc := status.RunningConfig.Controllers.RouteReflector
+
+	rc.ZoneLabel = DefaultRouteReflectorZoneLabel
+	rc.ReflectorsPerZone = DefaultRouteReflectorsPerZone
+	if ac := apiCfg.Controllers.RouteReflector; ac != nil {
+		if ac.ZoneLabel != "" {
+			rc.ZoneLabel = ac.ZoneLabel
+		}
+		if ac.ReflectorsPerZone != nil && *ac.ReflectorsPerZone > 0 {
+			rc.ReflectorsPerZone = *ac.ReflectorsPerZone
+		}
+		rc.NodeSelector = ac.NodeSelector
+	}
+
+	sc.ZoneLabel = rc.ZoneLabel
+	n := rc.ReflectorsPerZone
+	sc.ReflectorsPerZone = &n
+	sc.NodeSelector = rc.NodeSelector
+}
+
+func mergeLoadBalancer(status *v3.KubeControllersConfigurationStatus, rCfg *RunConfig, apiCfg v3.KubeControllersConfigurationSpec) {
+	// make these names shorter
+	rc := rCfg.Controllers.LoadBalancer
+	sc := status.RunningConfig.Controllers.LoadBalancer
+
+	rc.AssignIPs = v3.AllServices
+	if ac := apiCfg.Controllers.LoadBalancer; ac != nil && ac.AssignIPs != "" {
+		rc.AssignIPs = ac.AssignIPs
+	}
+	sc.AssignIPs = rc.AssignIPs
+}
+
 func mergeSyncNodeLabels(envVars map[string]string, status *v3.KubeControllersConfigurationStatus, rCfg *RunConfig, apiCfg v3.KubeControllersConfigurationSpec, cfg Config) {
 	// make these names shorter
 	rc := &rCfg.Controllers
@@ -496,6 +663,14 @@ func mergeReconcilerPeriod(envVars map[string]string, status *v3.KubeControllers
 			rc.Namespace.ReconcilerPeriod = d
 			sc.Namespace.ReconcilerPeriod = &v1.Duration{Duration: d}
 		}
+		if rc.RouteReflector != nil {
+			rc.RouteReflector.ReconcilerPeriod = d
+			sc.RouteReflector.ReconcilerPeriod = &v1.Duration{Duration: d}
+		}
+		if rc.LoadBalancer != nil {
+			rc.LoadBalancer.ReconcilerPeriod = d
+			sc.LoadBalancer.ReconcilerPeriod = &v1.Duration{Duration: d}
+		}
 	}
 }
 
@@ -509,6 +684,8 @@ func mergeEnabledControllers(envVars map[string]string, status *v3.KubeControlle
 	w := ac.WorkloadEndpoint
 	s := ac.ServiceAccount
 	ns := ac.Namespace
+	rr := ac.RouteReflector
+	lb := ac.LoadBalancer
 
 	v, p := envVars[EnvEnabledControllers]
 	if p {
@@ -530,6 +707,12 @@ func mergeEnabledControllers(envVars map[string]string, status *v3.KubeControlle
 			case "serviceaccount":
 				rc.ServiceAccount = &GenericControllerConfig{}
 				sc.ServiceAccount = &v3.ServiceAccountControllerConfig{}
+			case "routereflector":
+				rc.RouteReflector = &RouteReflectorControllerConfig{}
+				sc.RouteReflector = &v3.RouteReflectorControllerConfig{}
+			case "loadbalancer":
+				rc.LoadBalancer = &LoadBalancerControllerConfig{}
+				sc.LoadBalancer = &v3.LoadBalancerControllerConfig{}
 			case "flannelmigration":
 				log.WithField(EnvEnabledControllers, v).Fatal("cannot run flannelmigration with other controllers")
 			default:
@@ -568,6 +751,16 @@ func mergeEnabledControllers(envVars map[string]string, status *v3.KubeControlle
 			rc.Namespace = &GenericControllerConfig{}
 			sc.Namespace = &v3.NamespaceControllerConfig{}
 		}
+
+		if rr != nil {
+			rc.RouteReflector = &RouteReflectorControllerConfig{}
+			sc.RouteReflector = &v3.RouteReflectorControllerConfig{}
+		}
+
+		if lb != nil {
+			rc.LoadBalancer = &LoadBalancerControllerConfig{}
+			sc.LoadBalancer = &v3.LoadBalancerControllerConfig{}
+		}
 	}
 
 	// Set reconciler periods, if enabled
@@ -603,6 +796,26 @@ func mergeEnabledControllers(envVars map[string]string, status *v3.KubeControlle
 		}
 		sc.ServiceAccount.ReconcilerPeriod = s.ReconcilerPeriod
 	}
+	if rc.RouteReflector != nil {
+		if rr == nil || rr.ReconcilerPeriod == nil {
+			rc.RouteReflector.ReconcilerPeriod = time.Minute * 5
+		} else {
+			rc.RouteReflector.ReconcilerPeriod = rr.ReconcilerPeriod.Duration
+		}
+		if rr != nil {
+			sc.RouteReflector.ReconcilerPeriod = rr.ReconcilerPeriod
+		}
+	}
+	if rc.LoadBalancer != nil {
+		if lb == nil || lb.ReconcilerPeriod == nil {
+			rc.LoadBalancer.ReconcilerPeriod = time.Minute * 5
+		} else {
+			rc.LoadBalancer.ReconcilerPeriod = lb.ReconcilerPeriod.Duration
+		}
+		if lb != nil {
+			sc.LoadBalancer.ReconcilerPeriod = lb.ReconcilerPeriod
+		}
+	}
 }
 
 func mergeLogLevel(envVars map[string]string, status *v3.KubeControllersConfigurationStatus, rCfg *RunConfig, apiCfg v3.KubeControllersConfigurationSpec) {
@@ -626,5 +839,6 @@ func mergeLogLevel(envVars map[string]string, status *v3.KubeControllersConfigur
 			rCfg.LogLevelScreen = log.InfoLevel
 		}
 	}
-	status.RunningConfig.LogSeverityScreen = title.String(rCfg.LogLevelScreen.String())
+	// A Caser can't be shared between goroutines, so create one for each call.
+	status.RunningConfig.LogSeverityScreen = cases.Title(language.English).String(rCfg.LogLevelScreen.String())
 }
@@ -0,0 +1,160 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadbalancer
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	v1 "k8s.io/api/core/v1"
+
+	cnet "github.com/projectcalico/calico/libcalico-go/lib/net"
+)
+
+const (
+	// LoadBalancerIPsAnnotation may be set on a Service to request specific LoadBalancer addresses. The
+	// value is a JSON list of IP addresses, with at most one address per IP family, for example
+	// '["10.10.0.1", "fd00:10::1"]'. It takes precedence over the deprecated spec.loadBalancerIP field.
+	LoadBalancerIPsAnnotation = "projectcalico.org/loadBalancerIPs"
+
+	// AllocatedCondition is the type of the condition the controller maintains on the Services it manages.
+	AllocatedCondition = "projectcalico.org/LoadBalancerIPsAllocated"
+
+	// handlePrefix prefixes the IPAM handles of LoadBalancer allocations. The full handle
+	// is "lb.<namespace>.<name>"; neither part can contain a '.', so the handle can be split
+	// back into the Service's namespace and name.
+	handlePrefix = "lb."
+)
+
+// handleForService returns the IPAM handle used for the given Service's addresses.
+func handleForService(namespace, name string) string {
+	return handlePrefix + namespace + "." + name
+}
+
+// serviceForHandle returns the namespace and name of the Service owning the given IPAM handle. It
+// returns false if the handle was not created by this controller.
+func serviceForHandle(handle string) (string, string, bool) {
+	if !strings.HasPrefix(handle, handlePrefix) {
+		return "", "", false
+	}
+	parts := strings.Split(strings.TrimPrefix(handle, handlePrefix), ".")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", false
+	}
+	return parts[0], parts[1], true
+}
+
+// requestedIPs returns the addresses requested by the Service, if any.
+func requestedIPs(svc *v1.Service) ([]cnet.IP, error) {
+	var addrs []string
+	if v, ok := svc.Annotations[LoadBalancerIPsAnnotation]; ok {
+		if err := json.Unmarshal([]byte(v), &addrs); err != nil {
+			return nil, fmt.Errorf("failed to parse annotation %s: %w", LoadBalancerIPsAnnotation, err)
+		}
+	} else if svc.Spec.LoadBalancerIP != "" {
+		addrs = []string{svc.Spec.LoadBalancerIP}
+	}
+
+	var ips []cnet.IP
+	seen := map[int]bool{}
+	for _, a := range addrs {
+		ip := cnet.ParseIP(a)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address %q", a)
+		}
+		if seen[ip.Version()] {
+			return nil, fmt.Errorf("more than one IPv%d address requested", ip.Version())
+		}
+		seen[ip.Version()] = true
+		ips = append(ips, *ip)
+	}
+	return ips, nil
+}
+
+// wantsAddresses returns true if the controller should assign addresses to the given Service.
+func wantsAddresses(svc *v1.Service, assign api.AssignIPs) bool {
+	if svc.Spec.Type != v1.ServiceTypeLoadBalancer {
+		return false
+	}
+	if svc.Spec.LoadBalancerClass != nil {
+		// Another LoadBalancer implementation is responsible for this Service.
+		return false
+	}
+	if assign == api.RequestedServicesOnly {
+		_, annotated := svc.Annotations[LoadBalancerIPsAnnotation]
+		return annotated || svc.Spec.LoadBalancerIP != ""
+	}
+	return true
+}
+
+// ipVersions returns the IP versions the Service needs an address for. Services without any
+// IP families set, e.g. because they were created before dual-stack support, are IPv4 only.
+func ipVersions(svc *v1.Service) []int {
+	if len(svc.Spec.IPFamilies) == 0 {
+		return []int{4}
+	}
+	var versions []int
+	for _, f := range svc.Spec.IPFamilies {
+		switch f {
+		case v1.IPv4Protocol:
+			versions = append(versions, 4)
+		case v1.IPv6Protocol:
+			versions = append(versions, 6)
+		}
+	}
+	return versions
+}
+
+// inLoadBalancerPool returns true if the given address is within an enabled pool that allows LoadBalancer use.
+func inLoadBalancerPool(ip cnet.IP, pools []api.IPPool) bool {
+	for _, p := range pools {
+		if p.Spec.Disabled || !allowsLoadBalancer(p) {
+			continue
+		}
+		_, cidr, err := cnet.ParseCIDR(p.Spec.CIDR)
+		if err == nil && cidr.Contains(ip.IP) {
+			return true
+		}
+	}
+	return false
+}
+
+func allowsLoadBalancer(p api.IPPool) bool {
+	for _, u := range p.Spec.AllowedUses {
+		if u == api.IPPoolAllowedUseLoadBalancer {
+			return true
+		}
+	}
+	return false
+}
+
+// ingressForIPs returns the LoadBalancer ingress status for the given addresses, IPv4 first.
+func ingressForIPs(ips []cnet.IP) []v1.LoadBalancerIngress {
+	sorted := append([]cnet.IP(nil), ips...)
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Version() != sorted[j].Version() {
+			return sorted[i].Version() < sorted[j].Version()
+		}
+		return sorted[i].String() < sorted[j].String()
+	})
+	var ingress []v1.LoadBalancerIngress
+	for _, ip := range sorted {
+		ingress = append(ingress, v1.LoadBalancerIngress{IP: ip.String()})
+	}
+	return ingress
+}
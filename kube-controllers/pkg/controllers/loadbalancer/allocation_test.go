@@ -0,0 +1,125 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadbalancer
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	cnet "github.com/projectcalico/calico/libcalico-go/lib/net"
+)
+
+func lbService(annotations map[string]string, families ...v1.IPFamily) *v1.Service {
+	return &v1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "my-service", Annotations: annotations},
+		Spec: v1.ServiceSpec{
+			Type:       v1.ServiceTypeLoadBalancer,
+			IPFamilies: families,
+		},
+	}
+}
+
+var _ = Describe("LoadBalancer address allocation", func() {
+	It("should round trip service handles", func() {
+		h := handleForService("kube-system", "dns")
+		Expect(h).To(Equal("lb.kube-system.dns"))
+		ns, name, ok := serviceForHandle(h)
+		Expect(ok).To(BeTrue())
+		Expect(ns).To(Equal("kube-system"))
+		Expect(name).To(Equal("dns"))
+	})
+
+	DescribeTable("should only claim handles it created",
+		func(handle string) {
+			_, _, ok := serviceForHandle(handle)
+			Expect(ok).To(BeFalse())
+		},
+		Entry("pod handle", "k8s-pod-network.abcdef"),
+		Entry("missing name", "lb.default"),
+		Entry("empty name", "lb.default."),
+		Entry("too many parts", "lb.default.a.b"),
+	)
+
+	It("should parse requested addresses from the annotation", func() {
+		svc := lbService(map[string]string{LoadBalancerIPsAnnotation: `["10.0.0.1", "fd00::1"]`})
+		svc.Spec.LoadBalancerIP = "10.0.0.2"
+		ips, err := requestedIPs(svc)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ips).To(Equal([]cnet.IP{cnet.MustParseIP("10.0.0.1"), cnet.MustParseIP("fd00::1")}))
+	})
+
+	It("should fall back to spec.loadBalancerIP", func() {
+		svc := lbService(nil)
+		svc.Spec.LoadBalancerIP = "10.0.0.2"
+		ips, err := requestedIPs(svc)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(ips).To(Equal([]cnet.IP{cnet.MustParseIP("10.0.0.2")}))
+	})
+
+	DescribeTable("should reject invalid requests",
+		func(value string) {
+			_, err := requestedIPs(lbService(map[string]string{LoadBalancerIPsAnnotation: value}))
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("not a list", `10.0.0.1`),
+		Entry("invalid address", `["10.0.0.300"]`),
+		Entry("two addresses of one family", `["10.0.0.1", "10.0.0.2"]`),
+	)
+
+	It("should only want addresses for LoadBalancer services without a class", func() {
+		svc := lbService(nil)
+		Expect(wantsAddresses(svc, api.AllServices)).To(BeTrue())
+		Expect(wantsAddresses(svc, api.RequestedServicesOnly)).To(BeFalse())
+
+		class := "example.com/lb"
+		svc.Spec.LoadBalancerClass = &class
+		Expect(wantsAddresses(svc, api.AllServices)).To(BeFalse())
+
+		svc = lbService(map[string]string{LoadBalancerIPsAnnotation: `["10.0.0.1"]`})
+		Expect(wantsAddresses(svc, api.RequestedServicesOnly)).To(BeTrue())
+
+		svc.Spec.Type = v1.ServiceTypeClusterIP
+		Expect(wantsAddresses(svc, api.AllServices)).To(BeFalse())
+	})
+
+	It("should want an address per IP family", func() {
+		Expect(ipVersions(lbService(nil))).To(Equal([]int{4}))
+		Expect(ipVersions(lbService(nil, v1.IPv6Protocol, v1.IPv4Protocol))).To(Equal([]int{6, 4}))
+	})
+
+	It("should only accept requested addresses from LoadBalancer pools", func() {
+		pool := func(cidr string, disabled bool, uses ...api.IPPoolAllowedUse) api.IPPool {
+			return api.IPPool{Spec: api.IPPoolSpec{CIDR: cidr, Disabled: disabled, AllowedUses: uses}}
+		}
+		pools := []api.IPPool{
+			pool("10.0.0.0/24", false, api.IPPoolAllowedUseWorkload),
+			pool("10.1.0.0/24", false, api.IPPoolAllowedUseLoadBalancer),
+			pool("10.2.0.0/24", true, api.IPPoolAllowedUseLoadBalancer),
+		}
+		Expect(inLoadBalancerPool(cnet.MustParseIP("10.0.0.1"), pools)).To(BeFalse())
+		Expect(inLoadBalancerPool(cnet.MustParseIP("10.1.0.1"), pools)).To(BeTrue())
+		Expect(inLoadBalancerPool(cnet.MustParseIP("10.2.0.1"), pools)).To(BeFalse())
+		Expect(inLoadBalancerPool(cnet.MustParseIP("10.3.0.1"), pools)).To(BeFalse())
+	})
+
+	It("should report IPv4 ingress addresses first", func() {
+		ingress := ingressForIPs([]cnet.IP{cnet.MustParseIP("fd00::1"), cnet.MustParseIP("10.0.0.1")})
+		Expect(ingress).To(Equal([]v1.LoadBalancerIngress{{IP: "10.0.0.1"}, {IP: "fd00::1"}}))
+	})
+})
@@ -0,0 +1,391 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadbalancer
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	uruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/projectcalico/calico/kube-controllers/pkg/config"
+	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/controller"
+	bapi "github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/errors"
+	"github.com/projectcalico/calico/libcalico-go/lib/ipam"
+	cnet "github.com/projectcalico/calico/libcalico-go/lib/net"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+)
+
+// loadBalancerController implements the Controller interface. It assigns addresses from the IP pools
+// with the LoadBalancer allowed use to Services of type LoadBalancer, and reports them in the Service status.
+type loadBalancerController struct {
+	ctx             context.Context
+	cfg             config.LoadBalancerControllerConfig
+	k8sClientset    kubernetes.Interface
+	calicoClient    client.Interface
+	serviceInformer cache.SharedIndexInformer
+	queue           workqueue.RateLimitingInterface
+}
+
+// NewLoadBalancerController returns a controller which manages LoadBalancer addresses for Services.
+func NewLoadBalancerController(
+	ctx context.Context,
+	k8sClientset kubernetes.Interface,
+	calicoClient client.Interface,
+	cfg config.LoadBalancerControllerConfig,
+	serviceInformer cache.SharedIndexInformer,
+) controller.Controller {
+	c := &loadBalancerController{
+		ctx:             ctx,
+		cfg:             cfg,
+		k8sClientset:    k8sClientset,
+		calicoClient:    calicoClient,
+		serviceInformer: serviceInformer,
+		queue:           workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter()),
+	}
+
+	handlers := cache.ResourceEventHandlerFuncs{
+		AddFunc:    c.enqueue,
+		UpdateFunc: func(_, obj interface{}) { c.enqueue(obj) },
+		DeleteFunc: c.enqueue,
+	}
+	if _, err := serviceInformer.AddEventHandler(handlers); err != nil {
+		log.WithError(err).Error("failed to add event handler for service")
+		return nil
+	}
+	return c
+}
+
+func (c *loadBalancerController) enqueue(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		log.WithError(err).Error("Failed to generate key for service")
+		return
+	}
+	c.queue.Add(key)
+}
+
+// Run starts the controller.
+func (c *loadBalancerController) Run(stopCh chan struct{}) {
+	defer uruntime.HandleCrash()
+	defer c.queue.ShutDown()
+
+	log.Info("Starting LoadBalancer controller")
+
+	// Wait till k8s cache is synced
+	log.Debug("Waiting to sync with Kubernetes API (Services)")
+	if !cache.WaitForNamedCacheSync("services", stopCh, c.serviceInformer.HasSynced) {
+		log.Info("Failed to sync resources, received signal for controller to shut down.")
+		return
+	}
+	log.Debug("Finished syncing with Kubernetes API (Services)")
+
+	// Service events are only delivered while the controller is running, so periodically look for
+	// allocations belonging to Services that were deleted in the meantime.
+	go wait.Until(c.enqueueOrphans, c.cfg.ReconcilerPeriod, stopCh)
+	go wait.Until(c.runWorker, time.Second, stopCh)
+
+	log.Info("LoadBalancer controller is now running")
+	<-stopCh
+	log.Info("Stopping LoadBalancer controller")
+}
+
+func (c *loadBalancerController) runWorker() {
+	for c.processNextItem() {
+	}
+}
+
+func (c *loadBalancerController) processNextItem() bool {
+	key, quit := c.queue.Get()
+	if quit {
+		return false
+	}
+	defer c.queue.Done(key)
+
+	if err := c.syncService(key.(string)); err != nil {
+		log.WithError(err).WithField("service", key).Warn("Error syncing LoadBalancer addresses, will retry")
+		c.queue.AddRateLimited(key)
+		return true
+	}
+	c.queue.Forget(key)
+	return true
+}
+
+// enqueueOrphans queues every Service that has LoadBalancer addresses allocated, so that the addresses
+// of Services that no longer exist are released.
+func (c *loadBalancerController) enqueueOrphans() {
+	bc, ok := c.calicoClient.(interface{ Backend() bapi.Client })
+	if !ok {
+		log.Warn("Calico client does not expose the backend, unable to check for leaked LoadBalancer addresses")
+		return
+	}
+	handles, err := bc.Backend().List(c.ctx, model.IPAMHandleListOptions{}, "")
+	if err != nil {
+		log.WithError(err).Warn("Failed to list IPAM handles")
+		return
+	}
+	for _, kvp := range handles.KVPairs {
+		ns, name, ok := serviceForHandle(kvp.Key.(model.IPAMHandleKey).HandleID)
+		if ok {
+			c.queue.Add(ns + "/" + name)
+		}
+	}
+}
+
+// syncService makes the LoadBalancer addresses allocated to the given Service match what it needs.
+func (c *loadBalancerController) syncService(key string) error {
+	ns, name, err := cache.SplitMetaNamespaceKey(key)
+	if err != nil {
+		return err
+	}
+	handle := handleForService(ns, name)
+	clog := log.WithField("service", key)
+
+	obj, exists, err := c.serviceInformer.GetIndexer().GetByKey(key)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		clog.Debug("Service deleted, releasing LoadBalancer addresses")
+		return c.releaseHandle(handle)
+	}
+	svc := obj.(*v1.Service)
+
+	if !wantsAddresses(svc, c.cfg.AssignIPs) {
+		if err := c.releaseHandle(handle); err != nil {
+			return err
+		}
+		return c.updateStatus(svc, nil, nil)
+	}
+
+	current, err := c.calicoClient.IPAM().IPsByHandle(c.ctx, handle)
+	if _, ok := err.(errors.ErrorResourceDoesNotExist); err != nil && !ok {
+		return err
+	}
+
+	requested, err := requestedIPs(svc)
+	if err != nil {
+		// The request can't be satisfied until the Service is fixed, so there is no point retrying.
+		clog.WithError(err).Warn("Invalid LoadBalancer address request")
+		return c.updateStatus(svc, current, &metav1.Condition{
+			Type:    AllocatedCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  "InvalidRequest",
+			Message: err.Error(),
+		})
+	}
+
+	var assigned []cnet.IP
+	if len(requested) > 0 {
+		assigned, err = c.assignRequested(svc, handle, current, requested)
+	} else {
+		assigned, err = c.autoAssign(svc, handle, current)
+	}
+	if err != nil {
+		clog.WithError(err).Warn("Failed to assign LoadBalancer addresses")
+		if serr := c.updateStatus(svc, assigned, &metav1.Condition{
+			Type:    AllocatedCondition,
+			Status:  metav1.ConditionFalse,
+			Reason:  "AllocationFailed",
+			Message: err.Error(),
+		}); serr != nil {
+			clog.WithError(serr).Warn("Failed to update Service status")
+		}
+		return err
+	}
+
+	return c.updateStatus(svc, assigned, &metav1.Condition{
+		Type:    AllocatedCondition,
+		Status:  metav1.ConditionTrue,
+		Reason:  "Allocated",
+		Message: fmt.Sprintf("Assigned %d LoadBalancer address(es) from Calico IP pools", len(assigned)),
+	})
+}
+
+// assignRequested assigns the requested addresses to the Service and releases any others it holds. It
+// returns the addresses that are assigned to the Service.
+func (c *loadBalancerController) assignRequested(svc *v1.Service, handle string, current, requested []cnet.IP) ([]cnet.IP, error) {
+	wanted := map[string]bool{}
+	for _, ip := range requested {
+		wanted[ip.String()] = true
+	}
+	have := map[string]bool{}
+	var assigned, unwanted []cnet.IP
+	for _, ip := range current {
+		if wanted[ip.String()] {
+			have[ip.String()] = true
+			assigned = append(assigned, ip)
+		} else {
+			unwanted = append(unwanted, ip)
+		}
+	}
+	if err := c.releaseIPs(handle, unwanted); err != nil {
+		return assigned, err
+	}
+
+	pools, err := c.calicoClient.IPPools().List(c.ctx, options.ListOptions{})
+	if err != nil {
+		return assigned, err
+	}
+	for _, ip := range requested {
+		if have[ip.String()] {
+			continue
+		}
+		if !inLoadBalancerPool(ip, pools.Items) {
+			return assigned, fmt.Errorf("requested address %s is not within an IP pool that allows LoadBalancer use", ip)
+		}
+		err := c.calicoClient.IPAM().AssignIP(c.ctx, ipam.AssignIPArgs{
+			IP:       ip,
+			HandleID: &handle,
+			Attrs:    attrsForService(svc),
+			Hostname: ipam.LoadBalancerHost,
+		})
+		if err != nil {
+			return assigned, fmt.Errorf("failed to assign requested address %s: %w", ip, err)
+		}
+		log.WithFields(log.Fields{"service": svc.Namespace + "/" + svc.Name, "ip": ip}).Info("Assigned requested LoadBalancer address")
+		assigned = append(assigned, ip)
+	}
+	return assigned, nil
+}
+
+// autoAssign makes sure the Service has one address for each of its IP families, assigning new addresses
+// from the LoadBalancer pools as needed and releasing those of families the Service no longer uses. It
+// returns the addresses that are assigned to the Service.
+func (c *loadBalancerController) autoAssign(svc *v1.Service, handle string, current []cnet.IP) ([]cnet.IP, error) {
+	wanted := map[int]bool{}
+	for _, v := range ipVersions(svc) {
+		wanted[v] = true
+	}
+	have := map[int]bool{}
+	var assigned, unwanted []cnet.IP
+	for _, ip := range current {
+		if wanted[ip.Version()] && !have[ip.Version()] {
+			have[ip.Version()] = true
+			assigned = append(assigned, ip)
+		} else {
+			unwanted = append(unwanted, ip)
+		}
+	}
+	if err := c.releaseIPs(handle, unwanted); err != nil {
+		return assigned, err
+	}
+
+	args := ipam.AutoAssignArgs{
+		HandleID:    &handle,
+		Attrs:       attrsForService(svc),
+		Hostname:    ipam.LoadBalancerHost,
+		IntendedUse: api.IPPoolAllowedUseLoadBalancer,
+	}
+	if wanted[4] && !have[4] {
+		args.Num4 = 1
+	}
+	if wanted[6] && !have[6] {
+		args.Num6 = 1
+	}
+	if args.Num4 == 0 && args.Num6 == 0 {
+		return assigned, nil
+	}
+
+	v4, v6, err := c.calicoClient.IPAM().AutoAssign(c.ctx, args)
+	for _, a := range []*ipam.IPAMAssignments{v4, v6} {
+		if a == nil {
+			continue
+		}
+		for _, n := range a.IPs {
+			log.WithFields(log.Fields{"service": svc.Namespace + "/" + svc.Name, "ip": n.IP}).Info("Assigned LoadBalancer address")
+			assigned = append(assigned, cnet.IP{IP: n.IP})
+		}
+	}
+	if err != nil {
+		return assigned, err
+	}
+	if v4 != nil && len(v4.IPs) < args.Num4 {
+		return assigned, fmt.Errorf("no IPv4 addresses available in LoadBalancer IP pools")
+	}
+	if v6 != nil && len(v6.IPs) < args.Num6 {
+		return assigned, fmt.Errorf("no IPv6 addresses available in LoadBalancer IP pools")
+	}
+	return assigned, nil
+}
+
+func (c *loadBalancerController) releaseIPs(handle string, ips []cnet.IP) error {
+	if len(ips) == 0 {
+		return nil
+	}
+	var opts []ipam.ReleaseOptions
+	for _, ip := range ips {
+		log.WithFields(log.Fields{"handle": handle, "ip": ip}).Info("Releasing LoadBalancer address")
+		opts = append(opts, ipam.ReleaseOptions{Address: ip.String(), Handle: handle})
+	}
+	_, err := c.calicoClient.IPAM().ReleaseIPs(c.ctx, opts...)
+	return err
+}
+
+func (c *loadBalancerController) releaseHandle(handle string) error {
+	err := c.calicoClient.IPAM().ReleaseByHandle(c.ctx, handle)
+	if _, ok := err.(errors.ErrorResourceDoesNotExist); err != nil && !ok {
+		return err
+	}
+	if err == nil {
+		log.WithField("handle", handle).Info("Released LoadBalancer addresses")
+	}
+	return nil
+}
+
+// updateStatus reports the given addresses and condition on the Service. A nil condition removes the
+// controller's condition, and is used once the controller no longer manages the Service.
+func (c *loadBalancerController) updateStatus(svc *v1.Service, ips []cnet.IP, cond *metav1.Condition) error {
+	if cond == nil && meta.FindStatusCondition(svc.Status.Conditions, AllocatedCondition) == nil {
+		// We never managed this Service, so leave its status alone in case another
+		// implementation is responsible for it.
+		return nil
+	}
+
+	updated := svc.DeepCopy()
+	updated.Status.LoadBalancer.Ingress = ingressForIPs(ips)
+	if cond != nil {
+		cond.ObservedGeneration = svc.Generation
+		meta.SetStatusCondition(&updated.Status.Conditions, *cond)
+	} else {
+		meta.RemoveStatusCondition(&updated.Status.Conditions, AllocatedCondition)
+	}
+	if reflect.DeepEqual(svc.Status, updated.Status) {
+		return nil
+	}
+	_, err := c.k8sClientset.CoreV1().Services(svc.Namespace).UpdateStatus(c.ctx, updated, metav1.UpdateOptions{})
+	return err
+}
+
+func attrsForService(svc *v1.Service) map[string]string {
+	return map[string]string{
+		ipam.AttributeNamespace: svc.Namespace,
+		ipam.AttributeService:   svc.Name,
+	}
+}
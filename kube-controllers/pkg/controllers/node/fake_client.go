@@ -19,6 +19,8 @@ import (
 	"strings"
 	"sync"
 
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+
 	apiv3 "github.com/projectcalico/calico/libcalico-go/lib/apis/v3"
 	bapi "github.com/projectcalico/calico/libcalico-go/lib/backend/api"
 	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
@@ -38,16 +40,21 @@ func NewFakeCalicoClient() *FakeCalicoClient {
 		affinitiesReleased: make(map[string]bool),
 		handlesReleased:    make(map[string]bool),
 	}
+	hc := fakeHostEndpointClient{
+		heps: make(map[string]*api.HostEndpoint),
+	}
 	return &FakeCalicoClient{
 		nodeClient: &nc,
 		ipamClient: &ipamClient,
+		hepClient:  &hc,
 	}
 }
 
-// FakeCalicoClient is a fake client for use in the IPAM tests.
+// FakeCalicoClient is a fake client for use in the IPAM and host endpoint tests.
 type FakeCalicoClient struct {
 	nodeClient clientv3.NodeInterface
 	ipamClient ipam.Interface
+	hepClient  clientv3.HostEndpointInterface
 }
 
 // Tiers returns an interface for managing tier resources.
@@ -55,6 +62,10 @@ func (f *FakeCalicoClient) Tiers() clientv3.TierInterface {
 	panic("not implemented") // TODO: Implement
 }
 
+func (f *FakeCalicoClient) Transaction() clientv3.Transaction {
+	panic("not implemented") // TODO: Implement
+}
+
 func (f *FakeCalicoClient) Backend() bapi.Client {
 	return nil
 }
@@ -96,7 +107,7 @@ func (f *FakeCalicoClient) NetworkSets() clientv3.NetworkSetInterface {
 
 // HostEndpoints returns an interface for managing host endpoint resources.
 func (f *FakeCalicoClient) HostEndpoints() clientv3.HostEndpointInterface {
-	panic("not implemented")
+	return f.hepClient
 }
 
 // WorkloadEndpoints returns an interface for managing workload endpoint resources.
@@ -209,6 +220,72 @@ func (f *fakeNodeClient) Watch(ctx context.Context, opts options.ListOptions) (w
 	panic("not implemented") // TODO: Implement
 }
 
+// fakeHostEndpointClient implements the clientv3 HostEndpointInterface for testing purposes.
+type fakeHostEndpointClient struct {
+	sync.Mutex
+	heps map[string]*api.HostEndpoint
+}
+
+func (f *fakeHostEndpointClient) Create(ctx context.Context, res *api.HostEndpoint, opts options.SetOptions) (*api.HostEndpoint, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	if _, ok := f.heps[res.Name]; ok {
+		return nil, cerrors.ErrorResourceAlreadyExists{Identifier: res.Name}
+	}
+	f.heps[res.Name] = res.DeepCopy()
+	return res, nil
+}
+
+func (f *fakeHostEndpointClient) Update(ctx context.Context, res *api.HostEndpoint, opts options.SetOptions) (*api.HostEndpoint, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	if _, ok := f.heps[res.Name]; !ok {
+		return nil, cerrors.ErrorResourceDoesNotExist{Identifier: res.Name}
+	}
+	f.heps[res.Name] = res.DeepCopy()
+	return res, nil
+}
+
+func (f *fakeHostEndpointClient) Delete(ctx context.Context, name string, opts options.DeleteOptions) (*api.HostEndpoint, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	hep, ok := f.heps[name]
+	if !ok {
+		return nil, cerrors.ErrorResourceDoesNotExist{Identifier: name}
+	}
+	delete(f.heps, name)
+	return hep, nil
+}
+
+func (f *fakeHostEndpointClient) Get(ctx context.Context, name string, opts options.GetOptions) (*api.HostEndpoint, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	hep, ok := f.heps[name]
+	if !ok {
+		return nil, cerrors.ErrorResourceDoesNotExist{Identifier: name}
+	}
+	return hep.DeepCopy(), nil
+}
+
+func (f *fakeHostEndpointClient) List(ctx context.Context, opts options.ListOptions) (*api.HostEndpointList, error) {
+	f.Lock()
+	defer f.Unlock()
+
+	list := &api.HostEndpointList{}
+	for _, hep := range f.heps {
+		list.Items = append(list.Items, *hep.DeepCopy())
+	}
+	return list, nil
+}
+
+func (f *fakeHostEndpointClient) Watch(ctx context.Context, opts options.ListOptions) (watch.Interface, error) {
+	panic("not implemented") // TODO: Implement
+}
+
 // fakeIPAMClient implements ipam.Interface for testing purposes.
 type fakeIPAMClient struct {
 	sync.Mutex
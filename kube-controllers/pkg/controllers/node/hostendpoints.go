@@ -18,6 +18,8 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"regexp"
+	"strings"
 	"time"
 
 	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
@@ -34,14 +36,29 @@ import (
 	"github.com/projectcalico/calico/libcalico-go/lib/net"
 	"github.com/projectcalico/calico/libcalico-go/lib/options"
 	"github.com/projectcalico/calico/libcalico-go/lib/resources"
+	"github.com/projectcalico/calico/libcalico-go/lib/selector"
+	"github.com/projectcalico/calico/libcalico-go/lib/set"
 )
 
+// invalidNameChars matches characters in an interface name that are not valid
+// in a hostendpoint name.
+var invalidNameChars = regexp.MustCompile("[^a-z0-9.-]")
+
 func NewAutoHEPController(c config.NodeControllerConfig, client client.Interface) *autoHostEndpointController {
 	ctrl := &autoHostEndpointController{
 		rl:        workqueue.DefaultControllerRateLimiter(),
 		config:    c,
 		client:    client,
 		nodeCache: make(map[string]*libapi.Node),
+		nodeHeps:  make(map[string]set.Set[string]),
+	}
+	for _, t := range c.HostEndpointTemplates {
+		tmpl, err := newHostEndpointTemplate(t)
+		if err != nil {
+			logrus.WithError(err).WithField("template", t.GenerateName).Error("Ignoring invalid host endpoint template")
+			continue
+		}
+		ctrl.templates = append(ctrl.templates, tmpl)
 	}
 	return ctrl
 }
@@ -52,6 +69,32 @@ type autoHostEndpointController struct {
 	client     client.Interface
 	nodeCache  map[string]*libapi.Node
 	syncStatus bapi.SyncStatus
+	templates  []*hostEndpointTemplate
+
+	// nodeHeps maps node name to the names of the auto hostendpoints that exist
+	// for that node.
+	nodeHeps map[string]set.Set[string]
+}
+
+// hostEndpointTemplate is a parsed AutoHostEndpointTemplate.
+type hostEndpointTemplate struct {
+	api.AutoHostEndpointTemplate
+	nodeSelector     selector.Selector
+	interfacePattern *regexp.Regexp
+}
+
+func newHostEndpointTemplate(t api.AutoHostEndpointTemplate) (*hostEndpointTemplate, error) {
+	tmpl := &hostEndpointTemplate{AutoHostEndpointTemplate: t}
+	var err error
+	if t.NodeSelector != "" {
+		if tmpl.nodeSelector, err = selector.Parse(t.NodeSelector); err != nil {
+			return nil, fmt.Errorf("invalid node selector: %w", err)
+		}
+	}
+	if tmpl.interfacePattern, err = regexp.Compile(t.InterfacePattern); err != nil {
+		return nil, fmt.Errorf("invalid interface pattern: %w", err)
+	}
+	return tmpl, nil
 }
 
 func (c *autoHostEndpointController) RegisterWith(f *DataFeed) {
@@ -100,11 +143,20 @@ func (c *autoHostEndpointController) onUpdate(update bapi.Update) {
 				// Try to perform unmapping based on resource name (calico node name).
 				nodeName := update.KVPair.Key.(model.ResourceKey).Name
 				if c.config.AutoHostEndpoints && c.syncStatus == bapi.InSync {
-					hepName := c.generateAutoHostendpointName(nodeName)
-					err := c.deleteHostendpointWithRetries(context.Background(), hepName)
-					if err != nil {
-						logrus.WithError(err).Fatal()
+					hepNames := set.New[string]()
+					if c.config.CreateDefaultHostEndpoint {
+						hepNames.Add(c.generateAutoHostendpointName(nodeName))
+					}
+					if heps, ok := c.nodeHeps[nodeName]; ok {
+						hepNames.AddSet(heps)
 					}
+					for _, hepName := range hepNames.Slice() {
+						err := c.deleteHostendpointWithRetries(context.Background(), hepName)
+						if err != nil {
+							logrus.WithError(err).Fatal()
+						}
+					}
+					delete(c.nodeHeps, nodeName)
 				}
 			}
 		}
@@ -124,6 +176,7 @@ func (c *autoHostEndpointController) deleteAutoHostendpointsWithoutNodes(ctx con
 				logrus.WithError(err).Warnf("failed to delete hostendpoint %q", hep.Name)
 				return err
 			}
+			c.forgetHostendpoint(hep.Spec.Node, hep.Name)
 		}
 	}
 	return nil
@@ -152,6 +205,13 @@ func (c *autoHostEndpointController) syncAllAutoHostendpoints(ctx context.Contex
 			continue
 		}
 
+		// Record the existing auto hostendpoints for each node, so that we can
+		// clean up any that are no longer expected when syncing the node.
+		c.nodeHeps = make(map[string]set.Set[string])
+		for _, hep := range autoHeps {
+			c.rememberHostendpoint(hep.Spec.Node, hep.Name)
+		}
+
 		// Delete any dangling auto hostendpoints
 		if err := c.deleteAutoHostendpointsWithoutNodes(ctx, autoHeps); err != nil {
 			logrus.WithError(err).Warn("failed to delete dangling hostendpoints")
@@ -175,18 +235,43 @@ func (c *autoHostEndpointController) syncAllAutoHostendpoints(ctx context.Contex
 	return fmt.Errorf("too many retries when syncing all hostendpoints")
 }
 
-// syncAutoHostendpoint syncs the auto hostendpoint for the given node.
+// syncAutoHostendpoint syncs the auto hostendpoints for the given node, and
+// removes any of the node's auto hostendpoints that are no longer expected.
 func (c *autoHostEndpointController) syncAutoHostendpoint(ctx context.Context, node *libapi.Node) error {
-	hepName := c.generateAutoHostendpointName(node.Name)
-	logrus.Debugf("syncing hostendpoint %q from node %+v", hepName, node)
+	expectedHeps := c.generateAutoHostendpointsFromNode(node)
+	for _, expectedHep := range expectedHeps {
+		if err := c.syncHostendpoint(ctx, expectedHep); err != nil {
+			return err
+		}
+		c.rememberHostendpoint(node.Name, expectedHep.Name)
+	}
+
+	if heps, ok := c.nodeHeps[node.Name]; ok {
+		for _, hepName := range heps.Slice() {
+			if _, ok := expectedHeps[hepName]; ok {
+				continue
+			}
+			if err := c.deleteHostendpoint(ctx, hepName); err != nil {
+				if _, ok := err.(errors.ErrorResourceDoesNotExist); !ok {
+					return err
+				}
+			}
+			c.forgetHostendpoint(node.Name, hepName)
+		}
+	}
+	return nil
+}
+
+// syncHostendpoint creates or updates the given auto hostendpoint.
+func (c *autoHostEndpointController) syncHostendpoint(ctx context.Context, expectedHep *api.HostEndpoint) error {
+	logrus.Debugf("syncing hostendpoint %q", expectedHep.Name)
 
 	// Try getting the host endpoint.
-	expectedHep := c.generateAutoHostendpointFromNode(node)
-	currentHep, err := c.client.HostEndpoints().Get(ctx, hepName, options.GetOptions{})
+	currentHep, err := c.client.HostEndpoints().Get(ctx, expectedHep.Name, options.GetOptions{})
 	if err != nil {
 		switch err.(type) {
 		case errors.ErrorResourceDoesNotExist:
-			if _, err := c.createAutoHostendpoint(ctx, node); err != nil {
+			if _, err := c.createAutoHostendpoint(ctx, expectedHep); err != nil {
 				return err
 			}
 		default:
@@ -200,6 +285,24 @@ func (c *autoHostEndpointController) syncAutoHostendpoint(ctx context.Context, n
 	return nil
 }
 
+// rememberHostendpoint records that the named auto hostendpoint exists for the node.
+func (c *autoHostEndpointController) rememberHostendpoint(nodeName, hepName string) {
+	if _, ok := c.nodeHeps[nodeName]; !ok {
+		c.nodeHeps[nodeName] = set.New[string]()
+	}
+	c.nodeHeps[nodeName].Add(hepName)
+}
+
+// forgetHostendpoint records that the named auto hostendpoint no longer exists.
+func (c *autoHostEndpointController) forgetHostendpoint(nodeName, hepName string) {
+	if heps, ok := c.nodeHeps[nodeName]; ok {
+		heps.Discard(hepName)
+		if heps.Len() == 0 {
+			delete(c.nodeHeps, nodeName)
+		}
+	}
+}
+
 // syncAutoHostendpointWithRetries syncs the auto hostendpoint for the given
 // node, retrying a few times if needed.
 func (c *autoHostEndpointController) syncAutoHostendpointWithRetries(ctx context.Context, node *libapi.Node) error {
@@ -276,9 +379,8 @@ func isAutoHostendpoint(h *api.HostEndpoint) bool {
 	return ok && v == hepCreatedLabelValue
 }
 
-// createAutoHostendpoint creates an auto hostendpoint for the specified node.
-func (c *autoHostEndpointController) createAutoHostendpoint(ctx context.Context, n *libapi.Node) (*api.HostEndpoint, error) {
-	hep := c.generateAutoHostendpointFromNode(n)
+// createAutoHostendpoint creates the given auto hostendpoint.
+func (c *autoHostEndpointController) createAutoHostendpoint(ctx context.Context, hep *api.HostEndpoint) (*api.HostEndpoint, error) {
 	rlKey := rateLimiterItemKey{Type: RateLimitCalicoCreate, Name: hep.Name}
 
 	time.Sleep(c.rl.When(rlKey))
@@ -296,6 +398,14 @@ func (c *autoHostEndpointController) generateAutoHostendpointName(nodeName strin
 	return fmt.Sprintf("%s-auto-hep", nodeName)
 }
 
+// generateTemplateHostendpointName returns the name of the auto hostendpoint
+// created from a template for the given interface.  Characters that are not
+// valid in a resource name are replaced.
+func (c *autoHostEndpointController) generateTemplateHostendpointName(nodeName, generateName, ifaceName string) string {
+	ifaceName = invalidNameChars.ReplaceAllString(strings.ToLower(ifaceName), "-")
+	return fmt.Sprintf("%s-%s-%s-auto-hep", nodeName, generateName, ifaceName)
+}
+
 // getAutoHostendpointExpectedIPs returns all of the known IPs on the node resource
 // that should set on the auto hostendpoint.
 func (c *autoHostEndpointController) getAutoHostendpointExpectedIPs(node *libapi.Node) []string {
@@ -368,6 +478,61 @@ func (c *autoHostEndpointController) generateAutoHostendpointFromNode(node *liba
 	}
 }
 
+// generateAutoHostendpointsFromNode returns the expected auto hostendpoints for
+// the given node, keyed by name: the "*" interface hostendpoint (unless disabled)
+// and one for each interface matching each template that selects the node.
+func (c *autoHostEndpointController) generateAutoHostendpointsFromNode(node *libapi.Node) map[string]*api.HostEndpoint {
+	heps := make(map[string]*api.HostEndpoint)
+	if c.config.CreateDefaultHostEndpoint {
+		hep := c.generateAutoHostendpointFromNode(node)
+		heps[hep.Name] = hep
+	}
+	for _, t := range c.templates {
+		if t.nodeSelector != nil && !t.nodeSelector.Evaluate(node.Labels) {
+			continue
+		}
+		for _, iface := range node.Spec.Interfaces {
+			if !t.interfacePattern.MatchString(iface.Name) {
+				continue
+			}
+			hep := c.generateTemplateHostendpoint(node, t, iface)
+			heps[hep.Name] = hep
+		}
+	}
+	return heps
+}
+
+// generateTemplateHostendpoint returns the expected auto hostendpoint for the
+// given template and node interface.
+func (c *autoHostEndpointController) generateTemplateHostendpoint(node *libapi.Node, t *hostEndpointTemplate, iface libapi.NodeInterface) *api.HostEndpoint {
+	hepLabels := make(map[string]string, len(node.Labels)+len(t.Labels)+1)
+	for k, v := range node.Labels {
+		hepLabels[k] = v
+	}
+	for k, v := range t.Labels {
+		hepLabels[k] = v
+	}
+	hepLabels[hepCreatedLabelKey] = hepCreatedLabelValue
+
+	profiles := []string{resources.DefaultAllowProfileName}
+	if len(t.Profiles) > 0 {
+		profiles = append([]string(nil), t.Profiles...)
+	}
+
+	return &api.HostEndpoint{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   c.generateTemplateHostendpointName(node.Name, t.GenerateName, iface.Name),
+			Labels: hepLabels,
+		},
+		Spec: api.HostEndpointSpec{
+			Node:          node.Name,
+			InterfaceName: iface.Name,
+			ExpectedIPs:   append([]string(nil), iface.Addresses...),
+			Profiles:      profiles,
+		},
+	}
+}
+
 // hostendpointNeedsUpdate returns true if the current automatic hostendpoint
 // needs to be updated.
 func (c *autoHostEndpointController) hostendpointNeedsUpdate(current *api.HostEndpoint, expected *api.HostEndpoint) bool {
@@ -384,6 +549,10 @@ func (c *autoHostEndpointController) hostendpointNeedsUpdate(current *api.HostEn
 		logrus.WithField("hep.Name", current.Name).Debug("hostendpoint needs update because of interfaceName")
 		return true
 	}
+	if !reflect.DeepEqual(current.Spec.Profiles, expected.Spec.Profiles) {
+		logrus.WithField("hep.Name", current.Name).Debug("hostendpoint needs update because of profiles")
+		return true
+	}
 	logrus.WithField("hep.Name", current.Name).Debug("hostendpoint does not need update")
 	return false
 }
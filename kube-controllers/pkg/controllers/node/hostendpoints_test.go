@@ -0,0 +1,183 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package node
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/projectcalico/calico/kube-controllers/pkg/config"
+	libapiv3 "github.com/projectcalico/calico/libcalico-go/lib/apis/v3"
+	bapi "github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+)
+
+var _ = Describe("Auto hostendpoint templates", func() {
+	var cli *FakeCalicoClient
+	var c *autoHostEndpointController
+	var node *libapiv3.Node
+
+	hepNames := func() []string {
+		heps, err := cli.HostEndpoints().List(context.Background(), options.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		var names []string
+		for _, hep := range heps.Items {
+			names = append(names, hep.Name)
+		}
+		return names
+	}
+
+	getHep := func(name string) *apiv3.HostEndpoint {
+		hep, err := cli.HostEndpoints().Get(context.Background(), name, options.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		return hep
+	}
+
+	updateNode := func(n *libapiv3.Node) {
+		c.onUpdate(bapi.Update{
+			KVPair: model.KVPair{
+				Key:   model.ResourceKey{Name: n.Name, Kind: libapiv3.KindNode},
+				Value: n,
+			},
+			UpdateType: bapi.UpdateTypeKVUpdated,
+		})
+	}
+
+	BeforeEach(func() {
+		cli = NewFakeCalicoClient()
+		c = NewAutoHEPController(config.NodeControllerConfig{
+			AutoHostEndpoints:         true,
+			CreateDefaultHostEndpoint: true,
+			HostEndpointTemplates: []apiv3.AutoHostEndpointTemplate{
+				{
+					GenerateName:     "storage",
+					NodeSelector:     "has(storage)",
+					InterfacePattern: "^bond",
+					Labels:           map[string]string{"network": "storage"},
+					Profiles:         []string{"storage"},
+				},
+				{
+					GenerateName:     "mgmt",
+					InterfacePattern: "^eth0$",
+				},
+			},
+		}, cli)
+
+		node = libapiv3.NewNode()
+		node.Name = "node1"
+		node.Labels = map[string]string{"storage": "true", "zone": "a"}
+		node.Spec.Interfaces = []libapiv3.NodeInterface{
+			{Name: "eth0", Addresses: []string{"192.168.0.10"}},
+			{Name: "bond0", Addresses: []string{"10.0.0.10", "fd00::10"}},
+			{Name: "bond_1", Addresses: []string{"10.0.1.10"}},
+			{Name: "docker0"},
+		}
+
+		updateNode(node)
+		c.onStatusUpdate(bapi.InSync)
+	})
+
+	It("should create a hostendpoint for each matching interface", func() {
+		Expect(hepNames()).To(ConsistOf(
+			"node1-auto-hep",
+			"node1-storage-bond0-auto-hep",
+			"node1-storage-bond-1-auto-hep",
+			"node1-mgmt-eth0-auto-hep",
+		))
+
+		hep := getHep("node1-storage-bond0-auto-hep")
+		Expect(hep.Labels).To(Equal(map[string]string{
+			"storage":                      "true",
+			"zone":                         "a",
+			"network":                      "storage",
+			"projectcalico.org/created-by": "calico-kube-controllers",
+		}))
+		Expect(hep.Spec).To(Equal(apiv3.HostEndpointSpec{
+			Node:          "node1",
+			InterfaceName: "bond0",
+			ExpectedIPs:   []string{"10.0.0.10", "fd00::10"},
+			Profiles:      []string{"storage"},
+		}))
+
+		hep = getHep("node1-mgmt-eth0-auto-hep")
+		Expect(hep.Spec.InterfaceName).To(Equal("eth0"))
+		Expect(hep.Spec.Profiles).To(Equal([]string{"projectcalico-default-allow"}))
+	})
+
+	It("should remove hostendpoints that are no longer expected", func() {
+		By("removing an interface")
+		node = node.DeepCopy()
+		node.Spec.Interfaces = node.Spec.Interfaces[:2]
+		updateNode(node)
+		Expect(hepNames()).To(ConsistOf(
+			"node1-auto-hep",
+			"node1-storage-bond0-auto-hep",
+			"node1-mgmt-eth0-auto-hep",
+		))
+
+		By("removing the label that the storage template selects")
+		node = node.DeepCopy()
+		delete(node.Labels, "storage")
+		updateNode(node)
+		Expect(hepNames()).To(ConsistOf(
+			"node1-auto-hep",
+			"node1-mgmt-eth0-auto-hep",
+		))
+
+		By("deleting the node")
+		c.onUpdate(bapi.Update{
+			KVPair: model.KVPair{
+				Key: model.ResourceKey{Name: node.Name, Kind: libapiv3.KindNode},
+			},
+			UpdateType: bapi.UpdateTypeKVDeleted,
+		})
+		Expect(hepNames()).To(BeEmpty())
+	})
+
+	It("should clean up stale template hostendpoints on resync", func() {
+		stale := &apiv3.HostEndpoint{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "node1-storage-bond9-auto-hep",
+				Labels: map[string]string{hepCreatedLabelKey: hepCreatedLabelValue},
+			},
+			Spec: apiv3.HostEndpointSpec{Node: "node1", InterfaceName: "bond9"},
+		}
+		_, err := cli.HostEndpoints().Create(context.Background(), stale, options.SetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(c.syncAllAutoHostendpoints(context.Background())).To(Succeed())
+		Expect(hepNames()).NotTo(ContainElement(stale.Name))
+		Expect(hepNames()).To(HaveLen(4))
+	})
+
+	It("should not create the default hostendpoint if disabled", func() {
+		cli = NewFakeCalicoClient()
+		c = NewAutoHEPController(config.NodeControllerConfig{
+			AutoHostEndpoints: true,
+			HostEndpointTemplates: []apiv3.AutoHostEndpointTemplate{
+				{GenerateName: "mgmt", InterfacePattern: "^eth0$"},
+			},
+		}, cli)
+		updateNode(node)
+		c.onStatusUpdate(bapi.InSync)
+
+		Expect(hepNames()).To(ConsistOf("node1-mgmt-eth0-auto-hep"))
+	})
+})
@@ -730,6 +730,12 @@ func (c *ipamController) checkAllocations() ([]string, error) {
 	}
 	nodesToRelease := []string{}
 	for cnode, allocations := range nodesAndAllocations {
+		if cnode == ipam.LoadBalancerHost {
+			// LoadBalancer addresses are not tied to a node, and are managed by the
+			// LoadBalancer controller instead.
+			continue
+		}
+
 		// Lookup the corresponding Kubernetes node for each Calico node we found in IPAM.
 		// In KDD mode, these are identical. However, in etcd mode its possible that the Calico node has a
 		// different name from the Kubernetes node.
@@ -0,0 +1,327 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routereflector
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	log "github.com/sirupsen/logrus"
+	v1 "k8s.io/api/core/v1"
+	uruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/projectcalico/calico/kube-controllers/pkg/config"
+	"github.com/projectcalico/calico/kube-controllers/pkg/controllers/controller"
+	libapi "github.com/projectcalico/calico/libcalico-go/lib/apis/v3"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/errors"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+	"github.com/projectcalico/calico/libcalico-go/lib/selector"
+)
+
+var retryInterval = 5 * time.Second
+
+// routeReflectorController implements the Controller interface. It selects route reflectors in
+// each zone and maintains the BGPPeers that build the route reflector topology.
+type routeReflectorController struct {
+	ctx          context.Context
+	cfg          config.RouteReflectorControllerConfig
+	calicoClient client.Interface
+	nodeInformer cache.SharedIndexInformer
+	nodeSelector selector.Selector
+
+	// syncChan triggers a reconciliation in response to a node update.
+	syncChan chan interface{}
+}
+
+// NewRouteReflectorController returns a controller which manages the route reflector topology.
+func NewRouteReflectorController(
+	ctx context.Context,
+	calicoClient client.Interface,
+	cfg config.RouteReflectorControllerConfig,
+	nodeInformer cache.SharedIndexInformer,
+) controller.Controller {
+	c := &routeReflectorController{
+		ctx:          ctx,
+		cfg:          cfg,
+		calicoClient: calicoClient,
+		nodeInformer: nodeInformer,
+		syncChan:     make(chan interface{}, 1),
+	}
+
+	if cfg.NodeSelector != "" {
+		sel, err := selector.Parse(cfg.NodeSelector)
+		if err != nil {
+			log.WithError(err).WithField("selector", cfg.NodeSelector).Error("Invalid route reflector node selector")
+			return nil
+		}
+		c.nodeSelector = sel
+	}
+
+	// Any change to the Kubernetes nodes may affect which nodes are eligible to be route
+	// reflectors, e.g. a node being cordoned for draining, so trigger a reconcile.
+	handlers := cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj interface{}) { kick(c.syncChan) },
+		UpdateFunc: func(_, obj interface{}) { kick(c.syncChan) },
+		DeleteFunc: func(obj interface{}) { kick(c.syncChan) },
+	}
+	if _, err := nodeInformer.AddEventHandler(handlers); err != nil {
+		log.WithError(err).Error("failed to add event handler for node")
+		return nil
+	}
+	return c
+}
+
+// Run starts the controller.
+func (c *routeReflectorController) Run(stopCh chan struct{}) {
+	defer uruntime.HandleCrash()
+
+	log.Info("Starting RouteReflector controller")
+
+	// Wait till k8s cache is synced
+	log.Debug("Waiting to sync with Kubernetes API (Nodes)")
+	if !cache.WaitForNamedCacheSync("nodes", stopCh, c.nodeInformer.HasSynced) {
+		log.Info("Failed to sync resources, received signal for controller to shut down.")
+		return
+	}
+	log.Debug("Finished syncing with Kubernetes API (Nodes)")
+
+	// Calico nodes may change without a corresponding change to the Kubernetes node (for
+	// example, in etcd mode), so also reconcile periodically.
+	t := time.NewTicker(c.cfg.ReconcilerPeriod)
+	defer t.Stop()
+	kick(c.syncChan)
+
+	log.Info("RouteReflector controller is now running")
+	for {
+		select {
+		case <-t.C:
+			log.Debug("Periodic route reflector sync")
+			kick(c.syncChan)
+		case <-c.syncChan:
+			if err := c.reconcile(); err != nil {
+				log.WithError(err).Warn("Error syncing route reflector topology, will retry")
+				time.AfterFunc(retryInterval, func() { kick(c.syncChan) })
+			}
+		case <-stopCh:
+			log.Info("Stopping RouteReflector controller")
+			return
+		}
+	}
+}
+
+// reconcile selects the route reflectors for each zone and updates the Calico nodes and BGPPeers to match.
+func (c *routeReflectorController) reconcile() error {
+	nodes, err := c.calicoClient.Nodes().List(c.ctx, options.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	var infos []nodeInfo
+	nodesByName := map[string]*libapi.Node{}
+	for i := range nodes.Items {
+		n := &nodes.Items[i]
+		reflector := n.Labels[RouteReflectorLabel] == "true"
+		if !reflector && n.Spec.BGP != nil && n.Spec.BGP.RouteReflectorClusterID != "" {
+			// This node has been configured as a route reflector by hand. Leave it alone.
+			log.WithField("node", n.Name).Debug("Ignoring manually configured route reflector")
+			continue
+		}
+		nodesByName[n.Name] = n
+		infos = append(infos, nodeInfo{
+			Name:      n.Name,
+			Zone:      n.Labels[c.cfg.ZoneLabel],
+			Eligible:  c.eligible(n),
+			Reflector: reflector,
+		})
+	}
+
+	selected := selectReflectors(infos, c.cfg.ReflectorsPerZone)
+	desired := map[string]string{}
+	for zone, names := range selected {
+		clusterID := clusterIDForZone(zone)
+		for _, name := range names {
+			desired[name] = clusterID
+		}
+	}
+
+	// Promote the new route reflectors before demoting the old ones, so that there is
+	// always a reflector available for clients to peer with while the topology changes.
+	for name, clusterID := range desired {
+		n := nodesByName[name]
+		if n.Labels[RouteReflectorLabel] == "true" && n.Spec.BGP.RouteReflectorClusterID == clusterID {
+			continue
+		}
+		log.WithFields(log.Fields{"node": name, "clusterID": clusterID}).Info("Configuring node as route reflector")
+		if err := c.updateNode(name, clusterID); err != nil {
+			return err
+		}
+	}
+
+	if err := c.syncPeers(desiredPeers(c.cfg.ZoneLabel, selected)); err != nil {
+		return err
+	}
+
+	for name, n := range nodesByName {
+		if _, ok := desired[name]; ok || n.Labels[RouteReflectorLabel] != "true" {
+			continue
+		}
+		log.WithField("node", name).Info("Removing route reflector configuration from node")
+		if err := c.updateNode(name, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// eligible returns true if the given node may be selected as a route reflector.
+func (c *routeReflectorController) eligible(n *libapi.Node) bool {
+	if n.Spec.BGP == nil || (n.Spec.BGP.IPv4Address == "" && n.Spec.BGP.IPv6Address == "") {
+		return false
+	}
+	if c.nodeSelector != nil && !c.nodeSelector.Evaluate(n.Labels) {
+		return false
+	}
+
+	kn := kubernetesNodeName(n)
+	if kn == "" {
+		// Not a Kubernetes node, so there is no drain or readiness state to consider.
+		return true
+	}
+	obj, exists, err := c.nodeInformer.GetIndexer().GetByKey(kn)
+	if err != nil || !exists {
+		return false
+	}
+	return nodeIsReady(obj.(*v1.Node))
+}
+
+// updateNode sets the route reflector cluster ID on the given node, labelling it as a route reflector.
+// An empty clusterID removes the route reflector configuration.
+func (c *routeReflectorController) updateNode(name, clusterID string) error {
+	for i := 0; i < 5; i++ {
+		n, err := c.calicoClient.Nodes().Get(c.ctx, name, options.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if clusterID != "" {
+			if n.Labels == nil {
+				n.Labels = map[string]string{}
+			}
+			n.Labels[RouteReflectorLabel] = "true"
+		} else {
+			delete(n.Labels, RouteReflectorLabel)
+		}
+		if n.Spec.BGP == nil {
+			if clusterID == "" {
+				return nil
+			}
+			return fmt.Errorf("node %s has no BGP configuration", name)
+		}
+		n.Spec.BGP.RouteReflectorClusterID = clusterID
+
+		_, err = c.calicoClient.Nodes().Update(c.ctx, n, options.SetOptions{})
+		if err == nil {
+			return nil
+		}
+		if _, ok := err.(errors.ErrorResourceUpdateConflict); !ok {
+			return err
+		}
+		log.WithField("node", name).Debug("Conflict updating node, retrying")
+	}
+	return fmt.Errorf("too many retries updating node %s", name)
+}
+
+// syncPeers makes the BGPPeers created by this controller match the desired set.
+func (c *routeReflectorController) syncPeers(desired map[string]*api.BGPPeer) error {
+	peers, err := c.calicoClient.BGPPeers().List(c.ctx, options.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	for i := range peers.Items {
+		p := &peers.Items[i]
+		if p.Labels[createdByLabelKey] != createdByLabelValue || !strings.HasPrefix(p.Name, peerNamePrefix) {
+			continue
+		}
+		d, ok := desired[p.Name]
+		if !ok {
+			log.WithField("peer", p.Name).Info("Deleting route reflector BGPPeer")
+			_, err := c.calicoClient.BGPPeers().Delete(c.ctx, p.Name, options.DeleteOptions{})
+			if _, ok := err.(errors.ErrorResourceDoesNotExist); err != nil && !ok {
+				return err
+			}
+			continue
+		}
+		delete(desired, p.Name)
+		if reflect.DeepEqual(p.Spec, d.Spec) {
+			continue
+		}
+		log.WithField("peer", p.Name).Info("Updating route reflector BGPPeer")
+		p.Spec = d.Spec
+		if _, err := c.calicoClient.BGPPeers().Update(c.ctx, p, options.SetOptions{}); err != nil {
+			return err
+		}
+	}
+
+	for _, d := range desired {
+		log.WithField("peer", d.Name).Info("Creating route reflector BGPPeer")
+		if _, err := c.calicoClient.BGPPeers().Create(c.ctx, d, options.SetOptions{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// kubernetesNodeName returns the name of the Kubernetes node for the given Calico node, or ""
+// if the Calico node doesn't correspond to a Kubernetes node.
+func kubernetesNodeName(n *libapi.Node) string {
+	for _, ref := range n.Spec.OrchRefs {
+		if ref.Orchestrator == "k8s" {
+			return ref.NodeName
+		}
+	}
+	return ""
+}
+
+// nodeIsReady returns true if the Kubernetes node is schedulable and reports itself as ready. Nodes
+// that are cordoned, e.g. while being drained, are not considered ready.
+func nodeIsReady(n *v1.Node) bool {
+	if n.Spec.Unschedulable {
+		return false
+	}
+	for _, cond := range n.Status.Conditions {
+		if cond.Type == v1.NodeReady {
+			return cond.Status == v1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// kick puts an item on the channel in non-blocking write. This means if there
+// is already something pending, it has no effect. This allows us to coalesce
+// multiple requests into a single pending request.
+func kick(c chan<- interface{}) {
+	select {
+	case c <- nil:
+		// pass
+	default:
+		// pass
+	}
+}
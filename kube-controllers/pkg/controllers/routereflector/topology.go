@@ -0,0 +1,176 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routereflector
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"net"
+	"sort"
+	"strings"
+
+	api "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+)
+
+const (
+	// RouteReflectorLabel is applied to the Calico nodes that the controller has selected as route
+	// reflectors. The BGPPeers maintained by the controller select on it.
+	RouteReflectorLabel = "projectcalico.org/route-reflector"
+
+	createdByLabelKey   = "projectcalico.org/created-by"
+	createdByLabelValue = "calico-kube-controllers"
+
+	peerNamePrefix = "calico-rr-"
+	meshPeerName   = peerNamePrefix + "mesh"
+	zonePeerPrefix = peerNamePrefix + "zone-"
+
+	// unzonedName is used in resource names for nodes that don't carry the zone label.
+	unzonedName = "unzoned"
+)
+
+// nodeInfo is the controller's view of a Calico node when computing the topology.
+type nodeInfo struct {
+	Name string
+
+	// Zone is the value of the zone label, or "" if the node has no zone label.
+	Zone string
+
+	// Eligible is true if the node may act as a route reflector: it runs BGP, matches
+	// the configured node selector and is neither being drained nor unready.
+	Eligible bool
+
+	// Reflector is true if the controller previously selected this node as a route reflector.
+	Reflector bool
+}
+
+// selectReflectors returns the names of the nodes that should act as route reflectors, keyed
+// by zone. Existing reflectors that are still eligible are preferred over other nodes so that
+// BGP sessions are not churned needlessly; the remaining slots are filled in name order.
+func selectReflectors(nodes []nodeInfo, perZone int) map[string][]string {
+	byZone := map[string][]nodeInfo{}
+	for _, n := range nodes {
+		byZone[n.Zone] = append(byZone[n.Zone], n)
+	}
+
+	selected := map[string][]string{}
+	for zone, zoneNodes := range byZone {
+		sort.Slice(zoneNodes, func(i, j int) bool {
+			// Current reflectors first, then by name.
+			if zoneNodes[i].Reflector != zoneNodes[j].Reflector {
+				return zoneNodes[i].Reflector
+			}
+			return zoneNodes[i].Name < zoneNodes[j].Name
+		})
+		var names []string
+		for _, n := range zoneNodes {
+			if len(names) == perZone {
+				break
+			}
+			if n.Eligible {
+				names = append(names, n.Name)
+			}
+		}
+		sort.Strings(names)
+		selected[zone] = names
+	}
+	return selected
+}
+
+// clusterIDForZone returns the route reflector cluster ID used by the reflectors in the given zone.
+// Each zone needs a distinct cluster ID, otherwise reflectors in different zones would discard the
+// routes they reflect to each other. The ID is derived from the zone name so that it is stable
+// across restarts without the controller having to store any state.
+func clusterIDForZone(zone string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(zone))
+	id := h.Sum32()
+	if id == 0 {
+		// 0.0.0.0 is not a usable cluster ID.
+		id = 1
+	}
+	ip := make(net.IP, 4)
+	binary.BigEndian.PutUint32(ip, id)
+	return ip.String()
+}
+
+// zoneResourceName returns a string derived from the zone that is safe to use in a resource name.
+// If sanitising the zone changes it then a hash of the original zone is appended, so that zones
+// differing only by case or punctuation (e.g. "us_east" and "US-East") don't share a name.
+func zoneResourceName(zone string) string {
+	if zone == "" {
+		return unzonedName
+	}
+	name := strings.ToLower(strings.ReplaceAll(zone, "_", "-"))
+	if name == zone && zone != unzonedName {
+		return name
+	}
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(zone))
+	return fmt.Sprintf("%s-%08x", name, h.Sum32())
+}
+
+// zoneSelector returns the selector expression matching nodes in the given zone.
+func zoneSelector(zoneLabel, zone string) string {
+	if zone == "" {
+		return fmt.Sprintf("(!has(%s) || %s == '')", zoneLabel, zoneLabel)
+	}
+	return fmt.Sprintf("%s == '%s'", zoneLabel, zone)
+}
+
+// desiredPeers returns the BGPPeers needed for the given route reflector selection. The route
+// reflectors form a full mesh with each other, and every other node peers with the reflectors
+// in its own zone. Nodes in a zone without any reflectors peer with all reflectors instead.
+// The peerings use selectors, so both IPv4 and IPv6 sessions are established depending on the
+// addresses configured on each pair of nodes.
+func desiredPeers(zoneLabel string, selected map[string][]string) map[string]*api.BGPPeer {
+	rrSelector := fmt.Sprintf("has(%s)", RouteReflectorLabel)
+	clientSelector := fmt.Sprintf("!has(%s)", RouteReflectorLabel)
+
+	peers := map[string]*api.BGPPeer{}
+	anyReflectors := false
+	for _, names := range selected {
+		if len(names) > 0 {
+			anyReflectors = true
+			break
+		}
+	}
+	if !anyReflectors {
+		return peers
+	}
+
+	peers[meshPeerName] = newPeer(meshPeerName, rrSelector, rrSelector)
+	for zone := range selected {
+		name := zonePeerPrefix + zoneResourceName(zone)
+		zs := zoneSelector(zoneLabel, zone)
+		peerSelector := rrSelector
+		if len(selected[zone]) > 0 {
+			peerSelector = fmt.Sprintf("%s && %s", rrSelector, zs)
+		}
+		peers[name] = newPeer(name, fmt.Sprintf("%s && %s", clientSelector, zs), peerSelector)
+	}
+	return peers
+}
+
+func newPeer(name, nodeSelector, peerSelector string) *api.BGPPeer {
+	p := api.NewBGPPeer()
+	p.Name = name
+	p.Labels = map[string]string{createdByLabelKey: createdByLabelValue}
+	p.Spec = api.BGPPeerSpec{
+		NodeSelector: nodeSelector,
+		PeerSelector: peerSelector,
+	}
+	return p
+}
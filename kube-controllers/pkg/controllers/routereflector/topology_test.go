@@ -0,0 +1,122 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package routereflector
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/selector"
+)
+
+var _ = Describe("Route reflector topology", func() {
+	It("should select reflectors per zone in name order", func() {
+		nodes := []nodeInfo{
+			{Name: "a3", Zone: "a", Eligible: true},
+			{Name: "a1", Zone: "a", Eligible: true},
+			{Name: "a2", Zone: "a", Eligible: false},
+			{Name: "a4", Zone: "a", Eligible: true},
+			{Name: "b1", Zone: "b", Eligible: true},
+			{Name: "c1", Zone: "c", Eligible: false},
+		}
+		Expect(selectReflectors(nodes, 2)).To(Equal(map[string][]string{
+			"a": {"a1", "a3"},
+			"b": {"b1"},
+			"c": nil,
+		}))
+	})
+
+	It("should prefer existing reflectors and replace ineligible ones", func() {
+		nodes := []nodeInfo{
+			{Name: "a1", Zone: "a", Eligible: true},
+			{Name: "a2", Zone: "a", Eligible: true},
+			{Name: "a3", Zone: "a", Eligible: true, Reflector: true},
+			{Name: "a4", Zone: "a", Eligible: false, Reflector: true},
+		}
+		Expect(selectReflectors(nodes, 2)).To(Equal(map[string][]string{
+			"a": {"a1", "a3"},
+		}))
+	})
+
+	It("should assign distinct, stable cluster IDs to zones", func() {
+		Expect(clusterIDForZone("zone-a")).To(Equal(clusterIDForZone("zone-a")))
+		Expect(clusterIDForZone("zone-a")).NotTo(Equal(clusterIDForZone("zone-b")))
+		Expect(clusterIDForZone("")).NotTo(Equal("0.0.0.0"))
+	})
+
+	It("should give zones that differ only when sanitised distinct resource names", func() {
+		Expect(zoneResourceName("")).To(Equal("unzoned"))
+		Expect(zoneResourceName("us-east")).To(Equal("us-east"))
+		Expect(zoneResourceName("us_east")).To(MatchRegexp(`^us-east-[0-9a-f]{8}$`))
+
+		names := map[string]string{}
+		for _, zone := range []string{"", "unzoned", "us-east", "us_east", "US-East", "US_EAST"} {
+			name := zoneResourceName(zone)
+			Expect(names).NotTo(HaveKey(name), "zone %q has the same name as zone %q", zone, names[name])
+			Expect(name).To(MatchRegexp(`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`))
+			names[name] = zone
+		}
+	})
+
+	It("should generate no peers when there are no reflectors", func() {
+		Expect(desiredPeers("zone", map[string][]string{"a": nil})).To(BeEmpty())
+	})
+
+	It("should generate peers for zones with and without reflectors", func() {
+		peers := desiredPeers("zone", map[string][]string{
+			"us_east": {"a1"},
+			"b":       nil,
+			"":        {"x1"},
+		})
+		Expect(peers).To(HaveLen(4))
+
+		Expect(peers[meshPeerName].Spec.NodeSelector).To(Equal("has(projectcalico.org/route-reflector)"))
+		Expect(peers[meshPeerName].Spec.PeerSelector).To(Equal("has(projectcalico.org/route-reflector)"))
+
+		p := peers[zonePeerPrefix+zoneResourceName("us_east")]
+		Expect(p.Labels).To(HaveKeyWithValue("projectcalico.org/created-by", "calico-kube-controllers"))
+		Expect(p.Spec.NodeSelector).To(Equal("!has(projectcalico.org/route-reflector) && zone == 'us_east'"))
+		Expect(p.Spec.PeerSelector).To(Equal("has(projectcalico.org/route-reflector) && zone == 'us_east'"))
+
+		// Zone b has no reflectors, so its nodes peer with all of them.
+		p = peers["calico-rr-zone-b"]
+		Expect(p.Spec.NodeSelector).To(Equal("!has(projectcalico.org/route-reflector) && zone == 'b'"))
+		Expect(p.Spec.PeerSelector).To(Equal("has(projectcalico.org/route-reflector)"))
+
+		p = peers["calico-rr-zone-unzoned"]
+		Expect(p.Spec.NodeSelector).To(Equal("!has(projectcalico.org/route-reflector) && (!has(zone) || zone == '')"))
+
+		// All generated selectors must be valid.
+		for _, p := range peers {
+			_, err := selector.Parse(p.Spec.NodeSelector)
+			Expect(err).NotTo(HaveOccurred())
+			_, err = selector.Parse(p.Spec.PeerSelector)
+			Expect(err).NotTo(HaveOccurred())
+		}
+	})
+
+	It("should not consider cordoned or unready nodes ready", func() {
+		ready := v1.NodeCondition{Type: v1.NodeReady, Status: v1.ConditionTrue}
+		n := &v1.Node{Status: v1.NodeStatus{Conditions: []v1.NodeCondition{ready}}}
+		Expect(nodeIsReady(n)).To(BeTrue())
+
+		n.Spec.Unschedulable = true
+		Expect(nodeIsReady(n)).To(BeFalse())
+
+		n = &v1.Node{Status: v1.NodeStatus{Conditions: []v1.NodeCondition{{Type: v1.NodeReady, Status: v1.ConditionFalse}}}}
+		Expect(nodeIsReady(n)).To(BeFalse())
+	})
+})
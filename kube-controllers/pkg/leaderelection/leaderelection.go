@@ -0,0 +1,215 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package leaderelection elects a single leader between kube-controllers replicas, using a
+// Kubernetes Lease or, for the etcdv3 datastore, an etcd lease.
+package leaderelection
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/leaderelection"
+	"k8s.io/client-go/tools/leaderelection/resourcelock"
+)
+
+// etcdElectionPrefix is the etcd key prefix under which the election is held.  The lease name
+// is appended to it.
+const etcdElectionPrefix = "/calico/kube-controllers/v1/leader/"
+
+// Config holds the lease parameters for an election.
+type Config struct {
+	// Name of the lease.
+	Name string
+	// Namespace of the lease; only used for Kubernetes Leases.
+	Namespace string
+	// Identity of this replica.
+	Identity string
+
+	// How long a lease is valid for without being renewed.
+	LeaseDuration time.Duration
+	// How long the leader keeps trying to renew its Kubernetes Lease before giving up
+	// leadership.
+	RenewDeadline time.Duration
+	// How long to wait between attempts to acquire or renew the lease.
+	RetryPeriod time.Duration
+}
+
+// Callbacks are invoked as leadership changes.
+type Callbacks struct {
+	// OnStartedLeading is called in its own goroutine when this replica becomes the leader.
+	// The context is cancelled when leadership is lost.
+	OnStartedLeading func(ctx context.Context)
+	// OnStoppedLeading is called when Run returns, whether or not this replica was the
+	// leader.
+	OnStoppedLeading func()
+	// OnNewLeader is called when the leader changes, including to this replica.
+	OnNewLeader func(identity string)
+}
+
+// Elector campaigns for leadership.
+type Elector interface {
+	// Run campaigns for leadership and then holds it.  It returns when the context is
+	// cancelled or leadership is lost.  The lease is released when Run returns.
+	Run(ctx context.Context)
+}
+
+// NewKubernetesElector returns an Elector that uses a Kubernetes Lease.
+func NewKubernetesElector(cfg Config, client kubernetes.Interface, callbacks Callbacks) (Elector, error) {
+	lock := &resourcelock.LeaseLock{
+		LeaseMeta: metav1.ObjectMeta{
+			Name:      cfg.Name,
+			Namespace: cfg.Namespace,
+		},
+		Client: client.CoordinationV1(),
+		LockConfig: resourcelock.ResourceLockConfig{
+			Identity: cfg.Identity,
+		},
+	}
+	le, err := leaderelection.NewLeaderElector(leaderelection.LeaderElectionConfig{
+		Lock:            lock,
+		LeaseDuration:   cfg.LeaseDuration,
+		RenewDeadline:   cfg.RenewDeadline,
+		RetryPeriod:     cfg.RetryPeriod,
+		ReleaseOnCancel: true,
+		Name:            cfg.Name,
+		Callbacks: leaderelection.LeaderCallbacks{
+			OnStartedLeading: callbacks.OnStartedLeading,
+			OnStoppedLeading: callbacks.OnStoppedLeading,
+			OnNewLeader:      callbacks.OnNewLeader,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create leader elector: %w", err)
+	}
+	return le, nil
+}
+
+// NewEtcdElector returns an Elector that uses an etcd lease.  Only the LeaseDuration and
+// RetryPeriod timings are used; the etcd client keeps the lease alive.
+func NewEtcdElector(cfg Config, client *clientv3.Client, callbacks Callbacks) (Elector, error) {
+	if cfg.LeaseDuration < time.Second {
+		return nil, fmt.Errorf("lease duration must be at least 1s, got %v", cfg.LeaseDuration)
+	}
+	if callbacks.OnStartedLeading == nil || callbacks.OnStoppedLeading == nil {
+		return nil, fmt.Errorf("OnStartedLeading and OnStoppedLeading callbacks must be set")
+	}
+	return &etcdElector{cfg: cfg, client: client, callbacks: callbacks}, nil
+}
+
+type etcdElector struct {
+	cfg       Config
+	client    *clientv3.Client
+	callbacks Callbacks
+}
+
+func (e *etcdElector) Run(ctx context.Context) {
+	defer e.callbacks.OnStoppedLeading()
+
+	logCxt := log.WithFields(log.Fields{"name": e.cfg.Name, "identity": e.cfg.Identity})
+	for ctx.Err() == nil {
+		session, err := concurrency.NewSession(
+			e.client,
+			concurrency.WithTTL(int(e.cfg.LeaseDuration/time.Second)),
+			concurrency.WithContext(ctx),
+		)
+		if err != nil {
+			logCxt.WithError(err).Warn("Failed to create etcd session, retrying")
+			e.sleep(ctx)
+			continue
+		}
+		if e.campaign(ctx, session) {
+			// We were the leader, and have now lost leadership (or been cancelled).
+			return
+		}
+		e.sleep(ctx)
+	}
+}
+
+// campaign campaigns for leadership in the given session and, once elected, blocks until
+// leadership is lost.  It returns true if this replica was elected.  The session's lease is
+// revoked before returning.
+func (e *etcdElector) campaign(ctx context.Context, session *concurrency.Session) bool {
+	defer func() {
+		// Revoke the lease, which lets another replica take over immediately.  Use a
+		// fresh context since ours may have been cancelled.
+		session.Orphan()
+		revokeCtx, cancel := context.WithTimeout(context.Background(), e.cfg.RetryPeriod)
+		defer cancel()
+		if _, err := e.client.Revoke(revokeCtx, session.Lease()); err != nil {
+			log.WithError(err).Debug("Failed to revoke etcd lease")
+		}
+	}()
+
+	logCxt := log.WithFields(log.Fields{"name": e.cfg.Name, "identity": e.cfg.Identity})
+	election := concurrency.NewElection(session, etcdElectionPrefix+e.cfg.Name)
+
+	observeCtx, stopObserving := context.WithCancel(ctx)
+	defer stopObserving()
+	if e.callbacks.OnNewLeader != nil {
+		go func() {
+			var leader string
+			for resp := range election.Observe(observeCtx) {
+				if len(resp.Kvs) == 0 || string(resp.Kvs[0].Value) == leader {
+					continue
+				}
+				leader = string(resp.Kvs[0].Value)
+				e.callbacks.OnNewLeader(leader)
+			}
+		}()
+	}
+
+	// Campaign blocks until we are elected, the context is cancelled or the session expires.
+	campaignCtx, cancelCampaign := context.WithCancel(ctx)
+	defer cancelCampaign()
+	go func() {
+		select {
+		case <-session.Done():
+			cancelCampaign()
+		case <-campaignCtx.Done():
+		}
+	}()
+	if err := election.Campaign(campaignCtx, e.cfg.Identity); err != nil {
+		if ctx.Err() == nil {
+			logCxt.WithError(err).Warn("Failed to campaign for leadership, retrying")
+		}
+		return false
+	}
+	logCxt.Info("Became leader")
+
+	leaderCtx, cancelLeader := context.WithCancel(ctx)
+	defer cancelLeader()
+	go e.callbacks.OnStartedLeading(leaderCtx)
+
+	select {
+	case <-session.Done():
+		logCxt.Warn("etcd session expired, lost leadership")
+	case <-ctx.Done():
+		logCxt.Info("Stopping, releasing leadership")
+	}
+	return true
+}
+
+func (e *etcdElector) sleep(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(e.cfg.RetryPeriod):
+	}
+}
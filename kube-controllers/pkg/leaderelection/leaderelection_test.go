@@ -0,0 +1,121 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package leaderelection_test
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/projectcalico/calico/kube-controllers/pkg/leaderelection"
+)
+
+// replica runs an Elector and records the callbacks it receives.
+type replica struct {
+	lock     sync.Mutex
+	leading  bool
+	stopped  bool
+	leader   string
+	cancel   context.CancelFunc
+	finished chan struct{}
+}
+
+func (r *replica) isLeading() bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.leading
+}
+
+func (r *replica) observedLeader() string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.leader
+}
+
+var _ = Describe("Kubernetes leader election", func() {
+	var client *fake.Clientset
+
+	BeforeEach(func() {
+		client = fake.NewSimpleClientset()
+	})
+
+	startReplica := func(identity string) *replica {
+		r := &replica{finished: make(chan struct{})}
+		cfg := leaderelection.Config{
+			Name:          "calico-kube-controllers",
+			Namespace:     "kube-system",
+			Identity:      identity,
+			LeaseDuration: 2 * time.Second,
+			RenewDeadline: time.Second,
+			RetryPeriod:   100 * time.Millisecond,
+		}
+		e, err := leaderelection.NewKubernetesElector(cfg, client, leaderelection.Callbacks{
+			OnStartedLeading: func(ctx context.Context) {
+				r.lock.Lock()
+				defer r.lock.Unlock()
+				r.leading = true
+			},
+			OnStoppedLeading: func() {
+				r.lock.Lock()
+				defer r.lock.Unlock()
+				r.leading = false
+				r.stopped = true
+			},
+			OnNewLeader: func(identity string) {
+				r.lock.Lock()
+				defer r.lock.Unlock()
+				r.leader = identity
+			},
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		var ctx context.Context
+		ctx, r.cancel = context.WithCancel(context.Background())
+		go func() {
+			defer close(r.finished)
+			e.Run(ctx)
+		}()
+		return r
+	}
+
+	It("should elect a single leader and fail over when it stops", func() {
+		r1 := startReplica("replica-1")
+		defer r1.cancel()
+		Eventually(r1.isLeading, "5s").Should(BeTrue())
+		Eventually(r1.observedLeader, "5s").Should(Equal("replica-1"))
+
+		r2 := startReplica("replica-2")
+		defer r2.cancel()
+		Eventually(r2.observedLeader, "5s").Should(Equal("replica-1"))
+		Consistently(r2.isLeading, "500ms").Should(BeFalse())
+
+		lease, err := client.CoordinationV1().Leases("kube-system").Get(context.Background(), "calico-kube-controllers", metav1.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(*lease.Spec.HolderIdentity).To(Equal("replica-1"))
+
+		// Stopping the leader releases the lease, so the other replica takes over without
+		// waiting for the lease to expire.
+		r1.cancel()
+		Eventually(r1.finished, "5s").Should(BeClosed())
+		Expect(r1.stopped).To(BeTrue())
+		Eventually(r2.isLeading, "1500ms").Should(BeTrue())
+		Eventually(r2.observedLeader, "5s").Should(Equal("replica-2"))
+	})
+})
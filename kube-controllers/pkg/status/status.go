@@ -33,10 +33,19 @@ type ConditionStatus struct {
 	Reason string
 }
 
+// LeaderElectionStatus records the state of leader election, when it is enabled.
+type LeaderElectionStatus struct {
+	// IsLeader is true if this replica holds the leader lease and is running the controllers.
+	IsLeader bool
+	// Leader is the identity of the current leader, if known.
+	Leader string
+}
+
 type Status struct {
-	Readiness  map[string]ConditionStatus
-	readyMutex sync.Mutex
-	statusFile string
+	Readiness      map[string]ConditionStatus
+	LeaderElection *LeaderElectionStatus `json:",omitempty"`
+	readyMutex     sync.Mutex
+	statusFile     string
 }
 
 func New(file string) *Status {
@@ -68,6 +77,39 @@ func (s *Status) SetReady(key string, ready bool, reason string) {
 	}
 }
 
+// SetLeader records the leader election state.  Standby replicas are still considered ready,
+// so leadership does not affect the readiness checks.
+func (s *Status) SetLeader(isLeader bool, leader string) {
+	s.readyMutex.Lock()
+	defer s.readyMutex.Unlock()
+
+	le := &LeaderElectionStatus{IsLeader: isLeader, Leader: leader}
+	if s.LeaderElection != nil && *s.LeaderElection == *le {
+		return
+	}
+	logrus.WithFields(logrus.Fields{
+		"isLeader": isLeader,
+		"leader":   leader,
+	}).Info("Updating leader election status")
+	s.LeaderElection = le
+	if err := s.writeStatus(); err != nil {
+		logrus.WithError(err).Warnf("Failed to write status")
+	}
+}
+
+// GetLeaderElection returns a copy of the leader election state, or nil if leader election
+// is not enabled.
+func (s *Status) GetLeaderElection() *LeaderElectionStatus {
+	s.readyMutex.Lock()
+	defer s.readyMutex.Unlock()
+
+	if s.LeaderElection == nil {
+		return nil
+	}
+	le := *s.LeaderElection
+	return &le
+}
+
 // GetReady check the status of the specified ready key, if the key has never
 // been set then it is considered not ready (false).
 func (s *Status) GetReady(key string) bool {
@@ -151,4 +151,24 @@ var _ = Describe("Status pkg UTs", func() {
 			Expect(readSt.GetReadiness()).To(Equal(true))
 		})
 	})
+
+	It("should record the leader election state without affecting readiness", func() {
+		f, err := os.CreateTemp("", "test")
+		Expect(err).NotTo(HaveOccurred())
+		defer os.Remove(f.Name())
+		st := New(f.Name())
+		st.SetReady("anykey", true, "")
+		Expect(st.GetLeaderElection()).To(BeNil())
+
+		st.SetLeader(false, "replica-1")
+		readSt, err := ReadStatusFile(f.Name())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(readSt.GetLeaderElection()).To(Equal(&LeaderElectionStatus{IsLeader: false, Leader: "replica-1"}))
+		Expect(readSt.GetReadiness()).To(BeTrue())
+
+		st.SetLeader(true, "replica-2")
+		readSt, err = ReadStatusFile(f.Name())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(readSt.GetLeaderElection()).To(Equal(&LeaderElectionStatus{IsLeader: true, Leader: "replica-2"}))
+	})
 })
@@ -26,6 +26,7 @@ type DatastoreType string
 const (
 	EtcdV3              DatastoreType = "etcdv3"
 	Kubernetes          DatastoreType = "kubernetes"
+	File                DatastoreType = "file"
 	KindCalicoAPIConfig               = "CalicoAPIConfig"
 )
 
@@ -45,6 +46,8 @@ type CalicoAPIConfigSpec struct {
 	EtcdConfig
 	// Inline the k8s config fields.
 	KubeConfig
+	// Inline the file datastore config fields.
+	FileConfig
 }
 
 type EtcdConfig struct {
@@ -85,6 +88,12 @@ type KubeConfig struct {
 	K8sCurrentContext string `json:"k8sCurrentContext" envconfig:"K8S_CURRENT_CONTEXT" default:""`
 }
 
+type FileConfig struct {
+	// DatastoreFile is the path of the file used by the file datastore.  Defaults to
+	// /var/lib/calico/datastore.json.
+	DatastoreFile string `json:"datastoreFile" envconfig:"DATASTORE_FILE" default:""`
+}
+
 // NewCalicoAPIConfig creates a new (zeroed) CalicoAPIConfig struct with the
 // TypeMetadata initialised to the current version.
 func NewCalicoAPIConfig() *CalicoAPIConfig {
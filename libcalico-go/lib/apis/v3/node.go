@@ -67,6 +67,19 @@ type NodeSpec struct {
 
 	// Addresses list address that a client can reach the node at.
 	Addresses []NodeAddress `json:"addresses,omitempty" validate:"omitempty"`
+
+	// Interfaces lists the host interfaces on the node and their addresses, as detected
+	// by calico/node.  Used to generate per-interface host endpoints.
+	Interfaces []NodeInterface `json:"interfaces,omitempty" validate:"omitempty"`
+}
+
+// NodeInterface represents a host interface on a node.
+type NodeInterface struct {
+	// Name is the name of the interface.
+	Name string `json:"name" validate:"interface"`
+
+	// Addresses is the list of addresses on the interface.
+	Addresses []string `json:"addresses,omitempty" validate:"omitempty,dive,ip"`
 }
 
 // NodeAddress represents an address assigned to a node.
@@ -125,6 +125,40 @@ type Client interface {
 	//Close()
 }
 
+// TxnOpType identifies the operation performed by a TxnOp.
+type TxnOpType string
+
+const (
+	// TxnCreate creates the object specified in the KVPair, which must not already exist.
+	TxnCreate TxnOpType = "Create"
+	// TxnUpdate modifies the existing object specified in the KVPair.  If the KVPair has
+	// revision information then the update only succeeds if the revision is still current.
+	TxnUpdate TxnOpType = "Update"
+	// TxnApply updates or creates the object specified in the KVPair.
+	TxnApply TxnOpType = "Apply"
+	// TxnDelete removes the object specified by the KVPair key.  If the KVPair has revision
+	// information then the delete only succeeds if the revision is still current.
+	TxnDelete TxnOpType = "Delete"
+)
+
+// TxnOp is a single operation in a transaction.
+type TxnOp struct {
+	Type   TxnOpType
+	KVPair *model.KVPair
+}
+
+// TxnClient is implemented by backend clients that are able to perform a set of operations
+// as a single unit.
+type TxnClient interface {
+	// Txn performs the supplied operations in order.  Either all of the operations succeed,
+	// or (as far as the datastore allows) none of them take effect.  On success, returns a
+	// KVPair for each operation, in the same order as the operations.  On failure, returns
+	// the error from the first operation that could not be performed.
+	//
+	// A key may only appear once in a transaction.
+	Txn(ctx context.Context, ops []TxnOp) ([]*model.KVPair, error)
+}
+
 type Syncer interface {
 	// Starts the Syncer.  May start a background goroutine.
 	Start()
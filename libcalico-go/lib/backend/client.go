@@ -22,6 +22,7 @@ import (
 	"github.com/projectcalico/calico/libcalico-go/lib/apiconfig"
 	bapi "github.com/projectcalico/calico/libcalico-go/lib/backend/api"
 	"github.com/projectcalico/calico/libcalico-go/lib/backend/etcdv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/file"
 	"github.com/projectcalico/calico/libcalico-go/lib/backend/k8s"
 )
 
@@ -33,6 +34,8 @@ func NewClient(config apiconfig.CalicoAPIConfig) (c bapi.Client, err error) {
 		c, err = etcdv3.NewEtcdV3Client(&config.Spec.EtcdConfig)
 	case apiconfig.Kubernetes:
 		c, err = k8s.NewKubeClient(&config.Spec)
+	case apiconfig.File:
+		c, err = file.NewFileClient(&config.Spec.FileConfig)
 	default:
 		err = fmt.Errorf("unknown datastore type: %v",
 			config.Spec.DatastoreType)
@@ -1,11 +1,16 @@
 package etcdv3_test
 
 import (
+	"context"
+	"fmt"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 
 	"github.com/projectcalico/calico/libcalico-go/lib/apiconfig"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/api"
 	"github.com/projectcalico/calico/libcalico-go/lib/backend/etcdv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
 )
 
 var (
@@ -225,4 +230,21 @@ var _ = Describe("RulesAPIToBackend", func() {
 		Expect(err).To(HaveOccurred())
 		Expect(err).To(MatchError(ContainSubstring("failed to discover etcd endpoints through SRV discovery")))
 	})
+
+	It("should refuse transactions with more operations than etcd allows", func() {
+		c, err := etcdv3.NewEtcdV3Client(&apiconfig.EtcdConfig{
+			EtcdEndpoints: "http://127.0.0.1:2379",
+		})
+		Expect(err).NotTo(HaveOccurred())
+
+		var ops []api.TxnOp
+		for i := 0; i < 129; i++ {
+			ops = append(ops, api.TxnOp{
+				Type:   api.TxnDelete,
+				KVPair: &model.KVPair{Key: model.HostConfigKey{Hostname: "host", Name: fmt.Sprintf("key-%d", i)}},
+			})
+		}
+		_, err = c.(api.TxnClient).Txn(context.Background(), ops)
+		Expect(err).To(MatchError("transaction has 129 operations, more than the etcd limit of 128"))
+	})
 })
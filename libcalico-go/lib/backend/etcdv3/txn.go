@@ -0,0 +1,188 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package etcdv3
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	log "github.com/sirupsen/logrus"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+	cerrors "github.com/projectcalico/calico/libcalico-go/lib/errors"
+)
+
+// txnOp holds the etcdv3 details of a single operation in a transaction.
+type txnOp struct {
+	api.TxnOp
+	key   string
+	value string
+	rev   int64
+}
+
+// maxTxnOps is the default limit on the number of operations in an etcd transaction, set
+// by the etcd --max-txn-ops option.
+const maxTxnOps = 128
+
+// Txn performs the supplied operations as a single etcdv3 transaction.  The transaction
+// contains the same revision and existence checks as the individual Create, Update and
+// Delete requests, so either all of the operations succeed or none of them do.
+//
+// etcd rejects transactions with more than --max-txn-ops operations, so transactions are
+// limited to the default of 128 operations.
+func (c *etcdV3Client) Txn(ctx context.Context, ops []api.TxnOp) ([]*model.KVPair, error) {
+	log.WithField("numOps", len(ops)).Debug("Processing Txn request")
+	if len(ops) > maxTxnOps {
+		return nil, fmt.Errorf("transaction has %d operations, more than the etcd limit of %d", len(ops), maxTxnOps)
+	}
+
+	var conds []clientv3.Cmp
+	thenOps := make([]clientv3.Op, 0, len(ops))
+	elseOps := make([]clientv3.Op, 0, len(ops))
+	tops := make([]txnOp, len(ops))
+	keys := map[string]bool{}
+	for i, op := range ops {
+		t := txnOp{TxnOp: op}
+		var err error
+		var putOpts []clientv3.OpOption
+		switch op.Type {
+		case api.TxnCreate, api.TxnUpdate, api.TxnApply:
+			if t.key, t.value, err = getKeyValueStrings(op.KVPair); err != nil {
+				return nil, err
+			}
+			if putOpts, err = c.getTTLOption(ctx, op.KVPair); err != nil {
+				return nil, err
+			}
+		case api.TxnDelete:
+			if t.key, err = model.KeyToDefaultDeletePath(op.KVPair.Key); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("unknown transaction operation %q", op.Type)
+		}
+		if keys[t.key] {
+			return nil, fmt.Errorf("resource %v appears more than once in the transaction", op.KVPair.Key)
+		}
+		keys[t.key] = true
+
+		switch op.Type {
+		case api.TxnCreate:
+			conds = append(conds, clientv3.Compare(clientv3.Version(t.key), "=", 0))
+		case api.TxnUpdate:
+			// ResourceVersion must be set for an Update.
+			if t.rev, err = parseRevision(op.KVPair.Revision); err != nil {
+				return nil, err
+			}
+			conds = append(conds, clientv3.Compare(clientv3.ModRevision(t.key), "=", t.rev))
+		case api.TxnDelete:
+			if len(op.KVPair.Revision) != 0 {
+				if t.rev, err = parseRevision(op.KVPair.Revision); err != nil {
+					return nil, err
+				}
+				conds = append(conds, clientv3.Compare(clientv3.ModRevision(t.key), "=", t.rev))
+			} else {
+				// A delete of a resource that does not exist must fail the whole
+				// transaction, so check that the resource exists.
+				conds = append(conds, clientv3.Compare(clientv3.Version(t.key), ">", 0))
+			}
+		}
+
+		if op.Type == api.TxnDelete {
+			thenOps = append(thenOps, clientv3.OpDelete(t.key, clientv3.WithPrevKV()))
+		} else {
+			thenOps = append(thenOps, clientv3.OpPut(t.key, t.value, putOpts...))
+		}
+		elseOps = append(elseOps, clientv3.OpGet(t.key))
+		tops[i] = t
+	}
+
+	log.Debug("Performing etcdv3 transaction for Txn request")
+	txnResp, err := c.etcdClient.Txn(ctx).If(
+		conds...,
+	).Then(
+		thenOps...,
+	).Else(
+		elseOps...,
+	).Commit()
+	if err != nil {
+		log.WithError(err).Warning("Txn failed")
+		return nil, cerrors.ErrorDatastoreError{Err: err}
+	}
+
+	if !txnResp.Succeeded {
+		// One or more of the checks failed.  The else branch returns the current value of
+		// each key, so work out which operation failed and return the same error that the
+		// individual request would have returned.
+		for i, t := range tops {
+			getResp := txnResp.Responses[i].GetResponseRange()
+			var existing *mvccpb.KeyValue
+			if len(getResp.Kvs) != 0 {
+				existing = getResp.Kvs[0]
+			}
+			if err := checkTxnOp(t, existing); err != nil {
+				log.WithError(err).WithField("op", t.Type).Debug("Txn failed check")
+				return nil, err
+			}
+		}
+		return nil, cerrors.ErrorDatastoreError{Err: fmt.Errorf("transaction checks failed")}
+	}
+
+	rev := strconv.FormatInt(txnResp.Header.Revision, 10)
+	results := make([]*model.KVPair, len(tops))
+	for i, t := range tops {
+		if t.Type == api.TxnDelete {
+			// Parse the deleted value.  Don't propagate the error in this case since the
+			// delete did succeed.
+			delResp := txnResp.Responses[i].GetResponseDeleteRange()
+			if len(delResp.PrevKvs) != 0 {
+				results[i], _ = etcdToKVPair(t.KVPair.Key, delResp.PrevKvs[0])
+			}
+			continue
+		}
+		v, err := model.ParseValue(t.KVPair.Key, []byte(t.value))
+		if err != nil {
+			return nil, cerrors.ErrorPartialFailure{Err: fmt.Errorf("Unexpected error parsing stored datastore entry '%v': %+v", t.value, err)}
+		}
+		d := *t.KVPair
+		d.Value = v
+		d.Revision = rev
+		results[i] = &d
+	}
+	return results, nil
+}
+
+// checkTxnOp returns the error for the operation in a failed transaction, given the current
+// value of the key, or nil if the operation was not the cause of the failure.
+func checkTxnOp(t txnOp, existing *mvccpb.KeyValue) error {
+	k := t.KVPair.Key
+	switch t.Type {
+	case api.TxnCreate:
+		if existing != nil {
+			return cerrors.ErrorResourceAlreadyExists{Identifier: k}
+		}
+	case api.TxnUpdate, api.TxnDelete:
+		if existing == nil {
+			return cerrors.ErrorResourceDoesNotExist{Identifier: k}
+		}
+		if (t.Type == api.TxnUpdate || len(t.KVPair.Revision) != 0) && existing.ModRevision != t.rev {
+			return cerrors.ErrorResourceUpdateConflict{Identifier: k}
+		}
+	}
+	return nil
+}
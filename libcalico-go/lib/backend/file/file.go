@@ -0,0 +1,405 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package file implements a backend datastore that stores resources in a local file, for
+// single-node deployments that do not run etcd or Kubernetes.  Resources are stored under the
+// same keys as in the etcdv3 datastore, with etcd-style revisions, and may be watched by any
+// process that shares the file.  Entries do not expire: the TTL of a KVPair is ignored.
+package file
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/apiconfig"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+	cerrors "github.com/projectcalico/calico/libcalico-go/lib/errors"
+	"github.com/projectcalico/calico/libcalico-go/lib/resources"
+)
+
+const (
+	DefaultDatastoreFile = "/var/lib/calico/datastore.json"
+
+	profilesKey            = "/calico/resources/v3/projectcalico.org/profiles/"
+	defaultAllowProfileKey = "/calico/resources/v3/projectcalico.org/profiles/projectcalico-default-allow"
+)
+
+type fileClient struct {
+	store *store
+}
+
+func NewFileClient(config *apiconfig.FileConfig) (api.Client, error) {
+	path := config.DatastoreFile
+	if path == "" {
+		path = DefaultDatastoreFile
+	}
+	log.WithField("path", path).Info("Using file datastore")
+	c := &fileClient{store: newStore(path)}
+
+	// Check that the file is usable.
+	if _, err := c.store.read(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Create an entry in the datastore.  If the entry already exists, this will return
+// an ErrorResourceAlreadyExists error and the current entry.
+func (c *fileClient) Create(ctx context.Context, d *model.KVPair) (*model.KVPair, error) {
+	logCxt := log.WithFields(log.Fields{"key": d.Key, "value": d.Value, "rev": d.Revision})
+	logCxt.Debug("Processing Create request")
+	key, value, err := getKeyValueStrings(d)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing *model.KVPair
+	var rev int64
+	err = c.store.update(func(data *storeData) error {
+		if e := data.Entries[key]; e != nil {
+			existing, _ = entryToKVPair(d.Key, key, e)
+			return cerrors.ErrorResourceAlreadyExists{Identifier: d.Key}
+		}
+		rev = data.put(key, value).ModRevision
+		return nil
+	})
+	if err != nil {
+		logCxt.WithError(err).Debug("Create failed")
+		return existing, wrapError(err, d.Key)
+	}
+	return parsedKVPair(d, value, rev)
+}
+
+// Update an entry in the datastore.  If the entry does not exist, this will return
+// an ErrorResourceDoesNotExist error.  The ResourceVersion must be specified, and if
+// incorrect will return an ErrorResourceUpdateConflict error and the current entry.
+func (c *fileClient) Update(ctx context.Context, d *model.KVPair) (*model.KVPair, error) {
+	logCxt := log.WithFields(log.Fields{"key": d.Key, "value": d.Value, "rev": d.Revision})
+	logCxt.Debug("Processing Update request")
+	key, value, err := getKeyValueStrings(d)
+	if err != nil {
+		return nil, err
+	}
+
+	// ResourceVersion must be set for an Update.
+	expectedRev, err := parseRevision(d.Revision)
+	if err != nil {
+		return nil, err
+	}
+
+	var existing *model.KVPair
+	var rev int64
+	err = c.store.update(func(data *storeData) error {
+		e := data.Entries[key]
+		if e == nil {
+			return cerrors.ErrorResourceDoesNotExist{Identifier: d.Key}
+		}
+		if e.ModRevision != expectedRev {
+			existing, _ = entryToKVPair(d.Key, key, e)
+			return cerrors.ErrorResourceUpdateConflict{Identifier: d.Key}
+		}
+		rev = data.put(key, value).ModRevision
+		return nil
+	})
+	if err != nil {
+		logCxt.WithError(err).Debug("Update failed")
+		return existing, wrapError(err, d.Key)
+	}
+	return parsedKVPair(d, value, rev)
+}
+
+// Apply creates or updates an entry in the datastore, ignoring the revision.
+func (c *fileClient) Apply(ctx context.Context, d *model.KVPair) (*model.KVPair, error) {
+	logCxt := log.WithFields(log.Fields{"key": d.Key, "value": d.Value, "rev": d.Revision})
+	logCxt.Debug("Processing Apply request")
+	key, value, err := getKeyValueStrings(d)
+	if err != nil {
+		return nil, err
+	}
+
+	var rev int64
+	err = c.store.update(func(data *storeData) error {
+		rev = data.put(key, value).ModRevision
+		return nil
+	})
+	if err != nil {
+		logCxt.WithError(err).Warning("Apply failed")
+		return nil, wrapError(err, d.Key)
+	}
+	return parsedKVPair(d, value, rev)
+}
+
+func (c *fileClient) DeleteKVP(ctx context.Context, kvp *model.KVPair) (*model.KVPair, error) {
+	return c.Delete(ctx, kvp.Key, kvp.Revision)
+}
+
+// Delete an entry in the datastore.  This errors if the entry does not exists.
+func (c *fileClient) Delete(ctx context.Context, k model.Key, revision string) (*model.KVPair, error) {
+	logCxt := log.WithFields(log.Fields{"key": k, "rev": revision})
+	logCxt.Debug("Processing Delete request")
+	key, err := model.KeyToDefaultDeletePath(k)
+	if err != nil {
+		return nil, err
+	}
+
+	var expectedRev int64
+	if len(revision) != 0 {
+		if expectedRev, err = parseRevision(revision); err != nil {
+			return nil, err
+		}
+	}
+
+	var existing, deleted *model.KVPair
+	err = c.store.update(func(data *storeData) error {
+		e := data.Entries[key]
+		if e == nil {
+			return cerrors.ErrorResourceDoesNotExist{Identifier: k}
+		}
+		if expectedRev != 0 && e.ModRevision != expectedRev {
+			existing, _ = entryToKVPair(k, key, e)
+			return cerrors.ErrorResourceUpdateConflict{Identifier: k}
+		}
+		data.delete(key)
+		// Don't fail the delete if the previous value can't be parsed.
+		deleted, _ = entryToKVPair(k, key, e)
+		return nil
+	})
+	if err != nil {
+		logCxt.WithError(err).Debug("Delete failed")
+		return existing, wrapError(err, k)
+	}
+	return deleted, nil
+}
+
+// Get an entry from the datastore.  This errors if the entry does not exist.  The file
+// datastore only stores the current value of each entry, so the revision is ignored.
+func (c *fileClient) Get(ctx context.Context, k model.Key, revision string) (*model.KVPair, error) {
+	logCxt := log.WithFields(log.Fields{"key": k, "rev": revision})
+	logCxt.Debug("Processing Get request")
+	key, err := model.KeyToDefaultPath(k)
+	if err != nil {
+		logCxt.Error("Unable to convert model.Key to a path")
+		return nil, err
+	}
+
+	// Handle the static default-allow profile. Always return the default profile.
+	if key == defaultAllowProfileKey {
+		logCxt.Debug("Returning default-allow profile for get")
+		return resources.DefaultAllowProfile(), nil
+	}
+
+	data, err := c.store.read()
+	if err != nil {
+		return nil, cerrors.ErrorDatastoreError{Err: err}
+	}
+	e := data.Entries[key]
+	if e == nil {
+		return nil, cerrors.ErrorResourceDoesNotExist{Identifier: k}
+	}
+	return entryToKVPair(k, key, e)
+}
+
+// List entries in the datastore.  This may return an empty list of there are
+// no entries matching the request in the ListInterface.  The revision is ignored.
+func (c *fileClient) List(ctx context.Context, l model.ListInterface, revision string) (*model.KVPairList, error) {
+	logCxt := log.WithFields(log.Fields{"list-interface": l, "rev": revision})
+	logCxt.Debug("Processing List request")
+
+	data, err := c.store.read()
+	if err != nil {
+		return nil, cerrors.ErrorDatastoreError{Err: err}
+	}
+	matcher := newListMatcher(l)
+
+	keys := make([]string, 0, len(data.Entries))
+	for key := range data.Entries {
+		if matcher.matches(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	// Filter/process the results.
+	list := []*model.KVPair{}
+	for _, key := range keys {
+		if kv := convertListEntry(l, key, data.Entries[key]); kv != nil {
+			list = append(list, kv)
+		}
+	}
+
+	// If we're listing profiles, we need to handle the statically defined
+	// default-allow profile in the resources package.
+	// We always include the default profile.
+	if matcher.path == profilesKey || matcher.path == defaultAllowProfileKey {
+		list = append(list, resources.DefaultAllowProfile())
+	}
+
+	return &model.KVPairList{
+		KVPairs:  list,
+		Revision: strconv.FormatInt(data.Revision, 10),
+	}, nil
+}
+
+// EnsureInitialized makes sure that the datastore is initialized for use by Calico.
+func (c *fileClient) EnsureInitialized() error {
+	return nil
+}
+
+// Clean removes all of the Calico data from the datastore.
+func (c *fileClient) Clean() error {
+	log.Debug("Cleaning file datastore of all Calico data")
+	err := c.store.update(func(data *storeData) error {
+		for key := range data.Entries {
+			if strings.HasPrefix(key, "/calico/") {
+				data.delete(key)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return cerrors.ErrorDatastoreError{Err: err}
+	}
+	return nil
+}
+
+// IsClean returns true if there are no /calico/ prefixed entries in the datastore.
+func (c *fileClient) IsClean() (bool, error) {
+	data, err := c.store.read()
+	if err != nil {
+		return false, cerrors.ErrorDatastoreError{Err: err}
+	}
+	for key := range data.Entries {
+		if strings.HasPrefix(key, "/calico/") {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// listMatcher matches the paths of the entries listed by a ListInterface.
+type listMatcher struct {
+	path   string
+	prefix bool
+}
+
+func newListMatcher(l model.ListInterface) listMatcher {
+	// As for the etcdv3 datastore:
+	// -  If the final name segment of the name is itself a prefix, match paths with the
+	//    constructed path as a prefix.
+	// -  If the path is fully qualified, match it exactly.
+	// -  Otherwise the path is a parent of the entries, so match paths under it.
+	path := model.ListOptionsToDefaultPathRoot(l)
+	if model.IsListOptionsLastSegmentPrefix(l) {
+		return listMatcher{path: path, prefix: true}
+	} else if !model.ListOptionsIsFullyQualified(l) {
+		if !strings.HasSuffix(path, "/") {
+			path += "/"
+		}
+		return listMatcher{path: path, prefix: true}
+	}
+	return listMatcher{path: path}
+}
+
+func (m listMatcher) matches(key string) bool {
+	if m.prefix {
+		return strings.HasPrefix(key, m.path)
+	}
+	return key == m.path
+}
+
+// convertListEntry converts an entry to a model.KVPair with parsed values.  If the path does
+// not represent the resource specified by the ListInterface, or if value cannot be parsed,
+// this returns nil.
+func convertListEntry(l model.ListInterface, path string, e *storeEntry) *model.KVPair {
+	if k := l.KeyFromDefaultPath(path); k != nil {
+		if v, err := model.ParseValue(k, []byte(e.Value)); err == nil {
+			return &model.KVPair{Key: k, Value: v, Revision: strconv.FormatInt(e.ModRevision, 10)}
+		}
+	}
+	return nil
+}
+
+// entryToKVPair converts an entry into a model.KVPair.
+func entryToKVPair(key model.Key, path string, e *storeEntry) (*model.KVPair, error) {
+	v, err := model.ParseValue(key, []byte(e.Value))
+	if err != nil {
+		return nil, cerrors.ErrorParsingDatastoreEntry{
+			RawKey:   path,
+			RawValue: e.Value,
+			Err:      err,
+		}
+	}
+	return &model.KVPair{
+		Key:      key,
+		Value:    v,
+		Revision: strconv.FormatInt(e.ModRevision, 10),
+	}, nil
+}
+
+// parsedKVPair updates the KVPair with the stored value and revision.
+func parsedKVPair(d *model.KVPair, value string, rev int64) (*model.KVPair, error) {
+	v, err := model.ParseValue(d.Key, []byte(value))
+	if err != nil {
+		return nil, cerrors.ErrorPartialFailure{Err: fmt.Errorf("Unexpected error parsing stored datastore entry '%v': %+v", value, err)}
+	}
+	d.Value = v
+	d.Revision = strconv.FormatInt(rev, 10)
+	return d, nil
+}
+
+// getKeyValueStrings returns the path and serialized value calculated from the KVPair.
+func getKeyValueStrings(d *model.KVPair) (string, string, error) {
+	key, err := model.KeyToDefaultPath(d.Key)
+	if err != nil {
+		return "", "", cerrors.ErrorDatastoreError{Err: err, Identifier: d.Key}
+	}
+	bytes, err := model.SerializeValue(d)
+	if err != nil {
+		return "", "", cerrors.ErrorDatastoreError{Err: err, Identifier: d.Key}
+	}
+	return key, string(bytes), nil
+}
+
+// wrapError returns the Calico errors returned by the update functions as is, and wraps
+// failures to read or write the file as datastore errors.
+func wrapError(err error, k model.Key) error {
+	switch err.(type) {
+	case cerrors.ErrorResourceAlreadyExists, cerrors.ErrorResourceDoesNotExist, cerrors.ErrorResourceUpdateConflict:
+		return err
+	}
+	return cerrors.ErrorDatastoreError{Err: err, Identifier: k}
+}
+
+// parseRevision parses the model.KVPair revision string.
+func parseRevision(revs string) (int64, error) {
+	rev, err := strconv.ParseInt(revs, 10, 64)
+	if err != nil {
+		log.WithField("Revision", revs).Debug("Unable to parse Revision")
+		return 0, cerrors.ErrorValidation{
+			ErroredFields: []cerrors.ErroredField{
+				{
+					Name:  "ResourceVersion",
+					Value: revs,
+				},
+			},
+		}
+	}
+	return rev, nil
+}
@@ -0,0 +1,306 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/apiconfig"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/file"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+	cerrors "github.com/projectcalico/calico/libcalico-go/lib/errors"
+)
+
+func ipPoolKV(name, cidr string) *model.KVPair {
+	pool := apiv3.NewIPPool()
+	pool.Name = name
+	pool.Spec.CIDR = cidr
+	return &model.KVPair{
+		Key:   model.ResourceKey{Kind: apiv3.KindIPPool, Name: name},
+		Value: pool,
+	}
+}
+
+var ipPoolList = model.ResourceListOptions{Kind: apiv3.KindIPPool}
+
+var _ = Describe("File datastore", func() {
+	var dir, path string
+	var c api.Client
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "calico-file-datastore")
+		Expect(err).NotTo(HaveOccurred())
+		path = filepath.Join(dir, "data", "datastore.json")
+		c, err = file.NewFileClient(&apiconfig.FileConfig{DatastoreFile: path})
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		_ = os.RemoveAll(dir)
+	})
+
+	It("should create, update, get and delete resources", func() {
+		created, err := c.Create(ctx, ipPoolKV("pool-1", "10.0.0.0/16"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(created.Revision).To(Equal("1"))
+
+		_, err = c.Create(ctx, ipPoolKV("pool-1", "10.1.0.0/16"))
+		Expect(err).To(BeAssignableToTypeOf(cerrors.ErrorResourceAlreadyExists{}))
+
+		update := ipPoolKV("pool-1", "10.2.0.0/16")
+		update.Revision = created.Revision
+		updated, err := c.Update(ctx, update)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(updated.Revision).To(Equal("2"))
+
+		// An update with a stale revision fails and returns the current value.
+		stale := ipPoolKV("pool-1", "10.3.0.0/16")
+		stale.Revision = created.Revision
+		current, err := c.Update(ctx, stale)
+		Expect(err).To(BeAssignableToTypeOf(cerrors.ErrorResourceUpdateConflict{}))
+		Expect(current.Value.(*apiv3.IPPool).Spec.CIDR).To(Equal("10.2.0.0/16"))
+
+		got, err := c.Get(ctx, update.Key, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got.Value.(*apiv3.IPPool).Spec.CIDR).To(Equal("10.2.0.0/16"))
+		Expect(got.Revision).To(Equal("2"))
+
+		_, err = c.Delete(ctx, update.Key, "1")
+		Expect(err).To(BeAssignableToTypeOf(cerrors.ErrorResourceUpdateConflict{}))
+		deleted, err := c.Delete(ctx, update.Key, "2")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(deleted.Value.(*apiv3.IPPool).Spec.CIDR).To(Equal("10.2.0.0/16"))
+
+		_, err = c.Get(ctx, update.Key, "")
+		Expect(err).To(BeAssignableToTypeOf(cerrors.ErrorResourceDoesNotExist{}))
+		_, err = c.Delete(ctx, update.Key, "")
+		Expect(err).To(BeAssignableToTypeOf(cerrors.ErrorResourceDoesNotExist{}))
+	})
+
+	It("should list resources, including the default-allow profile", func() {
+		_, err := c.Apply(ctx, ipPoolKV("pool-2", "10.2.0.0/16"))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = c.Apply(ctx, ipPoolKV("pool-1", "10.1.0.0/16"))
+		Expect(err).NotTo(HaveOccurred())
+
+		list, err := c.List(ctx, ipPoolList, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.Revision).To(Equal("2"))
+		Expect(list.KVPairs).To(HaveLen(2))
+		Expect(list.KVPairs[0].Key.(model.ResourceKey).Name).To(Equal("pool-1"))
+		Expect(list.KVPairs[1].Key.(model.ResourceKey).Name).To(Equal("pool-2"))
+
+		list, err = c.List(ctx, model.ResourceListOptions{Kind: apiv3.KindIPPool, Name: "pool-2"}, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.KVPairs).To(HaveLen(1))
+
+		list, err = c.List(ctx, model.ResourceListOptions{Kind: apiv3.KindProfile}, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.KVPairs).To(HaveLen(1))
+		Expect(list.KVPairs[0].Key.(model.ResourceKey).Name).To(Equal("projectcalico-default-allow"))
+	})
+
+	It("should share the datastore between clients", func() {
+		other, err := file.NewFileClient(&apiconfig.FileConfig{DatastoreFile: path})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = other.Create(ctx, ipPoolKV("pool-1", "10.1.0.0/16"))
+		Expect(err).NotTo(HaveOccurred())
+
+		got, err := c.Get(ctx, ipPoolKV("pool-1", "").Key, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(got.Value.(*apiv3.IPPool).Spec.CIDR).To(Equal("10.1.0.0/16"))
+	})
+
+	It("should clean the datastore", func() {
+		_, err := c.Apply(ctx, ipPoolKV("pool-1", "10.1.0.0/16"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(c.Clean()).To(Succeed())
+		list, err := c.List(ctx, ipPoolList, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.KVPairs).To(BeEmpty())
+	})
+
+	It("should perform transactions atomically", func() {
+		existing, err := c.Create(ctx, ipPoolKV("pool-1", "10.1.0.0/16"))
+		Expect(err).NotTo(HaveOccurred())
+		_, err = c.Create(ctx, ipPoolKV("pool-2", "10.2.0.0/16"))
+		Expect(err).NotTo(HaveOccurred())
+
+		By("failing the whole transaction if one operation fails")
+		update := ipPoolKV("pool-1", "10.11.0.0/16")
+		update.Revision = existing.Revision
+		_, err = c.(api.TxnClient).Txn(ctx, []api.TxnOp{
+			{Type: api.TxnCreate, KVPair: ipPoolKV("pool-3", "10.3.0.0/16")},
+			{Type: api.TxnUpdate, KVPair: update},
+			{Type: api.TxnCreate, KVPair: ipPoolKV("pool-2", "10.22.0.0/16")},
+		})
+		Expect(err).To(BeAssignableToTypeOf(cerrors.ErrorResourceAlreadyExists{}))
+		list, err := c.List(ctx, ipPoolList, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.KVPairs).To(HaveLen(2))
+		Expect(list.KVPairs[0].Value.(*apiv3.IPPool).Spec.CIDR).To(Equal("10.1.0.0/16"))
+
+		By("performing all of the operations if they all succeed")
+		results, err := c.(api.TxnClient).Txn(ctx, []api.TxnOp{
+			{Type: api.TxnCreate, KVPair: ipPoolKV("pool-3", "10.3.0.0/16")},
+			{Type: api.TxnUpdate, KVPair: update},
+			{Type: api.TxnDelete, KVPair: &model.KVPair{Key: ipPoolKV("pool-2", "").Key}},
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(results).To(HaveLen(3))
+		Expect(results[2].Value.(*apiv3.IPPool).Spec.CIDR).To(Equal("10.2.0.0/16"))
+		list, err = c.List(ctx, ipPoolList, "")
+		Expect(err).NotTo(HaveOccurred())
+		Expect(list.KVPairs).To(HaveLen(2))
+		Expect(list.KVPairs[0].Value.(*apiv3.IPPool).Spec.CIDR).To(Equal("10.11.0.0/16"))
+		Expect(list.KVPairs[1].Key.(model.ResourceKey).Name).To(Equal("pool-3"))
+
+		By("rejecting a stale revision")
+		_, err = c.(api.TxnClient).Txn(ctx, []api.TxnOp{
+			{Type: api.TxnDelete, KVPair: update},
+		})
+		Expect(err).To(BeAssignableToTypeOf(cerrors.ErrorResourceUpdateConflict{}))
+	})
+
+	Describe("watches", func() {
+		nextEvent := func(w api.WatchInterface) api.WatchEvent {
+			var e api.WatchEvent
+			EventuallyWithOffset(1, w.ResultChan(), 5*time.Second).Should(Receive(&e))
+			return e
+		}
+
+		It("should send the current entries and then changes made by another client", func() {
+			_, err := c.Apply(ctx, ipPoolKV("pool-1", "10.1.0.0/16"))
+			Expect(err).NotTo(HaveOccurred())
+
+			w, err := c.Watch(ctx, ipPoolList, "")
+			Expect(err).NotTo(HaveOccurred())
+			defer w.Stop()
+			e := nextEvent(w)
+			Expect(e.Type).To(Equal(api.WatchAdded))
+			Expect(e.New.Key.(model.ResourceKey).Name).To(Equal("pool-1"))
+
+			other, err := file.NewFileClient(&apiconfig.FileConfig{DatastoreFile: path})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = other.Apply(ctx, ipPoolKV("pool-1", "10.2.0.0/16"))
+			Expect(err).NotTo(HaveOccurred())
+			e = nextEvent(w)
+			Expect(e.Type).To(Equal(api.WatchModified))
+			Expect(e.New.Value.(*apiv3.IPPool).Spec.CIDR).To(Equal("10.2.0.0/16"))
+
+			_, err = other.Delete(ctx, ipPoolKV("pool-1", "").Key, "")
+			Expect(err).NotTo(HaveOccurred())
+			e = nextEvent(w)
+			Expect(e.Type).To(Equal(api.WatchDeleted))
+			Expect(e.Old.Key.(model.ResourceKey).Name).To(Equal("pool-1"))
+			Expect(e.Old.Revision).To(Equal("3"))
+		})
+
+		It("should send the changes since the given revision", func() {
+			_, err := c.Apply(ctx, ipPoolKV("pool-1", "10.1.0.0/16"))
+			Expect(err).NotTo(HaveOccurred())
+			list, err := c.List(ctx, ipPoolList, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = c.Apply(ctx, ipPoolKV("pool-2", "10.2.0.0/16"))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = c.Delete(ctx, ipPoolKV("pool-1", "").Key, "")
+			Expect(err).NotTo(HaveOccurred())
+			// Not a pool, so not sent.
+			_, err = c.Apply(ctx, &model.KVPair{
+				Key:   model.ResourceKey{Kind: apiv3.KindProfile, Name: "prof"},
+				Value: apiv3.NewProfile(),
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			w, err := c.Watch(ctx, ipPoolList, list.Revision)
+			Expect(err).NotTo(HaveOccurred())
+			defer w.Stop()
+			e := nextEvent(w)
+			Expect(e.Type).To(Equal(api.WatchAdded))
+			Expect(e.New.Key.(model.ResourceKey).Name).To(Equal("pool-2"))
+			e = nextEvent(w)
+			Expect(e.Type).To(Equal(api.WatchDeleted))
+			Expect(e.Old.Key.(model.ResourceKey).Name).To(Equal("pool-1"))
+			Consistently(w.ResultChan(), "200ms").ShouldNot(Receive())
+		})
+
+		It("should send a deletion for a key that was deleted and re-created", func() {
+			_, err := c.Apply(ctx, ipPoolKV("pool-1", "10.1.0.0/16"))
+			Expect(err).NotTo(HaveOccurred())
+			list, err := c.List(ctx, ipPoolList, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = c.Delete(ctx, ipPoolKV("pool-1", "").Key, "")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = c.Create(ctx, ipPoolKV("pool-1", "10.2.0.0/16"))
+			Expect(err).NotTo(HaveOccurred())
+
+			w, err := c.Watch(ctx, ipPoolList, list.Revision)
+			Expect(err).NotTo(HaveOccurred())
+			defer w.Stop()
+			e := nextEvent(w)
+			Expect(e.Type).To(Equal(api.WatchDeleted))
+			Expect(e.Old.Value.(*apiv3.IPPool).Spec.CIDR).To(Equal("10.1.0.0/16"))
+			e = nextEvent(w)
+			Expect(e.Type).To(Equal(api.WatchAdded))
+			Expect(e.New.Value.(*apiv3.IPPool).Spec.CIDR).To(Equal("10.2.0.0/16"))
+			Consistently(w.ResultChan(), "200ms").ShouldNot(Receive())
+		})
+
+		It("should compact a deletion when the key is deleted again", func() {
+			_, err := c.Apply(ctx, ipPoolKV("pool-1", "10.1.0.0/16"))
+			Expect(err).NotTo(HaveOccurred())
+			first, err := c.List(ctx, ipPoolList, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = c.Delete(ctx, ipPoolKV("pool-1", "").Key, "")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = c.Create(ctx, ipPoolKV("pool-1", "10.2.0.0/16"))
+			Expect(err).NotTo(HaveOccurred())
+			second, err := c.List(ctx, ipPoolList, "")
+			Expect(err).NotTo(HaveOccurred())
+			_, err = c.Delete(ctx, ipPoolKV("pool-1", "").Key, "")
+			Expect(err).NotTo(HaveOccurred())
+
+			// The first deletion is no longer recorded.
+			_, err = c.Watch(ctx, ipPoolList, first.Revision)
+			Expect(err).To(HaveOccurred())
+
+			w, err := c.Watch(ctx, ipPoolList, second.Revision)
+			Expect(err).NotTo(HaveOccurred())
+			defer w.Stop()
+			e := nextEvent(w)
+			Expect(e.Type).To(Equal(api.WatchDeleted))
+			Expect(e.Old.Value.(*apiv3.IPPool).Spec.CIDR).To(Equal("10.2.0.0/16"))
+			Consistently(w.ResultChan(), "200ms").ShouldNot(Receive())
+		})
+
+		It("should refuse to watch from a revision in the future", func() {
+			_, err := c.Watch(ctx, ipPoolList, "10")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,231 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// maxDeletedEntries is the number of deletions that are remembered so that watchers that are
+// behind can be told about them.  Watchers that are further behind must resync.
+const maxDeletedEntries = 1000
+
+// storeEntry is a single key in the datastore file.  The revisions have the same meaning as
+// the etcd create and mod revisions.
+type storeEntry struct {
+	Value          string `json:"value"`
+	CreateRevision int64  `json:"createRevision"`
+	ModRevision    int64  `json:"modRevision"`
+}
+
+// storeData is the content of the datastore file.  Keys are the default (etcd) paths of the
+// model keys.
+type storeData struct {
+	// Revision is incremented by every write.
+	Revision int64 `json:"revision"`
+	// CompactRevision is the latest revision whose deletions are no longer recorded in Deleted.
+	CompactRevision int64                  `json:"compactRevision,omitempty"`
+	Entries         map[string]*storeEntry `json:"entries"`
+	// Deleted holds the last value of recently deleted keys.  The ModRevision of each entry is
+	// the revision that deleted it.
+	Deleted map[string]*storeEntry `json:"deleted,omitempty"`
+}
+
+func newStoreData() *storeData {
+	return &storeData{
+		Entries: map[string]*storeEntry{},
+		Deleted: map[string]*storeEntry{},
+	}
+}
+
+// put sets the value of the key in a new revision.
+func (d *storeData) put(key, value string) *storeEntry {
+	d.Revision++
+	e := &storeEntry{Value: value, CreateRevision: d.Revision, ModRevision: d.Revision}
+	if existing := d.Entries[key]; existing != nil {
+		e.CreateRevision = existing.CreateRevision
+	}
+	d.Entries[key] = e
+	// Keep any record of an earlier deletion of the key until it is compacted, so that
+	// watchers that are behind see the deletion before the key is re-created.
+	return e
+}
+
+// delete removes the key in a new revision and returns the deleted entry.
+func (d *storeData) delete(key string) *storeEntry {
+	e := d.Entries[key]
+	if e == nil {
+		return nil
+	}
+	d.Revision++
+	delete(d.Entries, key)
+	if prev := d.Deleted[key]; prev != nil && prev.ModRevision > d.CompactRevision {
+		// Only the latest deletion of each key is recorded, so the earlier deletion is
+		// compacted.
+		d.CompactRevision = prev.ModRevision
+	}
+	d.Deleted[key] = &storeEntry{Value: e.Value, CreateRevision: e.CreateRevision, ModRevision: d.Revision}
+	return e
+}
+
+// compact forgets the oldest deletions, so that the file does not grow without limit.
+func (d *storeData) compact() {
+	if len(d.Deleted) <= maxDeletedEntries {
+		return
+	}
+	keys := make([]string, 0, len(d.Deleted))
+	for k := range d.Deleted {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return d.Deleted[keys[i]].ModRevision < d.Deleted[keys[j]].ModRevision
+	})
+	for _, k := range keys[:len(keys)-maxDeletedEntries] {
+		if rev := d.Deleted[k].ModRevision; rev > d.CompactRevision {
+			d.CompactRevision = rev
+		}
+		delete(d.Deleted, k)
+	}
+}
+
+// store reads and writes the datastore file.  Writers take an exclusive lock on a separate lock
+// file and replace the datastore file atomically, so that several processes (for example
+// calicoctl and felix) can share the datastore.
+type store struct {
+	path     string
+	lockPath string
+}
+
+func newStore(path string) *store {
+	return &store{path: path, lockPath: path + ".lock"}
+}
+
+// read returns the current content of the datastore.  A missing file is an empty datastore.
+func (s *store) read() (*storeData, error) {
+	unlock, err := s.lock(false)
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	return s.load()
+}
+
+// update applies the function to the current content of the datastore and writes the result.
+// If the function returns an error, the datastore is not modified.
+func (s *store) update(f func(d *storeData) error) error {
+	unlock, err := s.lock(true)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	d, err := s.load()
+	if err != nil {
+		return err
+	}
+	rev := d.Revision
+	if err := f(d); err != nil {
+		return err
+	}
+	if d.Revision == rev {
+		// Nothing changed.
+		return nil
+	}
+	d.compact()
+	return s.save(d)
+}
+
+func (s *store) load() (*storeData, error) {
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return newStoreData(), nil
+	} else if err != nil {
+		return nil, err
+	}
+	d := newStoreData()
+	if len(b) == 0 {
+		return d, nil
+	}
+	if err := json.Unmarshal(b, d); err != nil {
+		return nil, fmt.Errorf("failed to parse datastore file %s: %w", s.path, err)
+	}
+	if d.Entries == nil {
+		d.Entries = map[string]*storeEntry{}
+	}
+	if d.Deleted == nil {
+		d.Deleted = map[string]*storeEntry{}
+	}
+	return d, nil
+}
+
+func (s *store) save(d *storeData) error {
+	b, err := json.MarshalIndent(d, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file and rename it over the datastore file so that readers never
+	// see a partially written file.
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := os.Remove(tmp.Name()); err != nil && !errors.Is(err, os.ErrNotExist) {
+			log.WithError(err).Warn("Failed to remove temporary datastore file")
+		}
+	}()
+	if _, err := tmp.Write(b); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
+
+// lock takes a shared or exclusive lock on the datastore and returns the function that
+// releases it.
+func (s *store) lock(exclusive bool) (func(), error) {
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return nil, err
+	}
+	f, err := os.OpenFile(s.lockPath, os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, err
+	}
+	if err := lockFile(f, exclusive); err != nil {
+		_ = f.Close()
+		return nil, fmt.Errorf("failed to lock datastore file %s: %w", s.lockPath, err)
+	}
+	return func() {
+		if err := unlockFile(f); err != nil {
+			log.WithError(err).Warn("Failed to unlock datastore file")
+		}
+		_ = f.Close()
+	}, nil
+}
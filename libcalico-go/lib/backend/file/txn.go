@@ -0,0 +1,108 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+	cerrors "github.com/projectcalico/calico/libcalico-go/lib/errors"
+)
+
+// Txn performs the supplied operations in a single update of the datastore file, so either
+// all of the operations succeed or the file is not modified.
+func (c *fileClient) Txn(ctx context.Context, ops []api.TxnOp) ([]*model.KVPair, error) {
+	log.WithField("numOps", len(ops)).Debug("Processing Txn request")
+
+	paths := make([]string, len(ops))
+	values := make([]string, len(ops))
+	revs := make([]int64, len(ops))
+	seen := map[string]bool{}
+	for i, op := range ops {
+		var err error
+		switch op.Type {
+		case api.TxnCreate, api.TxnUpdate, api.TxnApply:
+			paths[i], values[i], err = getKeyValueStrings(op.KVPair)
+		case api.TxnDelete:
+			paths[i], err = model.KeyToDefaultDeletePath(op.KVPair.Key)
+		default:
+			err = fmt.Errorf("unknown transaction operation %q", op.Type)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if seen[paths[i]] {
+			return nil, fmt.Errorf("resource %v appears more than once in the transaction", op.KVPair.Key)
+		}
+		seen[paths[i]] = true
+
+		// ResourceVersion must be set for an Update, and is optional for a Delete.
+		if op.Type == api.TxnUpdate || (op.Type == api.TxnDelete && len(op.KVPair.Revision) != 0) {
+			if revs[i], err = parseRevision(op.KVPair.Revision); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	results := make([]*model.KVPair, len(ops))
+	newRevs := make([]int64, len(ops))
+	err := c.store.update(func(data *storeData) error {
+		for i, op := range ops {
+			k := op.KVPair.Key
+			e := data.Entries[paths[i]]
+			switch op.Type {
+			case api.TxnCreate:
+				if e != nil {
+					return cerrors.ErrorResourceAlreadyExists{Identifier: k}
+				}
+			case api.TxnUpdate, api.TxnDelete:
+				if e == nil {
+					return cerrors.ErrorResourceDoesNotExist{Identifier: k}
+				}
+				if revs[i] != 0 && e.ModRevision != revs[i] {
+					return cerrors.ErrorResourceUpdateConflict{Identifier: k}
+				}
+			}
+
+			if op.Type == api.TxnDelete {
+				data.delete(paths[i])
+				// Don't fail the delete if the previous value can't be parsed.
+				results[i], _ = entryToKVPair(k, paths[i], e)
+				continue
+			}
+			newRevs[i] = data.put(paths[i], values[i]).ModRevision
+		}
+		return nil
+	})
+	if err != nil {
+		log.WithError(err).Debug("Txn failed")
+		return nil, wrapError(err, nil)
+	}
+
+	for i, op := range ops {
+		if op.Type == api.TxnDelete {
+			continue
+		}
+		kvp := *op.KVPair
+		if results[i], err = parsedKVPair(&kvp, values[i], newRevs[i]); err != nil {
+			return nil, err
+		}
+	}
+	return results, nil
+}
@@ -0,0 +1,294 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package file
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+)
+
+const resultsBufSize = 100
+
+// pollInterval is the interval at which the watcher re-reads the datastore file, in case a
+// file change notification is missed.
+var pollInterval = 10 * time.Second
+
+// Watch entries in the datastore matching the resources specified by the ListInterface.
+func (c *fileClient) Watch(ctx context.Context, l model.ListInterface, revision string) (api.WatchInterface, error) {
+	var rev int64
+	if len(revision) != 0 {
+		var err error
+		rev, err = strconv.ParseInt(revision, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if rev != 0 {
+		// Check that we can send the events since the requested revision.
+		data, err := c.store.read()
+		if err != nil {
+			return nil, err
+		}
+		if rev < data.CompactRevision || rev > data.Revision {
+			return nil, fmt.Errorf("revision %d is no longer available (current revision %d, compacted to %d)",
+				rev, data.Revision, data.CompactRevision)
+		}
+	}
+
+	// Watch the directory rather than the file, since writes replace the file.
+	dir := filepath.Dir(c.store.path)
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	fsw, err := fsnotify.NewWatcher()
+	if err == nil {
+		err = fsw.Add(dir)
+	}
+	if err != nil {
+		log.WithError(err).Warn("Unable to watch datastore file for changes, falling back to polling")
+		if fsw != nil {
+			_ = fsw.Close()
+		}
+		fsw = nil
+	}
+
+	wc := &watcher{
+		client:     c,
+		list:       l,
+		matcher:    newListMatcher(l),
+		rev:        rev,
+		fsw:        fsw,
+		resultChan: make(chan api.WatchEvent, resultsBufSize),
+	}
+	wc.ctx, wc.cancel = context.WithCancel(ctx)
+	go wc.watchLoop()
+	return wc, nil
+}
+
+// watcher implements watch.Interface.  It re-reads the datastore file whenever it changes and
+// sends events for the entries that changed since the last revision it saw.
+type watcher struct {
+	client     *fileClient
+	list       model.ListInterface
+	matcher    listMatcher
+	rev        int64
+	fsw        *fsnotify.Watcher
+	ctx        context.Context
+	cancel     context.CancelFunc
+	resultChan chan api.WatchEvent
+	terminated uint32
+}
+
+// Stop stops the watcher and releases associated resources.
+// This calls through to the context cancel function.
+func (wc *watcher) Stop() {
+	wc.cancel()
+}
+
+// ResultChan returns a channel used to receive WatchEvents.
+func (wc *watcher) ResultChan() <-chan api.WatchEvent {
+	return wc.resultChan
+}
+
+// HasTerminated returns true when the watcher has completed termination processing.
+func (wc *watcher) HasTerminated() bool {
+	return atomic.LoadUint32(&wc.terminated) != 0
+}
+
+func (wc *watcher) watchLoop() {
+	// When this loop exits, make sure we terminate the watcher resources.
+	defer wc.terminateWatcher()
+
+	data, err := wc.client.store.read()
+	if err != nil {
+		wc.sendError(err)
+		return
+	}
+	if wc.rev == 0 {
+		// No initial revision supplied, so send the current entries as added entries.
+		wc.sendCurrentEntries(data)
+	} else if !wc.sendChanges(data) {
+		return
+	}
+
+	var fsEvents <-chan fsnotify.Event
+	var fsErrors <-chan error
+	if wc.fsw != nil {
+		fsEvents = wc.fsw.Events
+		fsErrors = wc.fsw.Errors
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-wc.ctx.Done():
+			return
+		case e, ok := <-fsEvents:
+			if !ok {
+				fsEvents = nil
+				continue
+			}
+			if filepath.Base(e.Name) != filepath.Base(wc.client.store.path) {
+				continue
+			}
+			log.WithField("event", e).Debug("Datastore file changed")
+		case err, ok := <-fsErrors:
+			if !ok {
+				fsErrors = nil
+				continue
+			}
+			log.WithError(err).Warn("Error watching datastore file")
+			continue
+		case <-ticker.C:
+		}
+
+		data, err := wc.client.store.read()
+		if err != nil {
+			wc.sendError(err)
+			return
+		}
+		if !wc.sendChanges(data) {
+			return
+		}
+	}
+}
+
+// sendCurrentEntries sends an added event for each entry that matches the ListInterface.
+func (wc *watcher) sendCurrentEntries(data *storeData) {
+	keys := make([]string, 0, len(data.Entries))
+	for key := range data.Entries {
+		// The default-allow profile is not stored in the datastore, so we don't need to
+		// filter it out here.
+		if wc.matcher.matches(key) {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	log.WithField("NumEntries", len(keys)).Debug("Sending create events for each existing entry")
+	for _, key := range keys {
+		if kv := convertListEntry(wc.list, key, data.Entries[key]); kv != nil {
+			wc.sendEvent(&api.WatchEvent{Type: api.WatchAdded, New: kv})
+		}
+	}
+	wc.rev = data.Revision
+}
+
+// sendChanges sends events for the entries that changed after the watcher's revision.  It
+// returns false if the watcher is too far behind to determine the changes, after sending an
+// error.
+func (wc *watcher) sendChanges(data *storeData) bool {
+	if data.Revision == wc.rev {
+		return true
+	}
+	if data.Revision < wc.rev || wc.rev < data.CompactRevision {
+		// Either the file has been replaced, or we have missed deletions.
+		wc.sendError(fmt.Errorf("datastore revision %d is no longer available (current revision %d, compacted to %d)",
+			wc.rev, data.Revision, data.CompactRevision))
+		return false
+	}
+
+	type change struct {
+		rev   int64
+		event *api.WatchEvent
+	}
+	var changes []change
+	for key, e := range data.Entries {
+		if e.ModRevision <= wc.rev || !wc.matcher.matches(key) {
+			continue
+		}
+		kv := convertListEntry(wc.list, key, e)
+		if kv == nil {
+			continue
+		}
+		eventType := api.WatchModified
+		if e.CreateRevision > wc.rev {
+			eventType = api.WatchAdded
+		}
+		changes = append(changes, change{rev: e.ModRevision, event: &api.WatchEvent{Type: eventType, New: kv}})
+	}
+	for key, e := range data.Deleted {
+		// Skip entries that were created after our revision, since we never saw them.  A key
+		// that has since been re-created is also in Entries, and is sent as a deletion
+		// followed by an addition.
+		if e.ModRevision <= wc.rev || e.CreateRevision > wc.rev || !wc.matcher.matches(key) {
+			continue
+		}
+		kv := convertListEntry(wc.list, key, e)
+		if kv == nil {
+			continue
+		}
+		changes = append(changes, change{rev: e.ModRevision, event: &api.WatchEvent{Type: api.WatchDeleted, Old: kv}})
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].rev < changes[j].rev
+	})
+
+	for _, c := range changes {
+		wc.sendEvent(c.event)
+	}
+	wc.rev = data.Revision
+	return true
+}
+
+// terminateWatcher terminates the resources associated with the watcher.
+func (wc *watcher) terminateWatcher() {
+	log.Debug("Terminating file watcher")
+	wc.cancel()
+	if wc.fsw != nil {
+		if err := wc.fsw.Close(); err != nil {
+			log.WithError(err).Debug("Error closing file watcher")
+		}
+	}
+
+	// Close the results channel.
+	close(wc.resultChan)
+
+	// Increment the terminated counter using a goroutine safe operation.
+	atomic.AddUint32(&wc.terminated, 1)
+}
+
+// sendError packages up the error as an event and sends it in the results channel.
+func (wc *watcher) sendError(err error) {
+	wc.sendEvent(&api.WatchEvent{
+		Type:  api.WatchError,
+		Error: err,
+	})
+}
+
+// sendEvent sends an event in the results channel.
+func (wc *watcher) sendEvent(e *api.WatchEvent) {
+	if len(wc.resultChan) == resultsBufSize {
+		log.Warningf("Watch events backing up: %d events", resultsBufSize)
+	}
+	select {
+	case wc.resultChan <- *e:
+	case <-wc.ctx.Done():
+	}
+}
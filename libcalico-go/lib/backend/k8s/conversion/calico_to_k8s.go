@@ -0,0 +1,657 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conversion
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"github.com/projectcalico/api/pkg/lib/numorstring"
+	kapiv1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	adminpolicy "sigs.k8s.io/network-policy-api/apis/v1alpha1"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/names"
+	cnet "github.com/projectcalico/calico/libcalico-go/lib/net"
+	"github.com/projectcalico/calico/libcalico-go/lib/selector/parser"
+)
+
+// The conversions in this file are the reverse of K8sNetworkPolicyToCalico and
+// K8sAdminNetworkPolicyToCalico.  Calico policy is more expressive than the Kubernetes APIs, so
+// the conversions are lossy: anything that can't be expressed is left out of the result and
+// described in the returned list of unsupported features.  Rules are only ever dropped, never
+// widened, so that a converted policy never allows more than the original.
+
+// k8sNamespaceNameLabel is the label that Kubernetes adds to every namespace with its name.
+const k8sNamespaceNameLabel = "kubernetes.io/metadata.name"
+
+// CalicoNetworkPolicyToK8s converts a Calico NetworkPolicy to a Kubernetes NetworkPolicy.  It
+// returns an error if the policy's selector can't be expressed as a Kubernetes pod selector.
+func CalicoNetworkPolicyToK8s(p *apiv3.NetworkPolicy) (*networkingv1.NetworkPolicy, []string, error) {
+	var unsupported []string
+
+	podSelector, _, err := calicoSelectorToK8s(p.Spec.Selector, SelectorPod)
+	if err != nil {
+		return nil, nil, err
+	}
+	if podSelector == nil {
+		podSelector = &metav1.LabelSelector{}
+	}
+
+	if p.Spec.Tier != "" && p.Spec.Tier != names.DefaultTierName {
+		unsupported = append(unsupported, fmt.Sprintf(
+			"tier %q: Kubernetes network policies are enforced in the %s tier", p.Spec.Tier, names.DefaultTierName))
+	}
+	if p.Spec.ServiceAccountSelector != "" {
+		unsupported = append(unsupported, "serviceAccountSelector: the policy applies to all service accounts")
+	}
+
+	np := &networkingv1.NetworkPolicy{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "NetworkPolicy",
+			APIVersion: networkingv1.SchemeGroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        strings.TrimPrefix(p.Name, names.K8sNetworkPolicyNamePrefix),
+			Namespace:   p.Namespace,
+			Labels:      p.Labels,
+			Annotations: p.Annotations,
+		},
+		Spec: networkingv1.NetworkPolicySpec{
+			PodSelector: *podSelector,
+		},
+	}
+
+	for i, r := range p.Spec.Ingress {
+		rule, err := calicoRuleToK8s(&r, true)
+		if err != nil {
+			unsupported = append(unsupported, fmt.Sprintf("ingress rule %d dropped: %s", i+1, err))
+			continue
+		}
+		np.Spec.Ingress = append(np.Spec.Ingress, networkingv1.NetworkPolicyIngressRule{
+			From:  rule.peers,
+			Ports: rule.ports,
+		})
+	}
+	for i, r := range p.Spec.Egress {
+		rule, err := calicoRuleToK8s(&r, false)
+		if err != nil {
+			unsupported = append(unsupported, fmt.Sprintf("egress rule %d dropped: %s", i+1, err))
+			continue
+		}
+		np.Spec.Egress = append(np.Spec.Egress, networkingv1.NetworkPolicyEgressRule{
+			To:    rule.peers,
+			Ports: rule.ports,
+		})
+	}
+
+	for _, t := range calicoPolicyTypes(p.Spec.Types, len(p.Spec.Egress) > 0) {
+		switch t {
+		case apiv3.PolicyTypeIngress:
+			np.Spec.PolicyTypes = append(np.Spec.PolicyTypes, networkingv1.PolicyTypeIngress)
+		case apiv3.PolicyTypeEgress:
+			np.Spec.PolicyTypes = append(np.Spec.PolicyTypes, networkingv1.PolicyTypeEgress)
+		}
+	}
+
+	return np, unsupported, nil
+}
+
+// CalicoGlobalNetworkPolicyToK8sAdmin converts a Calico GlobalNetworkPolicy to a Kubernetes
+// AdminNetworkPolicy.  It returns an error if the policy's selectors can't be expressed as an
+// AdminNetworkPolicy subject.
+func CalicoGlobalNetworkPolicyToK8sAdmin(p *apiv3.GlobalNetworkPolicy) (*adminpolicy.AdminNetworkPolicy, []string, error) {
+	var unsupported []string
+
+	subject, podsOnly, err := calicoSelectorsToANPSubject(p.Spec.NamespaceSelector, p.Spec.Selector)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !podsOnly {
+		unsupported = append(unsupported, "selector: the policy only applies to pods, not to host endpoints")
+	}
+
+	if p.Spec.Tier != names.AdminNetworkPolicyTierName {
+		tier := p.Spec.Tier
+		if tier == "" {
+			tier = names.DefaultTierName
+		}
+		unsupported = append(unsupported, fmt.Sprintf(
+			"tier %q: admin network policies are enforced in the %s tier", tier, names.AdminNetworkPolicyTierName))
+	}
+
+	// Admin network policy priorities are integers in the range 0-1000.
+	priority := int32(1000)
+	if p.Spec.Order == nil {
+		unsupported = append(unsupported, "order: the policy has no order, so it is given the lowest priority (1000)")
+	} else {
+		order := *p.Spec.Order
+		priority = int32(math.Max(0, math.Min(1000, math.Floor(order))))
+		if float64(priority) != order {
+			unsupported = append(unsupported, fmt.Sprintf("order %v: converted to priority %d", order, priority))
+		}
+	}
+
+	if p.Spec.DoNotTrack {
+		unsupported = append(unsupported, "doNotTrack")
+	}
+	if p.Spec.PreDNAT {
+		unsupported = append(unsupported, "preDNAT")
+	}
+	if p.Spec.ApplyOnForward {
+		unsupported = append(unsupported, "applyOnForward")
+	}
+	if p.Spec.ServiceAccountSelector != "" {
+		unsupported = append(unsupported, "serviceAccountSelector: the policy applies to all service accounts")
+	}
+
+	anp := &adminpolicy.AdminNetworkPolicy{
+		TypeMeta: metav1.TypeMeta{
+			Kind:       "AdminNetworkPolicy",
+			APIVersion: adminpolicy.GroupVersion.String(),
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        strings.TrimPrefix(p.Name, names.K8sAdminNetworkPolicyNamePrefix),
+			Labels:      p.Labels,
+			Annotations: p.Annotations,
+		},
+		Spec: adminpolicy.AdminNetworkPolicySpec{
+			Priority: priority,
+			Subject:  *subject,
+		},
+	}
+
+	for i, r := range p.Spec.Ingress {
+		rule, err := calicoRuleToK8sAdmin(&r, true)
+		if err != nil {
+			unsupported = append(unsupported, fmt.Sprintf("ingress rule %d dropped: %s", i+1, err))
+			continue
+		}
+		unsupported = append(unsupported, prefixAll(fmt.Sprintf("ingress rule %d: ", i+1), rule.unsupported)...)
+		var from []adminpolicy.AdminNetworkPolicyIngressPeer
+		for _, peer := range rule.peers {
+			from = append(from, adminpolicy.AdminNetworkPolicyIngressPeer{
+				Namespaces: peer.Namespaces,
+				Pods:       peer.Pods,
+			})
+		}
+		anp.Spec.Ingress = append(anp.Spec.Ingress, adminpolicy.AdminNetworkPolicyIngressRule{
+			Name:   rule.name,
+			Action: rule.action,
+			From:   from,
+			Ports:  rule.ports,
+		})
+	}
+	for i, r := range p.Spec.Egress {
+		rule, err := calicoRuleToK8sAdmin(&r, false)
+		if err != nil {
+			unsupported = append(unsupported, fmt.Sprintf("egress rule %d dropped: %s", i+1, err))
+			continue
+		}
+		unsupported = append(unsupported, prefixAll(fmt.Sprintf("egress rule %d: ", i+1), rule.unsupported)...)
+		anp.Spec.Egress = append(anp.Spec.Egress, adminpolicy.AdminNetworkPolicyEgressRule{
+			Name:   rule.name,
+			Action: rule.action,
+			To:     rule.peers,
+			Ports:  rule.ports,
+		})
+	}
+
+	return anp, unsupported, nil
+}
+
+// calicoPolicyTypes returns the policy types of a Calico policy, applying the same defaulting
+// as the Calico API when no types are specified.
+func calicoPolicyTypes(types []apiv3.PolicyType, hasEgressRules bool) []apiv3.PolicyType {
+	if len(types) != 0 {
+		return types
+	}
+	if hasEgressRules {
+		return []apiv3.PolicyType{apiv3.PolicyTypeIngress, apiv3.PolicyTypeEgress}
+	}
+	return []apiv3.PolicyType{apiv3.PolicyTypeIngress}
+}
+
+type k8sRule struct {
+	peers []networkingv1.NetworkPolicyPeer
+	ports []networkingv1.NetworkPolicyPort
+}
+
+// calicoRuleToK8s converts a Calico rule to the peers and ports of a Kubernetes NetworkPolicy
+// rule.  It returns an error describing the first feature of the rule that can't be expressed.
+func calicoRuleToK8s(r *apiv3.Rule, ingress bool) (*k8sRule, error) {
+	if r.Action != apiv3.Allow {
+		return nil, fmt.Errorf("action %s is not supported, Kubernetes network policies can only allow traffic", r.Action)
+	}
+	if err := checkCalicoRuleFields(r, ingress, false); err != nil {
+		return nil, err
+	}
+	peerEntity := &r.Source
+	if !ingress {
+		peerEntity = &r.Destination
+	}
+
+	rule := &k8sRule{}
+	if len(peerEntity.Nets) != 0 {
+		if peerEntity.Selector != "" || peerEntity.NamespaceSelector != "" {
+			return nil, fmt.Errorf("nets can't be combined with selectors")
+		}
+		blocks, err := calicoNetsToIPBlocks(peerEntity.Nets, peerEntity.NotNets)
+		if err != nil {
+			return nil, err
+		}
+		for _, b := range blocks {
+			rule.peers = append(rule.peers, networkingv1.NetworkPolicyPeer{IPBlock: b})
+		}
+	} else if len(peerEntity.NotNets) != 0 {
+		return nil, fmt.Errorf("notNets is only supported with nets")
+	} else if peerEntity.Selector != "" || peerEntity.NamespaceSelector != "" {
+		// An empty namespace selector means the policy's own namespace, which is what a
+		// Kubernetes peer with only a pod selector selects.
+		podSelector, _, err := calicoSelectorToK8s(peerEntity.Selector, SelectorPod)
+		if err != nil {
+			return nil, err
+		}
+		nsSelector, _, err := calicoSelectorToK8s(peerEntity.NamespaceSelector, SelectorNamespace)
+		if err != nil {
+			return nil, err
+		}
+		if podSelector == nil && nsSelector == nil {
+			podSelector = &metav1.LabelSelector{}
+		}
+		rule.peers = append(rule.peers, networkingv1.NetworkPolicyPeer{
+			PodSelector:       podSelector,
+			NamespaceSelector: nsSelector,
+		})
+	}
+
+	if r.Protocol != nil {
+		protocol, err := calicoProtocolToK8s(*r.Protocol)
+		if err != nil {
+			return nil, err
+		}
+		if len(r.Destination.Ports) == 0 {
+			rule.ports = append(rule.ports, networkingv1.NetworkPolicyPort{Protocol: &protocol})
+		}
+		for _, p := range r.Destination.Ports {
+			port := networkingv1.NetworkPolicyPort{Protocol: &protocol}
+			if p.PortName != "" {
+				name := intstr.FromString(p.PortName)
+				port.Port = &name
+			} else {
+				num := intstr.FromInt32(int32(p.MinPort))
+				port.Port = &num
+				if p.MaxPort != p.MinPort {
+					end := int32(p.MaxPort)
+					port.EndPort = &end
+				}
+			}
+			rule.ports = append(rule.ports, port)
+		}
+	}
+	return rule, nil
+}
+
+type k8sAdminRule struct {
+	name        string
+	action      adminpolicy.AdminNetworkPolicyRuleAction
+	peers       []adminpolicy.AdminNetworkPolicyEgressPeer
+	ports       *[]adminpolicy.AdminNetworkPolicyPort
+	unsupported []string
+}
+
+// calicoRuleToK8sAdmin converts a Calico rule to an AdminNetworkPolicy rule.  The peers are
+// returned as egress peers; ingress rules only use the fields that ingress peers have.
+func calicoRuleToK8sAdmin(r *apiv3.Rule, ingress bool) (*k8sAdminRule, error) {
+	rule := &k8sAdminRule{}
+	switch r.Action {
+	case apiv3.Allow, apiv3.Deny, apiv3.Pass:
+		rule.action = adminpolicy.AdminNetworkPolicyRuleAction(r.Action)
+	default:
+		return nil, fmt.Errorf("action %s is not supported", r.Action)
+	}
+	if err := checkCalicoRuleFields(r, ingress, true); err != nil {
+		return nil, err
+	}
+	if r.Metadata != nil {
+		rule.name = r.Metadata.Annotations[AdminPolicyRuleNameLabel]
+	}
+	peerEntity := &r.Source
+	if !ingress {
+		peerEntity = &r.Destination
+	}
+
+	if len(peerEntity.Nets) != 0 {
+		if ingress {
+			return nil, fmt.Errorf("nets are not supported in ingress rules")
+		}
+		if peerEntity.Selector != "" || peerEntity.NamespaceSelector != "" {
+			return nil, fmt.Errorf("nets can't be combined with selectors")
+		}
+		if len(peerEntity.NotNets) != 0 {
+			return nil, fmt.Errorf("notNets is not supported")
+		}
+		peer := adminpolicy.AdminNetworkPolicyEgressPeer{}
+		for _, n := range peerEntity.Nets {
+			_, ipNet, err := cnet.ParseCIDROrIP(n)
+			if err != nil {
+				return nil, fmt.Errorf("invalid net %q: %w", n, err)
+			}
+			peer.Networks = append(peer.Networks, adminpolicy.CIDR(ipNet.String()))
+		}
+		rule.peers = append(rule.peers, peer)
+	} else if len(peerEntity.NotNets) != 0 {
+		return nil, fmt.Errorf("notNets is not supported")
+	} else {
+		// Admin network policy rules must have a peer.  The closest to "anywhere" is every pod.
+		if peerEntity.Selector == "" && peerEntity.NamespaceSelector == "" {
+			rule.unsupported = append(rule.unsupported, "the rule only matches pods, not traffic from or to anywhere")
+		}
+		subject, _, err := calicoSelectorsToANPSubject(peerEntity.NamespaceSelector, peerEntity.Selector)
+		if err != nil {
+			return nil, err
+		}
+		rule.peers = append(rule.peers, adminpolicy.AdminNetworkPolicyEgressPeer{
+			Namespaces: subject.Namespaces,
+			Pods:       subject.Pods,
+		})
+	}
+
+	if r.Protocol != nil {
+		protocol, err := calicoProtocolToK8s(*r.Protocol)
+		if err != nil {
+			return nil, err
+		}
+		var ports []adminpolicy.AdminNetworkPolicyPort
+		if len(r.Destination.Ports) == 0 {
+			// Admin network policy ports can't match a protocol alone, so match all its ports.
+			ports = append(ports, adminpolicy.AdminNetworkPolicyPort{
+				PortRange: &adminpolicy.PortRange{Protocol: protocol, Start: 1, End: 65535},
+			})
+		}
+		for _, p := range r.Destination.Ports {
+			switch {
+			case p.PortName != "":
+				name := p.PortName
+				ports = append(ports, adminpolicy.AdminNetworkPolicyPort{NamedPort: &name})
+				if protocol != kapiv1.ProtocolTCP {
+					rule.unsupported = append(rule.unsupported, fmt.Sprintf("protocol %s of named port %s", protocol, name))
+				}
+			case p.MinPort == p.MaxPort:
+				ports = append(ports, adminpolicy.AdminNetworkPolicyPort{
+					PortNumber: &adminpolicy.Port{Protocol: protocol, Port: int32(p.MinPort)},
+				})
+			default:
+				ports = append(ports, adminpolicy.AdminNetworkPolicyPort{
+					PortRange: &adminpolicy.PortRange{Protocol: protocol, Start: int32(p.MinPort), End: int32(p.MaxPort)},
+				})
+			}
+		}
+		rule.ports = &ports
+	}
+	return rule, nil
+}
+
+// checkCalicoRuleFields returns an error naming the first field of the rule that Kubernetes
+// policy rules don't have an equivalent for.
+func checkCalicoRuleFields(r *apiv3.Rule, ingress, admin bool) error {
+	switch {
+	case r.IPVersion != nil:
+		return fmt.Errorf("ipVersion is not supported")
+	case r.ICMP != nil || r.NotICMP != nil:
+		return fmt.Errorf("ICMP matches are not supported")
+	case r.NotProtocol != nil:
+		return fmt.Errorf("notProtocol is not supported")
+	case r.HTTP != nil:
+		return fmt.Errorf("HTTP matches are not supported")
+	case len(r.Destination.Ports) != 0 && r.Protocol == nil:
+		return fmt.Errorf("ports require a protocol")
+	case len(r.Source.Ports) != 0 || len(r.Source.NotPorts) != 0:
+		return fmt.Errorf("source ports are not supported")
+	case len(r.Destination.NotPorts) != 0:
+		return fmt.Errorf("notPorts is not supported")
+	}
+
+	// The policy's own endpoints are the destination of ingress rules and the source of egress
+	// rules; the policy's selector is the only way to select them.
+	local, peer := &r.Destination, &r.Source
+	if !ingress {
+		local, peer = &r.Source, &r.Destination
+	}
+	if local.Selector != "" || local.NamespaceSelector != "" || local.NotSelector != "" ||
+		len(local.Nets) != 0 || len(local.NotNets) != 0 {
+		side := "destination"
+		if !ingress {
+			side = "source"
+		}
+		return fmt.Errorf("matching the %s of %s rules is not supported", side, direction(ingress))
+	}
+	for _, e := range []*apiv3.EntityRule{local, peer} {
+		switch {
+		case e.NotSelector != "":
+			return fmt.Errorf("notSelector is not supported")
+		case e.ServiceAccounts != nil:
+			return fmt.Errorf("service account matches are not supported")
+		case e.Services != nil:
+			return fmt.Errorf("service matches are not supported")
+		}
+	}
+	return nil
+}
+
+func direction(ingress bool) string {
+	if ingress {
+		return "ingress"
+	}
+	return "egress"
+}
+
+// calicoNetsToIPBlocks converts a Calico nets/notNets match to IP blocks.  Each notNet must be
+// inside one of the nets, since IP block exceptions are per block.
+func calicoNetsToIPBlocks(nets, notNets []string) ([]*networkingv1.IPBlock, error) {
+	var blocks []*networkingv1.IPBlock
+	var ipNets []*cnet.IPNet
+	for _, n := range nets {
+		_, ipNet, err := cnet.ParseCIDROrIP(n)
+		if err != nil {
+			return nil, fmt.Errorf("invalid net %q: %w", n, err)
+		}
+		ipNets = append(ipNets, ipNet)
+		blocks = append(blocks, &networkingv1.IPBlock{CIDR: ipNet.String()})
+	}
+	for _, n := range notNets {
+		_, notNet, err := cnet.ParseCIDROrIP(n)
+		if err != nil {
+			return nil, fmt.Errorf("invalid net %q: %w", n, err)
+		}
+		found := false
+		for i, ipNet := range ipNets {
+			if ipNet.Covers(notNet.IPNet) {
+				blocks[i].Except = append(blocks[i].Except, notNet.String())
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("notNet %s is not inside any of the nets", notNet)
+		}
+	}
+	return blocks, nil
+}
+
+func calicoProtocolToK8s(p numorstring.Protocol) (kapiv1.Protocol, error) {
+	switch strings.ToUpper(p.String()) {
+	case numorstring.ProtocolTCP, "6":
+		return kapiv1.ProtocolTCP, nil
+	case numorstring.ProtocolUDP, "17":
+		return kapiv1.ProtocolUDP, nil
+	case numorstring.ProtocolSCTP, "132":
+		return kapiv1.ProtocolSCTP, nil
+	}
+	return "", fmt.Errorf("protocol %s is not supported", p.String())
+}
+
+// calicoSelectorsToANPSubject converts the namespace selector and selector of a Calico policy
+// or rule to the equivalent admin network policy subject.  It also returns whether the selectors
+// only select pods.
+func calicoSelectorsToANPSubject(nsSel, sel string) (*adminpolicy.AdminNetworkPolicySubject, bool, error) {
+	podSelector, podsOnly, err := calicoSelectorToK8s(sel, SelectorPod)
+	if err != nil {
+		return nil, false, err
+	}
+	nsSelector, _, err := calicoSelectorToK8s(nsSel, SelectorNamespace)
+	if err != nil {
+		return nil, false, err
+	}
+	if nsSel != "" {
+		// Namespace selectors only select workload endpoints.
+		podsOnly = true
+	}
+	if nsSelector == nil {
+		nsSelector = &metav1.LabelSelector{}
+	}
+	if podSelector == nil || isEmptyLabelSelector(podSelector) {
+		return &adminpolicy.AdminNetworkPolicySubject{Namespaces: nsSelector}, podsOnly, nil
+	}
+	return &adminpolicy.AdminNetworkPolicySubject{
+		Pods: &adminpolicy.NamespacedPod{
+			NamespaceSelector: *nsSelector,
+			PodSelector:       *podSelector,
+		},
+	}, podsOnly, nil
+}
+
+func isEmptyLabelSelector(s *metav1.LabelSelector) bool {
+	return len(s.MatchLabels) == 0 && len(s.MatchExpressions) == 0
+}
+
+// calicoSelectorToK8s converts a Calico selector to a Kubernetes label selector; it is the
+// reverse of k8sSelectorToCalico.  An empty selector converts to nil.  The selector must be a
+// conjunction of terms that label selectors support, so, for example, "||" and "contains" are
+// rejected.  For pod selectors, the projectcalico.org/orchestrator == 'k8s' term that
+// k8sSelectorToCalico adds is dropped, and the returned bool reports whether it was present.
+func calicoSelectorToK8s(sel string, selectorType selectorType) (*metav1.LabelSelector, bool, error) {
+	if strings.TrimSpace(sel) == "" {
+		return nil, false, nil
+	}
+	parsed, err := parser.Parse(sel)
+	if err != nil {
+		return nil, false, err
+	}
+	v := &k8sSelectorVisitor{
+		selectorType: selectorType,
+		ls:           &metav1.LabelSelector{},
+		handled:      map[any]bool{},
+	}
+	parsed.AcceptVisitor(v)
+	if v.err != nil {
+		return nil, false, fmt.Errorf("selector %q can't be expressed as a Kubernetes label selector: %w", sel, v.err)
+	}
+	if len(v.ls.MatchLabels) == 0 {
+		v.ls.MatchLabels = nil
+	}
+	return v.ls, v.podsOnly, nil
+}
+
+// k8sSelectorVisitor builds a Kubernetes label selector from the nodes of a parsed Calico
+// selector.
+type k8sSelectorVisitor struct {
+	selectorType selectorType
+	ls           *metav1.LabelSelector
+	podsOnly     bool
+	// handled holds the nodes that have already been converted as part of their parent.
+	handled map[any]bool
+	err     error
+}
+
+func (v *k8sSelectorVisitor) Visit(n any) {
+	if v.err != nil || v.handled[n] {
+		return
+	}
+	switch n := n.(type) {
+	case *parser.AndNode, *parser.AllNode:
+		// The children of an AndNode are visited in turn.
+	case *parser.LabelEqValueNode:
+		if v.selectorType == SelectorPod && n.LabelName == apiv3.LabelOrchestrator && n.Value == apiv3.OrchestratorKubernetes {
+			v.podsOnly = true
+			return
+		}
+		v.addExpression(n.LabelName, metav1.LabelSelectorOpIn, n.Value)
+	case *parser.LabelNeValueNode:
+		// Like Calico's !=, NotIn matches when the label is missing.
+		v.addExpression(n.LabelName, metav1.LabelSelectorOpNotIn, n.Value)
+	case *parser.LabelInSetNode:
+		v.addExpression(n.LabelName, metav1.LabelSelectorOpIn, n.Value.SliceCopy()...)
+	case *parser.LabelNotInSetNode:
+		v.addExpression(n.LabelName, metav1.LabelSelectorOpNotIn, n.Value.SliceCopy()...)
+	case *parser.HasNode:
+		v.addExpression(n.LabelName, metav1.LabelSelectorOpExists)
+	case *parser.NotNode:
+		var operand any = n.Operand
+		v.handled[operand] = true
+		switch op := operand.(type) {
+		case *parser.HasNode:
+			v.addExpression(op.LabelName, metav1.LabelSelectorOpDoesNotExist)
+		case *parser.LabelEqValueNode:
+			v.addExpression(op.LabelName, metav1.LabelSelectorOpNotIn, op.Value)
+		case *parser.LabelInSetNode:
+			v.addExpression(op.LabelName, metav1.LabelSelectorOpNotIn, op.Value.SliceCopy()...)
+		default:
+			v.err = fmt.Errorf("only has(), == and \"in\" expressions can be negated")
+		}
+	case *parser.OrNode:
+		v.err = fmt.Errorf("\"||\" is not supported")
+	case *parser.GlobalNode:
+		v.err = fmt.Errorf("global() is not supported")
+	default:
+		v.err = fmt.Errorf("only ==, !=, in, not in and has() expressions are supported")
+	}
+}
+
+func (v *k8sSelectorVisitor) addExpression(key string, op metav1.LabelSelectorOperator, values ...string) {
+	if v.selectorType == SelectorNamespace && key == NameLabel {
+		// Calico gives each namespace a label with its name; Kubernetes does the same with a
+		// different label.
+		key = k8sNamespaceNameLabel
+	}
+	if op == metav1.LabelSelectorOpIn && len(values) == 1 {
+		if _, ok := v.ls.MatchLabels[key]; !ok {
+			if v.ls.MatchLabels == nil {
+				v.ls.MatchLabels = map[string]string{}
+			}
+			v.ls.MatchLabels[key] = values[0]
+			return
+		}
+	}
+	values = append([]string(nil), values...)
+	sort.Strings(values)
+	v.ls.MatchExpressions = append(v.ls.MatchExpressions, metav1.LabelSelectorRequirement{
+		Key:      key,
+		Operator: op,
+		Values:   values,
+	})
+}
+
+func prefixAll(prefix string, s []string) []string {
+	var out []string
+	for _, x := range s {
+		out = append(out, prefix+x)
+	}
+	return out
+}
@@ -0,0 +1,243 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package conversion
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	"github.com/projectcalico/api/pkg/lib/numorstring"
+	kapiv1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	adminpolicy "sigs.k8s.io/network-policy-api/apis/v1alpha1"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/names"
+)
+
+var _ = Describe("Calico to Kubernetes policy conversion", func() {
+	c := NewConverter()
+	tcp := numorstring.ProtocolFromString("TCP")
+	protoTCP := kapiv1.ProtocolTCP
+	port80 := intstr.FromInt32(80)
+	port8080 := intstr.FromInt32(8080)
+	endPort := int32(8090)
+
+	DescribeTable("selector conversion",
+		func(sel string, st selectorType, expected *metav1.LabelSelector) {
+			ls, _, err := calicoSelectorToK8s(sel, st)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(ls).To(Equal(expected))
+		},
+		Entry("empty selector", "", SelectorPod, nil),
+		Entry("all()", "all()", SelectorNamespace, &metav1.LabelSelector{}),
+		Entry("orchestrator term only", "projectcalico.org/orchestrator == 'k8s'", SelectorPod, &metav1.LabelSelector{}),
+		Entry("equality", "projectcalico.org/orchestrator == 'k8s' && app == 'db' && tier == 'be'", SelectorPod,
+			&metav1.LabelSelector{MatchLabels: map[string]string{"app": "db", "tier": "be"}}),
+		Entry("expressions", "env in { 'a', 'b' } && has(x) && !has(y) && z != 'c' && w not in { 'd' }", SelectorPod,
+			&metav1.LabelSelector{MatchExpressions: []metav1.LabelSelectorRequirement{
+				{Key: "env", Operator: metav1.LabelSelectorOpIn, Values: []string{"a", "b"}},
+				{Key: "x", Operator: metav1.LabelSelectorOpExists},
+				{Key: "y", Operator: metav1.LabelSelectorOpDoesNotExist},
+				{Key: "z", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"c"}},
+				{Key: "w", Operator: metav1.LabelSelectorOpNotIn, Values: []string{"d"}},
+			}}),
+		Entry("namespace name", "projectcalico.org/name == 'kube-system'", SelectorNamespace,
+			&metav1.LabelSelector{MatchLabels: map[string]string{"kubernetes.io/metadata.name": "kube-system"}}),
+	)
+
+	DescribeTable("unsupported selectors",
+		func(sel string) {
+			_, _, err := calicoSelectorToK8s(sel, SelectorPod)
+			Expect(err).To(HaveOccurred())
+		},
+		Entry("or", "a == 'b' || c == 'd'"),
+		Entry("contains", "a contains 'b'"),
+		Entry("negated and", "!(a == 'b' && c == 'd')"),
+		Entry("global()", "global()"),
+	)
+
+	It("should reverse the conversion of a Kubernetes NetworkPolicy", func() {
+		np := &networkingv1.NetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-policy", Namespace: "default"},
+			Spec: networkingv1.NetworkPolicySpec{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+				Ingress: []networkingv1.NetworkPolicyIngressRule{{
+					From: []networkingv1.NetworkPolicyPeer{{
+						PodSelector:       &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+						NamespaceSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+					}},
+					Ports: []networkingv1.NetworkPolicyPort{{Protocol: &protoTCP, Port: &port80}},
+				}},
+				Egress: []networkingv1.NetworkPolicyEgressRule{{
+					To: []networkingv1.NetworkPolicyPeer{{
+						IPBlock: &networkingv1.IPBlock{CIDR: "10.0.0.0/8", Except: []string{"10.1.0.0/16"}},
+					}},
+					Ports: []networkingv1.NetworkPolicyPort{{Protocol: &protoTCP, Port: &port8080, EndPort: &endPort}},
+				}},
+				PolicyTypes: []networkingv1.PolicyType{networkingv1.PolicyTypeIngress, networkingv1.PolicyTypeEgress},
+			},
+		}
+		kvp, err := c.K8sNetworkPolicyToCalico(np)
+		Expect(err).NotTo(HaveOccurred())
+
+		converted, unsupported, err := CalicoNetworkPolicyToK8s(kvp.Value.(*apiv3.NetworkPolicy))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(unsupported).To(BeEmpty())
+		Expect(converted.Kind).To(Equal("NetworkPolicy"))
+		Expect(converted.APIVersion).To(Equal("networking.k8s.io/v1"))
+		Expect(converted.Name).To(Equal("test-policy"))
+		Expect(converted.Namespace).To(Equal("default"))
+		Expect(converted.Spec).To(Equal(np.Spec))
+	})
+
+	It("should report the features of a NetworkPolicy that Kubernetes can't express", func() {
+		order := 100.0
+		p := apiv3.NewNetworkPolicy()
+		p.Name = "mixed"
+		p.Namespace = "default"
+		p.Spec = apiv3.NetworkPolicySpec{
+			Tier:     "security",
+			Order:    &order,
+			Selector: "app == 'db'",
+			Ingress: []apiv3.Rule{
+				{Action: apiv3.Deny, Source: apiv3.EntityRule{Nets: []string{"10.0.0.0/8"}}},
+				{Action: apiv3.Allow, Protocol: &tcp, Source: apiv3.EntityRule{Selector: "app == 'web'"}},
+				{Action: apiv3.Allow, Source: apiv3.EntityRule{Selector: "a == 'b' || c == 'd'"}},
+				{Action: apiv3.Allow, HTTP: &apiv3.HTTPMatch{Methods: []string{"GET"}}},
+			},
+		}
+
+		converted, unsupported, err := CalicoNetworkPolicyToK8s(p)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(unsupported).To(ConsistOf(
+			ContainSubstring(`tier "security"`),
+			ContainSubstring("ingress rule 1 dropped: action Deny"),
+			ContainSubstring("ingress rule 3 dropped: selector"),
+			ContainSubstring("ingress rule 4 dropped: HTTP"),
+		))
+		Expect(converted.Spec.PolicyTypes).To(Equal([]networkingv1.PolicyType{networkingv1.PolicyTypeIngress}))
+		Expect(converted.Spec.Ingress).To(Equal([]networkingv1.NetworkPolicyIngressRule{{
+			From:  []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}}}},
+			Ports: []networkingv1.NetworkPolicyPort{{Protocol: &protoTCP}},
+		}}))
+	})
+
+	It("should fail to convert a NetworkPolicy whose selector Kubernetes can't express", func() {
+		p := apiv3.NewNetworkPolicy()
+		p.Spec.Selector = "app starts with 'db'"
+		_, _, err := CalicoNetworkPolicyToK8s(p)
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should reverse the conversion of a Kubernetes AdminNetworkPolicy", func() {
+		ports := []adminpolicy.AdminNetworkPolicyPort{
+			{PortNumber: &adminpolicy.Port{Protocol: kapiv1.ProtocolTCP, Port: 80}},
+		}
+		anp := &adminpolicy.AdminNetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "test-anp"},
+			Spec: adminpolicy.AdminNetworkPolicySpec{
+				Priority: 50,
+				Subject: adminpolicy.AdminNetworkPolicySubject{
+					Pods: &adminpolicy.NamespacedPod{
+						NamespaceSelector: metav1.LabelSelector{MatchLabels: map[string]string{"team": "a"}},
+						PodSelector:       metav1.LabelSelector{MatchLabels: map[string]string{"app": "db"}},
+					},
+				},
+				Ingress: []adminpolicy.AdminNetworkPolicyIngressRule{{
+					Name:   "allow-web",
+					Action: adminpolicy.AdminNetworkPolicyRuleActionAllow,
+					From: []adminpolicy.AdminNetworkPolicyIngressPeer{{
+						Namespaces: &metav1.LabelSelector{MatchLabels: map[string]string{"team": "b"}},
+					}},
+					Ports: &ports,
+				}},
+				Egress: []adminpolicy.AdminNetworkPolicyEgressRule{{
+					Name:   "deny-metadata",
+					Action: adminpolicy.AdminNetworkPolicyRuleActionDeny,
+					To: []adminpolicy.AdminNetworkPolicyEgressPeer{{
+						Networks: []adminpolicy.CIDR{"169.254.169.254/32"},
+					}},
+				}},
+			},
+		}
+		kvp, err := c.K8sAdminNetworkPolicyToCalico(anp)
+		Expect(err).NotTo(HaveOccurred())
+
+		converted, unsupported, err := CalicoGlobalNetworkPolicyToK8sAdmin(kvp.Value.(*apiv3.GlobalNetworkPolicy))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(unsupported).To(BeEmpty())
+		Expect(converted.Kind).To(Equal("AdminNetworkPolicy"))
+		Expect(converted.APIVersion).To(Equal("policy.networking.k8s.io/v1alpha1"))
+		Expect(converted.Name).To(Equal("test-anp"))
+		Expect(converted.Spec).To(Equal(anp.Spec))
+	})
+
+	It("should report the features of a GlobalNetworkPolicy that Kubernetes can't express", func() {
+		order := 10.5
+		p := apiv3.NewGlobalNetworkPolicy()
+		p.Name = "host-policy"
+		p.Spec = apiv3.GlobalNetworkPolicySpec{
+			Order:      &order,
+			Selector:   "role == 'gateway'",
+			DoNotTrack: true,
+			Ingress: []apiv3.Rule{
+				{Action: apiv3.Log},
+				{Action: apiv3.Pass, Protocol: &tcp},
+			},
+		}
+
+		converted, unsupported, err := CalicoGlobalNetworkPolicyToK8sAdmin(p)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(unsupported).To(ConsistOf(
+			ContainSubstring("not to host endpoints"),
+			ContainSubstring(`tier "default"`),
+			ContainSubstring("order 10.5: converted to priority 10"),
+			ContainSubstring("doNotTrack"),
+			ContainSubstring("ingress rule 1 dropped: action Log"),
+			ContainSubstring("ingress rule 2: the rule only matches pods"),
+		))
+		Expect(converted.Spec.Priority).To(Equal(int32(10)))
+		Expect(converted.Spec.Subject).To(Equal(adminpolicy.AdminNetworkPolicySubject{
+			Pods: &adminpolicy.NamespacedPod{
+				PodSelector: metav1.LabelSelector{MatchLabels: map[string]string{"role": "gateway"}},
+			},
+		}))
+		allTCP := []adminpolicy.AdminNetworkPolicyPort{
+			{PortRange: &adminpolicy.PortRange{Protocol: kapiv1.ProtocolTCP, Start: 1, End: 65535}},
+		}
+		Expect(converted.Spec.Ingress).To(Equal([]adminpolicy.AdminNetworkPolicyIngressRule{{
+			Action: adminpolicy.AdminNetworkPolicyRuleActionPass,
+			From:   []adminpolicy.AdminNetworkPolicyIngressPeer{{Namespaces: &metav1.LabelSelector{}}},
+			Ports:  &allTCP,
+		}}))
+	})
+
+	It("should strip the admin network policy name prefix", func() {
+		p := apiv3.NewGlobalNetworkPolicy()
+		p.Name = names.K8sAdminNetworkPolicyNamePrefix + "foo"
+		p.Spec.Tier = names.AdminNetworkPolicyTierName
+		p.Spec.NamespaceSelector = "all()"
+		order := 1.0
+		p.Spec.Order = &order
+		converted, unsupported, err := CalicoGlobalNetworkPolicyToK8sAdmin(p)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(unsupported).To(BeEmpty())
+		Expect(converted.Name).To(Equal("foo"))
+		Expect(converted.Spec.Subject).To(Equal(adminpolicy.AdminNetworkPolicySubject{Namespaces: &metav1.LabelSelector{}}))
+	})
+})
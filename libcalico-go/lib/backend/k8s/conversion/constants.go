@@ -44,6 +44,19 @@ const (
 	// name exactly.
 	NameLabel = "projectcalico.org/name"
 
+	// NamespaceIsolationAnnotation may be set on a Namespace to isolate its workloads without
+	// writing any policy. When set to NamespaceIsolationNamespace, the Profile generated for the
+	// Namespace only allows ingress from workloads in the same Namespace, plus DNS requests to the
+	// cluster DNS pods, instead of allowing all ingress; egress is unaffected. Like all Profile rules,
+	// these only apply to workloads that are not selected by any policy, so explicit policies in
+	// tiers continue to take precedence.
+	NamespaceIsolationAnnotation = "projectcalico.org/isolation"
+	NamespaceIsolationNamespace  = "Namespace"
+
+	// NamespaceIsolationDNSSelector selects the cluster DNS pods (kube-dns or CoreDNS) that keep
+	// accepting DNS requests from any source in an isolated Namespace.
+	NamespaceIsolationDNSSelector = "k8s-app == 'kube-dns'"
+
 	// AdminPolicyRuleNameLabel is a label that show a rule's name before conversion to Calico data model.
 	// As an example, it holds an admin network policy rule name before conversion to GNPs.
 	AdminPolicyRuleNameLabel = "name"
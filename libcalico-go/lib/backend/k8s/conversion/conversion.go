@@ -119,6 +119,13 @@ func (c converter) NamespaceToProfile(ns *kapiv1.Namespace) (*model.KVPair, erro
 		Egress:        []apiv3.Rule{{Action: apiv3.Allow}},
 		LabelsToApply: labels,
 	}
+	if v, ok := ns.Annotations[NamespaceIsolationAnnotation]; ok {
+		if v == NamespaceIsolationNamespace {
+			profile.Spec.Ingress = namespaceIsolationIngressRules(ns.Name)
+		} else {
+			log.WithFields(log.Fields{"namespace": ns.Name, "value": v}).Warn("Ignoring unknown namespace isolation mode")
+		}
+	}
 
 	// Embed the profile in a KVPair.
 	kvp := model.KVPair{
@@ -132,6 +139,27 @@ func (c converter) NamespaceToProfile(ns *kapiv1.Namespace) (*model.KVPair, erro
 	return &kvp, nil
 }
 
+// namespaceIsolationIngressRules returns the Profile ingress rules for a Namespace with namespace
+// isolation enabled: allow traffic from the same Namespace and DNS requests to the cluster DNS
+// pods from anywhere, and deny everything else.
+func namespaceIsolationIngressRules(namespace string) []apiv3.Rule {
+	udp := numorstring.ProtocolFromString(numorstring.ProtocolUDP)
+	tcp := numorstring.ProtocolFromString(numorstring.ProtocolTCP)
+	dns := apiv3.EntityRule{
+		Selector: NamespaceIsolationDNSSelector,
+		Ports:    []numorstring.Port{numorstring.SinglePort(53)},
+	}
+	return []apiv3.Rule{
+		{
+			Action: apiv3.Allow,
+			Source: apiv3.EntityRule{NamespaceSelector: fmt.Sprintf("%s == '%s'", NameLabel, namespace)},
+		},
+		{Action: apiv3.Allow, Protocol: &udp, Destination: dns},
+		{Action: apiv3.Allow, Protocol: &tcp, Destination: dns},
+		{Action: apiv3.Deny},
+	}
+}
+
 // IsValidCalicoWorkloadEndpoint returns true if the pod should be shown as a workloadEndpoint
 // in the Calico API and false otherwise.  Note: since we completely ignore notifications for
 // invalid Pods, it is important that pods can only transition from not-valid to valid and not
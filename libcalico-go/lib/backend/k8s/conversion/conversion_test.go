@@ -3374,6 +3374,56 @@ var _ = Describe("Test Namespace conversion", func() {
 			Expect(Egress[0]).To(Equal(apiv3.Rule{Action: apiv3.Allow}))
 		})
 	})
+
+	It("should isolate a Namespace with the isolation annotation", func() {
+		ns := kapiv1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "tenant-a",
+				Annotations: map[string]string{
+					"projectcalico.org/isolation": "Namespace",
+				},
+				UID: types.UID("30316465-6365-4463-ad63-3564622d3638"),
+			},
+			Spec: kapiv1.NamespaceSpec{},
+		}
+
+		p, err := c.NamespaceToProfile(&ns)
+		Expect(err).NotTo(HaveOccurred())
+
+		// The same Namespace is allowed in, plus DNS to the cluster DNS pods only.
+		udp := numorstring.ProtocolFromString("UDP")
+		tcp := numorstring.ProtocolFromString("TCP")
+		dns := apiv3.EntityRule{
+			Selector: "k8s-app == 'kube-dns'",
+			Ports:    []numorstring.Port{numorstring.SinglePort(53)},
+		}
+		Expect(p.Value.(*apiv3.Profile).Spec.Ingress).To(Equal([]apiv3.Rule{
+			{Action: apiv3.Allow, Source: apiv3.EntityRule{NamespaceSelector: "projectcalico.org/name == 'tenant-a'"}},
+			{Action: apiv3.Allow, Protocol: &udp, Destination: dns},
+			{Action: apiv3.Allow, Protocol: &tcp, Destination: dns},
+			{Action: apiv3.Deny},
+		}))
+
+		// Egress is not affected.
+		Expect(p.Value.(*apiv3.Profile).Spec.Egress).To(Equal([]apiv3.Rule{{Action: apiv3.Allow}}))
+	})
+
+	It("should ignore an unknown isolation mode", func() {
+		ns := kapiv1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: "tenant-a",
+				Annotations: map[string]string{
+					"projectcalico.org/isolation": "Bogus",
+				},
+				UID: types.UID("30316465-6365-4463-ad63-3564622d3638"),
+			},
+			Spec: kapiv1.NamespaceSpec{},
+		}
+
+		p, err := c.NamespaceToProfile(&ns)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(p.Value.(*apiv3.Profile).Spec.Ingress).To(Equal([]apiv3.Rule{{Action: apiv3.Allow}}))
+	})
 })
 
 var _ = Describe("Test ServiceAccount conversion", func() {
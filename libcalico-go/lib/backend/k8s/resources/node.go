@@ -55,6 +55,7 @@ const (
 	nodeWireguardIpv6IfaceAddrAnnotation  = "projectcalico.org/IPv6WireguardInterfaceAddr"
 	nodeWireguardPublicKeyAnnotation      = "projectcalico.org/WireguardPublicKey"
 	nodeWireguardPublicKeyV6Annotation    = "projectcalico.org/WireguardPublicKeyV6"
+	nodeInterfacesAnnotation              = "projectcalico.org/Interfaces"
 )
 
 func NewNodeClient(c *kubernetes.Clientset, usePodCIDR bool) K8sResourceClient {
@@ -287,6 +288,16 @@ func K8sNodeToCalico(k8sNode *kapiv1.Node, usePodCIDR bool) (*model.KVPair, erro
 	calicoNode.Spec.IPv6VXLANTunnelAddr = getAnnotation(k8sNode, nodeBgpIpv6VXLANTunnelAddrAnnotation, validatorv3.ValidateIPv6Network)
 	calicoNode.Spec.VXLANTunnelMACAddrV6 = getAnnotation(k8sNode, nodeBgpVXLANTunnelMACAddrV6Annotation, validatorv3.ValidateMAC)
 
+	// Set the host interfaces, which are stored as JSON.
+	if rawIfaces := annotations[nodeInterfacesAnnotation]; rawIfaces != "" {
+		var ifaces []libapiv3.NodeInterface
+		if err := json.Unmarshal([]byte(rawIfaces), &ifaces); err != nil {
+			log.WithError(err).Warnf("Failed to parse node interfaces from annotation: %s", nodeInterfacesAnnotation)
+		} else {
+			calicoNode.Spec.Interfaces = ifaces
+		}
+	}
+
 	// Set the node status
 	nodeStatus := libapiv3.NodeStatus{}
 	nodeStatus.WireguardPublicKey = annotations[nodeWireguardPublicKeyAnnotation]
@@ -439,6 +450,18 @@ func mergeCalicoNodeIntoK8sNode(calicoNode *libapiv3.Node, k8sNode *kapiv1.Node)
 		}
 	}
 
+	// Handle host interfaces.
+	if len(calicoNode.Spec.Interfaces) > 0 {
+		bytes, err := json.Marshal(calicoNode.Spec.Interfaces)
+		if err != nil {
+			log.WithError(err).Errorf("Error marshalling node interfaces")
+			return nil, err
+		}
+		k8sNode.Annotations[nodeInterfacesAnnotation] = string(bytes)
+	} else {
+		delete(k8sNode.Annotations, nodeInterfacesAnnotation)
+	}
+
 	// Handle Wireguard public-key.
 	if calicoNode.Status.WireguardPublicKey != "" {
 		k8sNode.Annotations[nodeWireguardPublicKeyAnnotation] = calicoNode.Status.WireguardPublicKey
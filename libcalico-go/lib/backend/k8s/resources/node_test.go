@@ -606,4 +606,35 @@ var _ = Describe("Test Node conversion", func() {
 			{Address: "192.168.1.100", Type: libapiv3.ExternalIP},
 		}))
 	})
+
+	It("should round trip host interfaces through an annotation", func() {
+		k8sNode := &k8sapi.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "TestNode",
+				ResourceVersion: "1234",
+				Annotations:     make(map[string]string),
+			},
+		}
+
+		calicoNode := libapiv3.NewNode()
+		calicoNode.Name = "TestNode"
+		calicoNode.Spec.Interfaces = []libapiv3.NodeInterface{
+			{Name: "eth0", Addresses: []string{"172.17.17.10", "fd10::10"}},
+			{Name: "eth1", Addresses: []string{"10.0.0.10"}},
+		}
+
+		newK8sNode, err := mergeCalicoNodeIntoK8sNode(calicoNode, k8sNode)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newK8sNode.Annotations).To(HaveKey(nodeInterfacesAnnotation))
+
+		n, err := K8sNodeToCalico(newK8sNode, false)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(n.Value.(*libapiv3.Node).Spec.Interfaces).To(Equal(calicoNode.Spec.Interfaces))
+
+		By("removing the annotation when there are no interfaces")
+		calicoNode.Spec.Interfaces = nil
+		newK8sNode, err = mergeCalicoNodeIntoK8sNode(calicoNode, newK8sNode)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(newK8sNode.Annotations).NotTo(HaveKey(nodeInterfacesAnnotation))
+	})
 })
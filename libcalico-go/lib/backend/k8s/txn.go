@@ -0,0 +1,141 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package k8s
+
+import (
+	"context"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+	cerrors "github.com/projectcalico/calico/libcalico-go/lib/errors"
+)
+
+// Txn performs the supplied operations in order.  The Kubernetes API does not support
+// transactions across multiple resources, so if an operation fails the operations that
+// have already been performed are reverted, in reverse order, before returning the error.
+// Other clients may observe the intermediate state while the transaction is in progress, so
+// unlike the etcdv3 transaction this is not atomic.
+func (c *KubeClient) Txn(ctx context.Context, ops []api.TxnOp) ([]*model.KVPair, error) {
+	log.WithField("numOps", len(ops)).Debug("Processing Txn request")
+
+	keys := map[string]bool{}
+	for _, op := range ops {
+		k := op.KVPair.Key.String()
+		if keys[k] {
+			return nil, fmt.Errorf("resource %v appears more than once in the transaction", op.KVPair.Key)
+		}
+		keys[k] = true
+	}
+
+	var undo []func() error
+	results := make([]*model.KVPair, len(ops))
+	for i, op := range ops {
+		kvp, rollback, err := c.txnOp(ctx, op)
+		if err != nil {
+			log.WithError(err).WithField("key", op.KVPair.Key).Info("Transaction operation failed, reverting transaction")
+			if rbErr := c.rollbackTxn(undo); rbErr != nil {
+				return nil, cerrors.ErrorPartialFailure{Err: fmt.Errorf("%w (reverting transaction failed: %v)", err, rbErr)}
+			}
+			return nil, err
+		}
+		results[i] = kvp
+		undo = append(undo, rollback)
+	}
+	return results, nil
+}
+
+// txnOp performs a single operation of a transaction.  It returns the result of the operation
+// and a function that reverts the operation.  Reverting a delete re-creates the resource, so
+// the restored resource has a new UID and resource version, and the deletion and re-creation
+// are both seen by watchers.
+func (c *KubeClient) txnOp(ctx context.Context, op api.TxnOp) (*model.KVPair, func() error, error) {
+	// Get the current value of the resource so that we can restore it.  A create doesn't
+	// need this since the rollback is a delete.
+	var prior *model.KVPair
+	if op.Type != api.TxnCreate {
+		var err error
+		prior, err = c.Get(ctx, op.KVPair.Key, "")
+		if err != nil {
+			if _, ok := err.(cerrors.ErrorResourceDoesNotExist); !ok || op.Type != api.TxnApply {
+				return nil, nil, err
+			}
+			prior = nil
+		}
+	}
+
+	var kvp *model.KVPair
+	var err error
+	switch op.Type {
+	case api.TxnCreate:
+		kvp, err = c.Create(ctx, op.KVPair)
+	case api.TxnUpdate:
+		kvp, err = c.Update(ctx, op.KVPair)
+	case api.TxnApply:
+		kvp, err = c.Apply(ctx, op.KVPair)
+	case api.TxnDelete:
+		kvp, err = c.DeleteKVP(ctx, op.KVPair)
+	default:
+		err = fmt.Errorf("unknown transaction operation %q", op.Type)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Use a background context for the rollback so that it is still attempted if the
+	// transaction context has been cancelled.
+	rollbackCtx := context.Background()
+	var rollback func() error
+	switch {
+	case op.Type == api.TxnDelete:
+		// Re-create the deleted resource.  The revision must not be set on a create.
+		rollback = func() error {
+			prior.Revision = ""
+			_, err := c.Create(rollbackCtx, prior)
+			return err
+		}
+	case prior == nil:
+		// The resource was created, so delete it again.
+		rollback = func() error {
+			_, err := c.DeleteKVP(rollbackCtx, kvp)
+			return err
+		}
+	default:
+		// The resource was updated, so restore the previous value.
+		rollback = func() error {
+			prior.Revision = kvp.Revision
+			_, err := c.Update(rollbackCtx, prior)
+			return err
+		}
+	}
+	return kvp, rollback, nil
+}
+
+// rollbackTxn calls the supplied rollback functions in reverse order.  All of the functions
+// are called, and the first error (if any) is returned.
+func (c *KubeClient) rollbackTxn(undo []func() error) error {
+	var firstErr error
+	for i := len(undo) - 1; i >= 0; i-- {
+		if err := undo[i](); err != nil {
+			log.WithError(err).Warning("Failed to revert transaction operation")
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
@@ -32,6 +32,7 @@ const (
 	IPAMBlockAttributePod             = "pod"
 	IPAMBlockAttributeNamespace       = "namespace"
 	IPAMBlockAttributeNode            = "node"
+	IPAMBlockAttributeService         = "service"
 	IPAMBlockAttributeType            = "type"
 	IPAMBlockAttributeTypeIPIP        = "ipipTunnelAddress"
 	IPAMBlockAttributeTypeVXLAN       = "vxlanTunnelAddress"
@@ -134,6 +134,12 @@ func (c client) Tiers() TierInterface {
 	return tiers{client: c}
 }
 
+// Transaction returns a new transaction, for making changes to several resources as a
+// single unit.
+func (c client) Transaction() Transaction {
+	return newTransaction(c)
+}
+
 // IPAM returns an interface for managing IP address assignment and releasing.
 func (c client) IPAM() ipam.Interface {
 	return ipam.NewIPAMClient(c.backend, poolAccessor{client: &c}, c.IPReservations())
@@ -0,0 +1,74 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	libapiv3 "github.com/projectcalico/calico/libcalico-go/lib/apis/v3"
+	"github.com/projectcalico/calico/libcalico-go/lib/names"
+	cnet "github.com/projectcalico/calico/libcalico-go/lib/net"
+	cresources "github.com/projectcalico/calico/libcalico-go/lib/resources"
+)
+
+// SetWriteDefaults updates the resource in place with the defaults and conversions the client
+// applies when the resource is written and read back, without writing it. This allows a
+// resource to be compared with the version currently stored in the datastore.
+func SetWriteDefaults(res runtime.Object) error {
+	switch r := res.(type) {
+	case *apiv3.GlobalNetworkPolicy:
+		defaultPolicyTypesField(r.Spec.Ingress, r.Spec.Egress, &r.Spec.Types)
+		r.Spec.Tier = names.TierOrDefault(r.Spec.Tier)
+		return setTieredPolicyName(&r.ObjectMeta, r.Spec.Tier)
+	case *apiv3.NetworkPolicy:
+		defaultPolicyTypesField(r.Spec.Ingress, r.Spec.Egress, &r.Spec.Types)
+		r.Spec.Tier = names.TierOrDefault(r.Spec.Tier)
+		return setTieredPolicyName(&r.ObjectMeta, r.Spec.Tier)
+	case *apiv3.IPPool:
+		// The CIDR is normalized when the pool is written. An invalid CIDR is reported by
+		// validation, so leave it as it is.
+		if _, cidr, err := cnet.ParseCIDR(r.Spec.CIDR); err == nil {
+			r.Spec.CIDR = cidr.String()
+		}
+		return convertIpPoolFromStorage(r)
+	case *apiv3.Tier:
+		cresources.DefaultTierFields(r)
+	case *apiv3.FelixConfiguration:
+		setDefaults(r)
+	case *apiv3.KubeControllersConfiguration:
+		kubeControllersConfiguration{}.fillDefaults(r)
+	case *libapiv3.WorkloadEndpoint:
+		w := workloadEndpoints{}
+		if err := w.assignOrValidateName(r); err != nil {
+			return err
+		}
+		w.updateLabelsForStorage(r)
+	}
+	return nil
+}
+
+// setTieredPolicyName sets the name and tier label of a policy to those it is read back with:
+// policies are stored with the name prefixed by the tier and read back with the tier label set.
+func setTieredPolicyName(meta metav1.Object, tier string) error {
+	name, err := names.BackendTieredPolicyName(meta.GetName(), tier)
+	if err != nil {
+		return err
+	}
+	meta.SetName(name)
+	meta.SetLabels(addTierLabel(meta.GetLabels(), tier))
+	return nil
+}
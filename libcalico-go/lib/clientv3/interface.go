@@ -44,6 +44,10 @@ type Interface interface {
 	// Tiers returns an interface for managing tier resources.
 	Tiers() TierInterface
 
+	// Transaction returns a new transaction, for making changes to several resources as a
+	// single unit.
+	Transaction() Transaction
+
 	// EnsureInitialized is used to ensure the backend datastore is correctly
 	// initialized for use by Calico.  This method may be called multiple times, and
 	// will have no effect if the datastore is already correctly initialized.
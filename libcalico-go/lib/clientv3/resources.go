@@ -69,45 +69,10 @@ type resources struct {
 
 // Create creates a resource in the backend datastore.
 func (c *resources) Create(ctx context.Context, opts options.SetOptions, kind string, in resource) (resource, error) {
-	// Resource must have a Name.  Currently we do not support GenerateName.
-	if len(in.GetObjectMeta().GetName()) == 0 {
-		var generateNameMessage string
-		if len(in.GetObjectMeta().GetGenerateName()) != 0 {
-			generateNameMessage = " (GenerateName is not supported)"
-		}
-		return nil, cerrors.ErrorValidation{
-			ErroredFields: []cerrors.ErroredField{{
-				Name:   "Metadata.Name",
-				Reason: "field must be set for a Create request" + generateNameMessage,
-				Value:  in.GetObjectMeta().GetName(),
-			}},
-		}
-	}
-
-	// A ResourceVersion should never be specified on a Create.
-	if len(in.GetObjectMeta().GetResourceVersion()) != 0 {
-		logWithResource(in).Info("Rejecting Create request with non-empty resource version")
-		return nil, cerrors.ErrorValidation{
-			ErroredFields: []cerrors.ErroredField{{
-				Name:   "Metadata.ResourceVersion",
-				Reason: "field must not be set for a Create request",
-				Value:  in.GetObjectMeta().GetResourceVersion(),
-			}},
-		}
-	}
-	if err := c.checkNamespace(in.GetObjectMeta().GetNamespace(), kind); err != nil {
+	if err := c.prepareCreate(kind, in); err != nil {
 		return nil, err
 	}
 
-	// Add in the UID and creation timestamp for the resource if needed.
-	creationTimestamp := in.GetObjectMeta().GetCreationTimestamp()
-	if creationTimestamp.IsZero() {
-		in.GetObjectMeta().SetCreationTimestamp(v1.Now())
-	}
-	if in.GetObjectMeta().GetUID() == "" {
-		in.GetObjectMeta().SetUID(uuid.NewUUID())
-	}
-
 	// Convert the resource to a KVPair and pass that to the backend datastore, converting
 	// the response (if we get one) back to a resource.
 	kvp, err := c.backend.Create(ctx, c.resourceToKVPair(opts, kind, in))
@@ -119,39 +84,9 @@ func (c *resources) Create(ctx context.Context, opts options.SetOptions, kind st
 
 // Update updates a resource in the backend datastore.
 func (c *resources) Update(ctx context.Context, opts options.SetOptions, kind string, in resource) (resource, error) {
-	// A ResourceVersion should always be specified on an Update.
-	if len(in.GetObjectMeta().GetResourceVersion()) == 0 {
-		logWithResource(in).Info("Rejecting Update request with empty resource version")
-		return nil, cerrors.ErrorValidation{
-			ErroredFields: []cerrors.ErroredField{{
-				Name:   "Metadata.ResourceVersion",
-				Reason: "field must be set for an Update request",
-				Value:  in.GetObjectMeta().GetResourceVersion(),
-			}},
-		}
-	}
-	if err := c.checkNamespace(in.GetObjectMeta().GetNamespace(), kind); err != nil {
+	if err := c.prepareUpdate(kind, in); err != nil {
 		return nil, err
 	}
-	creationTimestamp := in.GetObjectMeta().GetCreationTimestamp()
-	if creationTimestamp.IsZero() {
-		return nil, cerrors.ErrorValidation{
-			ErroredFields: []cerrors.ErroredField{{
-				Name:   "Metadata.CreationTimestamp",
-				Reason: "field must be set for an Update request",
-				Value:  in.GetObjectMeta().GetCreationTimestamp(),
-			}},
-		}
-	}
-	if in.GetObjectMeta().GetUID() == "" {
-		return nil, cerrors.ErrorValidation{
-			ErroredFields: []cerrors.ErroredField{{
-				Name:   "Metadata.UID",
-				Reason: "field must be set for an Update request",
-				Value:  in.GetObjectMeta().GetUID(),
-			}},
-		}
-	}
 
 	// Convert the resource to a KVPair and pass that to the backend datastore, converting
 	// the response (if we get one) back to a resource.
@@ -267,6 +202,88 @@ func (c *resources) Watch(ctx context.Context, opts options.ListOptions, kind st
 	return w, nil
 }
 
+// prepareCreate validates the metadata of a resource for a Create request, and fills in the
+// UID and creation timestamp.
+func (c *resources) prepareCreate(kind string, in resource) error {
+	// Resource must have a Name.  Currently we do not support GenerateName.
+	if len(in.GetObjectMeta().GetName()) == 0 {
+		var generateNameMessage string
+		if len(in.GetObjectMeta().GetGenerateName()) != 0 {
+			generateNameMessage = " (GenerateName is not supported)"
+		}
+		return cerrors.ErrorValidation{
+			ErroredFields: []cerrors.ErroredField{{
+				Name:   "Metadata.Name",
+				Reason: "field must be set for a Create request" + generateNameMessage,
+				Value:  in.GetObjectMeta().GetName(),
+			}},
+		}
+	}
+
+	// A ResourceVersion should never be specified on a Create.
+	if len(in.GetObjectMeta().GetResourceVersion()) != 0 {
+		logWithResource(in).Info("Rejecting Create request with non-empty resource version")
+		return cerrors.ErrorValidation{
+			ErroredFields: []cerrors.ErroredField{{
+				Name:   "Metadata.ResourceVersion",
+				Reason: "field must not be set for a Create request",
+				Value:  in.GetObjectMeta().GetResourceVersion(),
+			}},
+		}
+	}
+	if err := c.checkNamespace(in.GetObjectMeta().GetNamespace(), kind); err != nil {
+		return err
+	}
+
+	// Add in the UID and creation timestamp for the resource if needed.
+	creationTimestamp := in.GetObjectMeta().GetCreationTimestamp()
+	if creationTimestamp.IsZero() {
+		in.GetObjectMeta().SetCreationTimestamp(v1.Now())
+	}
+	if in.GetObjectMeta().GetUID() == "" {
+		in.GetObjectMeta().SetUID(uuid.NewUUID())
+	}
+	return nil
+}
+
+// prepareUpdate validates the metadata of a resource for an Update request.
+func (c *resources) prepareUpdate(kind string, in resource) error {
+	// A ResourceVersion should always be specified on an Update.
+	if len(in.GetObjectMeta().GetResourceVersion()) == 0 {
+		logWithResource(in).Info("Rejecting Update request with empty resource version")
+		return cerrors.ErrorValidation{
+			ErroredFields: []cerrors.ErroredField{{
+				Name:   "Metadata.ResourceVersion",
+				Reason: "field must be set for an Update request",
+				Value:  in.GetObjectMeta().GetResourceVersion(),
+			}},
+		}
+	}
+	if err := c.checkNamespace(in.GetObjectMeta().GetNamespace(), kind); err != nil {
+		return err
+	}
+	creationTimestamp := in.GetObjectMeta().GetCreationTimestamp()
+	if creationTimestamp.IsZero() {
+		return cerrors.ErrorValidation{
+			ErroredFields: []cerrors.ErroredField{{
+				Name:   "Metadata.CreationTimestamp",
+				Reason: "field must be set for an Update request",
+				Value:  in.GetObjectMeta().GetCreationTimestamp(),
+			}},
+		}
+	}
+	if in.GetObjectMeta().GetUID() == "" {
+		return cerrors.ErrorValidation{
+			ErroredFields: []cerrors.ErroredField{{
+				Name:   "Metadata.UID",
+				Reason: "field must be set for an Update request",
+				Value:  in.GetObjectMeta().GetUID(),
+			}},
+		}
+	}
+	return nil
+}
+
 // resourceToKVPair converts the resource to a KVPair that can be consumed by the
 // backend datastore client.
 func (c *resources) resourceToKVPair(opts options.SetOptions, kind string, in resource) *model.KVPair {
@@ -0,0 +1,272 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+
+	bapi "github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+	cerrors "github.com/projectcalico/calico/libcalico-go/lib/errors"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+)
+
+var errTransactionCommitted = errors.New("transaction has already been committed")
+
+// Transaction is a set of resource changes that are made in the datastore as a single unit.
+type Transaction interface {
+	// Client returns a client for staging changes in the transaction.  Create, Update and
+	// Delete requests made using this client are validated and defaulted in the same way as
+	// the main client, but are not made in the datastore until the transaction is committed.
+	// Get requests return the staged version of a resource, whereas List and Watch requests
+	// only return what is in the datastore.
+	//
+	// Only the resource changes are staged.  Other datastore operations, such as the IPAM
+	// clean up performed when deleting a Node or IPPool, are performed immediately.
+	Client() Interface
+
+	// Commit makes the staged changes in the datastore.  On the etcdv3 and file datastores
+	// the changes are made in a single datastore transaction.  On the Kubernetes datastore the
+	// changes are made in order, and if one of them fails the changes that have already been
+	// made are reverted.  A transaction may only be committed once.
+	Commit(ctx context.Context) error
+}
+
+// transaction implements the Transaction interface.
+type transaction struct {
+	lock      sync.Mutex
+	backend   bapi.Client
+	client    client
+	ops       []*bapi.TxnOp
+	index     map[model.ResourceKey]int
+	committed bool
+}
+
+func newTransaction(c client) *transaction {
+	t := &transaction{
+		backend: c.backend,
+		index:   map[model.ResourceKey]int{},
+	}
+	t.client = client{
+		config:  c.config,
+		backend: c.backend,
+		resources: &txnResources{
+			resources: &resources{backend: c.backend},
+			txn:       t,
+		},
+	}
+	return t
+}
+
+// Client returns the client used to stage changes in the transaction.
+func (t *transaction) Client() Interface {
+	return t.client
+}
+
+// Commit makes the staged changes in the datastore.
+func (t *transaction) Commit(ctx context.Context) error {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.committed {
+		return errTransactionCommitted
+	}
+	t.committed = true
+
+	var ops []bapi.TxnOp
+	for _, op := range t.ops {
+		if op != nil {
+			ops = append(ops, *op)
+		}
+	}
+	if len(ops) == 0 {
+		return nil
+	}
+
+	tc, ok := t.backend.(bapi.TxnClient)
+	if !ok {
+		return cerrors.ErrorOperationNotSupported{
+			Operation:  "Commit",
+			Identifier: "transaction",
+			Reason:     "the datastore does not support transactions",
+		}
+	}
+	log.WithField("numOps", len(ops)).Debug("Committing transaction")
+	_, err := tc.Txn(ctx, ops)
+	return err
+}
+
+// staged returns the staged operation for the key, if there is one.
+func (t *transaction) staged(key model.ResourceKey) *bapi.TxnOp {
+	if i, ok := t.index[key]; ok {
+		return t.ops[i]
+	}
+	return nil
+}
+
+// stage adds an operation to the transaction.  If the transaction already contains an
+// operation for the same resource, the two operations are combined.
+func (t *transaction) stage(opType bapi.TxnOpType, kvp *model.KVPair) error {
+	if t.committed {
+		return errTransactionCommitted
+	}
+	key := kvp.Key.(model.ResourceKey)
+	i, ok := t.index[key]
+	if !ok {
+		t.index[key] = len(t.ops)
+		t.ops = append(t.ops, &bapi.TxnOp{Type: opType, KVPair: kvp})
+		return nil
+	}
+
+	prev := t.ops[i]
+	switch {
+	case opType == bapi.TxnUpdate && prev.Type != bapi.TxnDelete:
+		// Keep the original operation and revision, with the updated value.
+		prev.KVPair = &model.KVPair{Key: key, Value: kvp.Value, TTL: kvp.TTL, Revision: prev.KVPair.Revision}
+	case opType == bapi.TxnDelete && prev.Type == bapi.TxnCreate:
+		// The resource was created in this transaction, so there is nothing to do.
+		t.ops[i] = nil
+		delete(t.index, key)
+	case opType == bapi.TxnDelete && prev.Type != bapi.TxnDelete:
+		prev.Type = bapi.TxnDelete
+		prev.KVPair = &model.KVPair{Key: key, Revision: prev.KVPair.Revision, UID: kvp.UID}
+	case opType == bapi.TxnCreate && prev.Type == bapi.TxnDelete:
+		// The resource is being replaced.
+		if prev.KVPair.Revision != "" {
+			prev.Type = bapi.TxnUpdate
+		} else {
+			prev.Type = bapi.TxnApply
+		}
+		prev.KVPair = &model.KVPair{Key: key, Value: kvp.Value, TTL: kvp.TTL, Revision: prev.KVPair.Revision}
+	case opType == bapi.TxnCreate:
+		return cerrors.ErrorResourceAlreadyExists{Identifier: key}
+	default:
+		return cerrors.ErrorResourceDoesNotExist{Identifier: key}
+	}
+	return nil
+}
+
+// txnResources implements resourceInterface, staging changes in a transaction rather than
+// making them in the datastore.
+type txnResources struct {
+	*resources
+	txn *transaction
+}
+
+// Create stages the creation of a resource.
+func (c *txnResources) Create(ctx context.Context, opts options.SetOptions, kind string, in resource) (resource, error) {
+	if err := c.prepareCreate(kind, in); err != nil {
+		return nil, err
+	}
+	kvp := c.resourceToKVPair(opts, kind, in.DeepCopyObject().(resource))
+
+	c.txn.lock.Lock()
+	defer c.txn.lock.Unlock()
+
+	// Check that the resource does not already exist, so that the caller can fall back to an
+	// update in the same way as for the main client.
+	if existing, err := c.get(ctx, kvp.Key.(model.ResourceKey)); err == nil {
+		return existing, cerrors.ErrorResourceAlreadyExists{Identifier: kvp.Key}
+	} else if _, ok := err.(cerrors.ErrorResourceDoesNotExist); !ok {
+		return nil, err
+	}
+
+	if err := c.txn.stage(bapi.TxnCreate, kvp); err != nil {
+		return nil, err
+	}
+	return c.stagedResource(kvp), nil
+}
+
+// Update stages the update of a resource.
+func (c *txnResources) Update(ctx context.Context, opts options.SetOptions, kind string, in resource) (resource, error) {
+	if err := c.prepareUpdate(kind, in); err != nil {
+		return nil, err
+	}
+	kvp := c.resourceToKVPair(opts, kind, in.DeepCopyObject().(resource))
+
+	c.txn.lock.Lock()
+	defer c.txn.lock.Unlock()
+	if err := c.txn.stage(bapi.TxnUpdate, kvp); err != nil {
+		return nil, err
+	}
+	return c.stagedResource(kvp), nil
+}
+
+// Delete stages the deletion of a resource, returning the current version of the resource.
+func (c *txnResources) Delete(ctx context.Context, opts options.DeleteOptions, kind, ns, name string) (resource, error) {
+	if err := c.checkNamespace(ns, kind); err != nil {
+		return nil, err
+	}
+	key := model.ResourceKey{
+		Kind:      kind,
+		Name:      name,
+		Namespace: ns,
+	}
+
+	c.txn.lock.Lock()
+	defer c.txn.lock.Unlock()
+	out, err := c.get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.txn.stage(bapi.TxnDelete, &model.KVPair{Key: key, Revision: opts.ResourceVersion, UID: opts.UID}); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Get gets a resource, including any changes staged in the transaction.
+func (c *txnResources) Get(ctx context.Context, opts options.GetOptions, kind, ns, name string) (resource, error) {
+	if err := c.checkNamespace(ns, kind); err != nil {
+		return nil, err
+	}
+	if opts.ResourceVersion != "" {
+		// A specific revision was requested, so the staged changes are not relevant.
+		return c.resources.Get(ctx, opts, kind, ns, name)
+	}
+
+	c.txn.lock.Lock()
+	defer c.txn.lock.Unlock()
+	return c.get(ctx, model.ResourceKey{Kind: kind, Name: name, Namespace: ns})
+}
+
+// get returns the staged version of the resource if there is one, or the version in the
+// datastore otherwise.  The transaction lock must be held.
+func (c *txnResources) get(ctx context.Context, key model.ResourceKey) (resource, error) {
+	if op := c.txn.staged(key); op != nil {
+		if op.Type == bapi.TxnDelete {
+			return nil, cerrors.ErrorResourceDoesNotExist{Identifier: key}
+		}
+		return c.stagedResource(op.KVPair), nil
+	}
+	kvp, err := c.backend.Get(ctx, key, "")
+	if err != nil {
+		return nil, err
+	}
+	return c.kvPairToResource(kvp), nil
+}
+
+// stagedResource returns a copy of the resource in a staged KVPair.  The resource version is
+// the version that the staged change is based on.
+func (c *txnResources) stagedResource(kvp *model.KVPair) resource {
+	return c.kvPairToResource(&model.KVPair{
+		Key:      kvp.Key,
+		Value:    kvp.Value.(resource).DeepCopyObject(),
+		Revision: kvp.Revision,
+	})
+}
@@ -0,0 +1,147 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package clientv3_test
+
+import (
+	"context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/apiconfig"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend"
+	"github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	cerrors "github.com/projectcalico/calico/libcalico-go/lib/errors"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+	"github.com/projectcalico/calico/libcalico-go/lib/testutils"
+)
+
+var _ = testutils.E2eDatastoreDescribe("Transaction tests", testutils.DatastoreAll, func(config apiconfig.CalicoAPIConfig) {
+	ctx := context.Background()
+	order := 10.0
+	var c clientv3.Interface
+
+	tier := func(name string) *apiv3.Tier {
+		return &apiv3.Tier{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec:       apiv3.TierSpec{Order: &order},
+		}
+	}
+	gnp := func(tier, name, selector string) *apiv3.GlobalNetworkPolicy {
+		return &apiv3.GlobalNetworkPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: tier + "." + name},
+			Spec: apiv3.GlobalNetworkPolicySpec{
+				Tier:     tier,
+				Selector: selector,
+				Ingress:  []apiv3.Rule{testutils.InRule1},
+			},
+		}
+	}
+
+	BeforeEach(func() {
+		var err error
+		c, err = clientv3.New(config)
+		Expect(err).NotTo(HaveOccurred())
+
+		be, err := backend.NewClient(config)
+		Expect(err).NotTo(HaveOccurred())
+		be.Clean()
+
+		err = c.EnsureInitialized(ctx, "", "")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	It("should make the staged changes when committed", func() {
+		txn := c.Transaction()
+		tc := txn.Client()
+
+		By("staging a tier and policies in the tier")
+		_, err := tc.Tiers().Create(ctx, tier("tier-1"), options.SetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = tc.GlobalNetworkPolicies().Create(ctx, gnp("tier-1", "policy-1", "all()"), options.SetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = tc.GlobalNetworkPolicies().Create(ctx, gnp("tier-1", "policy-2", "has(foo)"), options.SetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		By("checking the staged tier is visible to the transaction but not the datastore")
+		_, err = tc.Tiers().Get(ctx, "tier-1", options.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = c.Tiers().Get(ctx, "tier-1", options.GetOptions{})
+		Expect(err).To(BeAssignableToTypeOf(cerrors.ErrorResourceDoesNotExist{}))
+
+		By("committing the transaction")
+		Expect(txn.Commit(ctx)).To(Succeed())
+		_, err = c.Tiers().Get(ctx, "tier-1", options.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		policies, err := c.GlobalNetworkPolicies().List(ctx, options.ListOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(policies.Items).To(HaveLen(2))
+
+		By("refusing to commit the transaction again")
+		Expect(txn.Commit(ctx)).NotTo(Succeed())
+	})
+
+	It("should make none of the changes if one of them fails", func() {
+		_, err := c.Tiers().Create(ctx, tier("tier-1"), options.SetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		original, err := c.GlobalNetworkPolicies().Create(ctx, gnp("tier-1", "policy-1", "all()"), options.SetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		// Stage an update of the policy, and then update it outside of the transaction so that
+		// the staged update conflicts.
+		txn := c.Transaction()
+		tc := txn.Client()
+		_, err = tc.Tiers().Create(ctx, tier("tier-2"), options.SetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = tc.GlobalNetworkPolicies().Create(ctx, gnp("tier-2", "policy-2", "all()"), options.SetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		stale := original.DeepCopy()
+		stale.Spec.Selector = "has(staged)"
+		_, err = tc.GlobalNetworkPolicies().Update(ctx, stale, options.SetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		current := original.DeepCopy()
+		current.Spec.Selector = "has(current)"
+		_, err = c.GlobalNetworkPolicies().Update(ctx, current, options.SetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		err = txn.Commit(ctx)
+		Expect(err).To(BeAssignableToTypeOf(cerrors.ErrorResourceUpdateConflict{}))
+
+		By("checking that none of the staged changes were made")
+		_, err = c.Tiers().Get(ctx, "tier-2", options.GetOptions{})
+		Expect(err).To(BeAssignableToTypeOf(cerrors.ErrorResourceDoesNotExist{}))
+		_, err = c.GlobalNetworkPolicies().Get(ctx, "tier-2.policy-2", options.GetOptions{})
+		Expect(err).To(BeAssignableToTypeOf(cerrors.ErrorResourceDoesNotExist{}))
+		policy, err := c.GlobalNetworkPolicies().Get(ctx, "tier-1.policy-1", options.GetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		Expect(policy.Spec.Selector).To(Equal("has(current)"))
+	})
+
+	It("should report a staged create of an existing resource", func() {
+		_, err := c.Tiers().Create(ctx, tier("tier-1"), options.SetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+
+		txn := c.Transaction()
+		_, err = txn.Client().Tiers().Create(ctx, tier("tier-1"), options.SetOptions{})
+		Expect(err).To(BeAssignableToTypeOf(cerrors.ErrorResourceAlreadyExists{}))
+		_, err = txn.Client().Tiers().Create(ctx, tier("tier-2"), options.SetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+		_, err = txn.Client().Tiers().Create(ctx, tier("tier-2"), options.SetOptions{})
+		Expect(err).To(BeAssignableToTypeOf(cerrors.ErrorResourceAlreadyExists{}))
+	})
+})
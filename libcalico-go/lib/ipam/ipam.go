@@ -48,6 +48,7 @@ const (
 	AttributePod             = model.IPAMBlockAttributePod
 	AttributeNamespace       = model.IPAMBlockAttributeNamespace
 	AttributeNode            = model.IPAMBlockAttributeNode
+	AttributeService         = model.IPAMBlockAttributeService
 	AttributeTimestamp       = model.IPAMBlockAttributeTimestamp
 	AttributeType            = model.IPAMBlockAttributeType
 	AttributeTypeIPIP        = model.IPAMBlockAttributeTypeIPIP
@@ -55,6 +56,10 @@ const (
 	AttributeTypeVXLANV6     = model.IPAMBlockAttributeTypeVXLANV6
 	AttributeTypeWireguard   = model.IPAMBlockAttributeTypeWireguard
 	AttributeTypeWireguardV6 = model.IPAMBlockAttributeTypeWireguardV6
+
+	// LoadBalancerHost is the host used when assigning addresses for Services of type LoadBalancer.  These
+	// addresses are not tied to a node, so the blocks they come from are affine to this virtual host instead.
+	LoadBalancerHost = "load-balancer"
 )
 
 var (
@@ -328,17 +333,23 @@ func (c ipamClient) determinePools(ctx context.Context, requestedPoolNets []net.
 // It also releases any emptied blocks still affine to this host but no longer part of an IP Pool which
 // selects this node. It returns matching pools, list of host-affine blocks and any error encountered.
 func (c ipamClient) prepareAffinityBlocksForHost(ctx context.Context, requestedPools []net.IPNet, version int, host string, rsvdAttr *HostReservedAttr, use v3.IPPoolAllowedUse) ([]v3.IPPool, []net.IPNet, error) {
-	// Retrieve node for given hostname to use for ip pool node selection
-	node, err := c.client.Get(ctx, model.ResourceKey{Kind: libapiv3.KindNode, Name: host}, "")
-	if err != nil {
-		log.WithError(err).WithField("node", host).Error("failed to get node for host")
-		return nil, nil, err
-	}
+	// Retrieve node for given hostname to use for ip pool node selection.  LoadBalancer addresses
+	// are not tied to a node, and LoadBalancer pools cannot have a node selector, so there is no
+	// node to look up in that case.
+	v3n := &libapiv3.Node{}
+	if use != v3.IPPoolAllowedUseLoadBalancer {
+		node, err := c.client.Get(ctx, model.ResourceKey{Kind: libapiv3.KindNode, Name: host}, "")
+		if err != nil {
+			log.WithError(err).WithField("node", host).Error("failed to get node for host")
+			return nil, nil, err
+		}
 
-	// Make sure the returned value is OK.
-	v3n, ok := node.Value.(*libapiv3.Node)
-	if !ok {
-		return nil, nil, fmt.Errorf("Datastore returned malformed node object")
+		// Make sure the returned value is OK.
+		var ok bool
+		v3n, ok = node.Value.(*libapiv3.Node)
+		if !ok {
+			return nil, nil, fmt.Errorf("Datastore returned malformed node object")
+		}
 	}
 
 	maxPrefixLen, err := getMaxPrefixLen(version, rsvdAttr)
@@ -2065,6 +2065,40 @@ var _ = testutils.E2eDatastoreDescribe("IPAM tests", testutils.DatastoreAll, fun
 			}
 		})
 
+		// LoadBalancer addresses are not tied to a node, so they should be assigned from a LoadBalancer
+		// pool to the virtual load balancer host without a corresponding node existing.
+		It("should assign LoadBalancer addresses without a node", func() {
+			pool1 := cnet.MustParseNetwork("10.0.0.0/24")
+			pool2 := cnet.MustParseNetwork("20.0.0.0/24")
+
+			bc.Clean()
+			deleteAllPools()
+
+			applyPoolWithUses(pool1.String(), true, "",
+				[]v3.IPPoolAllowedUse{v3.IPPoolAllowedUseWorkload, v3.IPPoolAllowedUseTunnel})
+			applyPoolWithUses(pool2.String(), true, "",
+				[]v3.IPPoolAllowedUse{v3.IPPoolAllowedUseLoadBalancer})
+
+			handle := "lb.default.my-service"
+			v4ia, _, err := ic.AutoAssign(context.Background(), AutoAssignArgs{
+				Num4:        1,
+				HandleID:    &handle,
+				Hostname:    LoadBalancerHost,
+				IntendedUse: v3.IPPoolAllowedUseLoadBalancer,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(v4ia).ToNot(BeNil())
+			Expect(len(v4ia.IPs)).To(Equal(1))
+			Expect(pool2.IPNet.Contains(v4ia.IPs[0].IP)).To(BeTrue())
+
+			// The block should be affine to the load balancer host.
+			blocks := getAffineBlocks(bc, LoadBalancerHost)
+			Expect(len(blocks)).To(Equal(1))
+			Expect(pool2.IPNet.Contains(blocks[0].IP)).To(BeTrue())
+
+			Expect(ic.ReleaseByHandle(context.Background(), handle)).NotTo(HaveOccurred())
+		})
+
 		// Create one ip pool, call AutoAssign, call ReleaseIPs,
 		// create another ip pool, call AutoAssign explicitly passing the second pool,
 		// ensure that the block affinity from the first ip pool is not released.
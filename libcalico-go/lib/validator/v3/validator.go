@@ -250,6 +250,7 @@ func init() {
 	registerStructValidator(validate, validateRule, api.Rule{})
 	registerStructValidator(validate, validateEntityRule, api.EntityRule{})
 	registerStructValidator(validate, validateBGPPeerSpec, api.BGPPeerSpec{})
+	registerStructValidator(validate, validateBGPGracefulRestart, api.BGPGracefulRestart{})
 	registerStructValidator(validate, validateBGPFilterRuleV4, api.BGPFilterRuleV4{})
 	registerStructValidator(validate, validateBGPFilterRuleV6, api.BGPFilterRuleV6{})
 	registerStructValidator(validate, validateNetworkPolicy, api.NetworkPolicy{})
@@ -262,6 +263,7 @@ func init() {
 	registerStructValidator(validate, validateBGPConfigurationSpec, api.BGPConfigurationSpec{})
 	registerStructValidator(validate, validateBlockAffinitySpec, libapi.BlockAffinitySpec{})
 	registerStructValidator(validate, validateHealthTimeoutOverride, api.HealthTimeoutOverride{})
+	registerStructValidator(validate, validateAutoHostEndpointConfig, api.AutoHostEndpointConfig{})
 }
 
 // reason returns the provided error reason prefixed with an identifier that
@@ -1155,15 +1157,30 @@ func validateIPPoolSpec(structLevel validator.StructLevel) {
 	}
 
 	// Allowed use must be one of the enums.
+	loadBalancer := false
 	for _, a := range pool.AllowedUses {
 		switch a {
 		case api.IPPoolAllowedUseWorkload, api.IPPoolAllowedUseTunnel:
 			continue
+		case api.IPPoolAllowedUseLoadBalancer:
+			loadBalancer = true
 		default:
 			structLevel.ReportError(reflect.ValueOf(pool.AllowedUses),
 				"IPpool.AllowedUses", "", reason("unknown use: "+string(a)), "")
 		}
 	}
+
+	// LoadBalancer addresses are not tied to a node, so they must come from a dedicated pool.
+	if loadBalancer {
+		if len(pool.AllowedUses) > 1 {
+			structLevel.ReportError(reflect.ValueOf(pool.AllowedUses),
+				"IPpool.AllowedUses", "", reason("LoadBalancer cannot be combined with other uses"), "")
+		}
+		if pool.NodeSelector != "" && strings.TrimSpace(pool.NodeSelector) != "all()" {
+			structLevel.ReportError(reflect.ValueOf(pool.NodeSelector),
+				"IPpool.NodeSelector", "", reason("node selector cannot be set on a LoadBalancer pool"), "")
+		}
+	}
 }
 
 func vxLanModeEnabled(mode api.VXLANMode) bool {
@@ -1368,11 +1385,54 @@ func validateBGPPeerSpec(structLevel validator.StructLevel) {
 		structLevel.ReportError(reflect.ValueOf(ps.ASNumber), "ASNumber", "",
 			reason("ASNumber field must be empty when PeerSelector is specified"), "")
 	}
+	if ps.PeerInterface != "" || ps.PeerCIDR != "" {
+		numPeerFields := 0
+		for _, f := range []string{ps.PeerIP, ps.PeerSelector, ps.PeerInterface, ps.PeerCIDR} {
+			if f != "" {
+				numPeerFields++
+			}
+		}
+		if numPeerFields > 1 {
+			structLevel.ReportError(reflect.ValueOf(ps.PeerIP), "PeerIP", "",
+				reason("only one of PeerIP, PeerSelector, PeerInterface and PeerCIDR may be specified"), "")
+		}
+		if uint32(ps.ASNumber) == 0 {
+			structLevel.ReportError(reflect.ValueOf(ps.ASNumber), "ASNumber", "",
+				reason("ASNumber field must be specified when PeerInterface or PeerCIDR is specified"), "")
+		}
+	}
+	if ps.PeerInterface == "*" {
+		structLevel.ReportError(reflect.ValueOf(ps.PeerInterface), "PeerInterface", "",
+			reason("PeerInterface must name a single interface"), "")
+	}
 	ok, msg := validateReachableBy(ps.ReachableBy, ps.PeerIP)
 	if !ok {
 		structLevel.ReportError(reflect.ValueOf(ps.ReachableBy), "ReachableBy", "",
 			reason(msg), "")
 	}
+	if ps.MaxRestartTime != nil && ps.GracefulRestart != nil && ps.GracefulRestart.RestartTime != nil {
+		structLevel.ReportError(reflect.ValueOf(ps.MaxRestartTime), "MaxRestartTime", "",
+			reason("MaxRestartTime field must be empty when GracefulRestart.RestartTime is specified"), "")
+	}
+}
+
+func validateBGPGracefulRestart(structLevel validator.StructLevel) {
+	gr := structLevel.Current().Interface().(api.BGPGracefulRestart)
+
+	if gr.Mode == api.GracefulRestartDisabled {
+		if gr.RestartTime != nil {
+			structLevel.ReportError(reflect.ValueOf(gr.RestartTime), "RestartTime", "",
+				reason("RestartTime must be empty when graceful restart is disabled"), "")
+		}
+		if gr.LongLivedMode != "" && gr.LongLivedMode != api.GracefulRestartDisabled {
+			structLevel.ReportError(reflect.ValueOf(gr.LongLivedMode), "LongLivedMode", "",
+				reason("long-lived graceful restart requires graceful restart to be enabled"), "")
+		}
+	}
+	if gr.StaleRouteTime != nil && gr.LongLivedMode != api.GracefulRestartEnabled {
+		structLevel.ReportError(reflect.ValueOf(gr.StaleRouteTime), "StaleRouteTime", "",
+			reason("StaleRouteTime must be empty unless LongLivedMode is Enabled"), "")
+	}
 }
 
 func validateReachableBy(reachableBy, peerIP string) (bool, string) {
@@ -1960,6 +2020,20 @@ func validateRouteTableIDRange(structLevel validator.StructLevel) {
 	}
 }
 
+func validateAutoHostEndpointConfig(structLevel validator.StructLevel) {
+	cfg := structLevel.Current().Interface().(api.AutoHostEndpointConfig)
+
+	// The template name is used to name the generated host endpoints, so must be unique.
+	names := set.New[string]()
+	for _, t := range cfg.Templates {
+		if names.Contains(t.GenerateName) {
+			structLevel.ReportError(reflect.ValueOf(t.GenerateName), "Templates[].GenerateName", "",
+				reason("template names must be unique"), "")
+		}
+		names.Add(t.GenerateName)
+	}
+}
+
 func validateBGPConfigurationSpec(structLevel validator.StructLevel) {
 	spec := structLevel.Current().Interface().(api.BGPConfigurationSpec)
 
@@ -2008,6 +2082,16 @@ func validateBGPConfigurationSpec(structLevel validator.StructLevel) {
 	if spec.NodeMeshMaxRestartTime != nil && spec.NodeToNodeMeshEnabled != nil && !*spec.NodeToNodeMeshEnabled {
 		structLevel.ReportError(reflect.ValueOf(spec), "Spec.NodeMeshMaxRestartTime", "", reason("spec.NodeMeshMaxRestartTime cannot be set if spec.NodeToNodeMesh is disabled"), "")
 	}
+
+	// Check that node mesh graceful restart cannot be set if node to node mesh is disabled.
+	if spec.NodeMeshGracefulRestart != nil && spec.NodeToNodeMeshEnabled != nil && !*spec.NodeToNodeMeshEnabled {
+		structLevel.ReportError(reflect.ValueOf(spec), "Spec.NodeMeshGracefulRestart", "", reason("spec.NodeMeshGracefulRestart cannot be set if spec.NodeToNodeMesh is disabled"), "")
+	}
+
+	// Check that the node mesh restart time is not specified twice.
+	if spec.NodeMeshMaxRestartTime != nil && spec.NodeMeshGracefulRestart != nil && spec.NodeMeshGracefulRestart.RestartTime != nil {
+		structLevel.ReportError(reflect.ValueOf(spec), "Spec.NodeMeshMaxRestartTime", "", reason("spec.NodeMeshMaxRestartTime cannot be set together with spec.NodeMeshGracefulRestart.RestartTime"), "")
+	}
 }
 
 func validateBlockAffinitySpec(structLevel validator.StructLevel) {
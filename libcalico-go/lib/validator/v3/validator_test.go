@@ -528,6 +528,31 @@ func init() {
 				NodeMeshMaxRestartTime: &v1.Duration{Duration: 200 * time.Second},
 			}, false,
 		),
+		Entry("should accept node mesh graceful restart if node to node mesh is enabled",
+			api.BGPConfigurationSpec{
+				NodeToNodeMeshEnabled: &Vtrue,
+				NodeMeshGracefulRestart: &api.BGPGracefulRestart{
+					Mode:           api.GracefulRestartEnabled,
+					RestartTime:    &v1.Duration{Duration: 200 * time.Second},
+					LongLivedMode:  api.GracefulRestartEnabled,
+					StaleRouteTime: &v1.Duration{Duration: time.Hour},
+				},
+			}, true,
+		),
+		Entry("should reject node mesh graceful restart if node to node mesh is disabled",
+			api.BGPConfigurationSpec{
+				NodeToNodeMeshEnabled:   &Vfalse,
+				NodeMeshGracefulRestart: &api.BGPGracefulRestart{Mode: api.GracefulRestartAware},
+			}, false,
+		),
+		Entry("should reject node mesh max restart time together with graceful restart time",
+			api.BGPConfigurationSpec{
+				NodeMeshMaxRestartTime: &v1.Duration{Duration: 200 * time.Second},
+				NodeMeshGracefulRestart: &api.BGPGracefulRestart{
+					RestartTime: &v1.Duration{Duration: 200 * time.Second},
+				},
+			}, false,
+		),
 		Entry("should accept valid interface names",
 			api.BGPConfigurationSpec{
 				IgnoredInterfaces: []string{"valid_iface*", "interface_name"},
@@ -1052,6 +1077,34 @@ func init() {
 					},
 				},
 			}, false),
+		Entry("should accept IP pool for LoadBalancer addresses",
+			api.IPPool{
+				ObjectMeta: v1.ObjectMeta{Name: "pool.name"},
+				Spec: api.IPPoolSpec{
+					CIDR:        netv4_4,
+					AllowedUses: []api.IPPoolAllowedUse{api.IPPoolAllowedUseLoadBalancer},
+				},
+			}, true),
+		Entry("should reject IP pool combining LoadBalancer with other uses",
+			api.IPPool{
+				ObjectMeta: v1.ObjectMeta{Name: "pool.name"},
+				Spec: api.IPPoolSpec{
+					CIDR: netv4_4,
+					AllowedUses: []api.IPPoolAllowedUse{
+						api.IPPoolAllowedUseLoadBalancer,
+						api.IPPoolAllowedUseWorkload,
+					},
+				},
+			}, false),
+		Entry("should reject LoadBalancer IP pool with a node selector",
+			api.IPPool{
+				ObjectMeta: v1.ObjectMeta{Name: "pool.name"},
+				Spec: api.IPPoolSpec{
+					CIDR:         netv4_4,
+					NodeSelector: "has(lb)",
+					AllowedUses:  []api.IPPoolAllowedUse{api.IPPoolAllowedUseLoadBalancer},
+				},
+			}, false),
 
 		// (API) IPReservation
 		Entry("should accept IPReservation with an IP",
@@ -1877,6 +1930,50 @@ func init() {
 			NodeSelector: "has(mylabel)",
 			PeerSelector: "has(mylabel)",
 		}, true),
+		Entry("should accept BGPPeerSpec with PeerInterface and ASNumber", api.BGPPeerSpec{
+			PeerInterface: "eth0",
+			ASNumber:      as61234,
+		}, true),
+		Entry("should reject BGPPeerSpec with PeerInterface but no ASNumber", api.BGPPeerSpec{
+			PeerInterface: "eth0",
+		}, false),
+		Entry("should reject BGPPeerSpec with wildcard PeerInterface", api.BGPPeerSpec{
+			PeerInterface: "*",
+			ASNumber:      as61234,
+		}, false),
+		Entry("should reject BGPPeerSpec with invalid PeerInterface", api.BGPPeerSpec{
+			PeerInterface: "eth0 eth1",
+			ASNumber:      as61234,
+		}, false),
+		Entry("should reject BGPPeerSpec with both PeerInterface and PeerIP", api.BGPPeerSpec{
+			PeerInterface: "eth0",
+			PeerIP:        ipv4_1,
+			ASNumber:      as61234,
+		}, false),
+		Entry("should accept BGPPeerSpec with PeerCIDR (IPv4) and ASNumber", api.BGPPeerSpec{
+			PeerCIDR: netv4_1,
+			ASNumber: as61234,
+		}, true),
+		Entry("should accept BGPPeerSpec with PeerCIDR (IPv6) and ASNumber", api.BGPPeerSpec{
+			PeerCIDR: netv6_1,
+			ASNumber: as61234,
+		}, true),
+		Entry("should reject BGPPeerSpec with PeerCIDR but no ASNumber", api.BGPPeerSpec{
+			PeerCIDR: netv4_1,
+		}, false),
+		Entry("should reject BGPPeerSpec with invalid PeerCIDR", api.BGPPeerSpec{
+			PeerCIDR: "10.0.0.1/8",
+			ASNumber: as61234,
+		}, false),
+		Entry("should reject BGPPeerSpec with both PeerCIDR and PeerSelector", api.BGPPeerSpec{
+			PeerCIDR:     netv4_1,
+			PeerSelector: "has(mylabel)",
+		}, false),
+		Entry("should reject BGPPeerSpec with both PeerCIDR and PeerInterface", api.BGPPeerSpec{
+			PeerCIDR:      netv4_1,
+			PeerInterface: "eth0",
+			ASNumber:      as61234,
+		}, false),
 		Entry("should reject BGPPeer with ReachableBy but without PeerIP", api.BGPPeerSpec{
 			ReachableBy: ipv4_2,
 		}, false),
@@ -1915,6 +2012,40 @@ func init() {
 			PeerIP:      peerv6_1,
 			ReachableBy: ipv4_1,
 		}, false),
+		Entry("should accept BGPPeer with graceful restart settings", api.BGPPeerSpec{
+			PeerIP: ipv4_1,
+			GracefulRestart: &api.BGPGracefulRestart{
+				Mode:           api.GracefulRestartAware,
+				RestartTime:    &v1.Duration{Duration: 200 * time.Second},
+				LongLivedMode:  api.GracefulRestartEnabled,
+				StaleRouteTime: &v1.Duration{Duration: time.Hour},
+			},
+		}, true),
+		Entry("should reject BGPPeer with an invalid graceful restart mode", api.BGPPeerSpec{
+			PeerIP:          ipv4_1,
+			GracefulRestart: &api.BGPGracefulRestart{Mode: "On"},
+		}, false),
+		Entry("should reject BGPPeer with both MaxRestartTime and GracefulRestart.RestartTime", api.BGPPeerSpec{
+			PeerIP:         ipv4_1,
+			MaxRestartTime: &v1.Duration{Duration: 200 * time.Second},
+			GracefulRestart: &api.BGPGracefulRestart{
+				RestartTime: &v1.Duration{Duration: 200 * time.Second},
+			},
+		}, false),
+		Entry("should reject BGPPeer with long-lived graceful restart but graceful restart disabled", api.BGPPeerSpec{
+			PeerIP: ipv4_1,
+			GracefulRestart: &api.BGPGracefulRestart{
+				Mode:          api.GracefulRestartDisabled,
+				LongLivedMode: api.GracefulRestartAware,
+			},
+		}, false),
+		Entry("should reject BGPPeer with StaleRouteTime but long-lived graceful restart not enabled", api.BGPPeerSpec{
+			PeerIP: ipv4_1,
+			GracefulRestart: &api.BGPGracefulRestart{
+				LongLivedMode:  api.GracefulRestartAware,
+				StaleRouteTime: &v1.Duration{Duration: time.Hour},
+			},
+		}, false),
 		Entry("should accept BGPPeerSpec with Password", api.BGPPeerSpec{
 			PeerIP: ipv4_1,
 			Password: &api.BGPPassword{
@@ -3144,6 +3275,37 @@ func init() {
 		Entry("should accept empty host endpoint auto create",
 			api.NodeControllerConfig{HostEndpoint: &api.AutoHostEndpointConfig{}}, true,
 		),
+		Entry("should not accept invalid create default host endpoint",
+			api.AutoHostEndpointConfig{CreateDefaultHostEndpoint: "Totally"}, false,
+		),
+		Entry("should accept host endpoint templates",
+			api.AutoHostEndpointConfig{
+				AutoCreate:                "Enabled",
+				CreateDefaultHostEndpoint: "Disabled",
+				Templates: []api.AutoHostEndpointTemplate{
+					{GenerateName: "storage", NodeSelector: "has(storage)", InterfacePattern: "^bond1$", Labels: map[string]string{"network": "storage"}},
+					{GenerateName: "mgmt", InterfacePattern: "^eth[0-9]+$", Profiles: []string{"mgmt"}},
+				},
+			}, true,
+		),
+		Entry("should not accept host endpoint templates with duplicate names",
+			api.AutoHostEndpointConfig{Templates: []api.AutoHostEndpointTemplate{
+				{GenerateName: "storage", InterfacePattern: "^bond1$"},
+				{GenerateName: "storage", InterfacePattern: "^bond2$"},
+			}}, false,
+		),
+		Entry("should not accept host endpoint template without a name",
+			api.AutoHostEndpointConfig{Templates: []api.AutoHostEndpointTemplate{{InterfacePattern: "^bond1$"}}}, false,
+		),
+		Entry("should not accept host endpoint template with an invalid interface pattern",
+			api.AutoHostEndpointConfig{Templates: []api.AutoHostEndpointTemplate{{GenerateName: "storage", InterfacePattern: "bond[1"}}}, false,
+		),
+		Entry("should not accept host endpoint template with an invalid node selector",
+			api.AutoHostEndpointConfig{Templates: []api.AutoHostEndpointTemplate{{GenerateName: "storage", InterfacePattern: "^bond1$", NodeSelector: "has("}}}, false,
+		),
+		Entry("should not accept host endpoint template with invalid labels",
+			api.AutoHostEndpointConfig{Templates: []api.AutoHostEndpointTemplate{{GenerateName: "storage", InterfacePattern: "^bond1$", Labels: map[string]string{"%": "storage"}}}}, false,
+		),
 		Entry("should accept valid reconciliation period on policy",
 			api.PolicyControllerConfig{ReconcilerPeriod: &v1.Duration{Duration: time.Second * 330}}, true,
 		),
@@ -30,6 +30,7 @@ import (
 	"github.com/projectcalico/calico/node/cmd/calico-node/bpf"
 	"github.com/projectcalico/calico/node/pkg/allocateip"
 	"github.com/projectcalico/calico/node/pkg/cni"
+	"github.com/projectcalico/calico/node/pkg/felixdebug"
 	"github.com/projectcalico/calico/node/pkg/health"
 	"github.com/projectcalico/calico/node/pkg/hostpathinit"
 	"github.com/projectcalico/calico/node/pkg/lifecycle/shutdown"
@@ -71,6 +72,11 @@ var thresholdTime = flagSet.Duration("threshold-time", 30*time.Second, "Threshol
 var runStatusReporter = flagSet.Bool("status-reporter", false, "Run node status reporter")
 var showStatus = flagSet.Bool("show-status", false, "Print out node status")
 
+// Options for querying felix's calculation graph.
+var showCalcGraph = flagSet.Bool("show-calc-graph", false, "Print felix's calculation graph view of the local endpoints (requires felix's DebugPort to be set in the FelixConfiguration or FELIX_DEBUGPORT)")
+var calcGraphWorkload = flagSet.String("workload", "", "Used in combination with the show-calc-graph flag. Only show the endpoints of the given pod (namespace/name).")
+var calcGraphHostEndpoint = flagSet.String("host-endpoint", "", "Used in combination with the show-calc-graph flag. Only show the given host endpoint.")
+
 // confd flags
 var runConfd = flagSet.Bool("confd", false, "Run confd")
 var confdRunOnce = flagSet.Bool("confd-run-once", false, "Run confd in oneshot mode")
@@ -172,6 +178,8 @@ func main() {
 	} else if *showStatus {
 		status.Show()
 		os.Exit(0)
+	} else if *showCalcGraph {
+		felixdebug.ShowCalcGraph(*calcGraphWorkload, *calcGraphHostEndpoint)
 	} else {
 		fmt.Println("No valid options provided. Usage:")
 		flagSet.PrintDefaults()
@@ -1569,3 +1569,7 @@ func (c shimClient) EnsureInitialized(ctx context.Context, calicoVersion, cluste
 func (c shimClient) Tiers() client.TierInterface {
 	panic("not implemented")
 }
+
+func (c shimClient) Transaction() client.Transaction {
+	panic("not implemented")
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package felixdebug queries felix's debug HTTP API from within the calico-node container.
+package felixdebug
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/projectcalico/calico/felix/calc"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	cerrors "github.com/projectcalico/calico/libcalico-go/lib/errors"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+	"github.com/projectcalico/calico/node/pkg/calicoclient"
+	"github.com/projectcalico/calico/node/pkg/lifecycle/utils"
+)
+
+// ShowCalcGraph prints felix's calculation graph view of the local endpoints that match the
+// given workload (namespace/name) or host endpoint name; all local endpoints if both are empty.
+// It exits with a non-zero exit code if the request fails.
+func ShowCalcGraph(workload, hostEndpoint string) {
+	_, c := calicoclient.CreateClient()
+	addr, err := debugAddress(context.Background(), c, utils.DetermineNodeName())
+	if err == nil {
+		err = showCalcGraph(os.Stdout, addr, workload, hostEndpoint)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+func showCalcGraph(w io.Writer, addr, workload, hostEndpoint string) error {
+	query := url.Values{}
+	if workload != "" {
+		query.Set("workload", workload)
+	}
+	if hostEndpoint != "" {
+		query.Set("hostEndpoint", hostEndpoint)
+	}
+	u := url.URL{Scheme: "http", Host: addr, Path: calc.DebugEndpointsPath, RawQuery: query.Encode()}
+
+	c := &http.Client{Timeout: 15 * time.Second}
+	resp, err := c.Get(u.String())
+	if err != nil {
+		return fmt.Errorf("failed to query felix: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("felix returned %s: %s", resp.Status, body)
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// debugAddress returns the address of felix's debug port, which must be enabled by setting
+// DebugPort. Like felix, it reads the FELIX_DEBUGPORT and FELIX_DEBUGHOST environment variables
+// first, then the FelixConfiguration for this node, then the default FelixConfiguration. Settings
+// in felix's config file are not read.
+func debugAddress(ctx context.Context, c client.Interface, nodeName string) (string, error) {
+	var port *int
+	var host *string
+	if v := os.Getenv("FELIX_DEBUGPORT"); v != "" {
+		p, err := strconv.Atoi(v)
+		if err != nil {
+			return "", fmt.Errorf("invalid value for FELIX_DEBUGPORT: %q", v)
+		}
+		port = &p
+	}
+	if v := os.Getenv("FELIX_DEBUGHOST"); v != "" {
+		host = &v
+	}
+	for _, name := range []string{"node." + nodeName, "default"} {
+		if port != nil && host != nil {
+			break
+		}
+		fc, err := c.FelixConfigurations().Get(ctx, name, options.GetOptions{})
+		if _, ok := err.(cerrors.ErrorResourceDoesNotExist); ok {
+			continue
+		} else if err != nil {
+			return "", fmt.Errorf("failed to read FelixConfiguration %s: %w", name, err)
+		}
+		if port == nil {
+			port = fc.Spec.DebugPort
+		}
+		if host == nil {
+			host = fc.Spec.DebugHost
+		}
+	}
+
+	if port == nil || *port == 0 {
+		return "", fmt.Errorf("felix's debug port is not enabled; set DebugPort in the FelixConfiguration or FELIX_DEBUGPORT to enable it")
+	}
+	addr := "localhost"
+	if host != nil {
+		switch *host {
+		case "", "0.0.0.0", "::":
+		default:
+			addr = *host
+		}
+	}
+	return net.JoinHostPort(addr, strconv.Itoa(*port)), nil
+}
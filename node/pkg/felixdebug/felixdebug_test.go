@@ -0,0 +1,95 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package felixdebug
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apiv3 "github.com/projectcalico/api/pkg/apis/projectcalico/v3"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/apiconfig"
+	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
+	"github.com/projectcalico/calico/libcalico-go/lib/options"
+)
+
+var _ = Describe("Felix debug address", func() {
+	var (
+		c   client.Interface
+		dir string
+	)
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "felixdebug")
+		Expect(err).NotTo(HaveOccurred())
+		cfg := apiconfig.NewCalicoAPIConfig()
+		cfg.Spec.DatastoreType = apiconfig.File
+		cfg.Spec.DatastoreFile = filepath.Join(dir, "datastore.json")
+		c, err = client.New(*cfg)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.Unsetenv("FELIX_DEBUGPORT")
+		os.Unsetenv("FELIX_DEBUGHOST")
+		Expect(os.RemoveAll(dir)).To(Succeed())
+	})
+
+	createFelixConfig := func(name string, port int, host string) {
+		fc := apiv3.NewFelixConfiguration()
+		fc.Name = name
+		fc.Spec.DebugPort = &port
+		if host != "" {
+			fc.Spec.DebugHost = &host
+		}
+		_, err := c.FelixConfigurations().Create(context.Background(), fc, options.SetOptions{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	It("should fail if the debug port is not enabled", func() {
+		_, err := debugAddress(context.Background(), c, "node1")
+		Expect(err).To(HaveOccurred())
+
+		createFelixConfig("default", 0, "")
+		_, err = debugAddress(context.Background(), c, "node1")
+		Expect(err).To(HaveOccurred())
+	})
+
+	It("should read the debug port from the default FelixConfiguration", func() {
+		createFelixConfig("default", 6061, "0.0.0.0")
+		Expect(debugAddress(context.Background(), c, "node1")).To(Equal("localhost:6061"))
+	})
+
+	It("should prefer the node's FelixConfiguration to the default", func() {
+		createFelixConfig("default", 6061, "")
+		createFelixConfig("node.node1", 6062, "10.0.0.1")
+		Expect(debugAddress(context.Background(), c, "node1")).To(Equal("10.0.0.1:6062"))
+		Expect(debugAddress(context.Background(), c, "node2")).To(Equal("localhost:6061"))
+	})
+
+	It("should prefer the environment to the FelixConfiguration", func() {
+		createFelixConfig("default", 6061, "10.0.0.1")
+		os.Setenv("FELIX_DEBUGPORT", "6063")
+		Expect(debugAddress(context.Background(), c, "node1")).To(Equal("10.0.0.1:6063"))
+
+		os.Setenv("FELIX_DEBUGPORT", "port")
+		_, err := debugAddress(context.Background(), c, "node1")
+		Expect(err).To(HaveOccurred())
+	})
+})
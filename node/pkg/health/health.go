@@ -20,6 +20,7 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -35,6 +36,18 @@ var (
 	felixLivenessEp  string
 )
 
+// NodeIPRedetectionStatusFile holds the error from the last attempt by the calico/node address
+// monitor to re-detect the node's IP addresses.  It only exists while re-detection is failing,
+// in which case the node keeps its previous, possibly stale, addresses.
+var NodeIPRedetectionStatusFile = "/var/run/calico/node-ip-redetection-failed"
+
+// BIRDConfigDir is the directory holding the BIRD configuration rendered by confd.
+var BIRDConfigDir = "/etc/calico/confd/config"
+
+// skippedBGPPeerPrefix starts the comment that the BIRD templates render for each BGPPeer
+// they skip, because BIRD does not support listen range or interface peers.
+const skippedBGPPeerPrefix = "# Skipped BGPPeer "
+
 func init() {
 	felixPort := os.Getenv("FELIX_HEALTHPORT")
 	if felixPort == "" {
@@ -122,6 +135,15 @@ func Run(bird, bird6, felixReady, felixLive, birdLive, bird6Live bool, threshold
 		})
 	}
 
+	if readinessChecks {
+		g.Go(func() error {
+			if err := checkNodeIPRedetection(); err != nil {
+				return fmt.Errorf("calico/node is not ready: %+v", err)
+			}
+			return nil
+		})
+	}
+
 	if bird {
 		g.Go(func() error {
 			if err := checkBIRDReady("4", thresholdTime); err != nil {
@@ -170,9 +192,35 @@ func checkService(serviceName string) error {
 	return nil
 }
 
+// SetNodeIPRedetectionError records the result of re-detecting the node's IP addresses, so that
+// the readiness checks report a failure until re-detection succeeds.
+func SetNodeIPRedetectionError(redetectErr error) {
+	if redetectErr == nil {
+		if err := os.Remove(NodeIPRedetectionStatusFile); err != nil && !os.IsNotExist(err) {
+			log.WithError(err).Warn("Failed to remove node IP re-detection status file")
+		}
+		return
+	}
+	if err := os.WriteFile(NodeIPRedetectionStatusFile, []byte(redetectErr.Error()), 0644); err != nil {
+		log.WithError(err).Warn("Failed to write node IP re-detection status file")
+	}
+}
+
+// checkNodeIPRedetection returns an error if the last attempt to re-detect the node's IP
+// addresses failed.
+func checkNodeIPRedetection() error {
+	msg, err := os.ReadFile(NodeIPRedetectionStatusFile)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("Failed to read node IP re-detection status: %v", err)
+	}
+	return fmt.Errorf("node IP address re-detection failed, using previous addresses: %s", msg)
+}
+
 // checkBIRDReady checks if BIRD is ready by connecting to the BIRD
 // socket to gather all BGP peer connection status, and overall graceful
-// restart status.
+// restart status.  BIRD is not ready if its configuration skips any BGPPeers.
 func checkBIRDReady(ipv string, thresholdTime time.Duration) error {
 	// Stat nodename file to get the modified time of the file.
 	nodenameFileStat, err := os.Stat("/var/lib/calico/nodename")
@@ -180,6 +228,15 @@ func checkBIRDReady(ipv string, thresholdTime time.Duration) error {
 		return fmt.Errorf("Failed to stat() nodename file: %v", err)
 	}
 
+	// Check for BGPPeers that could not be configured in BIRD.
+	skipped, err := skippedBGPPeers(ipv)
+	if err != nil {
+		return err
+	} else if len(skipped) > 0 {
+		return fmt.Errorf("BGPPeer(s) %s not supported by BIRD: listen range and interface peers need the FRR BGP daemon",
+			strings.Join(skipped, ","))
+	}
+
 	// Check for unestablished peers
 	peers, err := bird.GetPeers(ipv)
 	log.Debugf("peers: %v", peers)
@@ -228,6 +285,31 @@ func checkBIRDReady(ipv string, thresholdTime time.Duration) error {
 	return nil
 }
 
+// skippedBGPPeers returns the names of the BGPPeers that the rendered BIRD configuration for
+// the given IP version skips.
+func skippedBGPPeers(ipv string) ([]string, error) {
+	name := "bird.cfg"
+	if ipv == "6" {
+		name = "bird6.cfg"
+	}
+	data, err := os.ReadFile(filepath.Join(BIRDConfigDir, name))
+	if os.IsNotExist(err) {
+		// confd has not rendered the configuration yet.
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("Failed to read BIRD configuration: %v", err)
+	}
+
+	var skipped []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if peer, ok := strings.CutPrefix(line, skippedBGPPeerPrefix); ok {
+			peer, _, _ = strings.Cut(peer, ":")
+			skipped = append(skipped, peer)
+		}
+	}
+	return skipped, nil
+}
+
 // checkFelixHealth checks if felix is ready or live by making an http request to
 // Felix's readiness or liveness endpoint.
 func checkFelixHealth(ctx context.Context, endpoint, probeType string) error {
@@ -0,0 +1,63 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package health
+
+import (
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Skipped BGPPeers", func() {
+	var oldConfigDir string
+
+	BeforeEach(func() {
+		oldConfigDir = BIRDConfigDir
+		var err error
+		BIRDConfigDir, err = os.MkdirTemp("", "bird-config")
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		Expect(os.RemoveAll(BIRDConfigDir)).To(Succeed())
+		BIRDConfigDir = oldConfigDir
+	})
+
+	It("should report no skipped BGPPeers before the configuration is rendered", func() {
+		Expect(skippedBGPPeers("4")).To(BeEmpty())
+	})
+
+	It("should report the BGPPeers skipped by the configuration for each IP version", func() {
+		Expect(os.WriteFile(filepath.Join(BIRDConfigDir, "bird.cfg"), []byte(
+			"# ------------- Listen range peers -------------\n"+
+				"# Skipped BGPPeer leaf-range: listen range 10.193.0.0/24\n"), 0644)).To(Succeed())
+		Expect(os.WriteFile(filepath.Join(BIRDConfigDir, "bird6.cfg"), []byte(
+			"# Skipped BGPPeer leaf-range-v6: listen range fd5f:1802::/64\n"+
+				"protocol bgp Global_2001__102 from bgp_template {\n"+
+				"}\n"+
+				"# Skipped BGPPeer unnumbered: interface eth1\n"), 0644)).To(Succeed())
+
+		Expect(skippedBGPPeers("4")).To(Equal([]string{"leaf-range"}))
+		Expect(skippedBGPPeers("6")).To(Equal([]string{"leaf-range-v6", "unnumbered"}))
+	})
+
+	It("should report no skipped BGPPeers when every BGPPeer is configured", func() {
+		Expect(os.WriteFile(filepath.Join(BIRDConfigDir, "bird.cfg"), []byte(
+			"protocol bgp Global_10_192_0_3 from bgp_template {\n}\n"), 0644)).To(Succeed())
+		Expect(skippedBGPPeers("4")).To(BeEmpty())
+	})
+})
@@ -1,4 +1,4 @@
-// Copyright (c) 2021 Tigera, Inc. All rights reserved.
+// Copyright (c) 2021-2024 Tigera, Inc. All rights reserved.
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -32,13 +32,95 @@ const (
 	AUTODETECTION_METHOD_INTERFACE      = "interface="
 	AUTODETECTION_METHOD_SKIP_INTERFACE = "skip-interface="
 	AUTODETECTION_METHOD_CIDR           = "cidr="
+	AUTODETECTION_METHOD_ROUTE_TO       = "route-to="
 	K8S_INTERNAL_IP                     = "kubernetes-internal-ip"
+	K8S_EXTERNAL_IP                     = "kubernetes-external-ip"
+
+	// AUTODETECTION_METHOD_SEPARATOR separates the methods in a chain of
+	// autodetection methods.
+	AUTODETECTION_METHOD_SEPARATOR = ";"
 )
 
-// autoDetectCIDR auto-detects the IP and Network using the requested
-// detection method.
+// autodetectionMethodPrefixes are the prefixes of the autodetection methods
+// that take a value, plus the kubernetes methods which don't.
+var autodetectionMethodPrefixes = []string{
+	AUTODETECTION_METHOD_CAN_REACH,
+	AUTODETECTION_METHOD_INTERFACE,
+	AUTODETECTION_METHOD_SKIP_INTERFACE,
+	AUTODETECTION_METHOD_CIDR,
+	AUTODETECTION_METHOD_ROUTE_TO,
+	K8S_INTERNAL_IP,
+	K8S_EXTERNAL_IP,
+}
+
+// AutoDetectCIDR auto-detects the IP and Network using the requested
+// detection method.  The method may be a chain of methods separated by ";"
+// (e.g. "cidr=10.0.0.0/8;interface=bond0;kubernetes-internal-ip"), in which
+// case each method is tried in order until one of them detects an address.
 func AutoDetectCIDR(method string, version int, k8sNode *v1.Node, getInterfaces func([]string, []string, int) ([]Interface, error)) *cnet.IPNet {
-	if method == "" || method == AUTODETECTION_METHOD_FIRST {
+	methods := SplitMethods(method)
+	if err := ValidateMethods(method); err != nil {
+		// The autodetection method is not recognised and is required.  Exit.
+		log.Error(err)
+		utils.Terminate()
+		return nil
+	}
+
+	for i, m := range methods {
+		if cidr := autoDetectCIDRUsingMethod(m, version, k8sNode, getInterfaces); cidr != nil {
+			return cidr
+		}
+		if i < len(methods)-1 {
+			log.Infof("IPv%d autodetection method %q did not detect an address, trying %q", version, m, methods[i+1])
+		}
+	}
+	return nil
+}
+
+// SplitMethods splits a chain of autodetection methods into its individual
+// methods, ignoring empty entries.  An empty chain means first-found.
+func SplitMethods(method string) []string {
+	var methods []string
+	for _, m := range strings.Split(method, AUTODETECTION_METHOD_SEPARATOR) {
+		if m = strings.TrimSpace(m); m != "" {
+			methods = append(methods, m)
+		}
+	}
+	if len(methods) == 0 {
+		methods = []string{AUTODETECTION_METHOD_FIRST}
+	}
+	return methods
+}
+
+// ValidateMethods returns an error if any of the methods in the given chain of
+// autodetection methods is not recognised.
+func ValidateMethods(method string) error {
+	for _, m := range SplitMethods(method) {
+		if !isValidMethod(m) {
+			return fmt.Errorf("Invalid IP autodetection method: %s", m)
+		}
+	}
+	return nil
+}
+
+// isValidMethod returns true if the given (single) autodetection method is
+// recognised.
+func isValidMethod(method string) bool {
+	if method == AUTODETECTION_METHOD_FIRST {
+		return true
+	}
+	for _, prefix := range autodetectionMethodPrefixes {
+		if strings.HasPrefix(method, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// autoDetectCIDRUsingMethod auto-detects the IP and Network using a single
+// detection method.
+func autoDetectCIDRUsingMethod(method string, version int, k8sNode *v1.Node, getInterfaces func([]string, []string, int) ([]Interface, error)) *cnet.IPNet {
+	if method == AUTODETECTION_METHOD_FIRST {
 		// Autodetect the IP by enumerating all interfaces (excluding
 		// known internal interfaces).
 		return autoDetectCIDRFirstFound(version)
@@ -74,18 +156,32 @@ func AutoDetectCIDR(method string, version int, k8sNode *v1.Node, getInterfaces
 		// Regexes are passed in as a string separated by ","
 		ifRegexes := regexp.MustCompile(`\s*,\s*`).Split(ifStr, -1)
 		return autoDetectCIDRBySkipInterface(ifRegexes, version)
+	} else if strings.HasPrefix(method, AUTODETECTION_METHOD_ROUTE_TO) {
+		// Autodetect the IP used by the host's routes to the supplied prefix.
+		prefixStr := strings.TrimPrefix(method, AUTODETECTION_METHOD_ROUTE_TO)
+		_, prefix, err := cnet.ParseCIDR(prefixStr)
+		if err != nil {
+			log.Errorf("Invalid prefix %q for IP autodetection method: %s", prefixStr, method)
+			return nil
+		}
+		return autoDetectCIDRByRoute(*prefix, version)
 	} else if strings.HasPrefix(method, K8S_INTERNAL_IP) {
 		// K8s InternalIP configured for node is used
 		if k8sNode == nil {
 			log.Error("Cannot use method 'kubernetes-internal-ip' when not running on a Kubernetes cluster")
 			return nil
 		}
-		return autoDetectUsingK8sInternalIP(version, k8sNode, getInterfaces)
+		return autoDetectUsingK8sNodeAddress(v1.NodeInternalIP, version, k8sNode, getInterfaces)
+	} else if strings.HasPrefix(method, K8S_EXTERNAL_IP) {
+		// K8s ExternalIP configured for node is used
+		if k8sNode == nil {
+			log.Error("Cannot use method 'kubernetes-external-ip' when not running on a Kubernetes cluster")
+			return nil
+		}
+		return autoDetectUsingK8sNodeAddress(v1.NodeExternalIP, version, k8sNode, getInterfaces)
 	}
 
-	// The autodetection method is not recognised and is required.  Exit.
 	log.Errorf("Invalid IP autodetection method: %s", method)
-	utils.Terminate()
 	return nil
 }
 
@@ -145,6 +241,24 @@ func autoDetectCIDRByReach(dest string, version int) *cnet.IPNet {
 	}
 }
 
+// autoDetectCIDRByRoute auto-detects the IP and Network that the host's routing
+// tables use to reach the supplied prefix.  Only the route to the first address
+// of the prefix is used, so a prefix that is split across several routes is
+// detected using the route that covers its first address.
+func autoDetectCIDRByRoute(prefix cnet.IPNet, version int) *cnet.IPNet {
+	if prefix.Version() != version {
+		log.Warnf("Unable to auto-detect an IPv%d address using a route to IPv%d prefix %s", version, prefix.Version(), prefix.String())
+		return nil
+	}
+	if cidr, err := ReachDestination(prefix.IP.String(), version); err != nil {
+		log.Warnf("Unable to auto-detect IPv%d address using route to %s: %s", version, prefix.String(), err)
+		return nil
+	} else {
+		log.Infof("Using autodetected IPv%d address %s, detected by route to %s", version, cidr.String(), prefix.String())
+		return cidr
+	}
+}
+
 // autoDetectCIDRBySkipInterface auto-detects the first valid Network on the interfaces
 // matching the supplied interface regexes.
 func autoDetectCIDRBySkipInterface(ifaceRegexes []string, version int) *cnet.IPNet {
@@ -162,14 +276,15 @@ func autoDetectCIDRBySkipInterface(ifaceRegexes []string, version int) *cnet.IPN
 	return cidr
 }
 
-// autoDetectUsingK8sInternalIP reads K8s Node InternalIP.
-func autoDetectUsingK8sInternalIP(version int, k8sNode *v1.Node, getInterfaces func([]string, []string, int) ([]Interface, error)) *cnet.IPNet {
+// autoDetectUsingK8sNodeAddress reads the K8s Node address of the given type
+// (InternalIP or ExternalIP).
+func autoDetectUsingK8sNodeAddress(addrType v1.NodeAddressType, version int, k8sNode *v1.Node, getInterfaces func([]string, []string, int) ([]Interface, error)) *cnet.IPNet {
 	var address string
 	var err error
 
 	nodeAddresses := k8sNode.Status.Addresses
 	for _, addr := range nodeAddresses {
-		if addr.Type == v1.NodeInternalIP {
+		if addr.Type == addrType {
 			if (version == 4 && utils.IsIPv4String(addr.Address)) || (version == 6 && utils.IsIPv6String(addr.Address)) {
 				address, err = GetLocalCIDR(addr.Address, version, getInterfaces)
 				if err != nil {
@@ -180,13 +295,18 @@ func autoDetectUsingK8sInternalIP(version int, k8sNode *v1.Node, getInterfaces f
 		}
 	}
 
+	if address == "" {
+		log.Warnf("Kubernetes node has no IPv%d %s address", version, addrType)
+		return nil
+	}
+
 	ip, ipNet, err := cnet.ParseCIDR(address)
 	if err != nil {
 		log.Errorf("Unable to parse CIDR %v : %v", address, err)
 		return nil
 	}
 	// ParseCIDR masks off the IP addr of the IPNet it returns eg. ParseCIDR("192.168.1.2/24" will return
-	//"192.168.1.2, 192.168.1.0/24". Callers of this function (autoDetectUsingK8sInternalIP) expect the full IP address
+	//"192.168.1.2, 192.168.1.0/24". Callers of this function (autoDetectUsingK8sNodeAddress) expect the full IP address
 	// to be preserved in the CIDR ie. we should return 192.168.1.2/24
 	ipNet.IP = ip.IP
 	return ipNet
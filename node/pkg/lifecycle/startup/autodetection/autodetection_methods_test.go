@@ -0,0 +1,119 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package autodetection_test
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+	v1 "k8s.io/api/core/v1"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/net"
+	"github.com/projectcalico/calico/node/pkg/lifecycle/startup/autodetection"
+	"github.com/projectcalico/calico/node/pkg/lifecycle/utils"
+)
+
+var _ = DescribeTable("Splitting autodetection methods",
+	func(method string, expected []string) {
+		Expect(autodetection.SplitMethods(method)).To(Equal(expected))
+	},
+	Entry("empty", "", []string{"first-found"}),
+	Entry("single method", "interface=eth0", []string{"interface=eth0"}),
+	Entry("chain", "cidr=10.0.0.0/8;interface=bond0;kubernetes-internal-ip",
+		[]string{"cidr=10.0.0.0/8", "interface=bond0", "kubernetes-internal-ip"}),
+	Entry("chain with spaces and empty entries", " cidr=10.0.0.0/8 ;; interface=eth.*,en.* ;",
+		[]string{"cidr=10.0.0.0/8", "interface=eth.*,en.*"}),
+)
+
+var _ = DescribeTable("Validating autodetection methods",
+	func(method string, valid bool) {
+		if valid {
+			Expect(autodetection.ValidateMethods(method)).To(Succeed())
+		} else {
+			Expect(autodetection.ValidateMethods(method)).NotTo(Succeed())
+		}
+	},
+	Entry("empty", "", true),
+	Entry("chain", "cidr=10.0.0.0/8;route-to=10.0.0.0/8;kubernetes-external-ip", true),
+	Entry("unknown method", "no-such-method", false),
+	Entry("misspelt method in a chain", "cidr=10.0.0.0/8;kubernetes-internl-ip", false),
+)
+
+var _ = Describe("Chained autodetection methods", func() {
+	mockGetInterfaces := func([]string, []string, int) ([]autodetection.Interface, error) {
+		return []autodetection.Interface{
+			{Name: "eth0", Cidrs: []net.IPNet{net.MustParseCIDR("192.168.1.10/24")}},
+			{Name: "eth1", Cidrs: []net.IPNet{net.MustParseCIDR("203.0.113.10/24")}},
+		}, nil
+	}
+
+	k8sNode := &v1.Node{
+		Status: v1.NodeStatus{
+			Addresses: []v1.NodeAddress{
+				{Type: v1.NodeInternalIP, Address: "192.168.1.10"},
+				{Type: v1.NodeExternalIP, Address: "203.0.113.10"},
+			},
+		},
+	}
+
+	It("should use the kubernetes node's external IP", func() {
+		cidr := autodetection.AutoDetectCIDR("kubernetes-external-ip", 4, k8sNode, mockGetInterfaces)
+		Expect(cidr).NotTo(BeNil())
+		Expect(cidr.String()).To(Equal("203.0.113.10/24"))
+	})
+
+	It("should use the first method that detects an address", func() {
+		cidr := autodetection.AutoDetectCIDR("kubernetes-internal-ip;kubernetes-external-ip", 4, k8sNode, mockGetInterfaces)
+		Expect(cidr).NotTo(BeNil())
+		Expect(cidr.String()).To(Equal("192.168.1.10/24"))
+	})
+
+	It("should fall back to later methods", func() {
+		internalOnly := &v1.Node{
+			Status: v1.NodeStatus{
+				Addresses: []v1.NodeAddress{{Type: v1.NodeInternalIP, Address: "192.168.1.10"}},
+			},
+		}
+		cidr := autodetection.AutoDetectCIDR(
+			"cidr=198.51.100.0/24;route-to=fd00::/8;kubernetes-external-ip;kubernetes-internal-ip",
+			4, internalOnly, mockGetInterfaces,
+		)
+		Expect(cidr).NotTo(BeNil())
+		Expect(cidr.String()).To(Equal("192.168.1.10/24"))
+	})
+
+	It("should return nil if no method detects an address", func() {
+		Expect(autodetection.AutoDetectCIDR("kubernetes-external-ip;kubernetes-internal-ip", 6, k8sNode, mockGetInterfaces)).To(BeNil())
+	})
+
+	It("should detect the address used by the route to a prefix", func() {
+		iface, addr, err := autodetection.FilteredEnumeration(nil, autodetection.DEFAULT_INTERFACES_TO_EXCLUDE, nil, 4)
+		if err != nil {
+			Skip("No IPv4 interfaces to route to")
+		}
+		cidr := autodetection.AutoDetectCIDR("route-to="+addr.Network().String(), 4, nil, nil)
+		Expect(cidr).NotTo(BeNil(), "no address detected for route to interface %s", iface.Name)
+		Expect(addr.Network().Contains(cidr.IP)).To(BeTrue())
+	})
+
+	It("should terminate if any method in the chain is invalid", func() {
+		exitCode := 0
+		oldExit := utils.GetExitFunction()
+		utils.SetExitFunction(func(ec int) { exitCode = ec })
+		defer utils.SetExitFunction(oldExit)
+
+		Expect(autodetection.AutoDetectCIDR("kubernetes-internal-ip;not-a-method", 4, k8sNode, mockGetInterfaces)).To(BeNil())
+		Expect(exitCode).To(Equal(1))
+	})
+})
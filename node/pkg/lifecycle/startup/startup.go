@@ -21,6 +21,7 @@ import (
 	"os"
 	"reflect"
 	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -43,8 +44,10 @@ import (
 	"github.com/projectcalico/calico/libcalico-go/lib/selector"
 	"github.com/projectcalico/calico/libcalico-go/lib/upgrade/migrator"
 	"github.com/projectcalico/calico/libcalico-go/lib/upgrade/migrator/clients"
+	validatorv3 "github.com/projectcalico/calico/libcalico-go/lib/validator/v3"
 	"github.com/projectcalico/calico/libcalico-go/lib/winutils"
 	"github.com/projectcalico/calico/node/pkg/calicoclient"
+	"github.com/projectcalico/calico/node/pkg/health"
 	"github.com/projectcalico/calico/node/pkg/lifecycle/startup/autodetection"
 	"github.com/projectcalico/calico/node/pkg/lifecycle/startup/autodetection/ipv4"
 	"github.com/projectcalico/calico/node/pkg/lifecycle/utils"
@@ -59,6 +62,15 @@ const (
 
 	DEFAULT_MONITOR_IP_POLL_INTERVAL = 60 * time.Second
 
+	// addressUpdateSettleTime is how long the address monitor waits after a host address
+	// change before re-running autodetection.
+	addressUpdateSettleTime = 2 * time.Second
+
+	// Annotations on the Kubernetes node that override the IP autodetection
+	// methods configured in the environment, for that node only.
+	ipv4AutodetectionMethodAnnotation = "projectcalico.org/IPv4AutodetectionMethod"
+	ipv6AutodetectionMethodAnnotation = "projectcalico.org/IPv6AutodetectionMethod"
+
 	// KubeadmConfigConfigMap is defined in k8s.io/kubernetes, which we can't import due to versioning issues.
 	KubeadmConfigConfigMap = "kubeadm-config"
 	// Rancher clusters store their state in this config map in the kube-system namespace.
@@ -186,6 +198,8 @@ func Run() {
 	needsNodeUpdate = configureASNumber(node) || needsNodeUpdate
 	// Populate a reference to the node based on orchestrator node identifiers.
 	needsNodeUpdate = configureNodeRef(node) || needsNodeUpdate
+	// Record the host interfaces, used to generate per-interface host endpoints.
+	needsNodeUpdate = configureNodeInterfaces(node, getSystemInterfaces) || needsNodeUpdate
 	if needsNodeUpdate {
 		// Apply the updated node resource.
 		if _, err := CreateOrUpdate(ctx, cli, node); err != nil {
@@ -260,9 +274,7 @@ func configureAndCheckIPAddressSubnets(ctx context.Context, cli client.Interface
 		return false
 	}
 	// Configure and verify the node IP addresses and subnets.
-	checkConflicts, err := configureIPsAndSubnets(node, k8sNode, func(incl []string, excl []string, version int) ([]autodetection.Interface, error) {
-		return autodetection.GetInterfaces(net.Interfaces, incl, excl, version)
-	})
+	checkConflicts, err := configureIPsAndSubnets(node, k8sNode, getSystemInterfaces)
 	if err != nil {
 		// If this is auto-detection error, do a cleanup before returning
 		clearv4 := os.Getenv("IP") == "autodetect"
@@ -310,6 +322,71 @@ func configureAndCheckIPAddressSubnets(ctx context.Context, cli client.Interface
 	return checkConflicts
 }
 
+// recheckIPAddressSubnets re-runs IP address autodetection for a node that has already been
+// started.  Unlike configureAndCheckIPAddressSubnets it never clears the node's addresses or
+// terminates: if the newly detected addresses can't be used then the node keeps its previous
+// addresses, so that BGP sessions and tunnel routes stay up until a usable address is found,
+// and the error is returned.
+func recheckIPAddressSubnets(
+	ctx context.Context,
+	cli client.Interface,
+	node *libapi.Node,
+	k8sNode *v1.Node,
+	getInterfaces func([]string, []string, int) ([]autodetection.Interface, error),
+) (bool, error) {
+	if os.Getenv("CALICO_NETWORKING_BACKEND") == "none" {
+		return false, nil
+	}
+
+	var oldBGP *libapi.NodeBGPSpec
+	if node.Spec.BGP != nil {
+		oldBGP = node.Spec.BGP.DeepCopy()
+	}
+
+	changed, err := configureIPsAndSubnets(node, k8sNode, getInterfaces)
+	if err != nil {
+		node.Spec.BGP = oldBGP
+		return false, fmt.Errorf("failed to re-detect node IP addresses: %w", err)
+	}
+	if !changed {
+		return false, nil
+	}
+
+	if os.Getenv("DISABLE_NODE_IP_CHECK") != "true" {
+		if _, _, err := checkConflictingNodes(ctx, cli, node); err != nil {
+			node.Spec.BGP = oldBGP
+			return false, fmt.Errorf("unable to use re-detected node IP addresses: %w", err)
+		}
+	}
+
+	log.WithFields(log.Fields{
+		"ipv4": node.Spec.BGP.IPv4Address,
+		"ipv6": node.Spec.BGP.IPv6Address,
+	}).Info("Node IP addresses changed")
+	return true, nil
+}
+
+// waitForNextCheck blocks until the poll interval expires or, if addrUpdateC is non-nil, until
+// the host's addresses change.  Address changes often arrive in bursts (for example, a DHCP
+// renewal removes the old address and then adds the new one), so after the first change we
+// wait for the addresses to settle before returning.
+func waitForNextCheck(pollInterval, settleTime time.Duration, addrUpdateC <-chan struct{}) {
+	timer := time.NewTimer(pollInterval)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		log.Debugf("Checking node IP address every %v", pollInterval)
+	case <-addrUpdateC:
+		log.Debug("Host addresses changed, checking node IP address")
+		time.Sleep(settleTime)
+		select {
+		case <-addrUpdateC:
+		default:
+		}
+	}
+}
+
 func MonitorIPAddressSubnets() {
 	ctx := context.Background()
 	_, cli := calicoclient.CreateClient()
@@ -338,23 +415,33 @@ func MonitorIPAddressSubnets() {
 		}
 	}
 
+	// As well as polling, re-check as soon as the host's addresses change so that the node's
+	// addresses (and hence BGP peerings and tunnel routes) follow a DHCP change promptly.
+	addrUpdateC := subscribeAddressUpdates(ctx)
+
 	for {
-		<-time.After(pollInterval)
-		log.Debugf("Checking node IP address every %v", pollInterval)
+		waitForNextCheck(pollInterval, addressUpdateSettleTime, addrUpdateC)
 
 		// Every polling interval, try to get the k8s Node and use the latest K8s node IP to configure.
 		if clientset != nil {
 			k8sNode, err = clientset.CoreV1().Nodes().Get(ctx, k8sNodeName, metav1.GetOptions{})
 			if err != nil {
-				log.WithError(err).Error("Failed to read Node from datastore")
-				return
+				log.WithError(err).Error("Failed to read Node from datastore, will retry")
+				continue
 			}
 		}
 
 		// Every polling interval, try to get new node configuration.
 		node = getNode(ctx, cli, nodeName)
 
-		updated := configureAndCheckIPAddressSubnets(ctx, cli, node, k8sNode)
+		// If re-detection fails then the node keeps its previous addresses; record the error so
+		// that calico/node reports not ready until re-detection succeeds.
+		updated, err := recheckIPAddressSubnets(ctx, cli, node, k8sNode, getSystemInterfaces)
+		if err != nil {
+			log.WithError(err).Error("Keeping existing node IP addresses")
+		}
+		health.SetNodeIPRedetectionError(err)
+		updated = configureNodeInterfaces(node, getSystemInterfaces) || updated
 		if updated {
 			// Apply the updated node resource.
 			// we try updating the resource up to 3 times, in case of transient issues.
@@ -370,6 +457,57 @@ func MonitorIPAddressSubnets() {
 	}
 }
 
+// getSystemInterfaces returns the host's interfaces, filtered by the given regexes.
+func getSystemInterfaces(incl []string, excl []string, version int) ([]autodetection.Interface, error) {
+	return autodetection.GetInterfaces(net.Interfaces, incl, excl, version)
+}
+
+// configureNodeInterfaces updates the node's list of host interfaces and their addresses.
+// Interfaces created by Calico or other CNI plugins and link-local addresses are ignored.
+// Returns true if the node object needs to be updated.
+func configureNodeInterfaces(node *libapi.Node, getInterfaces func([]string, []string, int) ([]autodetection.Interface, error)) bool {
+	addrsByName := map[string][]string{}
+	for _, version := range []int{4, 6} {
+		ifaces, err := getInterfaces(nil, autodetection.DEFAULT_INTERFACES_TO_EXCLUDE, version)
+		if err != nil {
+			log.WithError(err).Warnf("Failed to list IPv%d host interfaces, leaving node interfaces unchanged", version)
+			return false
+		}
+		for _, iface := range ifaces {
+			if _, ok := addrsByName[iface.Name]; !ok {
+				addrsByName[iface.Name] = []string{}
+			}
+			for _, cidr := range iface.Cidrs {
+				if cidr.IP.IsLinkLocalUnicast() {
+					continue
+				}
+				addrsByName[iface.Name] = append(addrsByName[iface.Name], cidr.IP.String())
+			}
+		}
+	}
+
+	var ifaces []libapi.NodeInterface
+	for name, addrs := range addrsByName {
+		iface := libapi.NodeInterface{Name: name}
+		if len(addrs) > 0 {
+			iface.Addresses = addrs
+		}
+		if err := validatorv3.Validate(iface); err != nil {
+			log.WithError(err).WithField("interface", name).Debug("Skipping interface that cannot be represented on the node")
+			continue
+		}
+		ifaces = append(ifaces, iface)
+	}
+	sort.Slice(ifaces, func(i, j int) bool { return ifaces[i].Name < ifaces[j].Name })
+
+	if reflect.DeepEqual(ifaces, node.Spec.Interfaces) {
+		return false
+	}
+	log.WithField("interfaces", ifaces).Info("Node interfaces changed")
+	node.Spec.Interfaces = ifaces
+	return true
+}
+
 // configureNodeRef will attempt to discover the cluster type it is running on, check to ensure we
 // have not already set it on this Node, and set it if need be.
 // Returns true if the node object needs to updated.
@@ -507,7 +645,7 @@ func configureIPsAndSubnets(node *libapi.Node, k8sNode *v1.Node, getInterfaces f
 	// value and possibly fix up missing subnet configuration.
 	ipv4Env := os.Getenv("IP")
 	if ipv4Env == "autodetect" || (ipv4Env == "" && node.Spec.BGP.IPv4Address == "") {
-		adm := os.Getenv("IP_AUTODETECTION_METHOD")
+		adm := getAutodetectionMethod("IP_AUTODETECTION_METHOD", ipv4AutodetectionMethodAnnotation, k8sNode)
 		cidr := autodetection.AutoDetectCIDR(adm, 4, k8sNode, getInterfaces)
 		if cidr != nil {
 			// We autodetected an IPv4 address so update the value in the node.
@@ -540,7 +678,7 @@ func configureIPsAndSubnets(node *libapi.Node, k8sNode *v1.Node, getInterfaces f
 
 	ipv6Env := os.Getenv("IP6")
 	if ipv6Env == "autodetect" {
-		adm := os.Getenv("IP6_AUTODETECTION_METHOD")
+		adm := getAutodetectionMethod("IP6_AUTODETECTION_METHOD", ipv6AutodetectionMethodAnnotation, k8sNode)
 		cidr := autodetection.AutoDetectCIDR(adm, 6, k8sNode, getInterfaces)
 		if cidr != nil {
 			// We autodetected an IPv6 address so update the value in the node.
@@ -579,6 +717,25 @@ func configureIPsAndSubnets(node *libapi.Node, k8sNode *v1.Node, getInterfaces f
 	return false, nil
 }
 
+// getAutodetectionMethod returns the IP autodetection method to use for this node.
+// A method set by annotation on the Kubernetes node takes precedence over the
+// method configured in the environment, unless it is not a valid method, in which
+// case the method from the environment is used.
+func getAutodetectionMethod(envName, annotation string, k8sNode *v1.Node) string {
+	if k8sNode != nil {
+		if method, ok := k8sNode.Annotations[annotation]; ok && strings.TrimSpace(method) != "" {
+			logCxt := log.WithFields(log.Fields{"annotation": annotation, "method": method})
+			if err := autodetection.ValidateMethods(method); err != nil {
+				logCxt.WithError(err).Errorf("Ignoring invalid IP autodetection method from Kubernetes node annotation, using %s", envName)
+				return os.Getenv(envName)
+			}
+			logCxt.Debug("Using IP autodetection method from Kubernetes node annotation")
+			return method
+		}
+	}
+	return os.Getenv(envName)
+}
+
 // fetchAndValidateIPAndNetwork fetches and validates the IP configuration from
 // either the environment variables or from the values already configured in the
 // node.
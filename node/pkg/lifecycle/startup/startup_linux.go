@@ -1,4 +1,4 @@
-// Copyright (c) 2018-2021 Tigera, Inc. All rights reserved.
+// Copyright (c) 2018-2024 Tigera, Inc. All rights reserved.
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -20,6 +20,7 @@ import (
 	"strings"
 
 	log "github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
 
 	client "github.com/projectcalico/calico/libcalico-go/lib/clientv3"
 	"github.com/projectcalico/calico/node/pkg/lifecycle/utils"
@@ -95,3 +96,39 @@ func ipv6Supported() bool {
 func ensureNetworkForOS(ctx context.Context, client client.Interface, nodeName string) error {
 	return nil
 }
+
+// subscribeAddressUpdates returns a channel that is signalled whenever a (non link-local)
+// address is added to or removed from one of the host's interfaces.  It returns nil if the
+// host's addresses can't be monitored, in which case the caller relies on polling.
+func subscribeAddressUpdates(ctx context.Context) <-chan struct{} {
+	updates := make(chan netlink.AddrUpdate)
+	err := netlink.AddrSubscribeWithOptions(updates, ctx.Done(), netlink.AddrSubscribeOptions{
+		ErrorCallback: func(err error) {
+			log.WithError(err).Warn("Error monitoring host address updates")
+		},
+	})
+	if err != nil {
+		log.WithError(err).Warn("Unable to monitor host address updates, falling back to polling")
+		return nil
+	}
+
+	addrUpdateC := make(chan struct{}, 1)
+	go func() {
+		for u := range updates {
+			if u.LinkAddress.IP.IsLinkLocalUnicast() {
+				continue
+			}
+			log.WithFields(log.Fields{
+				"address":   u.LinkAddress.String(),
+				"linkIndex": u.LinkIndex,
+				"added":     u.NewAddr,
+			}).Debug("Host address update")
+			select {
+			case addrUpdateC <- struct{}{}:
+			default:
+			}
+		}
+		log.Warn("Host address update monitoring stopped, falling back to polling")
+	}()
+	return addrUpdateC
+}
@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"path/filepath"
 	"strings"
 	"sync"
 	"time"
@@ -40,6 +41,7 @@ import (
 	"github.com/projectcalico/calico/libcalico-go/lib/net"
 	"github.com/projectcalico/calico/libcalico-go/lib/options"
 	"github.com/projectcalico/calico/libcalico-go/lib/set"
+	"github.com/projectcalico/calico/node/pkg/health"
 	"github.com/projectcalico/calico/node/pkg/lifecycle/startup/autodetection"
 	"github.com/projectcalico/calico/node/pkg/lifecycle/utils"
 )
@@ -1036,6 +1038,65 @@ var _ = Describe("UT for autodetection method k8s-internal-ip", func() {
 	)
 })
 
+var _ = Describe("UT for IP autodetection method node annotations", func() {
+	mockGetInterface := func([]string, []string, int) ([]autodetection.Interface, error) {
+		return []autodetection.Interface{
+			{Name: "eth1", Cidrs: []net.IPNet{net.MustParseCIDR("192.168.1.10/24"), net.MustParseCIDR("2001:db8::10/64")}},
+		}, nil
+	}
+
+	AfterEach(func() {
+		os.Unsetenv("IP")
+		os.Unsetenv("IP6")
+		os.Unsetenv("IP_AUTODETECTION_METHOD")
+		os.Unsetenv("IP6_AUTODETECTION_METHOD")
+	})
+
+	It("should prefer the method from the node annotation", func() {
+		os.Setenv("IP_AUTODETECTION_METHOD", "interface=eth0")
+		k8sNode := makeK8sNode("192.168.1.10", "2001:db8::10")
+		Expect(getAutodetectionMethod("IP_AUTODETECTION_METHOD", ipv4AutodetectionMethodAnnotation, k8sNode)).To(Equal("interface=eth0"))
+		Expect(getAutodetectionMethod("IP_AUTODETECTION_METHOD", ipv4AutodetectionMethodAnnotation, nil)).To(Equal("interface=eth0"))
+
+		k8sNode.Annotations = map[string]string{
+			ipv4AutodetectionMethodAnnotation: "cidr=10.0.0.0/8;kubernetes-internal-ip",
+			ipv6AutodetectionMethodAnnotation: " ",
+		}
+		Expect(getAutodetectionMethod("IP_AUTODETECTION_METHOD", ipv4AutodetectionMethodAnnotation, k8sNode)).To(Equal("cidr=10.0.0.0/8;kubernetes-internal-ip"))
+		Expect(getAutodetectionMethod("IP6_AUTODETECTION_METHOD", ipv6AutodetectionMethodAnnotation, k8sNode)).To(Equal(""))
+	})
+
+	It("should ignore an invalid method in the node annotation", func() {
+		os.Setenv("IP_AUTODETECTION_METHOD", "interface=eth0")
+		k8sNode := makeK8sNode("192.168.1.10", "2001:db8::10")
+		k8sNode.Annotations = map[string]string{
+			ipv4AutodetectionMethodAnnotation: "cidr=10.0.0.0/8;kubernetes-internl-ip",
+			ipv6AutodetectionMethodAnnotation: "no-such-method",
+		}
+		Expect(getAutodetectionMethod("IP_AUTODETECTION_METHOD", ipv4AutodetectionMethodAnnotation, k8sNode)).To(Equal("interface=eth0"))
+		Expect(getAutodetectionMethod("IP6_AUTODETECTION_METHOD", ipv6AutodetectionMethodAnnotation, k8sNode)).To(Equal(""))
+	})
+
+	It("should autodetect using the annotated methods", func() {
+		os.Setenv("IP", "autodetect")
+		os.Setenv("IP6", "autodetect")
+		os.Setenv("IP_AUTODETECTION_METHOD", "interface=this-does-not-exist")
+		os.Setenv("IP6_AUTODETECTION_METHOD", "interface=this-does-not-exist")
+		k8sNode := makeK8sNode("192.168.1.10", "2001:db8::10")
+		k8sNode.Annotations = map[string]string{
+			ipv4AutodetectionMethodAnnotation: "kubernetes-external-ip;kubernetes-internal-ip",
+			ipv6AutodetectionMethodAnnotation: "kubernetes-internal-ip",
+		}
+
+		node := &libapi.Node{}
+		check, err := configureIPsAndSubnets(node, k8sNode, mockGetInterface)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(check).To(BeTrue())
+		Expect(node.Spec.BGP.IPv4Address).To(Equal("192.168.1.10/24"))
+		Expect(node.Spec.BGP.IPv6Address).To(Equal("2001:db8::10/64"))
+	})
+})
+
 var _ = Describe("UT for CIDR returned by IP address autodetection k8s-internal-ip method", func() {
 	It("Verify that CIDR value returned using autodetection method k8s-internal-ip is not masked", func() {
 		expectedV4Cidr := "192.168.1.10/24"
@@ -1056,6 +1117,44 @@ var _ = Describe("UT for CIDR returned by IP address autodetection k8s-internal-
 	})
 })
 
+var _ = Describe("UT for node interface configuration", func() {
+	mockGetInterface := func(_ []string, _ []string, version int) ([]autodetection.Interface, error) {
+		if version == 4 {
+			return []autodetection.Interface{
+				{Name: "eth0", Cidrs: []net.IPNet{net.MustParseCIDR("192.168.1.10/24")}},
+				{Name: "eth1", Cidrs: []net.IPNet{net.MustParseCIDR("10.0.0.10/16"), net.MustParseCIDR("10.0.0.11/16")}},
+				{Name: "lo", Cidrs: []net.IPNet{net.MustParseCIDR("127.0.0.1/8")}},
+			}, nil
+		}
+		return []autodetection.Interface{
+			{Name: "eth0", Cidrs: []net.IPNet{net.MustParseCIDR("fe80::1/64"), net.MustParseCIDR("2001:db8::10/64")}},
+			{Name: "lo", Cidrs: []net.IPNet{net.MustParseCIDR("::1/128")}},
+		}, nil
+	}
+
+	It("should record the host interfaces and their addresses", func() {
+		node := &libapi.Node{}
+		Expect(configureNodeInterfaces(node, mockGetInterface)).To(BeTrue())
+		Expect(node.Spec.Interfaces).To(Equal([]libapi.NodeInterface{
+			{Name: "eth0", Addresses: []string{"192.168.1.10", "2001:db8::10"}},
+			{Name: "eth1", Addresses: []string{"10.0.0.10", "10.0.0.11"}},
+			{Name: "lo", Addresses: []string{"127.0.0.1", "::1"}},
+		}))
+
+		By("reporting no change when the interfaces are unchanged")
+		Expect(configureNodeInterfaces(node, mockGetInterface)).To(BeFalse())
+	})
+
+	It("should leave the interfaces unchanged if they cannot be listed", func() {
+		node := &libapi.Node{Spec: libapi.NodeSpec{Interfaces: []libapi.NodeInterface{{Name: "eth0"}}}}
+		failGetInterface := func([]string, []string, int) ([]autodetection.Interface, error) {
+			return nil, fmt.Errorf("failed to list interfaces")
+		}
+		Expect(configureNodeInterfaces(node, failGetInterface)).To(BeFalse())
+		Expect(node.Spec.Interfaces).To(Equal([]libapi.NodeInterface{{Name: "eth0"}}))
+	})
+})
+
 var _ = Describe("FV tests against K8s API server.", func() {
 	It("should not throw an error when multiple Nodes configure the same global CRD value.", func() {
 		ctx := context.Background()
@@ -1260,6 +1359,103 @@ var _ = Describe("UTs for monitor-addresses option", func() {
 		os.Setenv("AUTODETECT_POLL_INTERVAL", "30m")
 		Expect(getMonitorPollInterval()).To(Equal(30 * time.Minute))
 	})
+
+	It("should wake early and coalesce host address updates", func() {
+		addrUpdateC := make(chan struct{}, 1)
+		addrUpdateC <- struct{}{}
+		go func() {
+			defer GinkgoRecover()
+			time.Sleep(10 * time.Millisecond)
+			addrUpdateC <- struct{}{}
+		}()
+
+		start := time.Now()
+		waitForNextCheck(time.Minute, 100*time.Millisecond, addrUpdateC)
+		Expect(time.Since(start)).To(BeNumerically("<", 10*time.Second))
+		Consistently(addrUpdateC, "100ms").ShouldNot(Receive())
+	})
+
+	It("should poll if host address updates aren't available", func() {
+		start := time.Now()
+		waitForNextCheck(50*time.Millisecond, time.Minute, nil)
+		Expect(time.Since(start)).To(BeNumerically(">=", 50*time.Millisecond))
+	})
+
+	Describe("re-detecting the node IP addresses", func() {
+		mockGetInterface := func([]string, []string, int) ([]autodetection.Interface, error) {
+			return []autodetection.Interface{
+				{Name: "eth0", Cidrs: []net.IPNet{net.MustParseCIDR("10.0.0.20/24")}},
+			}, nil
+		}
+
+		BeforeEach(func() {
+			os.Setenv("IP", "autodetect")
+			os.Setenv("IP_AUTODETECTION_METHOD", "kubernetes-internal-ip")
+			os.Setenv("DISABLE_NODE_IP_CHECK", "true")
+			os.Setenv("CALICO_NETWORKING_BACKEND", "bird")
+		})
+
+		AfterEach(func() {
+			os.Unsetenv("IP")
+			os.Unsetenv("IP_AUTODETECTION_METHOD")
+			os.Unsetenv("DISABLE_NODE_IP_CHECK")
+			os.Unsetenv("CALICO_NETWORKING_BACKEND")
+		})
+
+		It("should pick up a changed address", func() {
+			node := makeNode("10.0.0.10/24", "")
+			k8sNode := makeK8sNode("10.0.0.20", "")
+			updated, err := recheckIPAddressSubnets(context.Background(), nil, node, k8sNode, mockGetInterface)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated).To(BeTrue())
+			Expect(node.Spec.BGP.IPv4Address).To(Equal("10.0.0.20/24"))
+
+			By("reporting no change once the node is up to date")
+			updated, err = recheckIPAddressSubnets(context.Background(), nil, node, k8sNode, mockGetInterface)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated).To(BeFalse())
+		})
+
+		It("should keep the existing address while no address is detected", func() {
+			node := makeNode("10.0.0.10/24", "")
+			updated, err := recheckIPAddressSubnets(context.Background(), nil, node, &v1.Node{}, mockGetInterface)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated).To(BeFalse())
+			Expect(node.Spec.BGP.IPv4Address).To(Equal("10.0.0.10/24"))
+		})
+
+		It("should leave the node unchanged if autodetection fails", func() {
+			node := &libapi.Node{}
+			updated, err := recheckIPAddressSubnets(context.Background(), nil, node, &v1.Node{}, mockGetInterface)
+			Expect(err).To(HaveOccurred())
+			Expect(updated).To(BeFalse())
+			Expect(node.Spec.BGP).To(BeNil())
+		})
+
+		It("should report a failed re-detection until re-detection succeeds", func() {
+			oldStatusFile := health.NodeIPRedetectionStatusFile
+			dir, err := os.MkdirTemp("", "calico-node")
+			Expect(err).NotTo(HaveOccurred())
+			defer os.RemoveAll(dir)
+			health.NodeIPRedetectionStatusFile = filepath.Join(dir, "node-ip-redetection-failed")
+			defer func() { health.NodeIPRedetectionStatusFile = oldStatusFile }()
+
+			node := &libapi.Node{}
+			_, err = recheckIPAddressSubnets(context.Background(), nil, node, &v1.Node{}, mockGetInterface)
+			Expect(err).To(HaveOccurred())
+			health.SetNodeIPRedetectionError(err)
+			msg, err := os.ReadFile(health.NodeIPRedetectionStatusFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(msg)).To(ContainSubstring("failed to re-detect node IP addresses"))
+
+			updated, err := recheckIPAddressSubnets(context.Background(), nil, node, makeK8sNode("10.0.0.20", ""), mockGetInterface)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(updated).To(BeTrue())
+			health.SetNodeIPRedetectionError(err)
+			Expect(health.NodeIPRedetectionStatusFile).NotTo(BeAnExistingFile())
+		})
+	})
+
 })
 
 var _ = Describe("UT for IP and IP6", func() {
@@ -1,4 +1,4 @@
-// Copyright (c) 2018-2021 Tigera, Inc. All rights reserved.
+// Copyright (c) 2018-2024 Tigera, Inc. All rights reserved.
 //
 // Licensed under the Apache License, Version 2.0 (the "License");
 // you may not use this file except in compliance with the License.
@@ -113,3 +113,8 @@ func ensureNetworkForOS(ctx context.Context, c client.Interface, nodeName string
 	logrus.Info("Ensure network is done.")
 	return nil
 }
+
+// subscribeAddressUpdates is not supported on Windows, the address monitor relies on polling.
+func subscribeAddressUpdates(ctx context.Context) <-chan struct{} {
+	return nil
+}
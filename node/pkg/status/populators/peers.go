@@ -54,6 +54,10 @@ type bgpPeer struct {
 	since    string
 	bgpState string
 	info     string
+
+	// caps holds the capabilities advertised by the neighbor, as listed in the "Neighbor caps"
+	// line of the BIRD output.  It is nil if BIRD didn't report any, e.g. if the session is down.
+	caps []string
 }
 
 var birdStateToBGPState map[string]apiv3.BGPSessionState = map[string]apiv3.BGPSessionState{
@@ -72,7 +76,34 @@ func (b *bgpPeer) toNodeStatusAPI() apiv3.CalicoNodePeer {
 		Type:   bgpTypeMap[b.peerType],
 		State:  birdStateToBGPState[b.bgpState],
 		Since:  b.since,
+
+		GracefulRestart: b.gracefulRestartCaps(),
+	}
+}
+
+// gracefulRestartCaps returns the graceful restart capabilities advertised by the neighbor, or nil
+// if the neighbor capabilities are not known.
+func (b *bgpPeer) gracefulRestartCaps() *apiv3.CalicoNodePeerGracefulRestart {
+	if b.caps == nil {
+		return nil
+	}
+	gr := &apiv3.CalicoNodePeerGracefulRestart{
+		Restart:          apiv3.GracefulRestartCapabilityNone,
+		LongLivedRestart: apiv3.GracefulRestartCapabilityNone,
+	}
+	for _, c := range b.caps {
+		switch c {
+		case "restart-able":
+			gr.Restart = apiv3.GracefulRestartCapabilityAble
+		case "restart-aware":
+			gr.Restart = apiv3.GracefulRestartCapabilityAware
+		case "llgr-able":
+			gr.LongLivedRestart = apiv3.GracefulRestartCapabilityAble
+		case "llgr-aware":
+			gr.LongLivedRestart = apiv3.GracefulRestartCapabilityAware
+		}
 	}
+	return gr
 }
 
 // Get BGP peer type and peer IP from session name.
@@ -137,7 +168,7 @@ func (b *bgpPeer) unmarshalBIRD(line, ipSep string) bool {
 }
 
 // Complete reads detailed information for a BGP session and fill in bgpPeer structure.
-// Currently we only set BGP state and PeerIP but could extend to other fields later.
+// Currently we only set BGP state, PeerIP and the neighbor capabilities but could extend to other fields later.
 func (b *bgpPeer) complete(bc *birdConn) error {
 	// Send the request.
 	cmd := fmt.Sprintf("show protocols all %s\n", b.session)
@@ -199,6 +230,8 @@ func (b *bgpPeer) complete(bc *birdConn) error {
 			b.bgpState = state
 		} else if ip, ok := getValue(str, "Neighbor address:"); ok {
 			b.peerIP = ip
+		} else if caps, ok := getValue(str, "Neighbor caps:"); ok {
+			b.caps = strings.Fields(caps)
 		}
 
 		// Before reading the next line, adjust the time-out for
@@ -75,7 +75,7 @@ BGP state:          Established
   Neighbor address: 172.17.8.103
   Neighbor AS:      65530
   Neighbor ID:      147.75.36.73
-  Neighbor caps:    refresh restart-aware AS4
+  Neighbor caps:    refresh restart-able llgr-aware AS4
   Session:          external AS4
   Source address:   10.99.182.129
   Hold timer:       66/90
@@ -114,6 +114,7 @@ BGP state:          OpenSent
 				since:    "2016-11-21",
 				bgpState: "Established",
 				info:     "",
+				caps:     []string{"refresh", "restart-aware", "AS4"},
 			},
 			{
 				session:  "Global_172_17_8_103",
@@ -123,6 +124,7 @@ BGP state:          OpenSent
 				since:    "2016-11-21",
 				bgpState: "Established",
 				info:     "",
+				caps:     []string{"refresh", "restart-able", "llgr-aware", "AS4"},
 			},
 			{
 				session:  "Node_172_17_8_104",
@@ -132,6 +134,7 @@ BGP state:          OpenSent
 				since:    "2016-11-21",
 				bgpState: "OpenSent",
 				info:     "Socket: error",
+				caps:     []string{"refresh", "restart-aware", "AS4"},
 			},
 		}
 		bgpPeers, err := readBIRDPeers(getMockBirdConn(IPFamilyV4, table))
@@ -217,6 +220,7 @@ BGP state:          Established
 				since:    "2016-11-21",
 				bgpState: "Established",
 				info:     "",
+				caps:     []string{"refresh", "restart-aware", "AS4"},
 			},
 		}
 		bgpPeers, err := readBIRDPeers(getMockBirdConn(IPFamilyV6, table))
@@ -257,5 +261,28 @@ BGP state:          Established
 				Since:  "2016-11-21",
 			},
 		),
+		Entry(
+			"graceful restart capabilities",
+			&bgpPeer{
+				session:  "Global_172_17_8_103",
+				peerIP:   "172.17.8.103",
+				peerType: "Global",
+				state:    "up",
+				since:    "2016-11-21",
+				bgpState: "Established",
+				info:     "",
+				caps:     []string{"refresh", "restart-able", "llgr-aware", "AS4"},
+			},
+			v3.CalicoNodePeer{
+				PeerIP: "172.17.8.103",
+				Type:   v3.BGPPeerTypeGlobalPeer,
+				State:  v3.BGPSessionStateEstablished,
+				Since:  "2016-11-21",
+				GracefulRestart: &v3.CalicoNodePeerGracefulRestart{
+					Restart:          v3.GracefulRestartCapabilityAble,
+					LongLivedRestart: v3.GracefulRestartCapabilityAware,
+				},
+			},
+		),
 	)
 })
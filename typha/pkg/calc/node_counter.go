@@ -18,6 +18,8 @@ import (
 	"fmt"
 	"sync"
 
+	corev1 "k8s.io/api/core/v1"
+
 	v3 "github.com/projectcalico/calico/libcalico-go/lib/apis/v3"
 	"github.com/projectcalico/calico/libcalico-go/lib/backend/api"
 	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
@@ -26,15 +28,16 @@ import (
 func NewNodeCounter(sink api.SyncerCallbacks) *NodeCounter {
 	return &NodeCounter{
 		sink:    sink,
-		nodeMap: map[string]bool{},
+		nodeMap: map[string]string{},
 	}
 }
 
 type NodeCounter struct {
 	sync.Mutex
-	sink    api.SyncerCallbacks
-	inSync  bool
-	nodeMap map[string]bool
+	sink   api.SyncerCallbacks
+	inSync bool
+	// nodeMap maps from node name to the node's zone, which is "" if the node has no zone label.
+	nodeMap map[string]string
 }
 
 func (c *NodeCounter) OnStatusUpdated(status api.SyncStatus) {
@@ -53,8 +56,9 @@ func (c *NodeCounter) OnUpdates(updates []api.Update) {
 			if k.Kind == v3.KindNode {
 				name := k.Name
 				switch update.UpdateType {
-				case api.UpdateTypeKVNew:
-					c.setNode(name)
+				case api.UpdateTypeKVNew, api.UpdateTypeKVUpdated:
+					// Updates may change the node's zone.
+					c.setNode(name, nodeZone(update.Value))
 				case api.UpdateTypeKVDeleted:
 					c.deleteNode(name)
 				}
@@ -73,10 +77,43 @@ func (c *NodeCounter) GetNumNodes() (int, error) {
 	return len(c.nodeMap), nil
 }
 
-func (c *NodeCounter) setNode(node string) {
+// GetNumNodesByZone returns the number of nodes in each zone.  Nodes without a zone are counted
+// under "".
+func (c *NodeCounter) GetNumNodesByZone() (map[string]int, error) {
+	c.Lock()
+	defer c.Unlock()
+	if !c.inSync {
+		return nil, fmt.Errorf("Node counter not yet in sync")
+	}
+	numNodes := map[string]int{}
+	for _, zone := range c.nodeMap {
+		numNodes[zone]++
+	}
+	return numNodes, nil
+}
+
+// GetNodeZone returns the zone of the given node, or "" if it has no zone.
+func (c *NodeCounter) GetNodeZone(node string) (string, error) {
+	c.Lock()
+	defer c.Unlock()
+	zone, ok := c.nodeMap[node]
+	if !ok {
+		return "", fmt.Errorf("Node %s not found", node)
+	}
+	return zone, nil
+}
+
+func nodeZone(value interface{}) string {
+	if node, ok := value.(*v3.Node); ok {
+		return node.Labels[corev1.LabelTopologyZone]
+	}
+	return ""
+}
+
+func (c *NodeCounter) setNode(node, zone string) {
 	c.Lock()
 	defer c.Unlock()
-	c.nodeMap[node] = true
+	c.nodeMap[node] = zone
 }
 
 func (c *NodeCounter) deleteNode(node string) {
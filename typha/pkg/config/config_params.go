@@ -113,6 +113,11 @@ type Config struct {
 	PrometheusProcessMetricsEnabled bool   `config:"bool;true"`
 
 	SnapshotCacheMaxBatchSize int `config:"int(1,);100"`
+	// SnapshotCacheReduceRemoteEndpoints enables node-scoped streams for Felix clients that request
+	// them, in which the workload endpoints on other nodes are sent in a reduced form.  This requires
+	// Typha to keep a second, reduced, copy of the Felix snapshot, so it is disabled by default; when
+	// disabled, Typha sends all workload endpoints in full to every client.
+	SnapshotCacheReduceRemoteEndpoints bool `config:"bool;false"`
 
 	ServerMaxMessageSize                 int           `config:"int(1,);100"`
 	ServerMaxFallBehindSecs              time.Duration `config:"seconds;300"`
@@ -133,6 +138,31 @@ type Config struct {
 	CAFile         string `config:"file(must-exist);;local"`
 	ClientCN       string `config:"string;"`
 	ClientURISAN   string `config:"string;"`
+	// ServerCN and ServerURISAN are optional: the CN and URI SAN that clients expect in
+	// ServerCertFile (Felix's TyphaCN and TyphaURISAN).  If set, a rotated certificate is only
+	// used if its CN or URI SAN matches them; otherwise it must match the certificate that it
+	// replaces.
+	ServerCN     string `config:"string;;local"`
+	ServerURISAN string `config:"string;;local"`
+
+	// Relay mode.  If RelayUpstreamAddr or RelayUpstreamK8sServiceName is set, Typha gets its data
+	// from an upstream Typha instead of from the datastore.  The upstream Typha is found in the same
+	// way that Felix finds Typha: RelayUpstreamAddr, if set, takes precedence over looking up the
+	// endpoints of the Kubernetes service.  The upstream service must not include this Typha.
+	RelayUpstreamAddr           string        `config:"authority;;local"`
+	RelayUpstreamK8sNamespace   string        `config:"string;kube-system;local"`
+	RelayUpstreamK8sServiceName string        `config:"string;;local"`
+	RelayUpstreamReadTimeout    time.Duration `config:"seconds;30;local"`
+	RelayUpstreamWriteTimeout   time.Duration `config:"seconds;10;local"`
+
+	// Client-side TLS config for the connection to the upstream Typha.  If any of these are
+	// specified, they _all_ must be - except that either RelayUpstreamCN or RelayUpstreamURISAN
+	// may be left unset.
+	RelayUpstreamKeyFile  string `config:"file(must-exist);;local"`
+	RelayUpstreamCertFile string `config:"file(must-exist);;local"`
+	RelayUpstreamCAFile   string `config:"file(must-exist);;local"`
+	RelayUpstreamCN       string `config:"string;;local"`
+	RelayUpstreamURISAN   string `config:"string;;local"`
 
 	DebugMemoryProfilePath  string `config:"file;;"`
 	DebugDisableLogDropping bool   `config:"bool;false"`
@@ -153,6 +183,11 @@ type Config struct {
 	K8sServiceName                        string        `config:"string;calico-typha"`
 	K8sPortName                           string        `config:"string;calico-typha"`
 
+	// NodeName is the name of the node that Typha is running on.  If it is set, and connection
+	// rebalancing is enabled, Typha raises its connection limit if the nodes in its zone need more
+	// connections per Typha than the cluster average (because clients prefer Typhas in their own zone).
+	NodeName string `config:"string;"`
+
 	// State tracking.
 
 	// nameToSource tracks where we loaded each config param from.
@@ -311,6 +346,16 @@ func (config *Config) requiringTLS() bool {
 	return config.ServerKeyFile+config.ServerCertFile+config.CAFile+config.ClientCN+config.ClientURISAN != ""
 }
 
+// RelayEnabled returns true if Typha should get its data from an upstream Typha.
+func (config *Config) RelayEnabled() bool {
+	return config.RelayUpstreamAddr != "" || config.RelayUpstreamK8sServiceName != ""
+}
+
+func (config *Config) relayRequiringTLS() bool {
+	return config.RelayUpstreamKeyFile+config.RelayUpstreamCertFile+config.RelayUpstreamCAFile+
+		config.RelayUpstreamCN+config.RelayUpstreamURISAN != ""
+}
+
 // Validate() performs cross-field validation.
 func (config *Config) Validate() (err error) {
 	if config.DatastoreType == "etcdv3" && len(config.EtcdEndpoints) == 0 {
@@ -335,6 +380,17 @@ func (config *Config) Validate() (err error) {
 				" - except that either ClientCN or ClientURISAN may be left unset.")
 		}
 	}
+
+	if config.relayRequiringTLS() {
+		if config.RelayUpstreamKeyFile == "" ||
+			config.RelayUpstreamCertFile == "" ||
+			config.RelayUpstreamCAFile == "" ||
+			(config.RelayUpstreamCN == "" && config.RelayUpstreamURISAN == "") {
+			err = errors.New("If any upstream Typha TLS config parameters are specified," +
+				" they _all_ must be" +
+				" - except that either RelayUpstreamCN or RelayUpstreamURISAN may be left unset.")
+		}
+	}
 	return
 }
 
@@ -81,6 +81,9 @@ var _ = DescribeTable("Config parsing",
 	Entry("PrometheusMetricsPort", "PrometheusMetricsPort", "1234", int(1234)),
 	Entry("PrometheusGoMetricsEnabled", "PrometheusGoMetricsEnabled", "false", false),
 	Entry("PrometheusProcessMetricsEnabled", "PrometheusProcessMetricsEnabled", "false", false),
+
+	Entry("SnapshotCacheReduceRemoteEndpoints", "SnapshotCacheReduceRemoteEndpoints", "true", true),
+	Entry("SnapshotCacheReduceRemoteEndpoints Empty", "SnapshotCacheReduceRemoteEndpoints", "", false),
 )
 
 var _ = DescribeTable("Config validation",
@@ -125,4 +128,20 @@ var _ = DescribeTable("Config validation",
 		"ClientCN":       "typha-peer",
 		"ClientURISAN":   "spiffe://k8s.example.com/typha-peer",
 	}, true),
+	Entry("just one upstream TLS setting", map[string]string{
+		"RelayUpstreamAddr":    "typha-central:5473",
+		"RelayUpstreamKeyFile": "/usr",
+	}, false),
+	Entry("upstream TLS certs and key but no CN or URI SAN", map[string]string{
+		"RelayUpstreamKeyFile":  "/usr",
+		"RelayUpstreamCertFile": "/usr",
+		"RelayUpstreamCAFile":   "/usr",
+	}, false),
+	Entry("all upstream TLS params", map[string]string{
+		"RelayUpstreamAddr":     "typha-central:5473",
+		"RelayUpstreamKeyFile":  "/usr",
+		"RelayUpstreamCertFile": "/usr",
+		"RelayUpstreamCAFile":   "/usr",
+		"RelayUpstreamCN":       "typha-server",
+	}, true),
 )
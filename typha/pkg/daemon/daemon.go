@@ -45,10 +45,13 @@ import (
 	"github.com/projectcalico/calico/typha/pkg/buildinfo"
 	"github.com/projectcalico/calico/typha/pkg/calc"
 	"github.com/projectcalico/calico/typha/pkg/config"
+	"github.com/projectcalico/calico/typha/pkg/discovery"
 	"github.com/projectcalico/calico/typha/pkg/jitter"
 	"github.com/projectcalico/calico/typha/pkg/k8s"
 	"github.com/projectcalico/calico/typha/pkg/logutils"
+	"github.com/projectcalico/calico/typha/pkg/relay"
 	"github.com/projectcalico/calico/typha/pkg/snapcache"
+	"github.com/projectcalico/calico/typha/pkg/syncclient"
 	"github.com/projectcalico/calico/typha/pkg/syncproto"
 	"github.com/projectcalico/calico/typha/pkg/syncserver"
 )
@@ -228,6 +231,12 @@ configRetry:
 			continue configRetry
 		}
 
+		if configParams.RelayEnabled() {
+			// In relay mode we get all our data from the upstream Typha so there's no need to
+			// connect to the datastore.
+			break configRetry
+		}
+
 		// We should now have enough config to connect to the datastore.
 		datastoreConfig = configParams.DatastoreConfig()
 		t.DatastoreClient, err = t.NewClientV3(datastoreConfig)
@@ -247,6 +256,15 @@ configRetry:
 	t.BuildInfoLogCxt.WithField("config", configParams).Info(
 		"Successfully loaded configuration.")
 
+	if configParams.RelayEnabled() {
+		log.WithFields(log.Fields{
+			"addr":    configParams.RelayUpstreamAddr,
+			"service": configParams.RelayUpstreamK8sServiceName,
+		}).Info("Relay mode enabled, skipping datastore initialization.")
+		t.ConfigParams = configParams
+		return nil
+	}
+
 	if datastoreConfig.Spec.DatastoreType == apiconfig.Kubernetes {
 		// Special case: for KDD v1 datamodel to v3 datamodel upgrade, we need to ensure that the datastore migration
 		// has completed before we start serving requests.  Otherwise, we might serve partially-migrated data to
@@ -353,6 +371,9 @@ func (t *TyphaDaemon) addSyncerPipeline(
 		MaxBatchSize:     t.ConfigParams.SnapshotCacheMaxBatchSize,
 		HealthAggregator: t.healthAggregator,
 		Name:             string(syncerType),
+		// Only Felix clients need the reduced form of the workload endpoints.
+		ReduceRemoteWorkloadEndpoints: syncerType == syncproto.SyncerTypeFelix &&
+			t.ConfigParams.SnapshotCacheReduceRemoteEndpoints,
 	})
 
 	pipeline := &syncerPipeline{
@@ -367,16 +388,55 @@ func (t *TyphaDaemon) addSyncerPipeline(
 	t.CachesBySyncerType[syncerType] = cache
 }
 
+// newRelaySyncerFactory returns a function that creates a syncer of the given type that gets its data
+// from the upstream Typha.
+func (t *TyphaDaemon) newRelaySyncerFactory(
+	syncerType syncproto.SyncerType,
+	discoverer *discovery.Discoverer,
+) func(callbacks bapi.SyncerCallbacks) bapi.Syncer {
+	hostname, err := os.Hostname()
+	if err != nil {
+		log.WithError(err).Warn("Failed to get hostname to report to upstream Typha.")
+	}
+	return func(callbacks bapi.SyncerCallbacks) bapi.Syncer {
+		return relay.New(discoverer, callbacks, relay.Config{
+			SyncerType: syncerType,
+			Hostname:   hostname,
+			ClientOptions: syncclient.Options{
+				ReadTimeout:  t.ConfigParams.RelayUpstreamReadTimeout,
+				WriteTimeout: t.ConfigParams.RelayUpstreamWriteTimeout,
+				KeyFile:      t.ConfigParams.RelayUpstreamKeyFile,
+				CertFile:     t.ConfigParams.RelayUpstreamCertFile,
+				CAFile:       t.ConfigParams.RelayUpstreamCAFile,
+				ServerCN:     t.ConfigParams.RelayUpstreamCN,
+				ServerURISAN: t.ConfigParams.RelayUpstreamURISAN,
+			},
+		})
+	}
+}
+
 // CreateServer creates and configures (but does not start) the server components.
 func (t *TyphaDaemon) CreateServer() {
 	// Health monitoring, for liveness and readiness endpoints.
 	t.healthAggregator = health.NewHealthAggregator()
 
 	// Now create the Syncer and caching layer (one pipeline for each syncer we support).
-	t.addSyncerPipeline(syncproto.SyncerTypeFelix, t.DatastoreClient.FelixSyncerByIface)
-	t.addSyncerPipeline(syncproto.SyncerTypeBGP, t.DatastoreClient.BGPSyncerByIface)
-	t.addSyncerPipeline(syncproto.SyncerTypeTunnelIPAllocation, t.DatastoreClient.TunnelIPAllocationSyncerByIface)
-	t.addSyncerPipeline(syncproto.SyncerTypeNodeStatus, t.DatastoreClient.NodeStatusSyncerByIface)
+	if t.ConfigParams.RelayEnabled() {
+		// In relay mode, each pipeline is fed by a connection to the upstream Typha.
+		discoverer := discovery.New(
+			discovery.WithAddrOverride(t.ConfigParams.RelayUpstreamAddr),
+			discovery.WithKubeService(t.ConfigParams.RelayUpstreamK8sNamespace, t.ConfigParams.RelayUpstreamK8sServiceName),
+			discovery.WithInClusterKubeClient(),
+		)
+		for _, syncerType := range syncproto.AllSyncerTypes {
+			t.addSyncerPipeline(syncerType, t.newRelaySyncerFactory(syncerType, discoverer))
+		}
+	} else {
+		t.addSyncerPipeline(syncproto.SyncerTypeFelix, t.DatastoreClient.FelixSyncerByIface)
+		t.addSyncerPipeline(syncproto.SyncerTypeBGP, t.DatastoreClient.BGPSyncerByIface)
+		t.addSyncerPipeline(syncproto.SyncerTypeTunnelIPAllocation, t.DatastoreClient.TunnelIPAllocationSyncerByIface)
+		t.addSyncerPipeline(syncproto.SyncerTypeNodeStatus, t.DatastoreClient.NodeStatusSyncerByIface)
+	}
 
 	// Create the server, which listens for connections from Felix.
 	t.Server = syncserver.New(
@@ -400,6 +460,8 @@ func (t *TyphaDaemon) CreateServer() {
 			CAFile:                         t.ConfigParams.CAFile,
 			ClientCN:                       t.ConfigParams.ClientCN,
 			ClientURISAN:                   t.ConfigParams.ClientURISAN,
+			ServerCN:                       t.ConfigParams.ServerCN,
+			ServerURISAN:                   t.ConfigParams.ServerURISAN,
 		},
 	)
 }
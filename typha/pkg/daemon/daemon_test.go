@@ -28,6 +28,7 @@ import (
 
 	"github.com/projectcalico/calico/libcalico-go/lib/apiconfig"
 	bapi "github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
 	"github.com/projectcalico/calico/libcalico-go/lib/clientv3"
 	"github.com/projectcalico/calico/libcalico-go/lib/ipam"
 	fvtests "github.com/projectcalico/calico/typha/fv-tests"
@@ -188,6 +189,74 @@ var _ = Describe("Daemon", func() {
 				// It should make it all the way through to our recorder.
 				Eventually(cbs.Status).Should(Equal(bapi.InSync))
 			})
+
+			It("should serve a relay Typha", func() {
+				d.ConfigParams.ServerPort = syncserver.PortRandom
+				d.CreateServer()
+				cxt, cancelFn := context.WithCancel(context.Background())
+				defer cancelFn()
+				d.Start(cxt)
+
+				// Create a second Typha that relays from the first.
+				relayConfig := config.New()
+				relayConfig.RelayUpstreamAddr = fmt.Sprintf("127.0.0.1:%d", d.Server.Port())
+				relayConfig.ServerPort = syncserver.PortRandom
+				relayDaemon := New()
+				relayDaemon.ConfigParams = relayConfig
+				relayDaemon.CreateServer()
+				relayDaemon.Start(cxt)
+
+				cbs := fvtests.NewRecorder()
+				client := syncclient.New(
+					discovery.New(discovery.WithAddrOverride(fmt.Sprintf("127.0.0.1:%d", relayDaemon.Server.Port()))),
+					"",
+					"",
+					"",
+					cbs,
+					nil,
+				)
+				clientCxt, clientCancelFn := context.WithCancel(context.Background())
+				recorderCtx, recorderCancelFn := context.WithCancel(context.Background())
+				defer func() {
+					clientCancelFn()
+					client.Finished.Wait()
+					recorderCancelFn()
+				}()
+				err := client.Start(clientCxt)
+				go cbs.Loop(recorderCtx)
+				Expect(err).NotTo(HaveOccurred())
+
+				// Updates sent into the first Typha should make it through the relay.
+				d.SyncerPipelines[0].SyncerToValidator.OnUpdates([]bapi.Update{{
+					KVPair: model.KVPair{
+						Key:      model.GlobalConfigKey{Name: "foo"},
+						Value:    "bar",
+						Revision: "1",
+					},
+					UpdateType: bapi.UpdateTypeKVNew,
+				}})
+				d.SyncerPipelines[0].SyncerToValidator.OnStatusUpdated(bapi.InSync)
+				Eventually(cbs.Status).Should(Equal(bapi.InSync))
+				Eventually(cbs.KVs).Should(HaveKey("/calico/v1/config/foo"))
+			})
+		})
+
+		Describe("with relay mode configured", func() {
+			BeforeEach(func() {
+				err := os.WriteFile(configFile.Name(), append(configContents, []byte("RelayUpstreamAddr=typha-central:5473\n")...), 0o644)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("should not connect to the datastore", func() {
+				d.NewClientV3 = func(config apiconfig.CalicoAPIConfig) (c DatastoreClient, err error) {
+					Fail("Unexpected datastore connection")
+					return nil, nil
+				}
+				err := d.LoadConfiguration(cxt)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(d.ConfigParams.RelayEnabled()).To(BeTrue())
+				Expect(datastore.getNumInitCalls()).To(Equal(0))
+			})
 		})
 
 		downSecsStr := strconv.Itoa(downSecs)
@@ -292,6 +361,10 @@ func (b *mockDatastore) Tiers() clientv3.TierInterface {
 	panic("not implemented")
 }
 
+func (b *mockDatastore) Transaction() clientv3.Transaction {
+	panic("not implemented")
+}
+
 // Nodes returns an interface for managing node resources.
 func (b *mockDatastore) Nodes() clientv3.NodeInterface {
 	panic("not implemented")
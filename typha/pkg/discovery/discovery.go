@@ -21,8 +21,11 @@ import (
 	"math/rand"
 	"net"
 	"os"
+	"slices"
 
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 
@@ -36,6 +39,12 @@ type Typha struct {
 	Addr     string
 	IP       string
 	NodeName *string
+
+	// Zone is the zone of the Typha's node.  ForZones is the list of zones that Kubernetes'
+	// topology-aware routing hints assign the Typha to.  Both are only known if the Typha was
+	// found through an EndpointSlice.
+	Zone     string
+	ForZones []string
 }
 
 func (t Typha) dedupeKey() string {
@@ -49,6 +58,8 @@ func (t Typha) dedupeKey() string {
 type Discoverer struct {
 	addrOverride       string
 	nodeName           string
+	zone               string
+	zoneKnown          bool
 	k8sClient          kubernetes.Interface
 	k8sServiceName     string
 	k8sNamespace       string
@@ -101,6 +112,16 @@ func WithNodeAffinity(nodeName string) Option {
 	}
 }
 
+// WithZone sets the zone of the local node, which is used to prefer Typhas in the same zone.  If
+// it isn't set, the zone is read from the topology.kubernetes.io/zone label of the node set by
+// WithNodeAffinity.
+func WithZone(zone string) Option {
+	return func(d *Discoverer) {
+		d.zone = zone
+		d.zoneKnown = true
+	}
+}
+
 func WithPostDiscoveryFilter(f func(typhaAddresses []Typha) ([]Typha, error)) Option {
 	return func(d *Discoverer) {
 		d.AddPostDiscoveryFilter(f)
@@ -186,63 +207,181 @@ func (d *Discoverer) discoverTyphaAddrs() ([]Typha, error) {
 
 	// If we get here, we need to look up the Typha service endpoints using the k8s API.
 	logrus.Info("(Re)discovering Typha endpoints using the Kubernetes API...")
-	epClient := d.k8sClient.CoreV1().Endpoints(d.k8sNamespace)
-	eps, err := epClient.Get(context.Background(), d.k8sServiceName, v1.GetOptions{})
+	typhas, err := LookupTyphas(context.Background(), d.k8sClient, d.k8sNamespace, d.k8sServiceName, d.k8sServicePortName)
 	if err != nil {
-		logrus.WithError(err).Error("Unable to get Typha service endpoints from Kubernetes.")
 		return nil, err
 	}
+	if len(typhas) == 0 {
+		logrus.Error("Didn't find any ready Typha instances.")
+		return nil, ErrServiceNotReady
+	}
+
+	// Order the Typhas by preference: those on our node, then those in our zone, then the rest.
+	// Kubernetes' topology hints take precedence over the zones of the Typhas, but, like
+	// kube-proxy, we only use them if all the Typhas have them.
+	var local, sameZone, remote []Typha
+	zone := d.localZone()
+	useHints := zone != "" && allHaveHints(typhas)
+	for _, t := range typhas {
+		if t.NodeName != nil && *t.NodeName == d.nodeName {
+			local = append(local, t)
+		} else if zone != "" && ((useHints && slices.Contains(t.ForZones, zone)) || (!useHints && t.Zone == zone)) {
+			sameZone = append(sameZone, t)
+		} else {
+			remote = append(remote, t)
+		}
+	}
+
+	shuffleInPlace(local)
+	shuffleInPlace(sameZone)
+	shuffleInPlace(remote)
 
-	var (
-		candidates               int
-		local, remote, addresses []Typha
-	)
+	var addresses []Typha
+	addresses = append(addresses, local...)
+	addresses = append(addresses, sameZone...)
+	addresses = append(addresses, remote...)
 
-	for _, subset := range eps.Subsets {
+	fields := logrus.Fields{"addresses": addresses}
+	if d.nodeName != "" {
+		fields["local"] = local
+		fields["remote"] = remote
+	}
+	if zone != "" {
+		fields["zone"] = zone
+		fields["sameZone"] = sameZone
+		fields["usedHints"] = useHints
+	}
+
+	logrus.WithFields(fields).Info("Found ready Typha addresses.")
+
+	return addresses, nil
+}
+
+// localZone returns the zone of the local node, looking it up from the node's labels the first
+// time it is called.  Returns "" if the zone isn't known.
+func (d *Discoverer) localZone() string {
+	if d.zoneKnown || d.nodeName == "" {
+		return d.zone
+	}
+	node, err := d.k8sClient.CoreV1().Nodes().Get(context.Background(), d.nodeName, v1.GetOptions{})
+	if err != nil {
+		// Not fatal, we just can't prefer Typhas in the same zone.  Try again next time.
+		logrus.WithError(err).Warn("Unable to get local node to find its zone.")
+		return ""
+	}
+	d.zone = node.Labels[corev1.LabelTopologyZone]
+	d.zoneKnown = true
+	logrus.WithField("zone", d.zone).Info("Looked up zone of local node.")
+	return d.zone
+}
+
+func allHaveHints(typhas []Typha) bool {
+	for _, t := range typhas {
+		if len(t.ForZones) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// LookupTyphas returns the ready Typha instances that back the given service and serve the given
+// port.  It uses the service's EndpointSlices, which include the Typhas' zones, and falls back to
+// the service's Endpoints if there are none (or if we're not allowed to list them).
+func LookupTyphas(ctx context.Context, client kubernetes.Interface, namespace, serviceName, portName string) ([]Typha, error) {
+	typhas, err := lookupTyphasFromEndpointSlices(ctx, client, namespace, serviceName, portName)
+	if err != nil {
+		logrus.WithError(err).Info("Unable to list Typha EndpointSlices, falling back to Endpoints.")
+	} else if len(typhas) > 0 {
+		return typhas, nil
+	}
+	return lookupTyphasFromEndpoints(ctx, client, namespace, serviceName, portName)
+}
+
+func lookupTyphasFromEndpointSlices(ctx context.Context, client kubernetes.Interface, namespace, serviceName, portName string) ([]Typha, error) {
+	sliceList, err := client.DiscoveryV1().EndpointSlices(namespace).List(ctx, v1.ListOptions{
+		LabelSelector: discoveryv1.LabelServiceName + "=" + serviceName,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var typhas []Typha
+	seen := set.New[string]()
+	for _, slice := range sliceList.Items {
+		if slice.AddressType == discoveryv1.AddressTypeFQDN {
+			continue
+		}
 		var portForOurVersion int32
-		for _, port := range subset.Ports {
-			if port.Name == d.k8sServicePortName {
-				portForOurVersion = port.Port
+		for _, port := range slice.Ports {
+			if port.Name != nil && *port.Name == portName && port.Port != nil {
+				portForOurVersion = *port.Port
 				break
 			}
 		}
-
 		if portForOurVersion == 0 {
 			continue
 		}
 
-		// If we get here, this endpoint supports the typha port we're looking for.
-		for _, h := range subset.Addresses {
-			typhaAddr := net.JoinHostPort(h.IP, fmt.Sprint(portForOurVersion))
-			if h.NodeName != nil && *h.NodeName == d.nodeName { // is local
-				local = append(local, Typha{Addr: typhaAddr, IP: h.IP, NodeName: h.NodeName})
-			} else {
-				remote = append(remote, Typha{Addr: typhaAddr, IP: h.IP, NodeName: h.NodeName})
+		for _, ep := range slice.Endpoints {
+			// A nil Ready condition means that the endpoint is ready.
+			if len(ep.Addresses) == 0 || (ep.Conditions.Ready != nil && !*ep.Conditions.Ready) {
+				continue
+			}
+			// Consumers of the EndpointSlice API are expected to use the first address.
+			ip := ep.Addresses[0]
+			typha := Typha{
+				Addr:     net.JoinHostPort(ip, fmt.Sprint(portForOurVersion)),
+				IP:       ip,
+				NodeName: ep.NodeName,
+			}
+			if seen.Contains(typha.Addr) {
+				// Endpoints may briefly appear in more than one slice.
+				continue
 			}
-			candidates++
+			seen.Add(typha.Addr)
+			if ep.Zone != nil {
+				typha.Zone = *ep.Zone
+			}
+			if ep.Hints != nil {
+				for _, z := range ep.Hints.ForZones {
+					typha.ForZones = append(typha.ForZones, z.Name)
+				}
+			}
+			typhas = append(typhas, typha)
 		}
 	}
+	return typhas, nil
+}
 
-	// return results with local endpoints first on the list
-	if candidates == 0 {
-		logrus.Error("Didn't find any ready Typha instances.")
-		return nil, ErrServiceNotReady
+func lookupTyphasFromEndpoints(ctx context.Context, client kubernetes.Interface, namespace, serviceName, portName string) ([]Typha, error) {
+	epClient := client.CoreV1().Endpoints(namespace)
+	eps, err := epClient.Get(ctx, serviceName, v1.GetOptions{})
+	if err != nil {
+		logrus.WithError(err).Error("Unable to get Typha service endpoints from Kubernetes.")
+		return nil, err
 	}
 
-	shuffleInPlace(local)
-	shuffleInPlace(remote)
+	var typhas []Typha
+	for _, subset := range eps.Subsets {
+		var portForOurVersion int32
+		for _, port := range subset.Ports {
+			if port.Name == portName {
+				portForOurVersion = port.Port
+				break
+			}
+		}
 
-	addresses = append(local, remote...)
+		if portForOurVersion == 0 {
+			continue
+		}
 
-	fields := logrus.Fields{"addresses": addresses}
-	if d.nodeName != "" {
-		fields["local"] = local
-		fields["remote"] = remote
+		// If we get here, this endpoint supports the typha port we're looking for.
+		for _, h := range subset.Addresses {
+			typhaAddr := net.JoinHostPort(h.IP, fmt.Sprint(portForOurVersion))
+			typhas = append(typhas, Typha{Addr: typhaAddr, IP: h.IP, NodeName: h.NodeName})
+		}
 	}
-
-	logrus.WithFields(fields).Info("Found ready Typha addresses.")
-
-	return addresses, nil
+	return typhas, nil
 }
 
 type AddressLoader interface {
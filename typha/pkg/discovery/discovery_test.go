@@ -22,6 +22,7 @@ import (
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	v1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes/fake"
 )
@@ -254,6 +255,142 @@ var _ = Describe("Typha address discovery", func() {
 	})
 })
 
+var _ = Describe("Typha address discovery with EndpointSlices", func() {
+	var (
+		k8sClient   *fake.Clientset
+		slice       *discoveryv1.EndpointSlice
+		localNode   *v1.Node
+		nodeNames   map[string]*string
+		ready       = true
+		notReady    = false
+		portName    = "calico-typha"
+		port        = int32(5473)
+		zoneA       = "zone-a"
+		zoneB       = "zone-b"
+		zoneC       = "zone-c"
+		typhaInZone = func(ip, node string, zone *string) Typha {
+			t := Typha{Addr: ip + ":5473", IP: ip, NodeName: nodeNames[node]}
+			if zone != nil {
+				t.Zone = *zone
+			}
+			return t
+		}
+	)
+
+	endpoint := func(ip, node string, zone *string) discoveryv1.Endpoint {
+		return discoveryv1.Endpoint{
+			Addresses:  []string{ip},
+			Conditions: discoveryv1.EndpointConditions{Ready: &ready},
+			NodeName:   nodeNames[node],
+			Zone:       zone,
+		}
+	}
+
+	BeforeEach(func() {
+		nodeNames = map[string]*string{}
+		for _, n := range []string{"node-1", "node-2", "node-3", "node-4", "node-5"} {
+			n := n
+			nodeNames[n] = &n
+		}
+		localNode = &v1.Node{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   "node-1",
+				Labels: map[string]string{v1.LabelTopologyZone: zoneA},
+			},
+		}
+		slice = &discoveryv1.EndpointSlice{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      "calico-typha-abcde",
+				Namespace: "kube-system",
+				Labels:    map[string]string{discoveryv1.LabelServiceName: "calico-typha"},
+			},
+			AddressType: discoveryv1.AddressTypeIPv4,
+			Ports:       []discoveryv1.EndpointPort{{Name: &portName, Port: &port}},
+			Endpoints: []discoveryv1.Endpoint{
+				endpoint("10.0.0.1", "node-1", &zoneA),
+				endpoint("10.0.0.2", "node-2", &zoneA),
+				endpoint("10.0.0.3", "node-3", &zoneB),
+				endpoint("10.0.0.4", "node-4", &zoneC),
+			},
+		}
+	})
+
+	discover := func(opts ...Option) []Typha {
+		k8sClient = fake.NewSimpleClientset(slice, localNode)
+		opts = append([]Option{
+			WithKubeService("kube-system", "calico-typha"),
+			WithKubeClient(k8sClient),
+		}, opts...)
+		typhas, err := DiscoverTyphaAddrs(opts...)
+		Expect(err).NotTo(HaveOccurred())
+		return typhas
+	}
+
+	It("should return the Typhas with their zones", func() {
+		Expect(discover()).To(ConsistOf(
+			typhaInZone("10.0.0.1", "node-1", &zoneA),
+			typhaInZone("10.0.0.2", "node-2", &zoneA),
+			typhaInZone("10.0.0.3", "node-3", &zoneB),
+			typhaInZone("10.0.0.4", "node-4", &zoneC),
+		))
+	})
+
+	It("should skip Typhas that aren't ready and tolerate duplicates", func() {
+		slice.Endpoints[1].Conditions.Ready = &notReady
+		slice.Endpoints[2].Conditions.Ready = nil
+		slice.Endpoints = append(slice.Endpoints, endpoint("10.0.0.4", "node-4", &zoneC))
+		Expect(discover()).To(ConsistOf(
+			typhaInZone("10.0.0.1", "node-1", &zoneA),
+			typhaInZone("10.0.0.3", "node-3", &zoneB),
+			typhaInZone("10.0.0.4", "node-4", &zoneC),
+		))
+	})
+
+	It("should put local Typhas first, then Typhas in the node's zone", func() {
+		typhas := discover(WithNodeAffinity("node-1"))
+		Expect(typhas[0]).To(Equal(typhaInZone("10.0.0.1", "node-1", &zoneA)))
+		Expect(typhas[1]).To(Equal(typhaInZone("10.0.0.2", "node-2", &zoneA)))
+		Expect(typhas[2:]).To(ConsistOf(
+			typhaInZone("10.0.0.3", "node-3", &zoneB),
+			typhaInZone("10.0.0.4", "node-4", &zoneC),
+		))
+	})
+
+	It("should use an explicit zone", func() {
+		typhas := discover(WithNodeAffinity("node-5"), WithZone(zoneB))
+		Expect(typhas[0]).To(Equal(typhaInZone("10.0.0.3", "node-3", &zoneB)))
+	})
+
+	It("should fall back to any order if the node has no zone", func() {
+		localNode.Labels = nil
+		Expect(discover(WithNodeAffinity("node-5"))).To(HaveLen(4))
+	})
+
+	It("should prefer Typhas that are hinted for the node's zone", func() {
+		forZones := map[int]string{0: zoneB, 1: zoneB, 2: zoneB, 3: zoneA}
+		for i, z := range forZones {
+			slice.Endpoints[i].Hints = &discoveryv1.EndpointHints{ForZones: []discoveryv1.ForZone{{Name: z}}}
+		}
+		typhas := discover(WithNodeAffinity("node-5"), WithZone(zoneA))
+		Expect(typhas[0].IP).To(Equal("10.0.0.4"))
+		Expect(typhas[0].ForZones).To(Equal([]string{zoneA}))
+	})
+
+	It("should ignore hints unless all Typhas have them", func() {
+		slice.Endpoints[3].Hints = &discoveryv1.EndpointHints{ForZones: []discoveryv1.ForZone{{Name: zoneA}}}
+		typhas := discover(WithNodeAffinity("node-5"), WithZone(zoneB))
+		Expect(typhas[0].IP).To(Equal("10.0.0.3"))
+	})
+
+	It("should ignore slices for other ports", func() {
+		otherPort := "other"
+		slice.Ports[0].Name = &otherPort
+		k8sClient = fake.NewSimpleClientset(slice)
+		_, err := DiscoverTyphaAddrs(WithKubeService("kube-system", "calico-typha"), WithKubeClient(k8sClient))
+		Expect(err).To(HaveOccurred())
+	})
+})
+
 func DiscoverTyphaAddrs(opts ...Option) ([]Typha, error) {
 	discoverer := New(opts...)
 	return discoverer.LoadTyphaAddrs()
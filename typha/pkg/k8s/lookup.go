@@ -25,6 +25,7 @@ import (
 	"github.com/projectcalico/calico/libcalico-go/lib/set"
 	"github.com/projectcalico/calico/libcalico-go/lib/winutils"
 	"github.com/projectcalico/calico/typha/pkg/calc"
+	"github.com/projectcalico/calico/typha/pkg/discovery"
 )
 
 func NewK8sAPI(nc *calc.NodeCounter) *RealK8sAPI {
@@ -88,3 +89,36 @@ func (r *RealK8sAPI) GetNumTyphas(ctx context.Context, namespace, serviceName, p
 func (r *RealK8sAPI) GetNumNodes() (int, error) {
 	return r.nodeCounter.GetNumNodes()
 }
+
+func (r *RealK8sAPI) GetNumTyphasByZone(ctx context.Context, namespace, serviceName, portName string) (map[string]int, error) {
+	clientSet, err := r.clientSet()
+	if err != nil {
+		return nil, err
+	}
+
+	typhas, err := discovery.LookupTyphas(ctx, clientSet, namespace, serviceName, portName)
+	if err != nil {
+		log.WithError(err).Error("Failed to get Typha endpoints from Kubernetes")
+		return nil, err
+	}
+
+	// Count distinct IPs, in case a Typha serves more than one address.
+	numTyphas := map[string]int{}
+	ips := set.New[string]()
+	for _, t := range typhas {
+		if ips.Contains(t.IP) {
+			continue
+		}
+		ips.Add(t.IP)
+		numTyphas[t.Zone]++
+	}
+	return numTyphas, nil
+}
+
+func (r *RealK8sAPI) GetNumNodesByZone() (map[string]int, error) {
+	return r.nodeCounter.GetNumNodesByZone()
+}
+
+func (r *RealK8sAPI) GetNodeZone(nodeName string) (string, error) {
+	return r.nodeCounter.GetNodeZone(nodeName)
+}
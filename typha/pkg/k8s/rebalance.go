@@ -32,6 +32,13 @@ type K8sAPI interface {
 	GetNumNodes() (int, error)
 }
 
+// ZonalK8sAPI is implemented by K8sAPIs that can also count the Typhas and nodes in each zone.
+type ZonalK8sAPI interface {
+	GetNumTyphasByZone(ctx context.Context, namespace, serviceName, portName string) (map[string]int, error)
+	GetNumNodesByZone() (map[string]int, error)
+	GetNodeZone(nodeName string) (string, error)
+}
+
 func PollK8sForConnectionLimit(
 	cxt context.Context,
 	configParams *config.Config,
@@ -65,6 +72,9 @@ func PollK8sForConnectionLimit(
 			reason := "error"
 			if tErr == nil && nErr == nil {
 				target, reason = CalculateMaxConnLimit(configParams, numTyphas, numNodes, numSyncerTypes)
+				if zonalAPI, ok := k8sAPI.(ZonalK8sAPI); ok && configParams.NodeName != "" {
+					target, reason = maybeRaiseLimitForZone(cxt, logCxt, configParams, zonalAPI, numSyncerTypes, target, reason)
+				}
 			}
 
 			if target != activeTarget {
@@ -85,6 +95,105 @@ func PollK8sForConnectionLimit(
 	}
 }
 
+// maybeRaiseLimitForZone looks up the number of Typhas and nodes in each zone and returns the
+// zonal connection limit if it is higher than the given limit.  On failure, it returns the given
+// limit.
+func maybeRaiseLimitForZone(
+	cxt context.Context,
+	logCxt *log.Entry,
+	configParams *config.Config,
+	k8sAPI ZonalK8sAPI,
+	numSyncerTypes int,
+	target int,
+	reason string,
+) (int, string) {
+	zone, err := k8sAPI.GetNodeZone(configParams.NodeName)
+	if err != nil {
+		logCxt.WithError(err).Warn("Failed to get our zone, using cluster-wide connection limit.")
+		return target, reason
+	}
+	if zone == "" {
+		return target, reason
+	}
+	reqCtx, cancel := context.WithTimeout(cxt, 30*time.Second)
+	typhasByZone, err := k8sAPI.GetNumTyphasByZone(reqCtx, configParams.K8sNamespace, configParams.K8sServiceName, configParams.K8sPortName)
+	cancel()
+	if err != nil {
+		logCxt.WithError(err).Warn("Failed to get number of Typhas per zone, using cluster-wide connection limit.")
+		return target, reason
+	}
+	if typhasByZone[zone] == 0 {
+		// We don't know which zones the Typhas are in, perhaps because the EndpointSlices aren't available.
+		logCxt.Debug("No Typhas found in our zone, using cluster-wide connection limit.")
+		return target, reason
+	}
+	nodesByZone, err := k8sAPI.GetNumNodesByZone()
+	if err != nil {
+		logCxt.WithError(err).Warn("Failed to get number of nodes per zone, using cluster-wide connection limit.")
+		return target, reason
+	}
+	zoneTarget, zoneReason := CalculateZonalMaxConnLimit(configParams, zone, typhasByZone, nodesByZone, numSyncerTypes)
+	logCxt.WithFields(log.Fields{
+		"zone":         zone,
+		"typhasByZone": typhasByZone,
+		"nodesByZone":  nodesByZone,
+		"zoneLimit":    zoneTarget,
+		"clusterLimit": target,
+	}).Debug("Calculated zonal connection limit.")
+	if zoneTarget > target {
+		return zoneTarget, zoneReason
+	}
+	return target, reason
+}
+
+// CalculateZonalMaxConnLimit calculates the connection limit for a Typha in the given zone.  Since
+// clients prefer Typhas in their own zone, a Typha in a zone with more nodes per Typha than the
+// cluster average needs a higher limit than CalculateMaxConnLimit would give it.  Nodes in zones
+// without a Typha may connect to any Typha so they're shared out across all the Typhas.
+func CalculateZonalMaxConnLimit(
+	configParams *config.Config,
+	zone string,
+	typhasByZone, nodesByZone map[string]int,
+	numSyncerTypes int,
+) (target int, reason string) {
+	var numTyphas, numNodesWithoutTypha int
+	for _, n := range typhasByZone {
+		numTyphas += n
+	}
+	for z, n := range nodesByZone {
+		if z == "" || typhasByZone[z] == 0 {
+			numNodesWithoutTypha += n
+		}
+	}
+
+	reason = "configured lower limit"
+	target = configParams.MaxConnectionsLowerLimit
+	if numTyphas <= 1 {
+		reason = "lone typha"
+		target = configParams.MaxConnectionsUpperLimit
+		return
+	}
+	// As for the cluster-wide limit, allow for one Typha in the zone being down, unless we're the
+	// only one, and add 20% headroom.
+	const headroomPercent = 20
+	otherTyphasInZone := typhasByZone[zone] - 1
+	if otherTyphasInZone < 1 {
+		otherTyphasInZone = 1
+	}
+	zoneNodesShare := nodesByZone[zone] * (100 + headroomPercent) / otherTyphasInZone
+	otherNodesShare := numNodesWithoutTypha * (100 + headroomPercent) / (numTyphas - 1)
+	candidate := numSyncerTypes * (1 + (zoneNodesShare+otherNodesShare)/100)
+	if candidate > target {
+		reason = "zone fraction+20%"
+		target = candidate
+	}
+	if target > configParams.MaxConnectionsUpperLimit {
+		reason = "configured upper limit"
+		target = configParams.MaxConnectionsUpperLimit
+	}
+	return
+}
+
 func CalculateMaxConnLimit(configParams *config.Config, numTyphas, numNodes, numSyncerTypes int) (target int, reason string) {
 	reason = "configured lower limit"
 	target = configParams.MaxConnectionsLowerLimit
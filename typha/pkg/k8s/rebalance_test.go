@@ -44,6 +44,25 @@ var _ = DescribeTable("CalculateMaxConnLimit tests",
 	Entry("Upper limit", 2, 500, 101, "configured upper limit"),
 )
 
+var _ = DescribeTable("CalculateZonalMaxConnLimit tests",
+	func(zone string, typhasByZone, nodesByZone map[string]int, expectedNumber int, expectedReason string) {
+		configParams := &config.Config{
+			MaxConnectionsLowerLimit: 11,
+			MaxConnectionsUpperLimit: 1001,
+		}
+		num, reason := CalculateZonalMaxConnLimit(configParams, zone, typhasByZone, nodesByZone, 3)
+		Expect(num).To(Equal(expectedNumber))
+		Expect(reason).To(Equal(expectedReason))
+	},
+	Entry("Single Typha", "a", map[string]int{"a": 1}, map[string]int{"a": 10}, 1001, "lone typha"),
+	Entry("Busy zone", "a",
+		map[string]int{"a": 3, "b": 1}, map[string]int{"a": 100, "b": 10, "": 5}, 189, "zone fraction+20%"),
+	Entry("Zone with one Typha", "b",
+		map[string]int{"a": 3, "b": 1}, map[string]int{"a": 100, "b": 10, "": 5}, 45, "zone fraction+20%"),
+	Entry("Lower limit", "a", map[string]int{"a": 3, "b": 1}, map[string]int{"a": 1}, 11, "configured lower limit"),
+	Entry("Upper limit", "a", map[string]int{"a": 3, "b": 1}, map[string]int{"a": 1000}, 1001, "configured upper limit"),
+)
+
 var _ = Describe("Poll loop tests", func() {
 	var tickerC chan time.Time
 	var cxt context.Context
@@ -110,6 +129,98 @@ var _ = Describe("Poll loop tests", func() {
 	})
 })
 
+var _ = Describe("Zone-aware poll loop tests", func() {
+	var tickerC chan time.Time
+	var cxt context.Context
+	var cancelFn context.CancelFunc
+	var server *dummyServer
+	var k8sAPI *dummyZonalK8sAPI
+	var configParams *config.Config
+
+	BeforeEach(func() {
+		tickerC = make(chan time.Time)
+		cxt, cancelFn = context.WithCancel(context.Background())
+		configParams = &config.Config{
+			K8sNamespace:             "ns",
+			K8sServiceName:           "svc",
+			K8sPortName:              "port",
+			MaxConnectionsUpperLimit: 1001,
+			MaxConnectionsLowerLimit: 11,
+			NodeName:                 "node-1",
+		}
+		k8sAPI = &dummyZonalK8sAPI{
+			dummyK8sAPI: dummyK8sAPI{
+				numTyphas: 5,
+				numNodes:  100,
+			},
+			nodeZone:     "a",
+			typhasByZone: map[string]int{"a": 2, "b": 3},
+			nodesByZone:  map[string]int{"a": 40, "b": 60},
+		}
+		server = &dummyServer{}
+	})
+
+	JustBeforeEach(func() {
+		go func() {
+			defer GinkgoRecover()
+			PollK8sForConnectionLimit(cxt, configParams, tickerC, k8sAPI, server, 3)
+		}()
+	})
+
+	AfterEach(func() {
+		cancelFn()
+	})
+
+	It("should raise the limit for a busy zone", func() {
+		tickerC <- time.Now()
+		Eventually(server.MaxConns).Should(Equal([]int{147}))
+	})
+
+	Describe("in a zone that needs fewer connections than average", func() {
+		BeforeEach(func() {
+			k8sAPI.nodesByZone = map[string]int{"a": 10, "b": 90}
+		})
+
+		It("should use the cluster-wide limit", func() {
+			tickerC <- time.Now()
+			Eventually(server.MaxConns).Should(Equal([]int{93}))
+		})
+	})
+
+	Describe("without a node name", func() {
+		BeforeEach(func() {
+			configParams.NodeName = ""
+		})
+
+		It("should use the cluster-wide limit", func() {
+			tickerC <- time.Now()
+			Eventually(server.MaxConns).Should(Equal([]int{93}))
+		})
+	})
+
+	Describe("without zone information for the Typhas", func() {
+		BeforeEach(func() {
+			k8sAPI.typhasByZone = map[string]int{"": 5}
+		})
+
+		It("should use the cluster-wide limit", func() {
+			tickerC <- time.Now()
+			Eventually(server.MaxConns).Should(Equal([]int{93}))
+		})
+	})
+
+	Describe("with an error getting the zone", func() {
+		BeforeEach(func() {
+			k8sAPI.nodeZoneErr = errors.New("no zone!")
+		})
+
+		It("should use the cluster-wide limit", func() {
+			tickerC <- time.Now()
+			Eventually(server.MaxConns).Should(Equal([]int{93}))
+		})
+	})
+})
+
 type dummyServer struct {
 	L       sync.Mutex
 	targets []int
@@ -143,3 +254,25 @@ func (d *dummyK8sAPI) GetNumTyphas(ctx context.Context, namespace, serviceName,
 func (d *dummyK8sAPI) GetNumNodes() (int, error) {
 	return d.numNodes, d.numNodesErr
 }
+
+type dummyZonalK8sAPI struct {
+	dummyK8sAPI
+	nodeZone     string
+	nodeZoneErr  error
+	typhasByZone map[string]int
+	nodesByZone  map[string]int
+}
+
+func (d *dummyZonalK8sAPI) GetNumTyphasByZone(ctx context.Context, namespace, serviceName, portName string) (map[string]int, error) {
+	Expect(serviceName).To(Equal("svc"))
+	return d.typhasByZone, nil
+}
+
+func (d *dummyZonalK8sAPI) GetNumNodesByZone() (map[string]int, error) {
+	return d.nodesByZone, nil
+}
+
+func (d *dummyZonalK8sAPI) GetNodeZone(nodeName string) (string, error) {
+	Expect(nodeName).To(Equal("node-1"))
+	return d.nodeZone, d.nodeZoneErr
+}
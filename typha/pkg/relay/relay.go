@@ -0,0 +1,226 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package relay implements Typha's relay mode, in which Typha gets its data from an upstream
+// Typha instead of from the datastore.  This allows Typha to be deployed in a hierarchy, for
+// example, with a Typha per zone that consumes from a central Typha, so that only the central
+// Typha needs to watch the datastore.
+package relay
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+	"github.com/projectcalico/calico/libcalico-go/lib/set"
+	"github.com/projectcalico/calico/typha/pkg/buildinfo"
+	"github.com/projectcalico/calico/typha/pkg/discovery"
+	"github.com/projectcalico/calico/typha/pkg/syncclient"
+	"github.com/projectcalico/calico/typha/pkg/syncproto"
+)
+
+const defaultRetryInterval = time.Second
+
+type Config struct {
+	SyncerType syncproto.SyncerType
+	// Hostname is the name that we report to the upstream Typha.
+	Hostname string
+	// Options for the upstream connection.  The SyncerType field is ignored.
+	ClientOptions syncclient.Options
+	// RetryInterval is the time to wait before reconnecting after the upstream connection fails.
+	RetryInterval time.Duration
+}
+
+// Syncer is an api.Syncer that relays the data from an upstream Typha.  If the upstream
+// connection fails, the Syncer reconnects (to any of the upstream Typhas found by the discoverer)
+// and resyncs without interrupting its own clients.
+type Syncer struct {
+	config     Config
+	discoverer *discovery.Discoverer
+	tracker    *resyncTracker
+
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+func New(discoverer *discovery.Discoverer, callbacks api.SyncerCallbacks, config Config) *Syncer {
+	if config.RetryInterval == 0 {
+		config.RetryInterval = defaultRetryInterval
+	}
+	config.ClientOptions.SyncerType = config.SyncerType
+	// The relay serves all the data to its own clients, so it must never ask for a node-scoped
+	// stream.
+	config.ClientOptions.FilterRemoteEndpoints = false
+	return &Syncer{
+		config:     config,
+		discoverer: discoverer,
+		tracker:    newResyncTracker(callbacks),
+	}
+}
+
+func (s *Syncer) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.cancel = cancel
+	s.wg.Add(1)
+	go s.loop(ctx)
+}
+
+func (s *Syncer) Stop() {
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *Syncer) loop(ctx context.Context) {
+	defer s.wg.Done()
+	logCxt := log.WithField("syncerType", s.config.SyncerType)
+	s.tracker.downstream.OnStatusUpdated(api.WaitForDatastore)
+	for ctx.Err() == nil {
+		// Each connection sends a complete snapshot, so the tracker needs to know when a new one
+		// starts.  Since the previous client has finished, there's no race with its callbacks.
+		s.tracker.OnConnecting()
+		options := s.config.ClientOptions
+		client := syncclient.New(
+			s.discoverer,
+			buildinfo.GitVersion,
+			s.config.Hostname,
+			"Typha relay",
+			s.tracker,
+			&options,
+		)
+		logCxt.Info("Connecting to upstream Typha.")
+		if err := client.Start(ctx); err != nil {
+			logCxt.WithError(err).Error("Failed to connect to upstream Typha.")
+		} else {
+			client.Finished.Wait()
+			if ctx.Err() != nil {
+				break
+			}
+			logCxt.Warn("Connection to upstream Typha failed, will reconnect and resync.")
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(s.config.RetryInterval):
+		}
+	}
+	logCxt.Info("Relay syncer stopped.")
+}
+
+// resyncTracker sits between the upstream connections and the downstream callbacks.  It tracks
+// the keys that have been sent downstream so that, when a new upstream connection has sent its
+// snapshot, it can delete the keys that were removed while we were disconnected.  It also hides
+// the upstream status from the downstream callbacks once we've been in sync; a reconnection
+// shouldn't make our own clients think that we've fallen out of sync.
+//
+// Its methods must not be called concurrently.  That holds because the syncer only has one
+// upstream connection at a time.
+type resyncTracker struct {
+	downstream api.SyncerCallbacks
+
+	// knownKeys contains the serialized keys that the downstream callbacks know about.
+	knownKeys set.Set[string]
+	// resyncKeys contains the keys sent by the current connection while it is sending its
+	// snapshot.  It is nil once the snapshot is complete.
+	resyncKeys set.Set[string]
+
+	status api.SyncStatus
+}
+
+func newResyncTracker(downstream api.SyncerCallbacks) *resyncTracker {
+	return &resyncTracker{
+		downstream: downstream,
+		knownKeys:  set.New[string](),
+		status:     api.WaitForDatastore,
+	}
+}
+
+func (r *resyncTracker) OnConnecting() {
+	r.resyncKeys = set.New[string]()
+}
+
+func (r *resyncTracker) OnStatusUpdated(status api.SyncStatus) {
+	if status == api.InSync && r.resyncKeys != nil {
+		r.finishResync()
+	}
+	if r.status == api.InSync || status == r.status {
+		return
+	}
+	r.status = status
+	r.downstream.OnStatusUpdated(status)
+}
+
+// finishResync sends deletions for any keys that we knew about that weren't in the new
+// connection's snapshot.
+func (r *resyncTracker) finishResync() {
+	var deletions []api.Update
+	r.knownKeys.Iter(func(key string) error {
+		if r.resyncKeys.Contains(key) {
+			return nil
+		}
+		k := model.KeyFromDefaultPath(key)
+		if k == nil {
+			log.WithField("key", key).Warn("Failed to parse stale key, unable to delete it.")
+			return nil
+		}
+		deletions = append(deletions, api.Update{
+			KVPair:     model.KVPair{Key: k},
+			UpdateType: api.UpdateTypeKVDeleted,
+		})
+		return set.RemoveItem
+	})
+	log.WithFields(log.Fields{
+		"numKeys":    r.knownKeys.Len(),
+		"numDeleted": len(deletions),
+	}).Info("Upstream Typha snapshot complete.")
+	r.resyncKeys = nil
+	if len(deletions) > 0 {
+		r.downstream.OnUpdates(deletions)
+	}
+}
+
+func (r *resyncTracker) OnUpdates(updates []api.Update) {
+	keys := make([]string, 0, len(updates))
+	for _, u := range updates {
+		key, err := model.KeyToDefaultPath(u.Key)
+		if err != nil {
+			log.WithError(err).WithField("key", u.Key).Warn("Failed to serialize key.")
+		}
+		keys = append(keys, key)
+	}
+	r.OnUpdatesKeysKnown(updates, keys)
+}
+
+// OnUpdatesKeysKnown is called by the syncclient in place of OnUpdates, it avoids re-serializing
+// the keys.
+func (r *resyncTracker) OnUpdatesKeysKnown(updates []api.Update, keys []string) {
+	for i, u := range updates {
+		key := keys[i]
+		if u.Value == nil {
+			r.knownKeys.Discard(key)
+			if r.resyncKeys != nil {
+				r.resyncKeys.Discard(key)
+			}
+			continue
+		}
+		r.knownKeys.Add(key)
+		if r.resyncKeys != nil {
+			r.resyncKeys.Add(key)
+		}
+	}
+	r.downstream.OnUpdates(updates)
+}
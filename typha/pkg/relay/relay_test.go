@@ -0,0 +1,115 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package relay
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+)
+
+type recorder struct {
+	statuses []api.SyncStatus
+	updates  []api.Update
+}
+
+func (r *recorder) OnStatusUpdated(status api.SyncStatus) {
+	r.statuses = append(r.statuses, status)
+}
+
+func (r *recorder) OnUpdates(updates []api.Update) {
+	r.updates = append(r.updates, updates...)
+}
+
+func configUpdate(name, value string) api.Update {
+	upd := api.Update{
+		KVPair:     model.KVPair{Key: model.GlobalConfigKey{Name: name}},
+		UpdateType: api.UpdateTypeKVNew,
+	}
+	if value != "" {
+		upd.Value = value
+	} else {
+		upd.UpdateType = api.UpdateTypeKVDeleted
+	}
+	return upd
+}
+
+func TestResyncTrackerFirstConnection(t *testing.T) {
+	RegisterTestingT(t)
+
+	rec := &recorder{}
+	tracker := newResyncTracker(rec)
+	tracker.OnConnecting()
+	tracker.OnStatusUpdated(api.WaitForDatastore)
+	tracker.OnStatusUpdated(api.ResyncInProgress)
+	tracker.OnUpdates([]api.Update{configUpdate("a", "1"), configUpdate("b", "2")})
+	tracker.OnStatusUpdated(api.InSync)
+
+	Expect(rec.statuses).To(Equal([]api.SyncStatus{api.ResyncInProgress, api.InSync}))
+	Expect(rec.updates).To(Equal([]api.Update{configUpdate("a", "1"), configUpdate("b", "2")}))
+}
+
+func TestResyncTrackerReconnect(t *testing.T) {
+	RegisterTestingT(t)
+
+	rec := &recorder{}
+	tracker := newResyncTracker(rec)
+	tracker.OnConnecting()
+	tracker.OnUpdates([]api.Update{configUpdate("a", "1"), configUpdate("b", "2"), configUpdate("c", "3")})
+	tracker.OnStatusUpdated(api.InSync)
+	tracker.OnUpdates([]api.Update{configUpdate("c", "")})
+
+	// Reconnect: "b" was deleted and "d" was created while we were disconnected.
+	rec.updates = nil
+	tracker.OnConnecting()
+	tracker.OnStatusUpdated(api.ResyncInProgress)
+	tracker.OnUpdates([]api.Update{configUpdate("a", "1"), configUpdate("d", "4")})
+	tracker.OnStatusUpdated(api.InSync)
+
+	Expect(rec.statuses).To(Equal([]api.SyncStatus{api.InSync}), "Status shouldn't regress on reconnection")
+	Expect(rec.updates).To(Equal([]api.Update{
+		configUpdate("a", "1"),
+		configUpdate("d", "4"),
+		configUpdate("b", ""),
+	}))
+
+	// Once the resync is complete, updates should be passed straight through.
+	rec.updates = nil
+	tracker.OnUpdates([]api.Update{configUpdate("a", "5")})
+	Expect(rec.updates).To(Equal([]api.Update{configUpdate("a", "5")}))
+	Expect(tracker.knownKeys.Len()).To(Equal(2))
+}
+
+func TestResyncTrackerReconnectBeforeInSync(t *testing.T) {
+	RegisterTestingT(t)
+
+	rec := &recorder{}
+	tracker := newResyncTracker(rec)
+	tracker.OnConnecting()
+	tracker.OnStatusUpdated(api.ResyncInProgress)
+	tracker.OnUpdates([]api.Update{configUpdate("a", "1"), configUpdate("b", "2")})
+
+	// Connection fails part way through the snapshot; "a" is deleted before we reconnect.
+	tracker.OnConnecting()
+	tracker.OnStatusUpdated(api.ResyncInProgress)
+	tracker.OnUpdates([]api.Update{configUpdate("b", "2")})
+	tracker.OnStatusUpdated(api.InSync)
+
+	Expect(rec.statuses).To(Equal([]api.SyncStatus{api.ResyncInProgress, api.InSync}))
+	Expect(rec.updates[len(rec.updates)-1]).To(Equal(configUpdate("a", "")))
+}
@@ -27,6 +27,7 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
 	"github.com/projectcalico/calico/libcalico-go/lib/health"
 	cprometheus "github.com/projectcalico/calico/libcalico-go/lib/prometheus"
 	"github.com/projectcalico/calico/typha/pkg/jitter"
@@ -118,6 +119,15 @@ func init() {
 // to one slow client) and keep track of what we'd sent to each channel.  All doable but, I think,
 // more fiddly than using a non-blocking linked list and a condition variable and letting each
 // client look after itself.
+//
+// # Reduced workload endpoints
+//
+// If Config.ReduceRemoteWorkloadEndpoints is set, the cache also maintains a second B-tree in which
+// each workload endpoint is stored in its reduced form (see syncproto.ReduceWorkloadEndpoint).  The
+// other keys are shared with the main tree.  Each Breadcrumb then also records the reduced form of
+// its deltas.  This allows a client that only needs the full details of the endpoints on its own node
+// to be sent the reduced tree, with the node's own endpoints taken from the main tree; since the
+// trees are sorted by key, the node's endpoints are a contiguous range in both.
 type Cache struct {
 	config Config
 
@@ -129,6 +139,9 @@ type Cache struct {
 	// kvs contains the current state of the datastore.  Its keys are the serialized form of our model keys
 	// and the values are SerializedUpdate objects.
 	kvs *btree.BTreeG[syncproto.SerializedUpdate]
+	// reducedKVs contains the same keys as kvs but with the workload endpoints in their reduced form.  Nil
+	// unless Config.ReduceRemoteWorkloadEndpoints is set.
+	reducedKVs *btree.BTreeG[syncproto.SerializedUpdate]
 	// breadcrumbCond is the condition variable used to signal when a new breadcrumb is available.
 	breadcrumbCond *sync.Cond
 	// lastBroadcast is the last time we did a broadcast to wake up breadcrumb followers.
@@ -165,6 +178,10 @@ type Config struct {
 	HealthAggregator healthAggregator
 	Name             string
 	HealthName       string
+
+	// ReduceRemoteWorkloadEndpoints enables the reduced form of the workload endpoints, for clients
+	// that request a node-scoped stream.
+	ReduceRemoteWorkloadEndpoints bool
 }
 
 func (config *Config) ApplyDefaults() {
@@ -198,7 +215,7 @@ func (config *Config) ApplyDefaults() {
 
 func New(config Config) *Cache {
 	config.ApplyDefaults()
-	kvs := btree.NewG[syncproto.SerializedUpdate](2, func(a, b syncproto.SerializedUpdate) bool { return a.Key < b.Key })
+	kvs := newTree()
 	cond := sync.NewCond(&sync.Mutex{})
 
 	c := &Cache{
@@ -209,6 +226,9 @@ func New(config Config) *Cache {
 		wakeUpTicker:   jitter.NewTicker(config.WakeUpInterval, config.WakeUpInterval/10),
 		healthTicks:    time.NewTicker(healthInterval).C,
 	}
+	if config.ReduceRemoteWorkloadEndpoints {
+		c.reducedKVs = newTree()
+	}
 
 	var err error
 	c.gaugeSnapSize, err = gaugeVecSnapshotSize.GetMetricWithLabelValues(config.Name)
@@ -234,6 +254,7 @@ func New(config Config) *Cache {
 		Timestamp:                 time.Now(),
 		nextCond:                  cond,
 		KVs:                       kvs.Clone(),
+		reducedKVs:                c.cloneReducedKVs(),
 		counterBreadcrumbBlock:    c.counterBreadcrumbBlock,
 		counterBreadcrumbNonBlock: c.counterBreadcrumbNonBlock,
 	}
@@ -246,6 +267,17 @@ func New(config Config) *Cache {
 	return c
 }
 
+func newTree() *btree.BTreeG[syncproto.SerializedUpdate] {
+	return btree.NewG[syncproto.SerializedUpdate](2, func(a, b syncproto.SerializedUpdate) bool { return a.Key < b.Key })
+}
+
+func (c *Cache) cloneReducedKVs() *btree.BTreeG[syncproto.SerializedUpdate] {
+	if c.reducedKVs == nil {
+		return nil
+	}
+	return c.reducedKVs.Clone()
+}
+
 // CurrentBreadcrumb returns the current Breadcrumb, which contains a snapshot of the datastore
 // at the time it was created and a method to wait for the next Breadcrumb to be dropped. It is
 // safe to call from any goroutine.
@@ -444,6 +476,10 @@ func (c *Cache) publishBreadcrumb() {
 		// Breadcrumbs can apply it as a delta.
 		newCrumb.Deltas = append(newCrumb.Deltas, newUpd)
 		somethingChanged = true
+
+		if c.reducedKVs != nil {
+			newCrumb.reducedDeltas = append(newCrumb.reducedDeltas, c.updateReducedKVs(upd, newUpd))
+		}
 	}
 
 	// Even if all updates were filtered out, report that to Prometheus; we
@@ -458,6 +494,7 @@ func (c *Cache) publishBreadcrumb() {
 	c.gaugeSnapSize.Set(float64(c.kvs.Len()))
 	// Add the new read-only snapshot to the new crumb.
 	newCrumb.KVs = c.kvs.Clone()
+	newCrumb.reducedKVs = c.cloneReducedKVs()
 
 	// Replace the Breadcrumb and link the old Breadcrumb to the new so that clients can follow
 	// the trail.
@@ -475,6 +512,33 @@ func (c *Cache) publishBreadcrumb() {
 	c.gaugeCurrentSequenceNumber.Set(float64(newCrumb.SequenceNumber))
 }
 
+// updateReducedKVs applies the update, which has already been applied to the main tree as newUpd, to
+// the reduced tree.  It returns the reduced delta to record in the Breadcrumb, which has an empty
+// key if the update doesn't change the reduced form.
+func (c *Cache) updateReducedKVs(upd api.Update, newUpd syncproto.SerializedUpdate) syncproto.SerializedUpdate {
+	if _, ok := upd.Value.(*model.WorkloadEndpoint); ok {
+		reducedUpd, err := syncproto.SerializeUpdate(syncproto.ReduceUpdate(upd))
+		if err != nil {
+			// Shouldn't happen since the full value serialized OK; fall back to the full value.
+			log.WithError(err).WithField("upd", upd).Error("Bug: failed to serialize reduced KV")
+		} else {
+			if oldUpd, exists := c.reducedKVs.Get(reducedUpd); exists && reducedUpd.WouldBeNoOp(oldUpd) {
+				// Only fields that aren't in the reduced form changed.
+				return syncproto.SerializedUpdate{}
+			}
+			newUpd = reducedUpd
+		}
+	}
+	if upd.Value == nil {
+		c.reducedKVs.Delete(newUpd)
+	} else {
+		updToStore := newUpd
+		updToStore.UpdateType = api.UpdateTypeKVNew
+		c.reducedKVs.ReplaceOrInsert(updToStore)
+	}
+	return newUpd
+}
+
 type Breadcrumb struct {
 	SequenceNumber uint64
 	Timestamp      time.Time
@@ -483,6 +547,12 @@ type Breadcrumb struct {
 	Deltas     []syncproto.SerializedUpdate
 	SyncStatus api.SyncStatus
 
+	// reducedKVs and reducedDeltas hold the reduced form of KVs and Deltas if the cache has
+	// ReduceRemoteWorkloadEndpoints set; otherwise they are nil.  reducedDeltas is parallel to
+	// Deltas; entries with an empty key are updates that don't change the reduced form.
+	reducedKVs    *btree.BTreeG[syncproto.SerializedUpdate]
+	reducedDeltas []syncproto.SerializedUpdate
+
 	nextCond *sync.Cond
 	next     unsafe.Pointer
 
@@ -516,6 +586,53 @@ func (b *Breadcrumb) Next(ctx context.Context) (*Breadcrumb, error) {
 	return next, nil
 }
 
+// SupportsNodeFiltering returns true if the Breadcrumb holds the reduced form of the workload
+// endpoints, as required by AscendKVsForNode and DeltasForNode.
+func (b *Breadcrumb) SupportsNodeFiltering() bool {
+	return b.reducedKVs != nil
+}
+
+// AscendKVsForNode calls f for each KV in the snapshot, in key order, until f returns false.  If
+// nodeName is set, the workload endpoints that are not on that node are in their reduced form.
+func (b *Breadcrumb) AscendKVsForNode(nodeName string, f func(syncproto.SerializedUpdate) bool) {
+	if nodeName == "" || b.reducedKVs == nil {
+		b.KVs.Ascend(f)
+		return
+	}
+	start, end := syncproto.LocalWorkloadEndpointsRange(nodeName)
+	keepGoing := true
+	wrapped := func(u syncproto.SerializedUpdate) bool {
+		keepGoing = f(u)
+		return keepGoing
+	}
+	b.reducedKVs.AscendLessThan(syncproto.SerializedUpdate{Key: start}, wrapped)
+	if keepGoing {
+		b.KVs.AscendRange(syncproto.SerializedUpdate{Key: start}, syncproto.SerializedUpdate{Key: end}, wrapped)
+	}
+	if keepGoing {
+		b.reducedKVs.AscendGreaterOrEqual(syncproto.SerializedUpdate{Key: end}, wrapped)
+	}
+}
+
+// DeltasForNode returns the Breadcrumb's deltas.  If nodeName is set, the workload endpoints that
+// are not on that node are in their reduced form, and updates that only change fields that are not
+// in the reduced form are omitted.
+func (b *Breadcrumb) DeltasForNode(nodeName string) []syncproto.SerializedUpdate {
+	if nodeName == "" || b.reducedKVs == nil {
+		return b.Deltas
+	}
+	start, end := syncproto.LocalWorkloadEndpointsRange(nodeName)
+	deltas := make([]syncproto.SerializedUpdate, 0, len(b.Deltas))
+	for i, upd := range b.Deltas {
+		if upd.Key >= start && upd.Key < end {
+			deltas = append(deltas, upd)
+		} else if b.reducedDeltas[i].Key != "" {
+			deltas = append(deltas, b.reducedDeltas[i])
+		}
+	}
+	return deltas
+}
+
 // loadNext does an atomic load of the next pointer.  It returns nil or the next Breadcrumb.
 func (b *Breadcrumb) loadNext() *Breadcrumb {
 	return (*Breadcrumb)(atomic.LoadPointer(&b.next))
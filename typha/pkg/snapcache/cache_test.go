@@ -17,12 +17,14 @@ package snapcache_test
 import (
 	"context"
 	"fmt"
+	"net"
 	"strconv"
 	"sync"
 	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	"github.com/projectcalico/api/pkg/lib/numorstring"
 	log "github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
@@ -30,6 +32,7 @@ import (
 	"github.com/projectcalico/calico/libcalico-go/lib/backend/api"
 	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
 	"github.com/projectcalico/calico/libcalico-go/lib/health"
+	calinet "github.com/projectcalico/calico/libcalico-go/lib/net"
 	"github.com/projectcalico/calico/libcalico-go/lib/set"
 	"github.com/projectcalico/calico/typha/pkg/snapcache"
 	"github.com/projectcalico/calico/typha/pkg/syncproto"
@@ -664,3 +667,179 @@ func deserialiseUpdates(serializedUpdates []syncproto.SerializedUpdate) []api.Up
 	}
 	return updates
 }
+
+var _ = Describe("Snapshot cache with reduced workload endpoints", func() {
+	var cache *snapcache.Cache
+	var cxt context.Context
+	var cancel context.CancelFunc
+	var crumb *snapcache.Breadcrumb
+
+	mac, _ := net.ParseMAC("01:02:03:04:05:06")
+	wepKey := func(node string) model.WorkloadEndpointKey {
+		return model.WorkloadEndpointKey{
+			Hostname:       node,
+			OrchestratorID: "k8s",
+			WorkloadID:     "default/pod-" + node,
+			EndpointID:     "eth0",
+		}
+	}
+	wep := func(node string, mac net.HardwareAddr) *model.WorkloadEndpoint {
+		return &model.WorkloadEndpoint{
+			State:      "active",
+			Name:       "cali1234",
+			Mac:        &calinet.MAC{HardwareAddr: mac},
+			ProfileIDs: []string{"kns.default"},
+			IPv4Nets:   []calinet.IPNet{calinet.MustParseCIDR("10.0.0.1/32")},
+			Labels:     map[string]string{"app": node},
+			Ports:      []model.EndpointPort{{Name: "http", Protocol: numorstring.ProtocolFromString("TCP"), Port: 80}},
+			Annotations: map[string]string{
+				"note": "not needed on other nodes",
+			},
+		}
+	}
+	reduced := func(w *model.WorkloadEndpoint) *model.WorkloadEndpoint {
+		return &model.WorkloadEndpoint{
+			Name:       w.Name,
+			ProfileIDs: w.ProfileIDs,
+			IPv4Nets:   w.IPv4Nets,
+			Labels:     w.Labels,
+			Ports:      w.Ports,
+		}
+	}
+	update := func(key model.Key, value interface{}, rev string, updateType api.UpdateType) api.Update {
+		return api.Update{
+			KVPair:     model.KVPair{Key: key, Value: value, Revision: rev},
+			UpdateType: updateType,
+		}
+	}
+	values := func(upds []syncproto.SerializedUpdate) map[model.Key]interface{} {
+		m := map[model.Key]interface{}{}
+		for _, u := range deserialiseUpdates(upds) {
+			m[u.Key] = u.Value
+		}
+		return m
+	}
+	snapshotValues := func(nodeName string) map[model.Key]interface{} {
+		var upds []syncproto.SerializedUpdate
+		crumb.AscendKVsForNode(nodeName, func(u syncproto.SerializedUpdate) bool {
+			upds = append(upds, u)
+			return true
+		})
+		Expect(upds).To(HaveLen(crumb.KVs.Len()))
+		return values(upds)
+	}
+
+	wepA, wepB := wep("node-a", mac), wep("node-b", mac)
+	configKey := model.GlobalConfigKey{Name: "foo"}
+
+	BeforeEach(func() {
+		cache = snapcache.New(snapcache.Config{
+			MaxBatchSize:                  10,
+			WakeUpInterval:                10 * time.Second,
+			Name:                          "reduced-cache",
+			ReduceRemoteWorkloadEndpoints: true,
+		})
+		cxt, cancel = context.WithCancel(context.Background())
+		cache.Start(cxt)
+
+		cache.OnUpdates([]api.Update{
+			update(wepKey("node-a"), wepA, "1", api.UpdateTypeKVNew),
+			update(wepKey("node-b"), wepB, "2", api.UpdateTypeKVNew),
+			update(configKey, "bar", "3", api.UpdateTypeKVNew),
+		})
+		Eventually(func() int {
+			crumb = cache.CurrentBreadcrumb()
+			return crumb.KVs.Len()
+		}).Should(Equal(3))
+	})
+
+	AfterEach(func() {
+		cancel()
+	})
+
+	It("should support node filtering", func() {
+		Expect(crumb.SupportsNodeFiltering()).To(BeTrue())
+	})
+
+	It("should send the full snapshot to clients that don't filter", func() {
+		Expect(snapshotValues("")).To(Equal(map[model.Key]interface{}{
+			wepKey("node-a"): wepA,
+			wepKey("node-b"): wepB,
+			configKey:        "bar",
+		}))
+	})
+
+	It("should reduce remote endpoints in the snapshot", func() {
+		Expect(snapshotValues("node-a")).To(Equal(map[model.Key]interface{}{
+			wepKey("node-a"): wepA,
+			wepKey("node-b"): reduced(wepB),
+			configKey:        "bar",
+		}))
+		Expect(snapshotValues("node-c")).To(Equal(map[model.Key]interface{}{
+			wepKey("node-a"): reduced(wepA),
+			wepKey("node-b"): reduced(wepB),
+			configKey:        "bar",
+		}))
+	})
+
+	It("should not treat a node whose name is a prefix of another as local", func() {
+		Expect(snapshotValues("node-")).To(HaveKeyWithValue(wepKey("node-a"), reduced(wepA)))
+	})
+
+	It("should stop early if asked to", func() {
+		n := 0
+		crumb.AscendKVsForNode("node-a", func(u syncproto.SerializedUpdate) bool {
+			n++
+			return false
+		})
+		Expect(n).To(Equal(1))
+	})
+
+	It("should only send remote endpoint deltas that change the reduced form", func() {
+		newMAC, _ := net.ParseMAC("0a:0b:0c:0d:0e:0f")
+		newWEPA, newWEPB := wep("node-a", newMAC), wep("node-b", newMAC)
+		cache.OnUpdates([]api.Update{
+			update(wepKey("node-a"), newWEPA, "4", api.UpdateTypeKVUpdated),
+			update(wepKey("node-b"), newWEPB, "5", api.UpdateTypeKVUpdated),
+		})
+		crumb, err := crumb.Next(cxt)
+		Expect(err).NotTo(HaveOccurred())
+
+		Expect(values(crumb.Deltas)).To(Equal(map[model.Key]interface{}{
+			wepKey("node-a"): newWEPA,
+			wepKey("node-b"): newWEPB,
+		}))
+		Expect(values(crumb.DeltasForNode("node-a"))).To(Equal(map[model.Key]interface{}{
+			wepKey("node-a"): newWEPA,
+		}))
+		Expect(crumb.DeltasForNode("node-c")).To(BeEmpty())
+	})
+
+	It("should send reduced updates and deletions of remote endpoints", func() {
+		newWEPB := wep("node-b", mac)
+		newWEPB.Labels = map[string]string{"app": "new"}
+		cache.OnUpdates([]api.Update{
+			update(wepKey("node-b"), newWEPB, "4", api.UpdateTypeKVUpdated),
+			update(wepKey("node-a"), nil, "5", api.UpdateTypeKVDeleted),
+		})
+		Eventually(func() uint64 {
+			return cache.CurrentBreadcrumb().SequenceNumber
+		}).Should(BeNumerically(">", crumb.SequenceNumber))
+
+		var deltas []syncproto.SerializedUpdate
+		for crumb.SequenceNumber < cache.CurrentBreadcrumb().SequenceNumber {
+			var err error
+			crumb, err = crumb.Next(cxt)
+			Expect(err).NotTo(HaveOccurred())
+			deltas = append(deltas, crumb.DeltasForNode("node-c")...)
+		}
+		Expect(values(deltas)).To(Equal(map[model.Key]interface{}{
+			wepKey("node-b"): reduced(newWEPB),
+			wepKey("node-a"): nil,
+		}))
+		Expect(snapshotValues("node-c")).To(Equal(map[model.Key]interface{}{
+			wepKey("node-b"): reduced(newWEPB),
+			configKey:        "bar",
+		}))
+	})
+})
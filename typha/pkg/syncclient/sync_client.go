@@ -23,7 +23,6 @@ import (
 	"fmt"
 	"io"
 	"net"
-	"os"
 	"sync"
 	"time"
 
@@ -56,6 +55,16 @@ type Options struct {
 	ServerURISAN   string
 	SyncerType     syncproto.SyncerType
 
+	// ClientCN and ClientURISAN are the CN and URI SAN that Typha expects in the certificate in
+	// CertFile.  If set, a rotated certificate is only used if it matches them.
+	ClientCN     string
+	ClientURISAN string
+
+	// FilterRemoteEndpoints requests a node-scoped stream, in which Typha sends the workload
+	// endpoints that are not on this client's node (myHostname) in their reduced form.  Only
+	// supported by SyncerTypeFelix; it is ignored by Typha versions that don't support it.
+	FilterRemoteEndpoints bool
+
 	// DisableDecoderRestart disables decoder restart and the features that depend on
 	// it (such as compression).  Useful for simulating an older client in UT.
 	DisableDecoderRestart bool
@@ -158,6 +167,7 @@ type SyncerClient struct {
 	decoder                     *gob.Decoder
 	handshakeStatus             *handshakeStatus
 	supportsNodeResourceUpdates bool
+	certs                       *tlsutils.CertReloader
 
 	callbacks callbacksWithKeysKnown
 	Finished  sync.WaitGroup
@@ -213,6 +223,10 @@ func (s *SyncerClient) Start(cxt context.Context) error {
 	// Then start our background goroutines.  We start the main loop and a second goroutine to
 	// manage shutdown.
 	cxt, cancelFn := context.WithCancel(cxt)
+	if s.certs != nil {
+		// Keep the certificate expiry metrics up to date while we're connected.
+		s.certs.Start(cxt, tlsutils.DefaultCertReloadInterval)
+	}
 	s.Finished.Add(1)
 	go s.loop(cxt, cancelFn)
 
@@ -249,6 +263,24 @@ func (s *SyncerClient) calculateConnectionAttemptLimit(numDiscoveredTyphas int)
 	return maxTries
 }
 
+// loadCerts loads the client certificate, key and CA bundle the first time it is called.  On
+// later calls, it picks up any (valid) changes to the files so that reconnections use rotated
+// certificates.
+func (s *SyncerClient) loadCerts() (*tlsutils.CertReloader, error) {
+	if s.certs == nil {
+		certs, err := tlsutils.NewCertReloader(s.options.CertFile, s.options.KeyFile, s.options.CAFile,
+			tlsutils.CertIdentity{CN: s.options.ClientCN, URISAN: s.options.ClientURISAN})
+		if err != nil {
+			return nil, err
+		}
+		s.certs = certs
+		return certs, nil
+	}
+	// On failure, Reload logs and keeps the previous certificates.
+	_, _ = s.certs.Reload()
+	return s.certs, nil
+}
+
 // SupportsNodeResourceUpdates waits for the Typha server to send a hello and returns true if
 // the server supports node resource updates. If the given timeout is reached, an error is returned.
 func (s *SyncerClient) SupportsNodeResourceUpdates(timeout time.Duration) (bool, error) {
@@ -276,13 +308,13 @@ func (s *SyncerClient) connect(cxt context.Context, typhaAddr discovery.Typha) e
 
 	var connFunc func(string) (net.Conn, error)
 	if s.options.requiringTLS() {
-		cert, err := tls.LoadX509KeyPair(s.options.CertFile, s.options.KeyFile)
+		certs, err := s.loadCerts()
 		if err != nil {
 			log.WithError(err).Error("Failed to load certificate and key")
 			return err
 		}
 		tlsConfig := calicotls.NewTLSConfig()
-		tlsConfig.Certificates = []tls.Certificate{cert}
+		tlsConfig.Certificates = []tls.Certificate{*certs.Certificate()}
 		// Typha API is a private binary API so we can enforce a recent TLS variant without
 		// worrying about back-compatibility with old browsers (for example).
 		tlsConfig.MinVersion = tls.VersionTLS12
@@ -292,17 +324,7 @@ func (s *SyncerClient) connect(cxt context.Context, typhaAddr discovery.Typha) e
 		// we don't always want that.  We will do certificate chain verification ourselves
 		// inside CertificateVerifier.
 		tlsConfig.InsecureSkipVerify = true
-		caPEMBlock, err := os.ReadFile(s.options.CAFile)
-		if err != nil {
-			log.WithError(err).Error("Failed to read CA data")
-			return err
-		}
-		tlsConfig.RootCAs = x509.NewCertPool()
-		ok := tlsConfig.RootCAs.AppendCertsFromPEM(caPEMBlock)
-		if !ok {
-			log.Error("Failed to add CA data to pool")
-			return errors.New("Failed to add CA data to pool")
-		}
+		tlsConfig.RootCAs = certs.CAPool()
 		tlsConfig.VerifyPeerCertificate = tlsutils.CertificateVerifier(
 			logCxt,
 			tlsConfig.RootCAs,
@@ -415,6 +437,10 @@ func (s *SyncerClient) loop(cxt context.Context, cancelFn context.CancelFunc) {
 	if ourSyncerType == "" {
 		ourSyncerType = syncproto.SyncerTypeFelix
 	}
+	var nodeName string
+	if s.options.FilterRemoteEndpoints {
+		nodeName = s.myHostname
+	}
 	compAlgs := []syncproto.CompressionAlgorithm{syncproto.CompressionSnappy}
 	if s.options.DisableDecoderRestart {
 		// Compression requires decoder restart.
@@ -429,6 +455,7 @@ func (s *SyncerClient) loop(cxt context.Context, cancelFn context.CancelFunc) {
 			SupportsDecoderRestart:         !s.options.DisableDecoderRestart,
 			SupportedCompressionAlgorithms: compAlgs,
 			ClientConnID:                   s.ID,
+			NodeName:                       nodeName,
 		},
 	)
 	if err != nil {
@@ -449,6 +476,9 @@ func (s *SyncerClient) loop(cxt context.Context, cancelFn context.CancelFunc) {
 		logCxt = logCxt.WithField("serverConnID", serverHello.ServerConnID)
 	}
 	logCxt.WithField("serverMsg", serverHello).Info("ServerHello message received")
+	if nodeName != "" && !serverHello.FilteringRemoteEndpoints {
+		logCxt.Info("Server doesn't support node-scoped streams, it will send all endpoints in full")
+	}
 
 	// Check whether Typha supports node resource updates.
 	if !serverHello.SupportsNodeResourceUpdates {
@@ -0,0 +1,55 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncproto
+
+import (
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+)
+
+// ReduceWorkloadEndpoint returns a copy of the workload endpoint with only the fields that Felix's
+// calculation graph needs for an endpoint on another node: its IPs and named ports (for IP sets
+// and routes), and its labels and profile IDs (for selector matching).  The name is also kept,
+// since Felix rejects endpoints without one.  Everything else, such as the MAC, NAT and gateway
+// configuration, is only used for local endpoints.
+//
+// The returned endpoint shares its slices and maps with the input.
+func ReduceWorkloadEndpoint(wep *model.WorkloadEndpoint) *model.WorkloadEndpoint {
+	return &model.WorkloadEndpoint{
+		Name:       wep.Name,
+		ProfileIDs: wep.ProfileIDs,
+		IPv4Nets:   wep.IPv4Nets,
+		IPv6Nets:   wep.IPv6Nets,
+		Labels:     wep.Labels,
+		Ports:      wep.Ports,
+	}
+}
+
+// ReduceUpdate returns the update with its value reduced, if it is a workload endpoint.  Other
+// updates are returned unchanged.
+func ReduceUpdate(u api.Update) api.Update {
+	if wep, ok := u.Value.(*model.WorkloadEndpoint); ok {
+		u.Value = ReduceWorkloadEndpoint(wep)
+	}
+	return u
+}
+
+// LocalWorkloadEndpointsRange returns the range of serialized keys, [start, end), that contains
+// the workload endpoints on the given node.
+func LocalWorkloadEndpointsRange(nodeName string) (start, end string) {
+	root := model.ListOptionsToDefaultPathRoot(model.WorkloadEndpointListOptions{Hostname: nodeName})
+	// The keys of the node's endpoints all start with root + "/"; "0" is the next character after "/".
+	return root + "/", root + "0"
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package syncproto
+
+import (
+	"testing"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/api"
+	"github.com/projectcalico/calico/libcalico-go/lib/backend/model"
+)
+
+func TestLocalWorkloadEndpointsRange(t *testing.T) {
+	RegisterTestingT(t)
+
+	start, end := LocalWorkloadEndpointsRange("node-a")
+	inRange := func(node string) bool {
+		key, err := model.KeyToDefaultPath(model.WorkloadEndpointKey{
+			Hostname:       node,
+			OrchestratorID: "k8s",
+			WorkloadID:     "default/pod",
+			EndpointID:     "eth0",
+		})
+		Expect(err).NotTo(HaveOccurred())
+		return key >= start && key < end
+	}
+
+	Expect(inRange("node-a")).To(BeTrue())
+	Expect(inRange("node-")).To(BeFalse())
+	Expect(inRange("node-a1")).To(BeFalse())
+	Expect(inRange("node-a.example.com")).To(BeFalse())
+	Expect(inRange("node-b")).To(BeFalse())
+}
+
+func TestReduceUpdate(t *testing.T) {
+	RegisterTestingT(t)
+
+	wep := &model.WorkloadEndpoint{
+		State:       "active",
+		Name:        "cali1234",
+		ProfileIDs:  []string{"kns.default"},
+		Labels:      map[string]string{"app": "nginx"},
+		Annotations: map[string]string{"foo": "bar"},
+	}
+	upd := ReduceUpdate(api.Update{KVPair: model.KVPair{Value: wep}})
+	Expect(upd.Value).To(Equal(&model.WorkloadEndpoint{
+		Name:       "cali1234",
+		ProfileIDs: []string{"kns.default"},
+		Labels:     map[string]string{"app": "nginx"},
+	}))
+
+	upd = ReduceUpdate(api.Update{KVPair: model.KVPair{Value: "foo"}})
+	Expect(upd.Value).To(Equal("foo"))
+}
@@ -261,6 +261,12 @@ type MsgClientHello struct {
 	SupportedCompressionAlgorithms []CompressionAlgorithm
 
 	ClientConnID uint64
+
+	// NodeName, if set, requests a node-scoped stream: the client only needs the full details of the
+	// workload endpoints on the named node.  The server may send other workload endpoints in their
+	// reduced form (see ReduceWorkloadEndpoint).  Only supported by SyncerTypeFelix.  If the server
+	// doesn't support filtering, it will not set MsgServerHello.FilteringRemoteEndpoints.
+	NodeName string
 }
 
 // MsgServerHello is the server's response to MsgClientHello.
@@ -275,6 +281,10 @@ type MsgServerHello struct {
 	SupportsNodeResourceUpdates bool
 
 	ServerConnID uint64
+
+	// FilteringRemoteEndpoints is set if the server will send workload endpoints that are not on
+	// MsgClientHello.NodeName in their reduced form.
+	FilteringRemoteEndpoints bool
 }
 
 // MsgDecoderRestart is sent (currently only from server to client) to tell it to restart its decoder with new
@@ -191,6 +191,7 @@ func (s *SnappySnapshotCache) writeDataToSnapshot(snap *snapshot) {
 		context.Background(),
 		s.logCtx.WithField("destination", "compressed in-memory cache"),
 		snap.crumb,
+		"", // Binary snapshots are shared by all clients so they are never node-scoped.
 		writeMsg,
 		1000, // Allow bigger messages in the snapshot.
 	)
@@ -18,7 +18,6 @@ import (
 	"bufio"
 	"context"
 	"crypto/tls"
-	"crypto/x509"
 	"encoding/gob"
 	"errors"
 	"fmt"
@@ -153,6 +152,11 @@ type Config struct {
 	ClientURISAN                   string
 	WriteBufferSize                int
 
+	// ServerCN and ServerURISAN are the CN and URI SAN that clients expect in the certificate
+	// in CertFile.  If set, a rotated certificate is only used if it matches them.
+	ServerCN     string
+	ServerURISAN string
+
 	// DebugLogWrites tells the server to wrap each connection with a Writer that
 	// logs every write.  Intended only for use in tests!
 	DebugLogWrites bool
@@ -325,6 +329,23 @@ func (s *Server) Port() int {
 	return s.chosenPort
 }
 
+// connectionTLSConfig returns the TLS config for a new connection, using the current certificates.
+func (s *Server) connectionTLSConfig(logCxt *log.Entry, certs *tlsutils.CertReloader) *tls.Config {
+	tlsConfig := calicotls.NewTLSConfig()
+	tlsConfig.Certificates = []tls.Certificate{*certs.Certificate()}
+
+	// Arrange for server to verify the clients' certificates.
+	tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
+	tlsConfig.ClientCAs = certs.CAPool()
+	tlsConfig.VerifyPeerCertificate = tlsutils.CertificateVerifier(
+		logCxt,
+		tlsConfig.ClientCAs,
+		s.config.ClientCN,
+		s.config.ClientURISAN,
+	)
+	return tlsConfig
+}
+
 func (s *Server) serve(cxt context.Context) {
 	defer s.Finished.Done()
 	var cancelFn context.CancelFunc
@@ -339,34 +360,22 @@ func (s *Server) serve(cxt context.Context) {
 	if s.config.requiringTLS() {
 		pwd, _ := os.Getwd()
 		logCxt.WithField("pwd", pwd).Info("Opening TLS listen socket")
-		cert, tlsErr := tls.LoadX509KeyPair(s.config.CertFile, s.config.KeyFile)
+		certs, tlsErr := tlsutils.NewCertReloader(s.config.CertFile, s.config.KeyFile, s.config.CAFile,
+			tlsutils.CertIdentity{CN: s.config.ServerCN, URISAN: s.config.ServerURISAN})
 		if tlsErr != nil {
 			logCxt.WithFields(log.Fields{
 				"certFile": s.config.CertFile,
 				"keyFile":  s.config.KeyFile,
+				"caFile":   s.config.CAFile,
 			}).WithError(tlsErr).Panic("Failed to load certificate and key")
 		}
+		// Pick up rotated certificates without a restart.  Each new connection gets a config with
+		// the current certificate and CAs.
+		certs.Start(cxt, tlsutils.DefaultCertReloadInterval)
 		tlsConfig := calicotls.NewTLSConfig()
-		tlsConfig.Certificates = []tls.Certificate{cert}
-
-		// Arrange for server to verify the clients' certificates.
-		logCxt.Info("Will verify client certificates")
-		tlsConfig.ClientAuth = tls.RequireAndVerifyClientCert
-		caPEMBlock, tlsErr := os.ReadFile(s.config.CAFile)
-		if tlsErr != nil {
-			logCxt.WithError(tlsErr).Panic("Failed to read CA data")
+		tlsConfig.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			return s.connectionTLSConfig(logCxt, certs), nil
 		}
-		tlsConfig.ClientCAs = x509.NewCertPool()
-		ok := tlsConfig.ClientCAs.AppendCertsFromPEM(caPEMBlock)
-		if !ok {
-			logCxt.Panic("Failed to add CA data to pool")
-		}
-		tlsConfig.VerifyPeerCertificate = tlsutils.CertificateVerifier(
-			logCxt,
-			tlsConfig.ClientCAs,
-			s.config.ClientCN,
-			s.config.ClientURISAN,
-		)
 
 		laddr := fmt.Sprintf("0.0.0.0:%v", s.config.ListenPort())
 		l, err = tls.Listen("tcp", laddr, tlsConfig)
@@ -686,6 +695,9 @@ type connection struct {
 	logCxt                       *log.Entry
 	chosenCompression            syncproto.CompressionAlgorithm
 	clientSupportsDecoderRestart bool
+	// filterNodeName is set if the client requested a node-scoped stream; workload endpoints that are
+	// not on this node are sent in their reduced form.
+	filterNodeName string
 
 	// Similarly to allCaches, allMetrics contains all the metrics relevant to a particular syncer.  We copy one
 	// of them to the unnamed field after the handshake.
@@ -733,7 +745,10 @@ func (h *connection) handle(finishedWG *sync.WaitGroup) (err error) {
 	// Figure out if we should restart the decoder with new settings.
 	var binSnapCache snapshotCache
 	if h.clientSupportsDecoderRestart {
-		binSnapCache = h.allSnapshotters[h.chosenCompression][h.syncerType]
+		if h.filterNodeName == "" {
+			// Binary snapshots are shared between clients, so they can't be used for node-scoped streams.
+			binSnapCache = h.allSnapshotters[h.chosenCompression][h.syncerType]
+		}
 		var reasonsToRestart []string
 		if h.chosenCompression != "" {
 			reasonsToRestart = append(reasonsToRestart, fmt.Sprintf("enable compression: %v", h.chosenCompression))
@@ -933,6 +948,16 @@ func (h *connection) doHandshake() error {
 		h.chosenCompression = ""
 	}
 
+	if hello.NodeName != "" {
+		if syncerType == syncproto.SyncerTypeFelix && h.cache.CurrentBreadcrumb().SupportsNodeFiltering() {
+			h.logCxt.WithField("nodeName", hello.NodeName).Info("Client requested node-scoped stream.")
+			h.filterNodeName = hello.NodeName
+		} else {
+			h.logCxt.WithField("nodeName", hello.NodeName).Info(
+				"Client requested node-scoped stream but it isn't supported, sending all endpoints.")
+		}
+	}
+
 	// Respond to client's hello.
 	err = h.sendMsg(syncproto.MsgServerHello{
 		Version: buildinfo.GitVersion,
@@ -941,6 +966,7 @@ func (h *connection) doHandshake() error {
 		SyncerType:                  syncerType,
 		SupportsNodeResourceUpdates: true,
 		ServerConnID:                h.ID,
+		FilteringRemoteEndpoints:    h.filterNodeName != "",
 	})
 	if err != nil {
 		log.WithError(err).Warning("Failed to send hello to client")
@@ -1166,13 +1192,13 @@ func (h *connection) sendDeltaUpdatesToClient(logCxt *log.Entry, breadcrumb *sna
 			if crumbAge < h.config.MinBatchingAgeThreshold && deltas == nil {
 				// We're not behind and we haven't already started to batch up updates.  Avoid
 				// copying the deltas and just send them
-				deltas = breadcrumb.Deltas
+				deltas = breadcrumb.DeltasForNode(h.filterNodeName)
 				break
 			}
 
 			// Either we're already batching up updates or we're behind.  Append the deltas to the
 			// buffer.
-			deltas = append(deltas, breadcrumb.Deltas...)
+			deltas = append(deltas, breadcrumb.DeltasForNode(h.filterNodeName)...)
 			h.summaryNextCatchupLatency.Observe(timeSpentInNext.Seconds())
 
 			if crumbAge < h.config.MinBatchingAgeThreshold {
@@ -1208,6 +1234,7 @@ func (h *connection) streamSnapshotToClient(logCxt *log.Entry, breadcrumb *snapc
 		h.cxt,
 		h.logCxt.WithField("destination", "direct to client"),
 		breadcrumb,
+		h.filterNodeName,
 		h.sendMsg,
 		h.config.MaxMessageSize,
 	)
@@ -1220,10 +1247,12 @@ func (h *connection) streamSnapshotToClient(logCxt *log.Entry, breadcrumb *snapc
 }
 
 // writeSnapshotMessages chunks the given breadcrumb up into syncproto.MsgKVs objects and calls writeMsg for each one.
+// If filterNodeName is set, the workload endpoints that are not on that node are written in their reduced form.
 func writeSnapshotMessages(
 	ctx context.Context,
 	logCxt *log.Entry,
 	breadcrumb *snapcache.Breadcrumb,
+	filterNodeName string,
 	writeMsg func(any) error,
 	maxMsgSize int,
 ) (err error) {
@@ -1252,7 +1281,7 @@ func writeSnapshotMessages(
 		return err
 	}
 
-	breadcrumb.KVs.Ascend(func(entry syncproto.SerializedUpdate) bool {
+	breadcrumb.AscendKVsForNode(filterNodeName, func(entry syncproto.SerializedUpdate) bool {
 		if ctx.Err() != nil {
 			err = ctx.Err()
 			return false
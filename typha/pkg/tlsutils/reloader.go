@@ -0,0 +1,306 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlsutils
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	gaugeVecCertExpiry = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "typha_tls_cert_expiry_timestamp_seconds",
+		Help: "Expiry time of the TLS certificate (or, for a CA bundle, the earliest-expiring CA certificate) " +
+			"loaded from the file, in seconds since the epoch.",
+	}, []string{"file"})
+	counterVecCertReloads = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "typha_tls_cert_reloads",
+		Help: "Number of times that changed TLS certificate files were reloaded, by result.",
+	}, []string{"file", "result"})
+)
+
+func init() {
+	prometheus.MustRegister(gaugeVecCertExpiry)
+	prometheus.MustRegister(counterVecCertReloads)
+}
+
+const DefaultCertReloadInterval = 10 * time.Second
+
+// CertReloader loads a certificate, its key and a CA bundle from files and reloads them if the
+// files change, so that certificates can be rotated without a restart.  Connections that are
+// already established keep using the certificate that they started with.
+//
+// The files are polled rather than watched with inotify because Kubernetes updates mounted
+// secrets by swapping a symlink, and because a rotation may update the certificate and key
+// files one at a time.  A changed set of files is only used once it is valid:
+//
+//   - the key must match the certificate,
+//   - the certificate must be within its validity period,
+//   - the certificate must match the configured CertIdentity, since that is what the peers
+//     verify.  If no identity is configured, the certificate must instead have the same CN and
+//     URI SANs as the one that it replaces.
+//
+// Otherwise, the previous files remain in use and the reload is retried on the next poll.
+type CertReloader struct {
+	certFile, keyFile, caFile string
+	identity                  CertIdentity
+
+	lock    sync.RWMutex
+	current *loadedCerts
+}
+
+type loadedCerts struct {
+	certPEM, keyPEM, caPEM []byte
+
+	cert   *tls.Certificate
+	leaf   *x509.Certificate
+	caPool *x509.CertPool
+}
+
+// CertIdentity is the CN and URI SAN that the peers expect in our certificate.  As when the
+// peers verify it, a certificate matches if either its CN or one of its URI SANs matches; an
+// empty field matches nothing.
+type CertIdentity struct {
+	CN     string
+	URISAN string
+}
+
+func (id CertIdentity) isSet() bool {
+	return id.CN != "" || id.URISAN != ""
+}
+
+func (id CertIdentity) matches(leaf *x509.Certificate) bool {
+	if id.CN != "" && leaf.Subject.CommonName == id.CN {
+		return true
+	}
+	if id.URISAN != "" {
+		for _, uri := range leaf.URIs {
+			if uri.String() == id.URISAN {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// NewCertReloader loads the certificate, key and CA bundle from the given files.  It returns an
+// error if they aren't valid, including if the certificate doesn't match the given identity
+// (which may be left empty if the identity that the peers expect is not known).
+func NewCertReloader(certFile, keyFile, caFile string, identity CertIdentity) (*CertReloader, error) {
+	r := &CertReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+		identity: identity,
+	}
+	if _, err := r.Reload(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Start polls the files for changes, at the given interval, until the context is canceled.
+func (r *CertReloader) Start(ctx context.Context, interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				_, _ = r.Reload()
+			}
+		}
+	}()
+}
+
+// Reload rereads the files and, if they have changed and are valid, starts using them.  It
+// returns true if the certificates were changed.
+func (r *CertReloader) Reload() (bool, error) {
+	logCxt := log.WithFields(log.Fields{
+		"certFile": r.certFile,
+		"keyFile":  r.keyFile,
+		"caFile":   r.caFile,
+	})
+	newCerts, err := r.readFiles()
+	if err != nil {
+		logCxt.WithError(err).Warn("Failed to read TLS certificate files.")
+		counterVecCertReloads.WithLabelValues(r.certFile, "error").Inc()
+		return false, err
+	}
+
+	r.lock.RLock()
+	current := r.current
+	r.lock.RUnlock()
+	if current != nil &&
+		bytes.Equal(current.certPEM, newCerts.certPEM) &&
+		bytes.Equal(current.keyPEM, newCerts.keyPEM) &&
+		bytes.Equal(current.caPEM, newCerts.caPEM) {
+		return false, nil
+	}
+
+	if err := newCerts.parseAndValidate(current, r.identity); err != nil {
+		if current == nil {
+			return false, err
+		}
+		// Likely to be a rotation in progress, for example, if we've seen the new certificate but
+		// not the new key.  Keep using the old certificates.
+		logCxt.WithError(err).Warn("TLS certificate files changed but they are not valid, " +
+			"continuing to use the previous certificate.")
+		counterVecCertReloads.WithLabelValues(r.certFile, "invalid").Inc()
+		return false, err
+	}
+
+	r.lock.Lock()
+	r.current = newCerts
+	r.lock.Unlock()
+
+	gaugeVecCertExpiry.WithLabelValues(r.certFile).Set(float64(newCerts.leaf.NotAfter.Unix()))
+	if caExpiry, ok := earliestExpiry(newCerts.caPEM); ok {
+		gaugeVecCertExpiry.WithLabelValues(r.caFile).Set(float64(caExpiry.Unix()))
+	}
+	if current != nil {
+		logCxt.WithField("expiry", newCerts.leaf.NotAfter).Info("Reloaded TLS certificates.")
+		counterVecCertReloads.WithLabelValues(r.certFile, "success").Inc()
+	}
+	return true, nil
+}
+
+func (r *CertReloader) readFiles() (*loadedCerts, error) {
+	var c loadedCerts
+	var err error
+	if c.certPEM, err = os.ReadFile(r.certFile); err != nil {
+		return nil, fmt.Errorf("failed to read certificate: %w", err)
+	}
+	if c.keyPEM, err = os.ReadFile(r.keyFile); err != nil {
+		return nil, fmt.Errorf("failed to read key: %w", err)
+	}
+	if c.caPEM, err = os.ReadFile(r.caFile); err != nil {
+		return nil, fmt.Errorf("failed to read CA data: %w", err)
+	}
+	return &c, nil
+}
+
+func (c *loadedCerts) parseAndValidate(previous *loadedCerts, identity CertIdentity) error {
+	cert, err := tls.X509KeyPair(c.certPEM, c.keyPEM)
+	if err != nil {
+		return fmt.Errorf("failed to load certificate and key: %w", err)
+	}
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return fmt.Errorf("failed to parse certificate: %w", err)
+	}
+	c.caPool = x509.NewCertPool()
+	if !c.caPool.AppendCertsFromPEM(c.caPEM) {
+		return errors.New("failed to add CA data to pool")
+	}
+	c.cert = &cert
+	c.leaf = leaf
+
+	// Note: we don't verify the certificate against the CA bundle; the CA bundle is used to
+	// verify the peer and our own certificate may be signed by a different CA.
+	now := time.Now()
+	if now.Before(leaf.NotBefore) || now.After(leaf.NotAfter) {
+		return fmt.Errorf("certificate is only valid from %v to %v", leaf.NotBefore, leaf.NotAfter)
+	}
+
+	if identity.isSet() {
+		if !identity.matches(leaf) {
+			return fmt.Errorf("certificate has CN %q and URI SANs %v, expected CN %q or URI SAN %q",
+				leaf.Subject.CommonName, leaf.URIs, identity.CN, identity.URISAN)
+		}
+		return nil
+	}
+	if previous == nil {
+		return nil
+	}
+	if leaf.Subject.CommonName != previous.leaf.Subject.CommonName {
+		return fmt.Errorf("certificate CN changed from %q to %q, a restart is needed to use it",
+			previous.leaf.Subject.CommonName, leaf.Subject.CommonName)
+	}
+	for _, oldURI := range previous.leaf.URIs {
+		found := false
+		for _, uri := range leaf.URIs {
+			if uri.String() == oldURI.String() {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("certificate no longer has URI SAN %q, a restart is needed to use it", oldURI)
+		}
+	}
+	return nil
+}
+
+func earliestExpiry(pemData []byte) (expiry time.Time, ok bool) {
+	for {
+		var block *pem.Block
+		block, pemData = pem.Decode(pemData)
+		if block == nil {
+			return
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			continue
+		}
+		if !ok || cert.NotAfter.Before(expiry) {
+			expiry = cert.NotAfter
+			ok = true
+		}
+	}
+}
+
+func (r *CertReloader) loaded() *loadedCerts {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.current
+}
+
+// Certificate returns the current certificate.
+func (r *CertReloader) Certificate() *tls.Certificate {
+	return r.loaded().cert
+}
+
+// CAPool returns a pool containing the current CA certificates.
+func (r *CertReloader) CAPool() *x509.CertPool {
+	return r.loaded().caPool
+}
+
+// GetCertificate can be used as the GetCertificate function of a server's tls.Config.
+func (r *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	return r.Certificate(), nil
+}
+
+// GetClientCertificate can be used as the GetClientCertificate function of a client's
+// tls.Config.
+func (r *CertReloader) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	return r.Certificate(), nil
+}
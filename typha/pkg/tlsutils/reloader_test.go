@@ -0,0 +1,159 @@
+// Copyright (c) 2024 Tigera, Inc. All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tlsutils_test
+
+import (
+	"crypto/x509"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/projectcalico/calico/typha/pkg/tlsutils"
+)
+
+var _ = Describe("CertReloader", func() {
+	var (
+		dir                       string
+		certFile, keyFile, caFile string
+		reloader                  *tlsutils.CertReloader
+	)
+
+	writeCert := func(cn, uriSAN string) []byte {
+		certCA, keyCA := tlsutils.MakeCACert("reloader-ca")
+		tlsutils.WriteCert(certCA.Raw, caFile)
+		certBytes, key := tlsutils.MakePeerCert(cn, uriSAN, x509.ExtKeyUsageServerAuth, certCA, keyCA)
+		tlsutils.WriteCert(certBytes, certFile)
+		tlsutils.WriteKey(key, keyFile)
+		return certBytes
+	}
+
+	BeforeEach(func() {
+		var err error
+		dir, err = os.MkdirTemp("", "reloader")
+		Expect(err).NotTo(HaveOccurred())
+		certFile = filepath.Join(dir, "tls.crt")
+		keyFile = filepath.Join(dir, "tls.key")
+		caFile = filepath.Join(dir, "ca.crt")
+	})
+
+	AfterEach(func() {
+		_ = os.RemoveAll(dir)
+	})
+
+	It("should fail if the files are missing", func() {
+		_, err := tlsutils.NewCertReloader(certFile, keyFile, caFile, tlsutils.CertIdentity{})
+		Expect(err).To(HaveOccurred())
+	})
+
+	Describe("with valid certificates", func() {
+		var origCert []byte
+
+		BeforeEach(func() {
+			origCert = writeCert(goodCN, goodURISAN)
+			var err error
+			reloader, err = tlsutils.NewCertReloader(certFile, keyFile, caFile, tlsutils.CertIdentity{})
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		It("should load the certificate", func() {
+			Expect(reloader.Certificate().Certificate[0]).To(Equal(origCert))
+			Expect(reloader.CAPool()).NotTo(BeNil())
+		})
+
+		It("should do nothing if the files are unchanged", func() {
+			changed, err := reloader.Reload()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).To(BeFalse())
+			Expect(reloader.Certificate().Certificate[0]).To(Equal(origCert))
+		})
+
+		It("should pick up a rotated certificate", func() {
+			newCert := writeCert(goodCN, goodURISAN)
+			changed, err := reloader.Reload()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).To(BeTrue())
+			Expect(reloader.Certificate().Certificate[0]).To(Equal(newCert))
+		})
+
+		It("should keep the old certificate if the CN changes", func() {
+			writeCert(badCN, goodURISAN)
+			changed, err := reloader.Reload()
+			Expect(err).To(HaveOccurred())
+			Expect(changed).To(BeFalse())
+			Expect(reloader.Certificate().Certificate[0]).To(Equal(origCert))
+		})
+
+		It("should keep the old certificate if the URI SAN changes", func() {
+			writeCert(goodCN, badURISAN)
+			changed, err := reloader.Reload()
+			Expect(err).To(HaveOccurred())
+			Expect(changed).To(BeFalse())
+			Expect(reloader.Certificate().Certificate[0]).To(Equal(origCert))
+		})
+
+		It("should keep the old certificate until the key is updated", func() {
+			// Simulate a rotation that has only updated the certificate so far.
+			oldKey, err := os.ReadFile(keyFile)
+			Expect(err).NotTo(HaveOccurred())
+			newCert := writeCert(goodCN, goodURISAN)
+			newKey, err := os.ReadFile(keyFile)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.WriteFile(keyFile, oldKey, 0600)).To(Succeed())
+
+			changed, err := reloader.Reload()
+			Expect(err).To(HaveOccurred())
+			Expect(changed).To(BeFalse())
+			Expect(reloader.Certificate().Certificate[0]).To(Equal(origCert))
+
+			Expect(os.WriteFile(keyFile, newKey, 0600)).To(Succeed())
+			changed, err = reloader.Reload()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).To(BeTrue())
+			Expect(reloader.Certificate().Certificate[0]).To(Equal(newCert))
+		})
+	})
+
+	Describe("with a configured identity", func() {
+		identity := tlsutils.CertIdentity{CN: goodCN, URISAN: goodURISAN}
+
+		It("should refuse a first certificate that doesn't match", func() {
+			writeCert(badCN, badURISAN)
+			_, err := tlsutils.NewCertReloader(certFile, keyFile, caFile, identity)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should check rotated certificates against the identity rather than the previous certificate", func() {
+			writeCert(goodCN, badURISAN)
+			var err error
+			reloader, err = tlsutils.NewCertReloader(certFile, keyFile, caFile, identity)
+			Expect(err).NotTo(HaveOccurred())
+
+			// Different CN and URI SAN from the previous certificate, but the URI SAN matches.
+			newCert := writeCert(badCN, goodURISAN)
+			changed, err := reloader.Reload()
+			Expect(err).NotTo(HaveOccurred())
+			Expect(changed).To(BeTrue())
+			Expect(reloader.Certificate().Certificate[0]).To(Equal(newCert))
+
+			writeCert(badCN, badURISAN)
+			changed, err = reloader.Reload()
+			Expect(err).To(HaveOccurred())
+			Expect(changed).To(BeFalse())
+			Expect(reloader.Certificate().Certificate[0]).To(Equal(newCert))
+		})
+	})
+})